@@ -0,0 +1,338 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+	"github.com/segmentio/ksuid"
+	"github.com/urfave/cli/v2"
+)
+
+// AuditCommand returns the audit command for reading the audit log
+func AuditCommand(logger *zerolog.Logger) *cli.Command {
+	return &cli.Command{
+		Name:  "audit",
+		Usage: "Read the audit log of changes made through the console, the API, this CLI and the state machine",
+		Description: `Read the append-only audit log. Every GraphQL mutation, every write made with this CLI and
+every build status change made by the state machine is recorded with its actor, action, target,
+before and after values and request ID.
+
+--since and --until accept RFC 3339 timestamps (2026-01-02T15:04:05Z) or durations before now (24h).
+
+Examples:
+  # What happened in prd today
+  aws-deployer audit list --env prd --since 24h
+
+  # Who changed the targets of my-app
+  aws-deployer audit list --env prd --action targets --repo my-app --since 720h
+
+  # Export last month as JSON lines
+  aws-deployer audit export --env prd --since 720h --output audit.jsonl`,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Aliases: []string{"ls", "l"},
+				Usage:   "List audit events, newest first",
+				Flags: append(auditFilterFlags(),
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Maximum events to show",
+						Value: 50,
+					},
+				),
+				Action: listAuditAction,
+			},
+			{
+				Name:  "export",
+				Usage: "Export audit events as JSON lines, newest first",
+				Flags: append(auditFilterFlags(),
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "File to write (default stdout)",
+					},
+				),
+				Action: exportAuditAction,
+			},
+		},
+	}
+}
+
+// auditFilterFlags returns the flags shared by the audit subcommands
+func auditFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "env",
+			Aliases:  []string{"e"},
+			Usage:    "AWS Deployer environment (dev, stg, or prd) - determines which DynamoDB table to use",
+			Required: true,
+			EnvVars:  []string{"ENV"},
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Earliest event, as a timestamp or a duration before now",
+			Value: "168h",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "Latest event, as a timestamp or a duration before now (default now)",
+		},
+		&cli.StringFlag{
+			Name:  "actor",
+			Usage: "Actor: an email, service:{name}, an IAM ARN or state-machine",
+		},
+		&cli.StringFlag{
+			Name:    "action",
+			Aliases: []string{"a"},
+			Usage:   "Action, or a prefix such as build for all build.* actions",
+		},
+		&cli.StringFlag{
+			Name:    "repo",
+			Aliases: []string{"r"},
+			Usage:   "Repository",
+		},
+		&cli.StringFlag{
+			Name:    "target-env",
+			Aliases: []string{"t"},
+			Usage:   "Target environment",
+		},
+		&cli.StringFlag{
+			Name:  "target",
+			Usage: "ID of the record changed, e.g. a build ID",
+		},
+	}
+}
+
+// auditQueryInput returns the query selected by the audit filter flags
+func auditQueryInput(c *cli.Context) (auditdao.QueryInput, error) {
+	now := time.Now()
+
+	since, err := parseAuditTime(c.String("since"), now)
+	if err != nil {
+		return auditdao.QueryInput{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	until := now
+	if c.String("until") != "" {
+		if until, err = parseAuditTime(c.String("until"), now); err != nil {
+			return auditdao.QueryInput{}, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+
+	return auditdao.QueryInput{
+		Since:  since,
+		Until:  until,
+		Actor:  c.String("actor"),
+		Action: c.String("action"),
+		Repo:   c.String("repo"),
+		Env:    c.String("target-env"),
+		Target: c.String("target"),
+	}, nil
+}
+
+// parseAuditTime parses an RFC 3339 timestamp or a duration before now
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// listAuditAction lists audit events
+func listAuditAction(c *cli.Context) error {
+	input, err := auditQueryInput(c)
+	if err != nil {
+		return err
+	}
+	input.Limit = c.Int("limit")
+
+	dao, err := createAuditDAO(c.String("env"))
+	if err != nil {
+		return err
+	}
+
+	records, err := dao.Query(c.Context, input)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No audit events")
+		return nil
+	}
+
+	fmt.Println()
+	for _, record := range records {
+		fmt.Printf("%s  %s  %s\n", time.Unix(record.CreatedAt, 0).Format(time.RFC3339), record.Action, record.Actor)
+		if record.Repo != "" || record.Env != "" {
+			fmt.Printf("  Repo/Env: %s/%s\n", valueOrAll(record.Repo), valueOrAll(record.Env))
+		}
+		if record.Target != "" {
+			fmt.Printf("  Target:   %s\n", record.Target)
+		}
+		if record.Before != "" {
+			fmt.Printf("  Before:   %s\n", record.Before)
+		}
+		if record.After != "" {
+			fmt.Printf("  After:    %s\n", record.After)
+		}
+		fmt.Printf("  Source:   %s", record.Source)
+		if record.RequestID != "" {
+			fmt.Printf(" (request %s)", record.RequestID)
+		}
+		fmt.Println()
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// auditLine is an audit event as exported to JSON lines
+type auditLine struct {
+	ID        string             `json:"id"`
+	Time      string             `json:"time"`
+	ActorType auditdao.ActorType `json:"actor_type"`
+	Actor     string             `json:"actor"`
+	ActorSub  string             `json:"actor_sub,omitempty"`
+	Action    string             `json:"action"`
+	Repo      string             `json:"repo,omitempty"`
+	Env       string             `json:"env,omitempty"`
+	Target    string             `json:"target,omitempty"`
+	Before    json.RawMessage    `json:"before,omitempty"`
+	After     json.RawMessage    `json:"after,omitempty"`
+	Source    string             `json:"source"`
+	RequestID string             `json:"request_id,omitempty"`
+}
+
+// exportAuditAction writes audit events as JSON lines
+func exportAuditAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	input, err := auditQueryInput(c)
+	if err != nil {
+		return err
+	}
+
+	dao, err := createAuditDAO(c.String("env"))
+	if err != nil {
+		return err
+	}
+
+	records, err := dao.Query(c.Context, input)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if path := c.String("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		line := auditLine{
+			ID:        record.GetID(),
+			Time:      time.Unix(record.CreatedAt, 0).UTC().Format(time.RFC3339),
+			ActorType: record.ActorType,
+			Actor:     record.Actor,
+			ActorSub:  record.ActorSub,
+			Action:    record.Action,
+			Repo:      record.Repo,
+			Env:       record.Env,
+			Target:    record.Target,
+			Source:    record.Source,
+			RequestID: record.RequestID,
+		}
+		if record.Before != "" {
+			line.Before = json.RawMessage(record.Before)
+		}
+		if record.After != "" {
+			line.After = json.RawMessage(record.After)
+		}
+		if err := encoder.Encode(line); err != nil {
+			return fmt.Errorf("failed to write audit event: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit events: %w", err)
+	}
+
+	logger.Info().
+		Int("events", len(records)).
+		Msg("Audit events exported")
+	return nil
+}
+
+// createAuditDAO creates an auditdao.DAO instance
+func createAuditDAO(env string) (*auditdao.DAO, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return auditdao.New(dbClient, auditdao.TableName(env)), nil
+}
+
+// cliAudit records the writes of a CLI command as the caller's IAM identity. All events of
+// one command share a request ID.
+type cliAudit struct {
+	recorder  *audit.Recorder
+	actor     audit.Actor
+	requestID string
+}
+
+// newCLIAudit resolves the caller's IAM identity. Commands create it before writing so that
+// no change is made by a caller who cannot be identified.
+func newCLIAudit(ctx context.Context, env string) (*cliAudit, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity for the audit log: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return &cliAudit{
+		recorder: audit.New(auditdao.New(dbClient, auditdao.TableName(env)), audit.SourceCLI),
+		actor: audit.Actor{
+			Type: auditdao.ActorIAM,
+			ID:   aws.ToString(identity.Arn),
+			Sub:  aws.ToString(identity.UserId),
+		},
+		requestID: ksuid.New().String(),
+	}, nil
+}
+
+// record appends an event to the audit log. The change has already been made, so a failure
+// is reported without failing the command.
+func (a *cliAudit) record(ctx context.Context, event audit.Event) {
+	if err := a.recorder.Record(audit.WithRequestID(ctx, a.requestID), a.actor, event); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Str("action", event.Action).
+			Str("target", event.Target).
+			Msg("Failed to record audit event")
+		fmt.Fprintf(os.Stderr, "warning: the change was made but could not be recorded in the audit log: %v\n", err)
+	}
+}
@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/lockdao"
+	"github.com/urfave/cli/v2"
+)
+
+// LocksCommand returns the locks command for inspecting and releasing deployment locks
+func LocksCommand(logger *zerolog.Logger) *cli.Command {
+	return &cli.Command{
+		Name:    "locks",
+		Aliases: []string{"lock"},
+		Usage:   "Inspect and force-release deployment locks",
+		Description: `A build holds its repo and environment's deployment lock while it deploys; other builds
+wait for it. Locks expire after 4 hours. Force-release a lock only when the build holding it
+is known to be stuck; the release is recorded in the audit log.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:   "show",
+				Usage:  "Show the build holding a lock",
+				Flags:  locksFlags(),
+				Action: showLockAction,
+			},
+			{
+				Name:  "release",
+				Usage: "Force-release a lock regardless of the build holding it",
+				Flags: append(locksFlags(),
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Skip confirmation prompt",
+					},
+				),
+				Action: releaseLockAction,
+			},
+		},
+	}
+}
+
+// locksFlags returns the flags shared by the locks subcommands
+func locksFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "env",
+			Aliases:  []string{"e"},
+			Usage:    "AWS Deployer environment (dev, stg, or prd) - determines which DynamoDB table to use",
+			Required: true,
+			EnvVars:  []string{"ENV"},
+		},
+		&cli.StringFlag{
+			Name:     "repo",
+			Aliases:  []string{"r"},
+			Usage:    "Repository",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "target-env",
+			Aliases:  []string{"t"},
+			Usage:    "Target environment",
+			Required: true,
+		},
+	}
+}
+
+// showLockAction shows the build holding a lock
+func showLockAction(c *cli.Context) error {
+	dao, err := createLockDAO(c.String("env"))
+	if err != nil {
+		return err
+	}
+
+	record, err := dao.Find(c.Context, lockdao.NewID(c.String("target-env"), c.String("repo")))
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		fmt.Println("Not locked")
+		return nil
+	}
+
+	printLock(record)
+	return nil
+}
+
+// releaseLockAction force-releases a lock
+func releaseLockAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	repo := c.String("repo")
+	targetEnv := c.String("target-env")
+	id := lockdao.NewID(targetEnv, repo)
+
+	dao, err := createLockDAO(env)
+	if err != nil {
+		return err
+	}
+
+	existing, err := dao.Find(c.Context, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		fmt.Println("Not locked")
+		return nil
+	}
+
+	printLock(existing)
+
+	if !c.Bool("force") {
+		fmt.Print("\nRelease this lock? (yes/no): ")
+		var response string
+		fmt.Scanln(&response)
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "yes" && response != "y" {
+			fmt.Println("Release cancelled")
+			return nil
+		}
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.Delete(c.Context, id); err != nil {
+		return err
+	}
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionLockRelease,
+		Repo:   repo,
+		Env:    targetEnv,
+		Target: id.String(),
+		Before: existing,
+	})
+
+	logger.Warn().
+		Str("env", env).
+		Str("id", id.String()).
+		Str("build_id", existing.BuildID).
+		Msg("Lock force-released")
+
+	fmt.Println("\n✓ Lock released")
+	return nil
+}
+
+// printLock prints the holder of a lock
+func printLock(record *lockdao.Record) {
+	fmt.Println()
+	fmt.Printf("%s\n", record.GetID())
+	fmt.Printf("  Build:     %s\n", record.BuildID)
+	if record.ExecutionArn != "" {
+		fmt.Printf("  Execution: %s\n", record.ExecutionArn)
+	}
+	fmt.Printf("  Acquired:  %s\n", time.Unix(record.AcquiredAt, 0).Format(time.RFC3339))
+	fmt.Printf("  Expires:   %s\n", time.Unix(record.TTL, 0).Format(time.RFC3339))
+}
+
+// createLockDAO creates a lockdao.DAO instance
+func createLockDAO(env string) (*lockdao.DAO, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return lockdao.New(dbClient, lockdao.TableName(env)), nil
+}
@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/notificationdao"
+	"github.com/savaki/aws-deployer/internal/notification"
+	"github.com/urfave/cli/v2"
+)
+
+// NotificationsCommand returns the notifications command for managing build notifications
+func NotificationsCommand(logger *zerolog.Logger) *cli.Command {
+	return &cli.Command{
+		Name:    "notifications",
+		Aliases: []string{"n"},
+		Usage:   "Manage Slack, webhook and SNS notifications for build status changes",
+		Description: `Manage subscriptions that notify Slack, HTTP webhooks or SNS topics when builds change status.
+
+Events:
+  pending_approval  Build is waiting for approval
+  waiting_on_lock   Build is queued behind another build's deployment lock
+  started           Deployment started
+  succeeded         Deployment succeeded
+  failed            Deployment failed
+
+Use $ as --repo or --target-env to subscribe to all repos or all environments.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "add",
+				Aliases: []string{"a"},
+				Usage:   "Add a notification subscription",
+				Description: `Add a notification subscription for a repo and target environment.
+
+Slack webhook URLs and webhook signing keys are secrets; store them in Secrets Manager under
+aws-deployer/{env}/notifications/ and pass the secret name with --secret.
+
+Examples:
+  # Post prd deployments of my-app to Slack
+  aws-deployer notifications add --env prd --repo my-app --target-env prd \
+    --channel slack --secret aws-deployer/prd/notifications/slack
+
+  # Send failures in any repo to a signed webhook
+  aws-deployer notifications add --env prd --repo '$' --target-env '$' \
+    --channel webhook --target https://hooks.example.com/deploys \
+    --secret aws-deployer/prd/notifications/webhook-key --events failed
+
+  # Publish to an SNS topic with a custom message
+  aws-deployer notifications add --env dev --repo my-app --target-env dev \
+    --channel sns --target arn:aws:sns:us-east-1:123456789012:deploys \
+    --template '{{.Repo}} {{.Version}} is {{.Status}} in {{.Env}}'`,
+				Flags: []cli.Flag{
+					notificationsEnvFlag(),
+					&cli.StringFlag{
+						Name:     "repo",
+						Aliases:  []string{"r"},
+						Usage:    "Repository name ($ for all repos)",
+						Required: true,
+						EnvVars:  []string{"REPO"},
+					},
+					&cli.StringFlag{
+						Name:     "target-env",
+						Aliases:  []string{"t"},
+						Usage:    "Target deployment environment ($ for all environments)",
+						Required: true,
+						EnvVars:  []string{"TARGET_ENV"},
+					},
+					&cli.StringFlag{
+						Name:     "channel",
+						Aliases:  []string{"c"},
+						Usage:    "Delivery channel: slack, webhook or sns",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "target",
+						Usage: "Webhook URL or SNS topic ARN",
+					},
+					&cli.StringFlag{
+						Name:    "secret",
+						Aliases: []string{"s"},
+						Usage:   "Secrets Manager secret holding the Slack webhook URL or webhook signing key",
+					},
+					&cli.StringFlag{
+						Name:  "events",
+						Usage: "Comma-separated events to notify (default: all)",
+					},
+					&cli.StringFlag{
+						Name:  "template",
+						Usage: "Go text/template for the message (default: built-in per event)",
+					},
+				},
+				Action: addNotificationAction,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls", "l"},
+				Usage:   "List notification subscriptions",
+				Flags: []cli.Flag{
+					notificationsEnvFlag(),
+					&cli.StringFlag{
+						Name:    "repo",
+						Aliases: []string{"r"},
+						Usage:   "Show subscriptions matching this repository",
+						EnvVars: []string{"REPO"},
+					},
+					&cli.StringFlag{
+						Name:    "target-env",
+						Aliases: []string{"t"},
+						Usage:   "Show subscriptions matching this target environment",
+						EnvVars: []string{"TARGET_ENV"},
+					},
+				},
+				Action: listNotificationsAction,
+			},
+			{
+				Name:    "remove",
+				Aliases: []string{"rm", "delete"},
+				Usage:   "Remove a notification subscription",
+				Flags: []cli.Flag{
+					notificationsEnvFlag(),
+					&cli.StringFlag{
+						Name:     "id",
+						Usage:    "Subscription ID (from list)",
+						Required: true,
+					},
+				},
+				Action: removeNotificationAction,
+			},
+		},
+	}
+}
+
+// notificationsEnvFlag returns the --env flag shared by the notifications subcommands
+func notificationsEnvFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "env",
+		Aliases:  []string{"e"},
+		Usage:    "AWS Deployer environment (dev, stg, or prd) - determines which DynamoDB table to use",
+		Required: true,
+		EnvVars:  []string{"ENV"},
+	}
+}
+
+// addNotificationAction adds a notification subscription
+func addNotificationAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	secret := c.String("secret")
+	if prefix := fmt.Sprintf("aws-deployer/%s/notifications/", env); secret != "" && !strings.HasPrefix(secret, prefix) {
+		return fmt.Errorf("--secret must be under %s", prefix)
+	}
+
+	events, err := notification.ParseEvents(c.String("events"))
+	if err != nil {
+		return err
+	}
+
+	dao, err := createNotificationDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	createdBy := os.Getenv("USER")
+	record, err := dao.Create(c.Context, notificationdao.CreateInput{
+		Repo:       c.String("repo"),
+		Env:        c.String("target-env"),
+		Channel:    notificationdao.Channel(c.String("channel")),
+		Target:     c.String("target"),
+		SecretName: secret,
+		Events:     events,
+		Template:   c.String("template"),
+		CreatedBy:  createdBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("env", env).
+		Str("id", record.GetID().String()).
+		Msg("Notification subscription created")
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionNotificationAdd,
+		Repo:   record.Repo,
+		Env:    record.Env,
+		Target: record.GetID().String(),
+		After:  record,
+	})
+
+	fmt.Printf("\n✓ Created subscription %s\n", record.GetID())
+	return nil
+}
+
+// listNotificationsAction lists notification subscriptions
+func listNotificationsAction(c *cli.Context) error {
+	env := c.String("env")
+	repo := c.String("repo")
+	targetEnv := c.String("target-env")
+
+	dao, err := createNotificationDAO(env)
+	if err != nil {
+		return err
+	}
+
+	var records []notificationdao.Record
+	if repo != "" && targetEnv != "" {
+		records, err = dao.QueryForBuild(c.Context, repo, targetEnv)
+	} else {
+		records, err = dao.FindAll(c.Context)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No notification subscriptions configured")
+		return nil
+	}
+
+	fmt.Println()
+	for _, record := range records {
+		if repo != "" && record.Repo != repo && record.Repo != notificationdao.Wildcard {
+			continue
+		}
+		if targetEnv != "" && record.Env != targetEnv && record.Env != notificationdao.Wildcard {
+			continue
+		}
+
+		events := "all"
+		if len(record.Events) > 0 {
+			events = strings.Join(record.Events, ",")
+		}
+
+		fmt.Printf("%s\n", record.GetID())
+		fmt.Printf("  Channel: %s\n", record.Channel)
+		if record.Target != "" {
+			fmt.Printf("  Target:  %s\n", record.Target)
+		}
+		if record.SecretName != "" {
+			fmt.Printf("  Secret:  %s\n", record.SecretName)
+		}
+		fmt.Printf("  Events:  %s\n", events)
+		if record.Template != "" {
+			fmt.Printf("  Template: %s\n", record.Template)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// removeNotificationAction removes a notification subscription
+func removeNotificationAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	id := notificationdao.ID(c.String("id"))
+
+	dao, err := createNotificationDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.Delete(c.Context, id); err != nil {
+		return err
+	}
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionNotificationRemove,
+		Target: id.String(),
+	})
+
+	logger.Info().
+		Str("env", env).
+		Str("id", id.String()).
+		Msg("Notification subscription removed")
+
+	fmt.Println("\n✓ Subscription removed")
+	return nil
+}
+
+// createNotificationDAO creates a notificationdao.DAO instance
+func createNotificationDAO(env string) (*notificationdao.DAO, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return notificationdao.New(dbClient, notificationdao.TableName(env)), nil
+}
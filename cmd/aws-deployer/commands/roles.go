@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/roledao"
+	"github.com/urfave/cli/v2"
+)
+
+// RolesCommand returns the roles command for managing role-based access control
+func RolesCommand(logger *zerolog.Logger) *cli.Command {
+	return &cli.Command{
+		Name:  "roles",
+		Usage: "Manage the roles users and groups hold in the console and GraphQL API",
+		Description: `Manage role bindings, which grant a role to a user or identity provider group for the
+repos and environments matching a glob.
+
+Roles (each includes the ones above it):
+  viewer    Read-only access
+  deployer  Redeploy and promote builds
+  approver  Approve and reject builds awaiting approval
+  admin     Override deploy freezes and allow replacement of protected resources
+
+Subjects are user:{email}, group:{name} (the groups claim of the ID token) or
+claim:{name}={value} (a custom claim copied into the profile). Roles are only enforced once the
+/{env}/aws-deployer/role-based-access parameter is set to true; grant yourself admin first.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "grant",
+				Aliases: []string{"add"},
+				Usage:   "Grant a role to a user or group",
+				Description: `Grant a role to a user or group for the repos and environments matching --repo and --target-env.
+
+Examples:
+  # Let the SRE group do anything, anywhere
+  aws-deployer roles grant --env prd --subject group:sre --role admin
+
+  # Let alice deploy the payments repos to any environment
+  aws-deployer roles grant --env prd --subject user:alice@example.com --role deployer --repo 'payments-*'
+
+  # Let bob approve prd deployments
+  aws-deployer roles grant --env prd --subject user:bob@example.com --role approver --target-env prd`,
+				Flags: []cli.Flag{
+					rolesEnvFlag(),
+					&cli.StringFlag{
+						Name:     "subject",
+						Aliases:  []string{"s"},
+						Usage:    "User, group or claim to grant the role to: user:{email}, group:{name} or claim:{name}={value}",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "role",
+						Usage:    "Role to grant: viewer, deployer, approver or admin",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:    "repo",
+						Aliases: []string{"r"},
+						Usage:   "Repository glob",
+						Value:   "*",
+					},
+					&cli.StringFlag{
+						Name:    "target-env",
+						Aliases: []string{"t"},
+						Usage:   "Target environment glob",
+						Value:   "*",
+					},
+				},
+				Action: grantRoleAction,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls", "l"},
+				Usage:   "List role bindings",
+				Flags: []cli.Flag{
+					rolesEnvFlag(),
+					&cli.StringFlag{
+						Name:    "subject",
+						Aliases: []string{"s"},
+						Usage:   "Show the roles of this user:{email}, group:{name} or claim:{name}={value}",
+					},
+				},
+				Action: listRolesAction,
+			},
+			{
+				Name:    "revoke",
+				Aliases: []string{"rm", "delete"},
+				Usage:   "Revoke a role binding",
+				Flags: []cli.Flag{
+					rolesEnvFlag(),
+					&cli.StringFlag{
+						Name:     "id",
+						Usage:    "Role binding ID (from list)",
+						Required: true,
+					},
+				},
+				Action: revokeRoleAction,
+			},
+		},
+	}
+}
+
+// rolesEnvFlag returns the --env flag shared by the roles subcommands
+func rolesEnvFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "env",
+		Aliases:  []string{"e"},
+		Usage:    "AWS Deployer environment (dev, stg, or prd) - determines which DynamoDB table to use",
+		Required: true,
+		EnvVars:  []string{"ENV"},
+	}
+}
+
+// grantRoleAction grants a role to a user or group
+func grantRoleAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	role, err := authz.ParseRole(c.String("role"))
+	if err != nil {
+		return err
+	}
+
+	dao, err := createRoleDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	record, err := dao.Create(c.Context, roledao.CreateInput{
+		Subject:   c.String("subject"),
+		Role:      role,
+		Repo:      c.String("repo"),
+		Env:       c.String("target-env"),
+		CreatedBy: os.Getenv("USER"),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("env", env).
+		Str("id", record.GetID().String()).
+		Str("role", string(record.Role)).
+		Msg("Role granted")
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionRoleGrant,
+		Repo:   record.Repo,
+		Env:    record.Env,
+		Target: record.GetID().String(),
+		After:  record,
+	})
+
+	fmt.Printf("\n✓ Granted %s on %s/%s to %s (%s)\n", record.Role, record.Repo, record.Env, record.PK, record.GetID())
+	return nil
+}
+
+// listRolesAction lists role bindings
+func listRolesAction(c *cli.Context) error {
+	env := c.String("env")
+	subject := c.String("subject")
+
+	dao, err := createRoleDAO(env)
+	if err != nil {
+		return err
+	}
+
+	var records []roledao.Record
+	if subject != "" {
+		records, err = dao.Query(c.Context, subject)
+	} else {
+		records, err = dao.FindAll(c.Context)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No roles granted")
+		return nil
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].PK != records[j].PK {
+			return records[i].PK < records[j].PK
+		}
+		return records[i].SK < records[j].SK
+	})
+
+	fmt.Println()
+	for _, record := range records {
+		fmt.Printf("%s\n", record.GetID())
+		fmt.Printf("  Role:  %s\n", record.Role)
+		fmt.Printf("  Repo:  %s\n", record.Repo)
+		fmt.Printf("  Env:   %s\n", record.Env)
+		if record.CreatedBy != "" {
+			fmt.Printf("  Granted by: %s\n", record.CreatedBy)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// revokeRoleAction revokes a role binding
+func revokeRoleAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	id := roledao.ID(c.String("id"))
+
+	dao, err := createRoleDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.Delete(c.Context, id); err != nil {
+		return err
+	}
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionRoleRevoke,
+		Target: id.String(),
+	})
+
+	logger.Info().
+		Str("env", env).
+		Str("id", id.String()).
+		Msg("Role revoked")
+
+	fmt.Println("\n✓ Role revoked")
+	return nil
+}
+
+// createRoleDAO creates a roledao.DAO instance
+func createRoleDAO(env string) (*roledao.DAO, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return roledao.New(dbClient, roledao.TableName(env)), nil
+}
@@ -251,6 +251,53 @@ Run this command from within the target account.`,
 					)
 				},
 			},
+			{
+				Name:  "setup-events-target",
+				Usage: "Create stack events reader role in target account",
+				Description: `Create the StackEventsReaderRole in a target account.
+
+This role allows the deployer account's server to read CloudFormation stack events of
+StackSet instances, so deployments can be watched live, and allows the deployer to record
+the stack outputs of each instance. The role is read-only: it can describe stacks and
+stack events, nothing else.
+
+Run this command from within the target account.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "deployer-account",
+						Usage:    "Deployer AWS account ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "env",
+						Usage:    "Environment (dev, staging, prod)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "region",
+						Usage: "AWS region",
+						Value: "us-west-2",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show what would be created without creating it",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					ctx := c.Context
+					handler, err := newAWSHandler(ctx, c.String("region"))
+					if err != nil {
+						return err
+					}
+
+					return handler.setupStackEventsTargetAccount(
+						ctx,
+						c.String("deployer-account"),
+						c.String("env"),
+						c.Bool("dry-run"),
+					)
+				},
+			},
 		},
 	}
 }
@@ -779,3 +826,128 @@ func (h *awsHandler) setupECRTargetAccount(ctx context.Context, deployerAccountI
 	return nil
 }
 
+// getStackEventsTrustPolicy creates the trust policy for StackEventsReaderRole
+func getStackEventsTrustPolicy(deployerAccountID, env string) string {
+	// Trust the server Lambda role (stack events) and the multi-account Lambda role
+	// (stack outputs) from the deployer account
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"AWS": []string{
+						fmt.Sprintf("arn:aws:iam::%s:role/%s-aws-deployer-lambda-role", deployerAccountID, env),
+						fmt.Sprintf("arn:aws:iam::%s:role/%s-aws-deployer-multi-account-lambda-role", deployerAccountID, env),
+					},
+				},
+				"Action": "sts:AssumeRole",
+			},
+		},
+	}
+
+	policyJSON, _ := json.Marshal(policy)
+	return string(policyJSON)
+}
+
+// getStackEventsPermissionsPolicy creates the read-only permissions policy for StackEventsReaderRole
+func getStackEventsPermissionsPolicy() string {
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":    "ReadStackEvents",
+				"Effect": "Allow",
+				"Action": []string{
+					"cloudformation:DescribeStacks",
+					"cloudformation:DescribeStackEvents",
+				},
+				"Resource": "arn:aws:cloudformation:*:*:stack/StackSet-*",
+			},
+		},
+	}
+
+	policyJSON, _ := json.Marshal(policy)
+	return string(policyJSON)
+}
+
+// setupStackEventsTargetAccount creates the StackEventsReaderRole in a target account
+func (h *awsHandler) setupStackEventsTargetAccount(ctx context.Context, deployerAccountID, env string, dryRun bool) error {
+	// Get current account ID
+	identity, err := h.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	targetAccountID := *identity.Account
+
+	roleName := constants.StackEventsReaderRoleName
+	trustPolicy := getStackEventsTrustPolicy(deployerAccountID, env)
+	permissionsPolicy := getStackEventsPermissionsPolicy()
+
+	if dryRun {
+		fmt.Printf("DRY RUN: Would create stack events reader role in account %s:\n", targetAccountID)
+		fmt.Printf("Role Name: %s\n", roleName)
+		fmt.Printf("Trust Policy:\n%s\n", prettyJSON(trustPolicy))
+		fmt.Printf("Permissions Policy:\n%s\n", prettyJSON(permissionsPolicy))
+		return nil
+	}
+
+	fmt.Printf("Creating stack events reader role in account %s...\n", targetAccountID)
+
+	// Check if role already exists
+	_, err = h.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	})
+	if err == nil {
+		fmt.Printf("Role %s already exists. Updating trust policy...\n", roleName)
+		_, err = h.iamClient.UpdateAssumeRolePolicy(ctx, &iam.UpdateAssumeRolePolicyInput{
+			RoleName:       aws.String(roleName),
+			PolicyDocument: aws.String(trustPolicy),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update trust policy: %w", err)
+		}
+		fmt.Printf("Updated trust policy\n")
+	} else {
+		_, err = h.iamClient.CreateRole(ctx, &iam.CreateRoleInput{
+			RoleName:                 aws.String(roleName),
+			AssumeRolePolicyDocument: aws.String(trustPolicy),
+			Description:              aws.String("Stack events reader role for aws-deployer (read-only)"),
+			Tags: []iamtypes.Tag{
+				{
+					Key:   aws.String("ManagedBy"),
+					Value: aws.String("aws-deployer"),
+				},
+				{
+					Key:   aws.String("Purpose"),
+					Value: aws.String("StackEventsReader"),
+				},
+				{
+					Key:   aws.String("Environment"),
+					Value: aws.String(env),
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		fmt.Printf("Created role: %s\n", roleName)
+	}
+
+	// Attach inline policy for read-only stack access
+	policyName := "StackEventsReaderPolicy"
+	_, err = h.iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyName:     aws.String(policyName),
+		PolicyDocument: aws.String(permissionsPolicy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach permissions policy: %w", err)
+	}
+	fmt.Printf("Attached permissions policy: %s\n", policyName)
+
+	fmt.Printf("\n✓ Stack events setup complete for account %s\n", targetAccountID)
+	fmt.Printf("  Role ARN: arn:aws:iam::%s:role/%s\n", targetAccountID, roleName)
+	fmt.Printf("  Trusted by: %s-aws-deployer-lambda-role in account %s\n", env, deployerAccountID)
+	return nil
+}
@@ -3,6 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
+	"regexp"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -22,6 +25,7 @@ This command sets up SSM parameters that control:
   - Whether signature verification is enabled
   - Enforcement mode (warn vs enforce)
   - Signing profile names for verification
+  - Cosign trust policy for container images (public key or keyless identity)
 
 Verification is performed by the verify-signatures Lambda function during deployments.
 
@@ -36,6 +40,15 @@ Examples:
   # Enable signature verification in production (enforce mode)
   aws-deployer setup-signing --env prod --enforcement-mode enforce
 
+  # Verify container images signed with a cosign key pair
+  aws-deployer setup-signing --env prod --enforcement-mode enforce --cosign-public-key cosign.pub
+
+  # Verify keyless container signatures from GitHub Actions
+  aws-deployer setup-signing --env prod --enforcement-mode enforce \
+    --cosign-issuer https://token.actions.githubusercontent.com \
+    --cosign-subject-regex '^https://github.com/acme/.+/\.github/workflows/release\.yml@refs/heads/main$' \
+    --fulcio-roots fulcio.pem --rekor-public-key rekor.pub
+
   # Disable signature verification
   aws-deployer setup-signing --env dev --lambda-verification disabled`,
 		Flags: []cli.Flag{
@@ -70,6 +83,26 @@ Examples:
 				Name:  "lambda-profile-name",
 				Usage: "AWS Signer profile name for Lambda signature verification (optional)",
 			},
+			&cli.StringFlag{
+				Name:  "cosign-public-key",
+				Usage: "Path to the PEM public key that container images are signed with (key-based cosign)",
+			},
+			&cli.StringFlag{
+				Name:  "cosign-issuer",
+				Usage: "OIDC issuer required on keyless cosign certificates",
+			},
+			&cli.StringFlag{
+				Name:  "cosign-subject-regex",
+				Usage: "Regex the keyless cosign certificate identity (email or URI) must match",
+			},
+			&cli.StringFlag{
+				Name:  "fulcio-roots",
+				Usage: "Path to the PEM Fulcio root and intermediate certificates (keyless cosign)",
+			},
+			&cli.StringFlag{
+				Name:  "rekor-public-key",
+				Usage: "Path to the PEM Rekor public key used to verify transparency log bundles",
+			},
 			&cli.BoolFlag{
 				Name:  "dry-run",
 				Usage: "Show what would be configured without making changes",
@@ -103,6 +136,11 @@ func setupSigningAction(c *cli.Context, logger *zerolog.Logger) error {
 		return fmt.Errorf("enforcement-mode must be 'warn' or 'enforce'")
 	}
 
+	cosign, err := cosignParameters(c)
+	if err != nil {
+		return err
+	}
+
 	// Show configuration
 	logger.Info().Msg("Signature Verification Configuration")
 	logger.Info().Msgf("Environment:             %s", env)
@@ -122,6 +160,9 @@ func setupSigningAction(c *cli.Context, logger *zerolog.Logger) error {
 		if lambdaProfileName != "" {
 			logger.Info().Msgf("  /%s/aws-deployer/signing/lambda-profile-name = %s", env, lambdaProfileName)
 		}
+		for name := range cosign {
+			logger.Info().Msgf("  /%s/aws-deployer/signing/cosign/%s", env, name)
+		}
 		return nil
 	}
 
@@ -144,15 +185,24 @@ func setupSigningAction(c *cli.Context, logger *zerolog.Logger) error {
 		parameters[fmt.Sprintf("/%s/aws-deployer/signing/lambda-profile-name", env)] = lambdaProfileName
 	}
 
+	for name, value := range cosign {
+		parameters[fmt.Sprintf("/%s/aws-deployer/signing/cosign/%s", env, name)] = value
+	}
+
 	logger.Info().Msg("Storing configuration in SSM Parameter Store...")
 
 	for path, value := range parameters {
-		logger.Info().Msgf("  Setting %s = %s", path, value)
+		if strings.Contains(path, "/signing/cosign/") {
+			logger.Info().Msgf("  Setting %s", path)
+		} else {
+			logger.Info().Msgf("  Setting %s = %s", path, value)
+		}
 
 		_, err := ssmClient.PutParameter(ctx, &ssm.PutParameterInput{
 			Name:      aws.String(path),
 			Value:     aws.String(value),
 			Type:      types.ParameterTypeString,
+			Tier:      parameterTier(value),
 			Overwrite: aws.Bool(true),
 			Description: aws.String(fmt.Sprintf("Code signing configuration for %s environment", env)),
 		})
@@ -189,3 +239,57 @@ func setupSigningAction(c *cli.Context, logger *zerolog.Logger) error {
 
 	return nil
 }
+
+// cosignParameters returns the cosign trust policy parameters to store, keyed by name under
+// /{env}/aws-deployer/signing/cosign/. Key-based and keyless settings are mutually exclusive.
+func cosignParameters(c *cli.Context) (map[string]string, error) {
+	parameters := map[string]string{}
+
+	files := map[string]string{
+		"public-key":       c.String("cosign-public-key"),
+		"fulcio-roots":     c.String("fulcio-roots"),
+		"rekor-public-key": c.String("rekor-public-key"),
+	}
+	for name, path := range files {
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		parameters[name] = string(content)
+	}
+	if issuer := c.String("cosign-issuer"); issuer != "" {
+		parameters["issuer"] = issuer
+	}
+	if subject := c.String("cosign-subject-regex"); subject != "" {
+		if _, err := regexp.Compile(subject); err != nil {
+			return nil, fmt.Errorf("invalid cosign-subject-regex: %w", err)
+		}
+		parameters["subject-regex"] = subject
+	}
+
+	_, hasKey := parameters["public-key"]
+	_, hasIssuer := parameters["issuer"]
+	_, hasSubject := parameters["subject-regex"]
+	_, hasRoots := parameters["fulcio-roots"]
+	keyless := hasIssuer || hasSubject || hasRoots
+
+	if hasKey && keyless {
+		return nil, fmt.Errorf("--cosign-public-key cannot be combined with keyless settings")
+	}
+	if keyless && !(hasIssuer && hasSubject && hasRoots && parameters["rekor-public-key"] != "") {
+		return nil, fmt.Errorf("keyless cosign verification requires --cosign-issuer, --cosign-subject-regex, --fulcio-roots and --rekor-public-key")
+	}
+
+	return parameters, nil
+}
+
+// parameterTier returns the SSM tier needed to store value; certificates can exceed the 4 KB standard limit
+func parameterTier(value string) types.ParameterTier {
+	if len(value) > 4096 {
+		return types.ParameterTierAdvanced
+	}
+	return types.ParameterTierStandard
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/smithy-go"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
 	"github.com/savaki/aws-deployer/internal/dao/lockdao"
@@ -221,6 +222,11 @@ func syncAction(c *cli.Context, logger *zerolog.Logger) error {
 		}
 	}
 
+	auditLog, err := newCLIAudit(ctx, env)
+	if err != nil {
+		return err
+	}
+
 	// Step 3: Delete data for orphaned repos
 	for _, o := range orphaned {
 		logger.Info().Str("repo", o.name).Msg("Deleting data for orphaned repo")
@@ -234,8 +240,20 @@ func syncAction(c *cli.Context, logger *zerolog.Logger) error {
 		// Delete locks
 		for _, targetEnv := range envs {
 			lockID := lockdao.NewID(targetEnv, o.name)
+			lock, err := lockDAO.Find(ctx, lockID)
+			if err != nil {
+				logger.Warn().Err(err).Str("repo", o.name).Str("env", targetEnv).Msg("Failed to find lock")
+			}
 			if err := lockDAO.Delete(ctx, lockID); err != nil {
 				logger.Warn().Err(err).Str("repo", o.name).Str("env", targetEnv).Msg("Failed to delete lock")
+			} else if lock != nil {
+				auditLog.record(ctx, audit.Event{
+					Action: audit.ActionLockRelease,
+					Repo:   o.name,
+					Env:    targetEnv,
+					Target: lockID.String(),
+					Before: lock,
+				})
 			}
 		}
 
@@ -277,14 +295,24 @@ func syncAction(c *cli.Context, logger *zerolog.Logger) error {
 		}
 
 		// Delete target records (all envs)
+		var deletedTargets []*targetdao.Record
 		for _, record := range targetRecords {
 			if record.PK.String() == o.name {
 				if err := targetDAO.Delete(ctx, record.GetID()); err != nil {
 					logger.Warn().Err(err).Str("id", record.GetID().String()).Msg("Failed to delete target")
+					continue
 				}
+				deletedTargets = append(deletedTargets, record)
 			}
 		}
 
+		auditLog.record(ctx, audit.Event{
+			Action: audit.ActionRepoPurge,
+			Repo:   o.name,
+			Target: o.name,
+			Before: deletedTargets,
+		})
+
 		fmt.Printf("✓ Deleted data for %s\n", o.name)
 	}
 
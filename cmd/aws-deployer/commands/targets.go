@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
 	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/urfave/cli/v2"
 )
@@ -55,7 +57,53 @@ Examples:
   aws-deployer targets set --env dev --target-env dev --repo my-app \
     --accounts "123456789012" \
     --regions "us-east-1" \
-    --overwrite`,
+    --overwrite
+
+  # Require a second person to approve promotions into prd
+  aws-deployer targets set --env prd --target-env prd --default \
+    --accounts "123456789012" \
+    --regions "us-east-1" \
+    --required-approvals 1 \
+    --approvers "alice@example.com,bob@example.com"
+
+  # Automatically promote dev builds to stg after a 30 minute soak
+  aws-deployer targets set --env dev --target-env dev --default \
+    --accounts "123456789012" \
+    --regions "us-east-1" \
+    --downstream-env "stg" \
+    --auto-promote \
+    --soak-time 30m
+
+  # Freeze prd deploys from Friday 18:00 until Monday 08:00 and only deploy on weekday mornings
+  aws-deployer targets set --env prd --target-env prd --default \
+    --accounts "123456789012" \
+    --regions "us-east-1" \
+    --timezone "America/New_York" \
+    --freeze "0 18 * * 5|62h|Weekend freeze" \
+    --allowed-window "0 9 * * 1-5|3h"
+
+  # Roll out prd StackSet instances to one canary target, then 25%, then the rest
+  aws-deployer targets set --env prd --target-env prd --default \
+    --accounts "123456789012,210987654321" \
+    --regions "us-east-1,us-west-2" \
+    --waves-json '[{"name":"canary","count":1,"bake_seconds":1800},{"percentage":25,"failure_tolerance_count":1,"bake_seconds":600},{"max_concurrent_count":5}]'
+
+  # Deploy prd StackSet instances one region at a time, 25% of accounts at once
+  aws-deployer targets set --env prd --target-env prd --default \
+    --accounts "123456789012,210987654321" \
+    --regions "us-east-1,us-west-2" \
+    --region-order "us-west-2,us-east-1" \
+    --region-concurrency SEQUENTIAL \
+    --max-concurrent-percentage 25 \
+    --failure-tolerance-count 1
+
+  # Protect the prd stack from deletion and its tables and buckets from replacement
+  aws-deployer targets set --env prd --target-env prd --default \
+    --accounts "123456789012" \
+    --regions "us-east-1" \
+    --termination-protection \
+    --protect-resources \
+    --protected-types "AWS::DynamoDB::Table,AWS::S3::Bucket"`,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:     "env",
@@ -106,6 +154,85 @@ Examples:
 						Usage:   "Comma-separated list of downstream environments (e.g., 'stg' for dev, 'prd' for stg)",
 						EnvVars: []string{"DOWNSTREAM_ENV"},
 					},
+					&cli.IntFlag{
+						Name:    "required-approvals",
+						Usage:   "Number of approvals required before a promoted build deploys to this environment (0 disables approval)",
+						EnvVars: []string{"REQUIRED_APPROVALS"},
+					},
+					&cli.StringFlag{
+						Name:    "approvers",
+						Usage:   "Comma-separated list of emails allowed to approve (default: any authenticated user)",
+						EnvVars: []string{"APPROVERS"},
+					},
+					&cli.BoolFlag{
+						Name:  "auto-promote",
+						Usage: "Automatically promote successful builds to the downstream environments",
+					},
+					&cli.DurationFlag{
+						Name:  "soak-time",
+						Usage: "How long a successful build bakes before auto-promoted builds deploy (e.g. 30m, 2h)",
+					},
+					&cli.BoolFlag{
+						Name:  "auto-rollback",
+						Usage: "Redeploy the last successful build when a deployment to this environment fails",
+					},
+					&cli.BoolFlag{
+						Name:  "block-on-drift",
+						Usage: "Refuse to promote builds into this environment while its stack has drifted",
+					},
+					&cli.BoolFlag{
+						Name:  "termination-protection",
+						Usage: "Enable termination protection on this environment's stack (single-account only)",
+					},
+					&cli.BoolFlag{
+						Name:  "protect-resources",
+						Usage: "Fail builds that would replace or delete stateful resources, and deny it with a stack policy",
+					},
+					&cli.StringFlag{
+						Name:  "protected-types",
+						Usage: "Comma-separated resource types --protect-resources guards (default: DynamoDB tables, S3 buckets, RDS and EFS)",
+					},
+					&cli.StringFlag{
+						Name:  "timezone",
+						Usage: "IANA timezone of the --freeze and --allowed-window cron expressions (default: UTC)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "freeze",
+						Usage: "Freeze window as \"CRON|DURATION|REASON\"; builds created while it is open are held as BLOCKED (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "allowed-window",
+						Usage: "Allowed deploy window as \"CRON|DURATION\"; when set, builds created outside every window are held as BLOCKED (repeatable)",
+					},
+					&cli.StringFlag{
+						Name:    "waves-json",
+						Usage:   "Ordered StackSet rollout waves as JSON array; the last wave deploys to the remaining targets (multi-account only)",
+						EnvVars: []string{"WAVES_JSON"},
+					},
+					&cli.StringFlag{
+						Name:  "region-order",
+						Usage: "Comma-separated order StackSet operations deploy regions in (multi-account only)",
+					},
+					&cli.StringFlag{
+						Name:  "region-concurrency",
+						Usage: "Deploy StackSet regions SEQUENTIAL or PARALLEL (multi-account only, default: SEQUENTIAL)",
+					},
+					&cli.IntFlag{
+						Name:  "max-concurrent-count",
+						Usage: "Accounts a StackSet operation deploys to at once per region (multi-account only, default: 10)",
+					},
+					&cli.IntFlag{
+						Name:  "max-concurrent-percentage",
+						Usage: "Percentage of accounts a StackSet operation deploys to at once per region (multi-account only)",
+					},
+					&cli.IntFlag{
+						Name:  "failure-tolerance-count",
+						Usage: "Failed accounts per region tolerated before a StackSet operation fails (multi-account only)",
+					},
+					&cli.IntFlag{
+						Name:  "failure-tolerance-percentage",
+						Usage: "Percentage of failed accounts per region tolerated before a StackSet operation fails (multi-account only)",
+					},
 					&cli.BoolFlag{
 						Name:    "overwrite",
 						Aliases: []string{"o"},
@@ -295,6 +422,29 @@ func setAction(c *cli.Context) error {
 	regionsStr := c.String("regions")
 	targetsJSON := c.String("targets-json")
 	downstreamEnvStr := c.String("downstream-env")
+	requiredApprovals := c.Int("required-approvals")
+	approversStr := c.String("approvers")
+	autoPromote := c.Bool("auto-promote")
+	soakTime := c.Duration("soak-time")
+	autoRollback := c.Bool("auto-rollback")
+	blockOnDrift := c.Bool("block-on-drift")
+	protection := &targetdao.Protection{
+		TerminationProtection: c.Bool("termination-protection"),
+		ProtectResources:      c.Bool("protect-resources"),
+		ResourceTypes:         parseCommaSeparated(c.String("protected-types")),
+	}
+	timezone := c.String("timezone")
+	freezes := c.StringSlice("freeze")
+	allowedWindows := c.StringSlice("allowed-window")
+	wavesJSON := c.String("waves-json")
+	preferences := &targetdao.OperationPreferences{
+		RegionOrder:                parseCommaSeparated(c.String("region-order")),
+		RegionConcurrencyType:      strings.ToUpper(c.String("region-concurrency")),
+		MaxConcurrentCount:         c.Int("max-concurrent-count"),
+		MaxConcurrentPercentage:    c.Int("max-concurrent-percentage"),
+		FailureToleranceCount:      c.Int("failure-tolerance-count"),
+		FailureTolerancePercentage: c.Int("failure-tolerance-percentage"),
+	}
 	overwrite := c.Bool("overwrite")
 	isDefault := c.Bool("default")
 
@@ -358,12 +508,101 @@ func setAction(c *cli.Context) error {
 		downstreamEnv = parseCommaSeparated(downstreamEnvStr)
 	}
 
+	// Parse approval policy
+	if requiredApprovals < 0 {
+		return fmt.Errorf("--required-approvals cannot be negative")
+	}
+	if approversStr != "" && requiredApprovals == 0 {
+		return fmt.Errorf("--approvers requires --required-approvals")
+	}
+	var approval *targetdao.ApprovalPolicy
+	if requiredApprovals > 0 {
+		approval = &targetdao.ApprovalPolicy{
+			RequiredApprovals: requiredApprovals,
+			Approvers:         parseCommaSeparated(approversStr),
+		}
+		if len(approval.Approvers) > 0 && len(approval.Approvers) < requiredApprovals {
+			return fmt.Errorf("--required-approvals (%d) exceeds the number of approvers (%d)", requiredApprovals, len(approval.Approvers))
+		}
+	}
+
+	// Validate auto-promotion settings
+	if autoPromote && len(downstreamEnv) == 0 {
+		return fmt.Errorf("--auto-promote requires --downstream-env")
+	}
+	if soakTime < 0 {
+		return fmt.Errorf("--soak-time cannot be negative")
+	}
+	if soakTime > 0 && !autoPromote {
+		return fmt.Errorf("--soak-time requires --auto-promote")
+	}
+
+	// Parse change calendar
+	var calendar *targetdao.ChangeCalendar
+	if len(freezes) > 0 || len(allowedWindows) > 0 {
+		calendar = &targetdao.ChangeCalendar{Timezone: timezone}
+		for _, s := range freezes {
+			w, err := parseWindow(s)
+			if err != nil {
+				return fmt.Errorf("invalid --freeze: %w", err)
+			}
+			calendar.Freezes = append(calendar.Freezes, w)
+		}
+		for _, s := range allowedWindows {
+			w, err := parseWindow(s)
+			if err != nil {
+				return fmt.Errorf("invalid --allowed-window: %w", err)
+			}
+			calendar.Allowed = append(calendar.Allowed, w)
+		}
+		if err := calendar.Validate(); err != nil {
+			return err
+		}
+	} else if timezone != "" {
+		return fmt.Errorf("--timezone requires --freeze or --allowed-window")
+	}
+
+	// Parse rollout waves
+	var waves []targetdao.Wave
+	if wavesJSON != "" {
+		if err := json.Unmarshal([]byte(wavesJSON), &waves); err != nil {
+			return fmt.Errorf("failed to parse waves JSON: %w", err)
+		}
+		if err := targetdao.ValidateWaves(waves); err != nil {
+			return err
+		}
+	}
+
+	// Validate StackSet operation preferences
+	if err := preferences.Validate(); err != nil {
+		return err
+	}
+	if preferences.RegionConcurrencyType == targetdao.RegionConcurrencyParallel && len(preferences.RegionOrder) > 0 {
+		return fmt.Errorf("--region-order requires SEQUENTIAL region concurrency")
+	}
+	if preferences.IsEmpty() {
+		preferences = nil
+	}
+
+	// Validate stack protection; explicitly disabling it is kept so deploys remove the protection
+	if err := protection.Validate(); err != nil {
+		return fmt.Errorf("invalid --protected-types: %w", err)
+	}
+	if !c.IsSet("termination-protection") && !c.IsSet("protect-resources") {
+		protection = nil
+	}
+
 	// Create DAO
 	dao, err := createDAO(env)
 	if err != nil {
 		return err
 	}
 
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
 	// Check if targets already exist
 	id := targetdao.NewID(repo, targetEnv)
 	existing, err := dao.Find(c.Context, id)
@@ -383,6 +622,15 @@ func setAction(c *cli.Context) error {
 			Env:           targetEnv,
 			Targets:       targets,
 			DownstreamEnv: downstreamEnv,
+			Approval:      approval,
+			AutoPromote:   autoPromote,
+			SoakSeconds:   int(soakTime.Seconds()),
+			AutoRollback:  autoRollback,
+			BlockOnDrift:  blockOnDrift,
+			Calendar:      calendar,
+			Waves:         waves,
+			Preferences:   preferences,
+			Protection:    protection,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create targets: %w", err)
@@ -393,6 +641,15 @@ func setAction(c *cli.Context) error {
 			ID:            id,
 			Targets:       targets,
 			DownstreamEnv: downstreamEnv,
+			Approval:      approval,
+			AutoPromote:   autoPromote,
+			SoakSeconds:   int(soakTime.Seconds()),
+			AutoRollback:  autoRollback,
+			BlockOnDrift:  blockOnDrift,
+			Calendar:      calendar,
+			Waves:         waves,
+			Preferences:   preferences,
+			Protection:    protection,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to update targets: %w", err)
@@ -400,6 +657,15 @@ func setAction(c *cli.Context) error {
 		logger.Info().Msg("Targets updated successfully")
 	}
 
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionTargetsSet,
+		Repo:   repo,
+		Env:    targetEnv,
+		Target: id.String(),
+		Before: existing,
+		After:  record,
+	})
+
 	// Display the targets
 	displayTargets(record, isDefault, false)
 
@@ -519,6 +785,28 @@ func parseCommaSeparated(s string) []string {
 	return result
 }
 
+// parseWindow parses a "CRON|DURATION[|REASON]" window, e.g. "0 18 * * 5|62h|Weekend freeze"
+func parseWindow(s string) (targetdao.Window, error) {
+	parts := strings.SplitN(s, "|", 3)
+	if len(parts) < 2 {
+		return targetdao.Window{}, fmt.Errorf("%q: expected CRON|DURATION[|REASON]", s)
+	}
+
+	duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return targetdao.Window{}, fmt.Errorf("%q: invalid duration: %w", s, err)
+	}
+
+	w := targetdao.Window{
+		Cron:            strings.TrimSpace(parts[0]),
+		DurationSeconds: int(duration.Seconds()),
+	}
+	if len(parts) == 3 {
+		w.Reason = strings.TrimSpace(parts[2])
+	}
+	return w, nil
+}
+
 // displayTargets prints the deployment targets in a readable format
 func displayTargets(record *targetdao.Record, isDefault, usedFallback bool) {
 	fmt.Println()
@@ -545,11 +833,106 @@ func displayTargets(record *targetdao.Record, isDefault, usedFallback bool) {
 		fmt.Println()
 	}
 
+	// Show auto-promotion if configured
+	if record.AutoPromote {
+		fmt.Printf("Auto-promote: enabled (soak time %s)\n", record.SoakTime())
+		fmt.Println()
+	}
+
+	// Show auto-rollback if configured
+	if record.AutoRollback {
+		fmt.Println("Auto-rollback: enabled")
+		fmt.Println()
+	}
+
+	// Show drift blocking if configured
+	if record.BlockOnDrift {
+		fmt.Println("Block on drift: enabled")
+		fmt.Println()
+	}
+
+	// Show stack protection if configured
+	if !record.Protection.IsEmpty() {
+		if record.Protection.TerminationProtection {
+			fmt.Println("Termination protection: enabled")
+		}
+		if types := record.Protection.Types(); len(types) > 0 {
+			fmt.Printf("Protected resources: %s\n", strings.Join(types, ", "))
+		}
+		fmt.Println()
+	}
+
+	// Show approval policy if configured
+	if record.Approval.Required() {
+		fmt.Printf("Approvals required: %d\n", record.Approval.RequiredApprovals)
+		if len(record.Approval.Approvers) > 0 {
+			fmt.Printf("Approvers: %s\n", strings.Join(record.Approval.Approvers, ", "))
+		}
+		fmt.Println()
+	}
+
+	// Show change calendar if configured
+	if !record.Calendar.IsEmpty() {
+		timezone := record.Calendar.Timezone
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		fmt.Printf("Change calendar (%s):\n", timezone)
+		for _, w := range record.Calendar.Freezes {
+			fmt.Printf("  Freeze: %q for %s", w.Cron, w.Duration())
+			if w.Reason != "" {
+				fmt.Printf(" - %s", w.Reason)
+			}
+			fmt.Println()
+		}
+		for _, w := range record.Calendar.Allowed {
+			fmt.Printf("  Allowed: %q for %s\n", w.Cron, w.Duration())
+		}
+		fmt.Println()
+	}
+
+	// Show StackSet operation preferences if configured
+	if p := record.Preferences; p != nil {
+		fmt.Println("StackSet operation preferences:")
+		if p.RegionConcurrencyType != "" {
+			fmt.Printf("  Region concurrency: %s\n", p.RegionConcurrencyType)
+		}
+		if len(p.RegionOrder) > 0 {
+			fmt.Printf("  Region order: %s\n", strings.Join(p.RegionOrder, ", "))
+		}
+		if p.MaxConcurrentPercentage > 0 {
+			fmt.Printf("  Max concurrent: %d%%\n", p.MaxConcurrentPercentage)
+		} else if p.MaxConcurrentCount > 0 {
+			fmt.Printf("  Max concurrent: %d\n", p.MaxConcurrentCount)
+		}
+		if p.FailureTolerancePercentage > 0 {
+			fmt.Printf("  Failure tolerance: %d%%\n", p.FailureTolerancePercentage)
+		} else if p.FailureToleranceCount > 0 {
+			fmt.Printf("  Failure tolerance: %d\n", p.FailureToleranceCount)
+		}
+		fmt.Println()
+	}
+
 	// Show expanded targets
 	expanded := targetdao.ExpandTargets(record.Targets)
 	fmt.Printf("Total deployments: %d\n", len(expanded))
 	fmt.Println()
 
+	// Show rollout waves if configured
+	if len(record.Waves) > 0 {
+		fmt.Println("Rollout waves:")
+		for i, w := range targetdao.PlanWaves(expanded, record.Waves, record.Preferences) {
+			fmt.Printf("  %d. %s: %d targets, max concurrent %s, failure tolerance %s", i+1, w.Name, len(w.Targets),
+				countOrPercentage(w.Preferences.MaxConcurrentCount, w.Preferences.MaxConcurrentPercentage),
+				countOrPercentage(w.Preferences.FailureToleranceCount, w.Preferences.FailureTolerancePercentage))
+			if w.BakeSeconds > 0 {
+				fmt.Printf(", bake %s", w.BakeTime())
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
 	// Group by account for display
 	accountMap := make(map[string][]string)
 	for _, target := range expanded {
@@ -572,6 +955,14 @@ func displayTargets(record *targetdao.Record, isDefault, usedFallback bool) {
 	}
 }
 
+// countOrPercentage formats an operation preference set either as a count or as a percentage
+func countOrPercentage(count, percentage int) string {
+	if percentage > 0 {
+		return fmt.Sprintf("%d%%", percentage)
+	}
+	return fmt.Sprintf("%d", count)
+}
+
 // displayJSON prints the targets as JSON
 func displayJSON(record *targetdao.Record) {
 	output := map[string]interface{}{
@@ -585,6 +976,31 @@ func displayJSON(record *targetdao.Record) {
 	if len(record.DownstreamEnv) > 0 {
 		output["downstream_env"] = record.DownstreamEnv
 	}
+	if record.Approval.Required() {
+		output["approval"] = record.Approval
+	}
+	if record.AutoPromote {
+		output["auto_promote"] = true
+		output["soak_seconds"] = record.SoakSeconds
+	}
+	if record.AutoRollback {
+		output["auto_rollback"] = true
+	}
+	if record.BlockOnDrift {
+		output["block_on_drift"] = true
+	}
+	if !record.Protection.IsEmpty() {
+		output["protection"] = record.Protection
+	}
+	if !record.Calendar.IsEmpty() {
+		output["calendar"] = record.Calendar
+	}
+	if len(record.Waves) > 0 {
+		output["waves"] = record.Waves
+	}
+	if record.Preferences != nil {
+		output["operation_preferences"] = record.Preferences
+	}
 	jsonBytes, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
@@ -737,6 +1153,24 @@ func displayMultipleTargets(records []envRecord, isDefault bool, config *targetd
 		if len(rec.record.DownstreamEnv) > 0 {
 			fmt.Printf("Next: %s\n", strings.Join(rec.record.DownstreamEnv, " → "))
 		}
+		if rec.record.Approval.Required() {
+			fmt.Printf("Approvals required: %d\n", rec.record.Approval.RequiredApprovals)
+		}
+		if rec.record.AutoPromote {
+			fmt.Printf("Auto-promote: enabled (soak time %s)\n", rec.record.SoakTime())
+		}
+		if rec.record.AutoRollback {
+			fmt.Println("Auto-rollback: enabled")
+		}
+		if rec.record.BlockOnDrift {
+			fmt.Println("Block on drift: enabled")
+		}
+		if rec.record.Protection != nil && rec.record.Protection.TerminationProtection {
+			fmt.Println("Termination protection: enabled")
+		}
+		if types := rec.record.Protection.Types(); len(types) > 0 {
+			fmt.Printf("Protected resources: %s\n", strings.Join(types, ", "))
+		}
 		fmt.Println()
 
 		expanded := targetdao.ExpandTargets(rec.record.Targets)
@@ -788,6 +1222,22 @@ func displayMultipleJSON(records []envRecord, config *targetdao.Record) {
 		if len(rec.record.DownstreamEnv) > 0 {
 			step["next"] = rec.record.DownstreamEnv
 		}
+		if rec.record.Approval.Required() {
+			step["approval"] = rec.record.Approval
+		}
+		if rec.record.AutoPromote {
+			step["auto_promote"] = true
+			step["soak_seconds"] = rec.record.SoakSeconds
+		}
+		if rec.record.AutoRollback {
+			step["auto_rollback"] = true
+		}
+		if rec.record.BlockOnDrift {
+			step["block_on_drift"] = true
+		}
+		if !rec.record.Protection.IsEmpty() {
+			step["protection"] = rec.record.Protection
+		}
 		steps[i] = step
 	}
 	output["steps"] = steps
@@ -831,11 +1281,29 @@ func configAction(c *cli.Context) error {
 	}
 
 	if initialEnv != "" {
+		auditLog, err := newCLIAudit(c.Context, env)
+		if err != nil {
+			return err
+		}
+
+		existing, err := dao.GetConfig(c.Context, repo)
+		if err != nil {
+			return fmt.Errorf("failed to get configuration: %w", err)
+		}
+
 		record, err := dao.SetConfig(c.Context, repo, initialEnv)
 		if err != nil {
 			return fmt.Errorf("failed to set initial environment: %w", err)
 		}
 
+		auditLog.record(c.Context, audit.Event{
+			Action: audit.ActionTargetsConfig,
+			Repo:   repo,
+			Target: record.GetID().String(),
+			Before: existing,
+			After:  record,
+		})
+
 		logger.Info().
 			Str("repo", repo).
 			Str("initial_env", initialEnv).
@@ -955,11 +1423,24 @@ func deleteAction(c *cli.Context) error {
 		}
 	}
 
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
 	err = dao.Delete(c.Context, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete targets: %w", err)
 	}
 
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionTargetsDelete,
+		Repo:   repo,
+		Env:    targetEnv,
+		Target: id.String(),
+		Before: existing,
+	})
+
 	logger.Info().
 		Str("env", env).
 		Str("repo", repo).
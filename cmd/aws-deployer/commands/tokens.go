@@ -0,0 +1,340 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/apitoken"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/tokendao"
+	"github.com/urfave/cli/v2"
+)
+
+// TokensCommand returns the token command for managing API tokens
+func TokensCommand(logger *zerolog.Logger) *cli.Command {
+	return &cli.Command{
+		Name:    "token",
+		Aliases: []string{"tokens"},
+		Usage:   "Manage API tokens for scripts, CI and bots",
+		Description: `Manage API tokens, which authenticate requests to the GraphQL API sent with
+Authorization: Bearer {token}.
+
+Personal tokens (--owner) act as a user and hold that user's roles. Service tokens (--service)
+act as service:{name}; grant them roles with:
+  aws-deployer roles grant --subject service:{name} ...
+
+Every token is scoped to the mutations in --actions on the repos and environments matching
+--repo and --target-env; a token without actions is read-only. Tokens expire and are stored
+hashed, so a token is only shown once, when it is created.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "create",
+				Aliases: []string{"add"},
+				Usage:   "Create an API token",
+				Description: `Create an API token and print it. The token cannot be shown again.
+
+Examples:
+  # Let CI redeploy and promote anything in dev for 90 days
+  aws-deployer token create --env prd --name github-actions --service ci \
+    --actions redeploy,promote --target-env dev
+
+  # A read-only token for alice's dashboards
+  aws-deployer token create --env prd --name dashboards --owner alice@example.com --expires-in-days 30`,
+				Flags: []cli.Flag{
+					tokensEnvFlag(),
+					&cli.StringFlag{
+						Name:     "name",
+						Aliases:  []string{"n"},
+						Usage:    "Name of the token, e.g. github-actions",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "owner",
+						Usage: "Email of the user a personal token acts as",
+					},
+					&cli.StringFlag{
+						Name:  "service",
+						Usage: "Name of the service a service token acts as (service:{name})",
+					},
+					&cli.StringSliceFlag{
+						Name:    "actions",
+						Aliases: []string{"a"},
+						Usage:   "Mutations the token may run: redeploy, promote, approve, reject, overrideFreeze, allowReplacement (omit for read-only)",
+					},
+					&cli.StringFlag{
+						Name:    "repo",
+						Aliases: []string{"r"},
+						Usage:   "Repository glob",
+						Value:   "*",
+					},
+					&cli.StringFlag{
+						Name:    "target-env",
+						Aliases: []string{"t"},
+						Usage:   "Target environment glob",
+						Value:   "*",
+					},
+					&cli.StringSliceFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Usage:   "Group the token acts as a member of (repeatable), e.g. to hold the roles granted to it",
+					},
+					&cli.IntFlag{
+						Name:  "expires-in-days",
+						Usage: "Days until the token expires (at most 365)",
+						Value: 90,
+					},
+				},
+				Action: createTokenAction,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls", "l"},
+				Usage:   "List API tokens",
+				Flags: []cli.Flag{
+					tokensEnvFlag(),
+				},
+				Action: listTokensAction,
+			},
+			{
+				Name:    "revoke",
+				Aliases: []string{"rm", "delete"},
+				Usage:   "Revoke an API token",
+				Flags: []cli.Flag{
+					tokensEnvFlag(),
+					&cli.StringFlag{
+						Name:     "id",
+						Usage:    "Token ID (from list)",
+						Required: true,
+					},
+				},
+				Action: revokeTokenAction,
+			},
+		},
+	}
+}
+
+// tokensEnvFlag returns the --env flag shared by the token subcommands
+func tokensEnvFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "env",
+		Aliases:  []string{"e"},
+		Usage:    "AWS Deployer environment (dev, stg, or prd) - determines which DynamoDB table to use",
+		Required: true,
+		EnvVars:  []string{"ENV"},
+	}
+}
+
+// createTokenAction creates an API token and prints it once
+func createTokenAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	owner, service := c.String("owner"), c.String("service")
+	if (owner == "") == (service == "") {
+		return fmt.Errorf("exactly one of --owner or --service is required")
+	}
+
+	input := apitoken.Input{
+		Name:      c.String("name"),
+		Kind:      tokendao.KindPersonal,
+		Owner:     owner,
+		Groups:    c.StringSlice("group"),
+		Actions:   splitActions(c.StringSlice("actions")),
+		Repo:      c.String("repo"),
+		Env:       c.String("target-env"),
+		TTL:       time.Duration(c.Int("expires-in-days")) * 24 * time.Hour,
+		CreatedBy: os.Getenv("USER"),
+	}
+	if service != "" {
+		input.Kind = tokendao.KindService
+		input.Owner = service
+	}
+
+	token, createInput, err := apitoken.New(input)
+	if err != nil {
+		return err
+	}
+
+	dao, err := createTokenDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	record, err := dao.Create(c.Context, createInput)
+	if err != nil {
+		return err
+	}
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionTokenCreate,
+		Repo:   record.Repo,
+		Env:    record.Env,
+		Target: record.GetID(),
+		After:  record,
+	})
+
+	logger.Info().
+		Str("env", env).
+		Str("id", record.GetID()).
+		Str("kind", string(record.Kind)).
+		Str("owner", record.Owner).
+		Msg("API token created")
+
+	fmt.Printf("\n✓ Created %s token %s (%s) for %s, expires %s\n", record.Kind, record.Name, record.GetID(), record.Owner,
+		time.Unix(record.ExpiresAt, 0).Format(time.RFC3339))
+	fmt.Println("\nStore this token now; it will not be shown again:")
+	fmt.Printf("\n  %s\n\n", token)
+	return nil
+}
+
+// splitActions accepts actions given as repeated flags or comma separated values
+func splitActions(values []string) []string {
+	var actions []string
+	for _, value := range values {
+		for _, action := range strings.Split(value, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+// listTokensAction lists API tokens
+func listTokensAction(c *cli.Context) error {
+	env := c.String("env")
+
+	dao, err := createTokenDAO(env)
+	if err != nil {
+		return err
+	}
+
+	records, err := dao.FindAll(c.Context)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No API tokens")
+		return nil
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt < records[j].CreatedAt
+	})
+
+	now := time.Now()
+	fmt.Println()
+	for _, record := range records {
+		status := "active"
+		switch {
+		case record.IsRevoked():
+			status = "revoked"
+		case record.IsExpired(now):
+			status = "expired"
+		}
+
+		actions := "read-only"
+		if len(record.Actions) > 0 {
+			actions = strings.Join(record.Actions, ", ")
+		}
+
+		fmt.Printf("%s\n", record.GetID())
+		fmt.Printf("  Name:    %s\n", record.Name)
+		fmt.Printf("  Owner:   %s (%s)\n", record.Owner, record.Kind)
+		fmt.Printf("  Status:  %s\n", status)
+		fmt.Printf("  Actions: %s\n", actions)
+		fmt.Printf("  Repo:    %s\n", valueOrAll(record.Repo))
+		fmt.Printf("  Env:     %s\n", valueOrAll(record.Env))
+		if len(record.Groups) > 0 {
+			fmt.Printf("  Groups:  %s\n", strings.Join(record.Groups, ", "))
+		}
+		fmt.Printf("  Expires: %s\n", time.Unix(record.ExpiresAt, 0).Format(time.RFC3339))
+		if record.LastUsedAt != 0 {
+			fmt.Printf("  Last used: %s\n", time.Unix(record.LastUsedAt, 0).Format(time.RFC3339))
+		} else {
+			fmt.Printf("  Last used: never\n")
+		}
+		if record.CreatedBy != "" {
+			fmt.Printf("  Created by: %s\n", record.CreatedBy)
+		}
+		if record.RevokedBy != "" {
+			fmt.Printf("  Revoked by: %s\n", record.RevokedBy)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// valueOrAll returns glob, or * if it is empty
+func valueOrAll(glob string) string {
+	if glob == "" {
+		return "*"
+	}
+	return glob
+}
+
+// revokeTokenAction revokes an API token
+func revokeTokenAction(c *cli.Context) error {
+	logger := zerolog.Ctx(c.Context)
+
+	env := c.String("env")
+	id := c.String("id")
+
+	dao, err := createTokenDAO(env)
+	if err != nil {
+		return err
+	}
+
+	auditLog, err := newCLIAudit(c.Context, env)
+	if err != nil {
+		return err
+	}
+
+	existing, err := dao.Find(c.Context, id)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.Revoke(c.Context, id, os.Getenv("USER")); err != nil {
+		return err
+	}
+
+	auditLog.record(c.Context, audit.Event{
+		Action: audit.ActionTokenRevoke,
+		Target: id,
+		Before: existing,
+	})
+
+	logger.Info().
+		Str("env", env).
+		Str("id", id).
+		Msg("API token revoked")
+
+	fmt.Println("\n✓ API token revoked")
+	return nil
+}
+
+// createTokenDAO creates a tokendao.DAO instance
+func createTokenDAO(env string) (*tokendao.DAO, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	dbClient := dynamodb.NewFromConfig(cfg)
+	return tokendao.New(dbClient, tokendao.TableName(env)), nil
+}
@@ -21,13 +21,21 @@ func main() {
 This tool provides commands for:
   - Setting up AWS accounts for multi-account deployments
   - Configuring GitHub repositories with OIDC authentication
-  - Managing deployment targets across accounts and regions`,
+  - Managing deployment targets across accounts and regions
+  - Managing build notifications
+  - Managing role bindings and API tokens
+  - Reading the audit log`,
 		Commands: []*cli.Command{
 			commands.SetupAWSCommand(&logger),
 			commands.SetupGitHubCommand(&logger),
 			commands.SetupECRCommand(&logger),
 			commands.SetupSigningCommand(&logger),
 			commands.TargetsCommand(&logger),
+			commands.NotificationsCommand(&logger),
+			commands.RolesCommand(&logger),
+			commands.TokensCommand(&logger),
+			commands.LocksCommand(&logger),
+			commands.AuditCommand(&logger),
 			commands.SyncCommand(&logger),
 		},
 	}
@@ -16,19 +16,98 @@ export type Scalars = {
   DateTime: { input: string; output: string; }
 };
 
+/** Approval records an approver's decision on a build awaiting approval */
+export type Approval = {
+  __typename?: 'Approval';
+  /** Optional comment or rejection reason */
+  comment?: Maybe<Scalars['String']['output']>;
+  /** Timestamp of the decision */
+  createdAt: Scalars['DateTime']['output'];
+  /** Decision (APPROVED or REJECTED) */
+  decision: Scalars['String']['output'];
+  /** Approver email */
+  email: Scalars['String']['output'];
+  /** Approver display name */
+  name?: Maybe<Scalars['String']['output']>;
+};
+
+/** ApprovalPolicy describes the sign-off required before deploying into an environment */
+export type ApprovalPolicy = {
+  __typename?: 'ApprovalPolicy';
+  /** Emails allowed to approve (empty allows any authenticated user) */
+  approvers: Array<Scalars['String']['output']>;
+  /** Number of approvals required */
+  requiredApprovals: Scalars['Int']['output'];
+};
+
+/** AuditEvent records a change made through GraphQL, the aws-deployer CLI or the deployment state machine */
+export type AuditEvent = {
+  __typename?: 'AuditEvent';
+  /** What was done, e.g. build.redeploy, targets.set or lock.release */
+  action: Scalars['String']['output'];
+  /** Who made the change: an email, service:{name}, an IAM ARN or state-machine */
+  actor: Scalars['String']['output'];
+  /** Kind of actor: user, service, iam or system */
+  actorType: Scalars['String']['output'];
+  /** JSON of the value after the change */
+  after?: Maybe<Scalars['String']['output']>;
+  /** JSON of the value before the change */
+  before?: Maybe<Scalars['String']['output']>;
+  /** Timestamp of the change */
+  createdAt: Scalars['DateTime']['output'];
+  /** Environment affected */
+  env?: Maybe<Scalars['String']['output']>;
+  /** Event ID ({day}:{ksuid}) */
+  id: Scalars['ID']['output'];
+  /** Repository affected */
+  repo?: Maybe<Scalars['String']['output']>;
+  /** ID of the request that made the change */
+  requestId?: Maybe<Scalars['String']['output']>;
+  /** Where the change was made: graphql, cli or state-machine */
+  source: Scalars['String']['output'];
+  /** ID of the record changed, e.g. a build ID */
+  target?: Maybe<Scalars['String']['output']>;
+};
+
+/** AuditFilter selects audit events; omitted fields match everything */
+export type AuditFilter = {
+  /** Action, or a prefix such as build for all build.* actions */
+  action?: InputMaybe<Scalars['String']['input']>;
+  /** Actor, e.g. alice@example.com or service:ci */
+  actor?: InputMaybe<Scalars['String']['input']>;
+  env?: InputMaybe<Scalars['String']['input']>;
+  repo?: InputMaybe<Scalars['String']['input']>;
+  /** Earliest event (defaults to 7 days before until) */
+  since?: InputMaybe<Scalars['DateTime']['input']>;
+  /** ID of the record changed */
+  target?: InputMaybe<Scalars['String']['input']>;
+  /** Latest event (defaults to now) */
+  until?: InputMaybe<Scalars['DateTime']['input']>;
+};
+
 /** Build represents a deployment build */
 export type Build = {
   __typename?: 'Build';
+  /** Approval decisions recorded on this build */
+  approvals: Array<Approval>;
+  /** Why the build is held (BLOCKED builds), e.g. an active deploy freeze */
+  blockedReason?: Maybe<Scalars['String']['output']>;
   /** Git branch */
   branch: Scalars['String']['output'];
   /** Build number from version */
   buildNumber: Scalars['String']['output'];
+  /** Change set preview (single-account deployments) */
+  changeSet?: Maybe<ChangeSet>;
   /** Git commit hash */
   commitHash: Scalars['String']['output'];
+  /** Link to the build's commit on GitHub (when GitHub reporting is configured) */
+  commitUrl?: Maybe<Scalars['String']['output']>;
   /** Deployment errors from multi-account deployments */
   deploymentErrors: Array<DeploymentError>;
   /** Downstream environments configured for promotion */
   downstreamEnvs: Array<Scalars['String']['output']>;
+  /** Latest drift detection results of the build's stacks */
+  drift: Array<StackDrift>;
   /** Timestamp when build ended (if completed) */
   endTime?: Maybe<Scalars['DateTime']['output']>;
   /** Environment name (dev, staging, prod) */
@@ -37,27 +116,97 @@ export type Build = {
   errorMsg?: Maybe<Scalars['String']['output']>;
   /** Step Functions execution ARN */
   executionArn?: Maybe<Scalars['String']['output']>;
+  /** Who deployed this build despite a deploy freeze, and why */
+  freezeOverride?: Maybe<FreezeOverride>;
+  /** ID of the GitHub Deployment reporting this build */
+  githubDeploymentId?: Maybe<Scalars['String']['output']>;
   /** Unique build ID in format: {repo}/{env}:{ksuid} */
   id: Scalars['ID']['output'];
+  /** Stack outputs of each StackSet instance deployed by this build (multi-account deployments) */
+  instanceOutputs: Array<StackInstanceOutputs>;
+  /** Whether this build is an automatic rollback to the last known-good version */
+  isRollback: Scalars['Boolean']['output'];
+  /** Stack outputs recorded once the stack deployed (single-account deployments) */
+  outputs: Array<StackOutput>;
+  /** Template policy violations found while deploying this build */
+  policyViolations: Array<PolicyViolation>;
+  /** Email of the user who promoted this build */
+  promotedBy?: Maybe<Scalars['String']['output']>;
+  /** ID of the upstream build this build was promoted from */
+  promotedFrom?: Maybe<Scalars['ID']['output']>;
+  /** Who allowed this build to replace or delete protected resources, and why */
+  replacementOverride?: Maybe<ReplacementOverride>;
   /** Repository name */
   repo: Scalars['String']['output'];
+  /** Number of approvals required before this build deploys */
+  requiredApprovals: Scalars['Int']['output'];
+  /** ID of the failed build this build rolls back */
+  rollbackOf?: Maybe<Scalars['ID']['output']>;
   /** CloudFormation stack name */
   stackName: Scalars['String']['output'];
+  /** Time the deployment waits for before starting (soak time of an auto-promotion) */
+  startAfter?: Maybe<Scalars['DateTime']['output']>;
   /** Timestamp when build started */
   startTime: Scalars['DateTime']['output'];
   /** Current build status */
   status: BuildStatus;
+  /** States visited by the build's Step Functions execution, in the order they were entered */
+  timeline: Array<TimelineStep>;
   /** Version string */
   version: Scalars['String']['output'];
 };
 
+/** BuildConnection is a page of builds */
+export type BuildConnection = {
+  __typename?: 'BuildConnection';
+  edges: Array<BuildEdge>;
+  pageInfo: PageInfo;
+};
+
+/** BuildEdge is a build and the cursor to resume after it */
+export type BuildEdge = {
+  __typename?: 'BuildEdge';
+  /** Pass as after to get the builds following this one */
+  cursor: Scalars['String']['output'];
+  node: Build;
+};
+
+/** BuildFilter selects builds; omitted fields match everything */
+export type BuildFilter = {
+  /** Exact branch name */
+  branch?: InputMaybe<Scalars['String']['input']>;
+  /** Prefix of the commit hash */
+  commitPrefix?: InputMaybe<Scalars['String']['input']>;
+  /** Earliest start time */
+  since?: InputMaybe<Scalars['DateTime']['input']>;
+  /** Any of these statuses */
+  status?: InputMaybe<Array<BuildStatus>>;
+  /** Start times before this */
+  until?: InputMaybe<Scalars['DateTime']['input']>;
+  /** Exact version */
+  version?: InputMaybe<Scalars['String']['input']>;
+};
+
 /** Build status enum representing the current state of a build */
 export type BuildStatus =
+  | 'BLOCKED'
   | 'FAILED'
   | 'IN_PROGRESS'
   | 'PENDING'
+  | 'PENDING_APPROVAL'
   | 'SUCCESS';
 
+/** ChangeSet is a preview of the changes a build applies to its stack */
+export type ChangeSet = {
+  __typename?: 'ChangeSet';
+  /** Resource changes in the change set */
+  changes: Array<ResourceChange>;
+  /** Change set name */
+  name: Scalars['String']['output'];
+  /** Change set type (CREATE or UPDATE) */
+  type: Scalars['String']['output'];
+};
+
 /** Deployment error from a multi-account deployment */
 export type DeploymentError = {
   __typename?: 'DeploymentError';
@@ -74,22 +223,78 @@ export type DeploymentError = {
 /** DeploymentTargets represents deployment configuration for a specific environment */
 export type DeploymentTargets = {
   __typename?: 'DeploymentTargets';
+  /** Approval required before deploying into this environment */
+  approvalPolicy?: Maybe<ApprovalPolicy>;
+  /** Whether successful builds are promoted to downstream environments automatically */
+  autoPromote: Scalars['Boolean']['output'];
+  /** Whether a failed deployment redeploys the last successful build */
+  autoRollback: Scalars['Boolean']['output'];
+  /** Whether promotions into this environment are refused while its stack has drifted */
+  blockOnDrift: Scalars['Boolean']['output'];
   /** Downstream environments for promotion */
   downstreamEnvs: Array<Scalars['String']['output']>;
+  /** Latest drift detection results of this environment's stacks */
+  drift: Array<StackDrift>;
   /** Environment name */
   env: Scalars['String']['output'];
+  /** Termination protection and stack policy of this environment's stack */
+  protection?: Maybe<StackProtection>;
   /** Repository name (or '$' for default) */
   repo: Scalars['String']['output'];
+  /** Seconds a build soaks in this environment before auto-promoted builds deploy */
+  soakSeconds: Scalars['Int']['output'];
   /** List of deployment targets */
   targets: Array<Target>;
 };
 
+/** FreezeOverride records an emergency deploy of a build held by a deploy freeze */
+export type FreezeOverride = {
+  __typename?: 'FreezeOverride';
+  /** Timestamp of the override */
+  createdAt: Scalars['DateTime']['output'];
+  /** Email of the user who overrode the freeze */
+  email: Scalars['String']['output'];
+  /** Display name of the user who overrode the freeze */
+  name?: Maybe<Scalars['String']['output']>;
+  /** Why the freeze was overridden */
+  reason: Scalars['String']['output'];
+};
+
 export type Mutation = {
   __typename?: 'Mutation';
+  /**
+   * Redeploy a build that failed because it would replace or delete protected resources; the override
+   * and its reason are recorded on the new build
+   */
+  allowReplacement: Query;
+  /** Approve a build awaiting approval; the build deploys once all required approvals are recorded */
+  approve: Query;
+  /** Deploy a build held by a deploy freeze; the override and its reason are recorded on the build */
+  overrideFreeze: Query;
   /** Promote a build to downstream environments */
   promote: Query;
   /** Redeploy a specific version */
   redeploy: Query;
+  /** Reject a build awaiting approval */
+  reject: Query;
+};
+
+
+export type MutationAllowReplacementArgs = {
+  buildId: Scalars['ID']['input'];
+  reason: Scalars['String']['input'];
+};
+
+
+export type MutationApproveArgs = {
+  buildId: Scalars['ID']['input'];
+  comment?: InputMaybe<Scalars['String']['input']>;
+};
+
+
+export type MutationOverrideFreezeArgs = {
+  buildId: Scalars['ID']['input'];
+  reason: Scalars['String']['input'];
 };
 
 
@@ -102,6 +307,25 @@ export type MutationRedeployArgs = {
   buildId: Scalars['ID']['input'];
 };
 
+
+export type MutationRejectArgs = {
+  buildId: Scalars['ID']['input'];
+  reason?: InputMaybe<Scalars['String']['input']>;
+};
+
+/** PageInfo describes the position of a page within a connection */
+export type PageInfo = {
+  __typename?: 'PageInfo';
+  /** Cursor of the last result of the page; pass as after to get the next page */
+  endCursor?: Maybe<Scalars['String']['output']>;
+  /** Whether more results follow this page */
+  hasNextPage: Scalars['Boolean']['output'];
+  /** Always false; connections only page forward */
+  hasPreviousPage: Scalars['Boolean']['output'];
+  /** Cursor of the first result of the page */
+  startCursor?: Maybe<Scalars['String']['output']>;
+};
+
 /** PipelineConfig represents the promotion structure for a repository */
 export type PipelineConfig = {
   __typename?: 'PipelineConfig';
@@ -113,12 +337,40 @@ export type PipelineConfig = {
   repo: Scalars['String']['output'];
 };
 
+/** PolicyViolation is a template policy violation found while deploying a build */
+export type PolicyViolation = {
+  __typename?: 'PolicyViolation';
+  /** Violation message reported by the policy */
+  message: Scalars['String']['output'];
+  /** Policy mode (enforce or warn) */
+  mode: Scalars['String']['output'];
+  /** Name of the policy that was violated */
+  policy: Scalars['String']['output'];
+};
+
+/** PropertyDifference is a resource property whose live value differs from the template */
+export type PropertyDifference = {
+  __typename?: 'PropertyDifference';
+  /** Value of the live resource */
+  actualValue?: Maybe<Scalars['String']['output']>;
+  /** Difference type (ADD, REMOVE, NOT_EQUAL) */
+  differenceType: Scalars['String']['output'];
+  /** Value in the template */
+  expectedValue?: Maybe<Scalars['String']['output']>;
+  /** Path of the property (e.g. /Properties/Tags) */
+  propertyPath: Scalars['String']['output'];
+};
+
 export type Query = {
   __typename?: 'Query';
-  /** List recent builds for a given environment */
-  builds: Array<Build>;
-  /** List all builds for a specific repository and environment */
-  buildsByRepo: Array<Build>;
+  /** List audit events matching the filter, newest first. The time range may span at most 90 days. */
+  auditEvents: Array<AuditEvent>;
+  /** Get a build by ID, or null if it does not exist */
+  build?: Maybe<Build>;
+  /** Page through the latest build of each repository in a given environment, ordered by repository */
+  builds: BuildConnection;
+  /** Page through the builds of a specific repository and environment, newest first */
+  buildsByRepo: BuildConnection;
   /** Simple health check that returns "ok" */
   ok: Scalars['String']['output'];
   /** Get all pipeline configurations (default and per-repo) */
@@ -126,16 +378,166 @@ export type Query = {
 };
 
 
+export type QueryAuditEventsArgs = {
+  filter?: InputMaybe<AuditFilter>;
+  limit?: InputMaybe<Scalars['Int']['input']>;
+};
+
+
+export type QueryBuildArgs = {
+  id: Scalars['ID']['input'];
+};
+
+
 export type QueryBuildsArgs = {
+  after?: InputMaybe<Scalars['String']['input']>;
   env: Scalars['String']['input'];
+  filter?: InputMaybe<BuildFilter>;
+  first?: InputMaybe<Scalars['Int']['input']>;
 };
 
 
 export type QueryBuildsByRepoArgs = {
+  after?: InputMaybe<Scalars['String']['input']>;
   env: Scalars['String']['input'];
+  filter?: InputMaybe<BuildFilter>;
+  first?: InputMaybe<Scalars['Int']['input']>;
   repo: Scalars['String']['input'];
 };
 
+/** ReplacementOverride records who allowed a build to replace or delete protected resources */
+export type ReplacementOverride = {
+  __typename?: 'ReplacementOverride';
+  /** Timestamp of the override */
+  createdAt: Scalars['DateTime']['output'];
+  /** Email of the user who allowed the replacement */
+  email: Scalars['String']['output'];
+  /** Display name of the user who allowed the replacement */
+  name?: Maybe<Scalars['String']['output']>;
+  /** Why the replacement was allowed */
+  reason: Scalars['String']['output'];
+};
+
+/** ResourceChange describes a single resource change in a CloudFormation change set */
+export type ResourceChange = {
+  __typename?: 'ResourceChange';
+  /** Change action (Add, Modify, Remove, Import, Dynamic) */
+  action: Scalars['String']['output'];
+  /** Logical resource ID from the template */
+  logicalResourceId: Scalars['String']['output'];
+  /** Physical resource ID (for existing resources) */
+  physicalResourceId?: Maybe<Scalars['String']['output']>;
+  /** Whether the resource will be replaced (True, False, Conditional) */
+  replacement?: Maybe<Scalars['String']['output']>;
+  /** CloudFormation resource type */
+  resourceType: Scalars['String']['output'];
+};
+
+/** ResourceDrift is a resource modified or deleted outside CloudFormation */
+export type ResourceDrift = {
+  __typename?: 'ResourceDrift';
+  /** Properties that differ from the template */
+  differences: Array<PropertyDifference>;
+  /** Logical resource ID from the template */
+  logicalResourceId: Scalars['String']['output'];
+  /** Physical resource ID */
+  physicalResourceId?: Maybe<Scalars['String']['output']>;
+  /** CloudFormation resource type */
+  resourceType: Scalars['String']['output'];
+  /** Drift status (MODIFIED or DELETED) */
+  status: Scalars['String']['output'];
+};
+
+/** StackDrift is the result of the latest drift detection of a stack or StackSet instance */
+export type StackDrift = {
+  __typename?: 'StackDrift';
+  /** AWS Account ID of the stack */
+  accountId: Scalars['String']['output'];
+  /** Timestamp of the drift detection */
+  checkedAt: Scalars['DateTime']['output'];
+  /** Why drift could not be determined (UNKNOWN only) */
+  error?: Maybe<Scalars['String']['output']>;
+  /** AWS Region of the stack */
+  region: Scalars['String']['output'];
+  /** Drifted resources */
+  resources: Array<ResourceDrift>;
+  /** Stack or StackSet name */
+  stackName: Scalars['String']['output'];
+  /** Drift status (IN_SYNC, DRIFTED, UNKNOWN) */
+  status: Scalars['String']['output'];
+};
+
+/** StackEvent is a resource-level CloudFormation stack event */
+export type StackEvent = {
+  __typename?: 'StackEvent';
+  /** Target account of the StackSet instance, null for single-account stacks */
+  accountId?: Maybe<Scalars['String']['output']>;
+  /** CloudFormation event ID */
+  id: Scalars['ID']['output'];
+  /** Logical ID of the resource in the template */
+  logicalResourceId: Scalars['String']['output'];
+  /** Physical ID of the resource, once created */
+  physicalResourceId?: Maybe<Scalars['String']['output']>;
+  /** Target region of the StackSet instance, null for single-account stacks */
+  region?: Maybe<Scalars['String']['output']>;
+  /** Resource status (e.g. CREATE_IN_PROGRESS, UPDATE_FAILED) */
+  resourceStatus: Scalars['String']['output'];
+  /** Reason for the status, if any */
+  resourceStatusReason?: Maybe<Scalars['String']['output']>;
+  /** Resource type (e.g. AWS::S3::Bucket) */
+  resourceType: Scalars['String']['output'];
+  /** Stack the event belongs to */
+  stackName: Scalars['String']['output'];
+  /** Time of the event */
+  timestamp: Scalars['DateTime']['output'];
+};
+
+/** StackInstanceOutputs are the outputs of a StackSet instance in a multi-account deployment */
+export type StackInstanceOutputs = {
+  __typename?: 'StackInstanceOutputs';
+  /** AWS Account ID */
+  accountId: Scalars['String']['output'];
+  /** Stack outputs of the instance */
+  outputs: Array<StackOutput>;
+  /** AWS Region */
+  region: Scalars['String']['output'];
+};
+
+/** StackOutput is an output of a deployed CloudFormation stack */
+export type StackOutput = {
+  __typename?: 'StackOutput';
+  /** Output key */
+  key: Scalars['String']['output'];
+  /** Output value */
+  value: Scalars['String']['output'];
+};
+
+/** StackProtection guards an environment's stack against deletion and against replacing stateful resources */
+export type StackProtection = {
+  __typename?: 'StackProtection';
+  /** Resource types the stack policy refuses to replace or delete */
+  protectedTypes: Array<Scalars['String']['output']>;
+  /** Whether the stack has termination protection enabled */
+  terminationProtection: Scalars['Boolean']['output'];
+};
+
+export type Subscription = {
+  __typename?: 'Subscription';
+  /**
+   * Stream CloudFormation stack events of a build while it deploys: the build's stack in
+   * single-account mode, or each of its StackSet instances in multi-account mode.
+   * Completes once the build finishes. Pass the timestamp of the last event received as
+   * after to resume; events in that second may be sent again, so de-duplicate by id.
+   */
+  stackEvents: StackEvent;
+};
+
+
+export type SubscriptionStackEventsArgs = {
+  after?: InputMaybe<Scalars['DateTime']['input']>;
+  buildId: Scalars['ID']['input'];
+};
+
 /** Target represents account IDs and regions for deployment */
 export type Target = {
   __typename?: 'Target';
@@ -145,12 +547,43 @@ export type Target = {
   regions: Array<Scalars['String']['output']>;
 };
 
+/**
+ * TimelineStep is a single visit to a state of a build's Step Functions execution.
+ * States visited more than once (such as the lock wait loop) appear once per visit.
+ */
+export type TimelineStep = {
+  __typename?: 'TimelineStep';
+  /** Error cause of the last failure */
+  cause?: Maybe<Scalars['String']['output']>;
+  /** Seconds spent in the state, null while in progress */
+  durationSeconds?: Maybe<Scalars['Float']['output']>;
+  /** Time the state was entered */
+  enteredAt: Scalars['DateTime']['output'];
+  /** Error name of the last failure */
+  error?: Maybe<Scalars['String']['output']>;
+  /** Time the state was exited, null while in progress */
+  exitedAt?: Maybe<Scalars['DateTime']['output']>;
+  /** Map iteration index, null outside a Map iteration */
+  iteration?: Maybe<Scalars['Int']['output']>;
+  /** State name */
+  name: Scalars['String']['output'];
+  /** Enclosing Map or Parallel state, null for top-level states */
+  parent?: Maybe<Scalars['String']['output']>;
+  /** Number of times a Task state was retried */
+  retries: Scalars['Int']['output'];
+  /** Outcome of the visit (IN_PROGRESS, SUCCEEDED, FAILED) */
+  status: Scalars['String']['output'];
+  /** State type (Task, Choice, Wait, Pass, Map, Parallel, Succeed, Fail) */
+  type: Scalars['String']['output'];
+};
+
 export type BuildsQueryVariables = Exact<{
   env: Scalars['String']['input'];
+  after?: InputMaybe<Scalars['String']['input']>;
 }>;
 
 
-export type BuildsQuery = { __typename?: 'Query', builds: Array<{ __typename?: 'Build', id: string, repo: string, env: string, buildNumber: string, branch: string, version: string, commitHash: string, status: BuildStatus, stackName: string, executionArn?: string | null, downstreamEnvs: Array<string>, startTime: string, endTime?: string | null, errorMsg?: string | null, deploymentErrors: Array<{ __typename?: 'DeploymentError', accountId: string, region: string, statusReason?: string | null, stackEvents: Array<string> }> }> };
+export type BuildsQuery = { __typename?: 'Query', builds: { __typename?: 'BuildConnection', edges: Array<{ __typename?: 'BuildEdge', node: { __typename?: 'Build', id: string, repo: string, env: string, buildNumber: string, branch: string, version: string, commitHash: string, status: BuildStatus, stackName: string, executionArn?: string | null, downstreamEnvs: Array<string>, startTime: string, endTime?: string | null, errorMsg?: string | null, deploymentErrors: Array<{ __typename?: 'DeploymentError', accountId: string, region: string, statusReason?: string | null, stackEvents: Array<string> }> } }>, pageInfo: { __typename?: 'PageInfo', hasNextPage: boolean, endCursor?: string | null } } };
 
 export type BuildsByRepoQueryVariables = Exact<{
   repo: Scalars['String']['input'];
@@ -158,7 +591,7 @@ export type BuildsByRepoQueryVariables = Exact<{
 }>;
 
 
-export type BuildsByRepoQuery = { __typename?: 'Query', buildsByRepo: Array<{ __typename?: 'Build', id: string, repo: string, env: string, buildNumber: string, branch: string, version: string, commitHash: string, status: BuildStatus, stackName: string, executionArn?: string | null, downstreamEnvs: Array<string>, startTime: string, endTime?: string | null, errorMsg?: string | null, deploymentErrors: Array<{ __typename?: 'DeploymentError', accountId: string, region: string, statusReason?: string | null, stackEvents: Array<string> }> }> };
+export type BuildsByRepoQuery = { __typename?: 'Query', buildsByRepo: { __typename?: 'BuildConnection', edges: Array<{ __typename?: 'BuildEdge', node: { __typename?: 'Build', id: string, repo: string, env: string, buildNumber: string, branch: string, version: string, commitHash: string, status: BuildStatus, stackName: string, executionArn?: string | null, downstreamEnvs: Array<string>, startTime: string, endTime?: string | null, errorMsg?: string | null, deploymentErrors: Array<{ __typename?: 'DeploymentError', accountId: string, region: string, statusReason?: string | null, stackEvents: Array<string> }> } }> } };
 
 export type PromoteMutationVariables = Exact<{
   buildId: Scalars['ID']['input'];
@@ -167,6 +600,13 @@ export type PromoteMutationVariables = Exact<{
 
 export type PromoteMutation = { __typename?: 'Mutation', promote: { __typename?: 'Query', ok: string } };
 
+export type RedeployMutationVariables = Exact<{
+  buildId: Scalars['ID']['input'];
+}>;
+
+
+export type RedeployMutation = { __typename?: 'Mutation', redeploy: { __typename?: 'Query', ok: string } };
+
 export type PipelinesQueryVariables = Exact<{ [key: string]: never; }>;
 
 
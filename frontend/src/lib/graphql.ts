@@ -8,45 +8,61 @@ const client = new GraphQLClient(`${window.location.origin}/graphql`)
 
 // GraphQL query for fetching builds
 export const BUILDS_QUERY = /* GraphQL */ `
-  query Builds($env: String!) {
-    builds(env: $env) {
-      id
-      repo
-      env
-      buildNumber
-      branch
-      version
-      commitHash
-      status
-      stackName
-      executionArn
-      downstreamEnvs
-      startTime
-      endTime
-      errorMsg
-      deploymentErrors {
-        accountId
-        region
-        statusReason
-        stackEvents
+  query Builds($env: String!, $after: String) {
+    builds(env: $env, first: 100, after: $after) {
+      edges {
+        node {
+          id
+          repo
+          env
+          buildNumber
+          branch
+          version
+          commitHash
+          status
+          stackName
+          executionArn
+          downstreamEnvs
+          startTime
+          endTime
+          errorMsg
+          deploymentErrors {
+            accountId
+            region
+            statusReason
+            stackEvents
+          }
+        }
+      }
+      pageInfo {
+        hasNextPage
+        endCursor
       }
     }
   }
 `
 
-export type Build = BuildsQuery['builds'][0]
+export type Build = BuildsQuery['builds']['edges'][0]['node']
 
 // Hook to fetch builds for a specific environment
 export function createBuildsQuery(env: string) {
-    const [data] = createResource<BuildsQuery>(async () => {
-        return await client.request<BuildsQuery, BuildsQueryVariables>(
-            BUILDS_QUERY,
-            {env}
-        )
+    // Page through the latest build of every repo in the environment
+    const [data] = createResource<Build[]>(async () => {
+        const builds: Build[] = []
+        let after: string | null | undefined = null
+        do {
+            const result: BuildsQuery = await client.request<BuildsQuery, BuildsQueryVariables>(
+                BUILDS_QUERY,
+                {env, after}
+            )
+            builds.push(...result.builds.edges.map(edge => edge.node))
+            after = result.builds.pageInfo.hasNextPage ? result.builds.pageInfo.endCursor : null
+        } while (after)
+        return builds
     })
 
     return {
-        builds: () => data()?.builds || [],
+        builds: () => data() || [],
         loading: () => data.loading,
         error: () => data.error,
     }
@@ -55,26 +71,30 @@ export function createBuildsQuery(env: string) {
 // GraphQL query for fetching builds by repo and env
 export const BUILDS_BY_REPO_QUERY = /* GraphQL */ `
   query BuildsByRepo($repo: String!, $env: String!) {
-    buildsByRepo(repo: $repo, env: $env) {
-      id
-      repo
-      env
-      buildNumber
-      branch
-      version
-      commitHash
-      status
-      stackName
-      executionArn
-      downstreamEnvs
-      startTime
-      endTime
-      errorMsg
-      deploymentErrors {
-        accountId
-        region
-        statusReason
-        stackEvents
+    buildsByRepo(repo: $repo, env: $env, first: 50) {
+      edges {
+        node {
+          id
+          repo
+          env
+          buildNumber
+          branch
+          version
+          commitHash
+          status
+          stackName
+          executionArn
+          downstreamEnvs
+          startTime
+          endTime
+          errorMsg
+          deploymentErrors {
+            accountId
+            region
+            statusReason
+            stackEvents
+          }
+        }
       }
     }
   }
@@ -86,16 +106,18 @@ export interface BuildsByRepoVariables {
 }
 
 export interface BuildsByRepoQuery {
-    buildsByRepo: Build[]
+    buildsByRepo: {
+        edges: { node: Build }[]
+    }
 }
 
-// Function to fetch builds by repo and env
+// Function to fetch the 50 most recent builds by repo and env
 export async function fetchBuildsByRepo(repo: string, env: string): Promise<Build[]> {
     const result = await client.request<BuildsByRepoQuery, BuildsByRepoVariables>(
         BUILDS_BY_REPO_QUERY,
         { repo, env }
     )
-    return result.buildsByRepo
+    return result.buildsByRepo.edges.map(edge => edge.node)
 }
 
 // GraphQL mutation for promoting a build
@@ -0,0 +1,186 @@
+// Package apitoken issues and verifies the API tokens that scripts, CI and bots send to
+// the GraphQL API as Authorization: Bearer {token}.
+//
+// A token is adt_{id}_{secret}. The ID locates the token record; only the SHA-256 of the
+// secret is stored, so a leaked table does not leak usable tokens.
+package apitoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/tokendao"
+	"github.com/segmentio/ksuid"
+)
+
+// Prefix identifies aws-deployer tokens, e.g. for secret scanners
+const Prefix = "adt_"
+
+// MaxTTL is the longest lifetime a token may be issued with
+const MaxTTL = 365 * 24 * time.Hour
+
+// touchInterval limits how often the last used time of a token is written
+const touchInterval = time.Hour
+
+// ErrInvalidToken is returned for malformed, unknown, expired or revoked tokens
+var ErrInvalidToken = errors.New("invalid API token")
+
+// Input describes a token to issue
+type Input struct {
+	Name      string        // Human-readable name
+	Kind      tokendao.Kind // personal|service
+	Owner     string        // Owner email (personal) or service name (service)
+	Groups    []string      // Groups the token acts as a member of (optional)
+	Actions   []string      // Mutations the token may run (empty for read-only)
+	Repo      string        // Repository glob (optional)
+	Env       string        // Environment glob (optional)
+	TTL       time.Duration // Lifetime of the token
+	CreatedBy string        // Who created the token (optional)
+}
+
+// New generates a token and the record to store for it. The token is only returned here;
+// it cannot be recovered from the record.
+func New(input Input) (string, tokendao.CreateInput, error) {
+	if input.TTL <= 0 || input.TTL > MaxTTL {
+		return "", tokendao.CreateInput{}, fmt.Errorf("token lifetime must be between 0 and %s, got %s", MaxTTL, input.TTL)
+	}
+	for _, action := range input.Actions {
+		if _, err := authz.ParseAction(action); err != nil {
+			return "", tokendao.CreateInput{}, err
+		}
+	}
+	for _, glob := range []string{input.Repo, input.Env} {
+		if err := authz.ValidateGlob(glob); err != nil {
+			return "", tokendao.CreateInput{}, err
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", tokendao.CreateInput{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	id := ksuid.New().String()
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	return Prefix + id + "_" + secret, tokendao.CreateInput{
+		ID:        id,
+		Name:      input.Name,
+		Kind:      input.Kind,
+		Owner:     input.Owner,
+		Groups:    input.Groups,
+		Actions:   input.Actions,
+		Repo:      input.Repo,
+		Env:       input.Env,
+		Hash:      hash(secret),
+		TTL:       input.TTL,
+		CreatedBy: input.CreatedBy,
+	}, nil
+}
+
+// Parse splits a token into its ID and secret
+func Parse(token string) (id, secret string, err error) {
+	rest, ok := strings.CutPrefix(token, Prefix)
+	if !ok {
+		return "", "", ErrInvalidToken
+	}
+	// KSUIDs are alphanumeric, so the first underscore ends the ID
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", "", ErrInvalidToken
+	}
+	return id, secret, nil
+}
+
+// hash returns the stored form of a token secret
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Profile returns the profile a token acts as. Personal tokens act as their owner, so the
+// owner's user bindings apply; service tokens act as service:{owner}.
+func Profile(record *tokendao.Record) auth.Profile {
+	scope := &authz.Scope{Repo: record.Repo, Env: record.Env}
+	for _, action := range record.Actions {
+		scope.Actions = append(scope.Actions, authz.Action(action))
+	}
+
+	if record.Kind == tokendao.KindService {
+		return auth.Profile{
+			Sub:    authz.SubjectServicePrefix + record.Owner,
+			Name:   record.Owner,
+			Groups: record.Groups,
+			Scope:  scope,
+		}
+	}
+	return auth.Profile{
+		Sub:    "token:" + record.GetID(),
+		Name:   record.Owner,
+		Email:  record.Owner,
+		Groups: record.Groups,
+		Scope:  scope,
+	}
+}
+
+// Store loads tokens and records their use
+type Store interface {
+	Find(ctx context.Context, id string) (*tokendao.Record, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// Verifier implements auth.TokenVerifier against the tokens table
+type Verifier struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewVerifier creates a verifier for the tokens in dao
+func NewVerifier(dao *tokendao.DAO) *Verifier {
+	return &Verifier{
+		store: dao,
+		now:   time.Now,
+	}
+}
+
+// VerifyToken returns the profile of a valid token
+func (v *Verifier) VerifyToken(ctx context.Context, token string) (auth.Profile, error) {
+	id, secret, err := Parse(token)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	record, err := v.store.Find(ctx, id)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+	if record == nil || subtle.ConstantTimeCompare([]byte(record.Hash), []byte(hash(secret))) != 1 {
+		return auth.Profile{}, ErrInvalidToken
+	}
+
+	now := v.now()
+	if record.IsRevoked() {
+		return auth.Profile{}, fmt.Errorf("%w: token %s was revoked", ErrInvalidToken, id)
+	}
+	if record.IsExpired(now) {
+		return auth.Profile{}, fmt.Errorf("%w: token %s expired", ErrInvalidToken, id)
+	}
+
+	if now.Sub(time.Unix(record.LastUsedAt, 0)) >= touchInterval {
+		if err := v.store.Touch(ctx, id, now); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("token_id", id).Msg("Failed to record token use")
+		}
+	}
+
+	return Profile(record), nil
+}
@@ -0,0 +1,123 @@
+package apitoken
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/tokendao"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore holds tokens in memory and records touches
+type memoryStore struct {
+	records map[string]*tokendao.Record
+	touched []string
+}
+
+func (m *memoryStore) Find(ctx context.Context, id string) (*tokendao.Record, error) {
+	return m.records[id], nil
+}
+
+func (m *memoryStore) Touch(ctx context.Context, id string, at time.Time) error {
+	m.touched = append(m.touched, id)
+	m.records[id].LastUsedAt = at.Unix()
+	return nil
+}
+
+// issue creates a token and stores its record the way tokendao.DAO.Create would
+func issue(t *testing.T, store *memoryStore, now time.Time, input Input) string {
+	token, in, err := New(input)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	store.records[in.ID] = &tokendao.Record{
+		PK:        in.ID,
+		Name:      in.Name,
+		Kind:      in.Kind,
+		Owner:     in.Owner,
+		Groups:    in.Groups,
+		Actions:   in.Actions,
+		Repo:      in.Repo,
+		Env:       in.Env,
+		Hash:      in.Hash,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(in.TTL).Unix(),
+	}
+	return token
+}
+
+func TestNew(t *testing.T) {
+	token, in, err := New(Input{Name: "ci", Kind: tokendao.KindService, Owner: "ci", TTL: time.Hour})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, Prefix+in.ID+"_"))
+	assert.NotContains(t, in.Hash, strings.TrimPrefix(token, Prefix+in.ID+"_"), "only the hash is stored")
+
+	id, secret, err := Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, in.ID, id)
+	assert.Equal(t, in.Hash, hash(secret))
+
+	_, _, err = New(Input{Kind: tokendao.KindService, Owner: "ci", TTL: 2 * MaxTTL})
+	assert.Error(t, err)
+	_, _, err = New(Input{Kind: tokendao.KindService, Owner: "ci", TTL: time.Hour, Actions: []string{"deleteEverything"}})
+	assert.Error(t, err)
+	_, _, err = New(Input{Kind: tokendao.KindService, Owner: "ci", TTL: time.Hour, Repo: "["})
+	assert.Error(t, err)
+
+	for _, s := range []string{"", "ghp_abc", Prefix + "abc", Prefix + "_secret"} {
+		_, _, err := Parse(s)
+		assert.ErrorIs(t, err, ErrInvalidToken, s)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &memoryStore{records: map[string]*tokendao.Record{}}
+	verifier := &Verifier{store: store, now: func() time.Time { return now }}
+
+	ci := issue(t, store, now, Input{
+		Kind:    tokendao.KindService,
+		Owner:   "ci",
+		Actions: []string{"redeploy"},
+		Env:     "dev",
+		TTL:     time.Hour,
+	})
+	profile, err := verifier.VerifyToken(ctx, ci)
+	assert.NoError(t, err)
+	assert.Equal(t, "service:ci", profile.Sub)
+	assert.Empty(t, profile.Email)
+	assert.Equal(t, &authz.Scope{Actions: []authz.Action{authz.ActionRedeploy}, Env: "dev"}, profile.Scope)
+	assert.Len(t, store.touched, 1)
+
+	// Use within the touch interval is not written again
+	_, err = verifier.VerifyToken(ctx, ci)
+	assert.NoError(t, err)
+	assert.Len(t, store.touched, 1)
+
+	alice := issue(t, store, now, Input{Kind: tokendao.KindPersonal, Owner: "alice@example.com", TTL: time.Hour})
+	profile, err = verifier.VerifyToken(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", profile.Email)
+	assert.Empty(t, profile.Scope.Actions, "tokens without actions are read-only")
+
+	// A wrong secret for a known ID is rejected
+	id, _, _ := Parse(alice)
+	_, err = verifier.VerifyToken(ctx, Prefix+id+"_guess")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	store.records[id].RevokedAt = now.Unix()
+	_, err = verifier.VerifyToken(ctx, alice)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	later := &Verifier{store: store, now: func() time.Time { return now.Add(2 * time.Hour) }}
+	_, err = later.VerifyToken(ctx, ci)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = verifier.VerifyToken(ctx, Prefix+"unknown_secret")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
@@ -0,0 +1,157 @@
+// Package audit records who changed what in the append-only audit table: GraphQL mutations,
+// writes made with the aws-deployer CLI and build status changes made by the state machine.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+)
+
+// Sources of audit events
+const (
+	SourceGraphQL      = "graphql"
+	SourceCLI          = "cli"
+	SourceStateMachine = "state-machine"
+)
+
+// Actions recorded in the audit table, named {resource}.{verb}
+const (
+	ActionRedeploy         = "build.redeploy"
+	ActionPromote          = "build.promote"
+	ActionApprove          = "build.approve"
+	ActionReject           = "build.reject"
+	ActionOverrideFreeze   = "build.overrideFreeze"
+	ActionAllowReplacement = "build.allowReplacement"
+	ActionBuildStatus      = "build.status"
+
+	ActionTargetsSet    = "targets.set"
+	ActionTargetsConfig = "targets.config"
+	ActionTargetsDelete = "targets.delete"
+
+	ActionNotificationAdd    = "notifications.add"
+	ActionNotificationRemove = "notifications.remove"
+
+	ActionRoleGrant  = "roles.grant"
+	ActionRoleRevoke = "roles.revoke"
+
+	ActionTokenCreate = "tokens.create"
+	ActionTokenRevoke = "tokens.revoke"
+
+	ActionLockRelease = "lock.release"
+	ActionRepoPurge   = "repo.purge"
+)
+
+// Actor identifies who or what performed an action
+type Actor struct {
+	Type auditdao.ActorType
+	ID   string // Email, service:{name}, IAM ARN or system component
+	Sub  string // Subject of the session, token or IAM principal (optional)
+}
+
+// SystemActor is the actor of changes made by the deployment state machine
+var SystemActor = Actor{Type: auditdao.ActorSystem, ID: "state-machine"}
+
+// ActorFromProfile returns the actor of an authenticated GraphQL request
+func ActorFromProfile(profile auth.Profile) Actor {
+	switch {
+	case strings.HasPrefix(profile.Sub, authz.SubjectServicePrefix):
+		return Actor{Type: auditdao.ActorService, ID: profile.Sub, Sub: profile.Sub}
+	case profile.Email != "":
+		return Actor{Type: auditdao.ActorUser, ID: profile.Email, Sub: profile.Sub}
+	case profile.Sub != "":
+		return Actor{Type: auditdao.ActorUser, ID: profile.Sub, Sub: profile.Sub}
+	default:
+		// Authentication is disabled
+		return Actor{Type: auditdao.ActorUser, ID: "anonymous"}
+	}
+}
+
+// Event is a change to record
+type Event struct {
+	Action string
+	Repo   string
+	Env    string
+	Target string      // ID of the record changed
+	Before interface{} // Value before the change, marshalled as JSON (nil if created)
+	After  interface{} // Value after the change, marshalled as JSON (nil if deleted)
+}
+
+// Store appends audit events
+type Store interface {
+	Append(ctx context.Context, input auditdao.CreateInput) (auditdao.Record, error)
+}
+
+// Recorder appends events from one source to the audit table
+type Recorder struct {
+	store  Store
+	source string
+}
+
+// New creates a recorder for events from source
+func New(store Store, source string) *Recorder {
+	return &Recorder{
+		store:  store,
+		source: source,
+	}
+}
+
+// Record appends an event performed by actor. The request ID is taken from the context.
+func (r *Recorder) Record(ctx context.Context, actor Actor, event Event) error {
+	before, err := marshal(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshal(event.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.store.Append(ctx, auditdao.CreateInput{
+		ActorType: actor.Type,
+		Actor:     actor.ID,
+		ActorSub:  actor.Sub,
+		Action:    event.Action,
+		Repo:      event.Repo,
+		Env:       event.Env,
+		Target:    event.Target,
+		Before:    before,
+		After:     after,
+		Source:    r.source,
+		RequestID: RequestIDFromContext(ctx),
+	})
+	return err
+}
+
+// marshal returns the JSON of v, or an empty string if v is nil or a nil pointer
+func marshal(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit value: %w", err)
+	}
+	if string(data) == "null" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID of the context, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
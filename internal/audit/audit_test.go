@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore keeps appended events in memory
+type memoryStore []auditdao.CreateInput
+
+func (m *memoryStore) Append(ctx context.Context, input auditdao.CreateInput) (auditdao.Record, error) {
+	*m = append(*m, input)
+	return auditdao.Record{Action: input.Action}, nil
+}
+
+func TestActorFromProfile(t *testing.T) {
+	assert.Equal(t,
+		Actor{Type: auditdao.ActorUser, ID: "alice@example.com", Sub: "user-1"},
+		ActorFromProfile(auth.Profile{Sub: "user-1", Email: "alice@example.com"}))
+	assert.Equal(t,
+		Actor{Type: auditdao.ActorService, ID: "service:ci", Sub: "service:ci"},
+		ActorFromProfile(auth.Profile{Sub: "service:ci", Name: "ci"}))
+	assert.Equal(t, "anonymous", ActorFromProfile(auth.Profile{}).ID)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	store := &memoryStore{}
+	recorder := New(store, SourceGraphQL)
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	err := recorder.Record(ctx, Actor{Type: auditdao.ActorUser, ID: "alice@example.com"}, Event{
+		Action: ActionRedeploy,
+		Repo:   "my-app",
+		Env:    "dev",
+		Target: "my-app/dev:abc",
+		After:  map[string]string{"status": "PENDING"},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, *store, 1) {
+		got := (*store)[0]
+		assert.Equal(t, "req-1", got.RequestID)
+		assert.Equal(t, SourceGraphQL, got.Source)
+		assert.Empty(t, got.Before, "nil values are omitted")
+		assert.JSONEq(t, `{"status":"PENDING"}`, got.After)
+	}
+
+	err = recorder.Record(ctx, SystemActor, Event{Action: ActionBuildStatus, Before: func() {}})
+	assert.Error(t, err, "values must marshal to JSON")
+}
+
+func TestBuildStatusEvent(t *testing.T) {
+	failed := "stack rolled back"
+	oldRecord := &builddao.Record{PK: builddao.NewPK("my-app", "dev"), SK: "abc", Repo: "my-app", Env: "dev", Status: builddao.BuildStatusInProgress}
+	newRecord := *oldRecord
+	newRecord.Status = builddao.BuildStatusFailed
+	newRecord.ErrorMsg = &failed
+
+	event, ok := BuildStatusEvent(oldRecord, &newRecord)
+	if assert.True(t, ok) {
+		assert.Equal(t, ActionBuildStatus, event.Action)
+		assert.Equal(t, "my-app/dev:abc", event.Target)
+		assert.Equal(t, buildStatus{Status: builddao.BuildStatusInProgress}, event.Before)
+		assert.Equal(t, buildStatus{Status: builddao.BuildStatusFailed, Error: failed}, event.After)
+	}
+
+	_, ok = BuildStatusEvent(&newRecord, &newRecord)
+	assert.False(t, ok, "updates that keep the status are not status changes")
+
+	event, ok = BuildStatusEvent(nil, oldRecord)
+	assert.True(t, ok, "new builds")
+	assert.Nil(t, event.Before)
+}
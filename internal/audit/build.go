@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// buildStatus is the before and after value of a build.status event
+type buildStatus struct {
+	Status builddao.BuildStatus `json:"status"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// BuildStatusEvent returns the event for a change to a build record seen on the builds table
+// stream, or false if the change did not move the build to a new status. oldRecord is nil
+// for new builds.
+func BuildStatusEvent(oldRecord, newRecord *builddao.Record) (Event, bool) {
+	if oldRecord != nil && oldRecord.Status == newRecord.Status {
+		return Event{}, false
+	}
+
+	event := Event{
+		Action: ActionBuildStatus,
+		Repo:   newRecord.Repo,
+		Env:    newRecord.Env,
+		Target: newRecord.GetID().String(),
+		After:  statusOf(newRecord),
+	}
+	if oldRecord != nil {
+		event.Before = statusOf(oldRecord)
+	}
+	return event, true
+}
+
+// statusOf returns the status of a build for the audit table
+func statusOf(record *builddao.Record) buildStatus {
+	status := buildStatus{Status: record.Status}
+	if record.ErrorMsg != nil {
+		status.Error = *record.ErrorMsg
+	}
+	return status
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultGroupsClaim is the ID token claim holding the user's groups
+const DefaultGroupsClaim = "groups"
+
+// ClaimMapping selects the ID token claims copied into a Profile beyond sub, name and email.
+// Claim names may be dotted paths into nested objects, e.g. "realm_access.roles" for Keycloak.
+type ClaimMapping struct {
+	GroupsClaim string   // claim holding the user's groups (default DefaultGroupsClaim)
+	Claims      []string // custom claims to copy into Profile.Claims
+}
+
+// Profile builds a profile from the decoded claims of an ID token
+func (m ClaimMapping) Profile(claims map[string]interface{}) Profile {
+	profile := Profile{
+		Sub:   claimString(claims, "sub"),
+		Name:  claimString(claims, "name"),
+		Email: claimString(claims, "email"),
+	}
+
+	groupsClaim := m.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = DefaultGroupsClaim
+	}
+	profile.Groups = claimValues(claims, groupsClaim)
+
+	for _, name := range m.Claims {
+		if values := claimValues(claims, name); len(values) > 0 {
+			if profile.Claims == nil {
+				profile.Claims = map[string][]string{}
+			}
+			profile.Claims[name] = values
+		}
+	}
+
+	return profile
+}
+
+// lookupClaim returns the claim at a dotted path. A claim whose name itself contains dots,
+// such as a namespaced Auth0 claim, is matched before the path is split.
+func lookupClaim(claims map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := claims[name]; ok {
+		return v, true
+	}
+
+	head, rest, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil, false
+	}
+	nested, ok := claims[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupClaim(nested, rest)
+}
+
+// claimString returns a string claim, or "" if missing or not a string
+func claimString(claims map[string]interface{}, name string) string {
+	v, _ := lookupClaim(claims, name)
+	s, _ := v.(string)
+	return s
+}
+
+// claimValues returns a claim as a list of strings: a string is a single value, an array
+// is a value per element and any other scalar is formatted
+func claimValues(claims map[string]interface{}, name string) []string {
+	v, ok := lookupClaim(claims, name)
+	if !ok || v == nil {
+		return nil
+	}
+
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			switch item := item.(type) {
+			case string:
+				values = append(values, item)
+			case nil, map[string]interface{}, []interface{}:
+				// skip values that cannot be matched as strings
+			default:
+				values = append(values, fmt.Sprint(item))
+			}
+		}
+		return values
+	case map[string]interface{}:
+		return nil
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
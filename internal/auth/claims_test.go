@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestClaimMapping_Profile(t *testing.T) {
+	var claims map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"sub": "00u1",
+		"name": "Alice",
+		"email": "alice@example.com",
+		"groups": ["sre", "payments"],
+		"roles": "deployer",
+		"realm_access": {"roles": ["approver", "offline_access"]},
+		"https://example.com/tenant": "acme",
+		"level": 3
+	}`), &claims)
+	if err != nil {
+		t.Fatalf("failed to decode claims: %v", err)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		got := ClaimMapping{}.Profile(claims)
+		want := Profile{Sub: "00u1", Name: "Alice", Email: "alice@example.com", Groups: []string{"sre", "payments"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Profile() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("custom claims", func(t *testing.T) {
+		got := ClaimMapping{
+			GroupsClaim: "realm_access.roles",
+			Claims:      []string{"roles", "https://example.com/tenant", "level", "missing"},
+		}.Profile(claims)
+
+		if want := []string{"approver", "offline_access"}; !reflect.DeepEqual(got.Groups, want) {
+			t.Errorf("Groups = %v, want %v", got.Groups, want)
+		}
+		want := map[string][]string{
+			"roles":                      {"deployer"},
+			"https://example.com/tenant": {"acme"},
+			"level":                      {"3"},
+		}
+		if !reflect.DeepEqual(got.Claims, want) {
+			t.Errorf("Claims = %v, want %v", got.Claims, want)
+		}
+	})
+}
+
+func TestOIDCProvider_GetLogoutURL(t *testing.T) {
+	p := &OIDCProvider{IssuerURL: "https://idp.example.com"}
+	if got := p.GetLogoutURL("client", "https://deployer.example.com"); got != "https://deployer.example.com" {
+		t.Errorf("GetLogoutURL() = %s, want the return URL", got)
+	}
+
+	p.EndSessionURL = "https://idp.example.com/logout"
+	want := "https://idp.example.com/logout?client_id=client&post_logout_redirect_uri=https%3A%2F%2Fdeployer.example.com"
+	if got := p.GetLogoutURL("client", "https://deployer.example.com"); got != want {
+		t.Errorf("GetLogoutURL() = %s, want %s", got, want)
+	}
+}
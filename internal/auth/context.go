@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type contextKey string
+
+const profileContextKey contextKey = "profile"
+
+// WithProfile returns a copy of ctx carrying the authenticated profile
+func WithProfile(ctx context.Context, profile Profile) context.Context {
+	return context.WithValue(ctx, profileContextKey, profile)
+}
+
+// ProfileFromContext returns the authenticated profile stored in ctx, if any
+func ProfileFromContext(ctx context.Context) (Profile, bool) {
+	profile, ok := ctx.Value(profileContextKey).(Profile)
+	return profile, ok
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestProfileFromContext(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		want := Profile{Sub: "123", Name: "Alice", Email: "alice@example.com", Groups: []string{"sre"}}
+		got, ok := ProfileFromContext(WithProfile(context.Background(), want))
+		if !ok {
+			t.Fatal("ProfileFromContext() ok = false, want true")
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ProfileFromContext() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, ok := ProfileFromContext(context.Background()); ok {
+			t.Error("ProfileFromContext() ok = true, want false")
+		}
+	})
+}
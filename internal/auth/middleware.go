@@ -24,6 +24,12 @@ func (a *Authenticator) RequireAuth(redirectOnFail bool) func(http.Handler) http
 				return
 			}
 
+			// API clients authenticate with a bearer token instead of a session cookie
+			if token, ok := bearerToken(r); ok {
+				a.serveToken(w, r, next, token)
+				return
+			}
+
 			session, err := a.sessionStore.Get(r, sessionName)
 			if err != nil {
 				// securecookie errors are expected when:
@@ -47,7 +53,7 @@ func (a *Authenticator) RequireAuth(redirectOnFail bool) func(http.Handler) http
 				return
 			}
 
-			// Parse profile to extract email (for logging and future use)
+			// Parse profile to extract email (for logging and downstream handlers)
 			var profile Profile
 			if err := json.Unmarshal([]byte(profileJSON), &profile); err != nil {
 				logger.Error().Err(err).Msg("Failed to parse profile from session")
@@ -61,12 +67,43 @@ func (a *Authenticator) RequireAuth(redirectOnFail bool) func(http.Handler) http
 				Str("sub", profile.Sub).
 				Msg("Authenticated request")
 
-			// User is authenticated, continue
-			next.ServeHTTP(w, r)
+			// User is authenticated, continue with the profile available to handlers
+			next.ServeHTTP(w, r.WithContext(WithProfile(r.Context(), profile)))
 		})
 	}
 }
 
+// serveToken authenticates a request by its API token. The login policies (allowed email,
+// allowed groups) are not applied: tokens are issued by an authorized user and are limited
+// by their scope and the role bindings of their principal instead. Requests with an invalid
+// token are rejected rather than falling back to the session cookie.
+func (a *Authenticator) serveToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	logger := zerolog.Ctx(r.Context())
+
+	if a.tokens == nil {
+		a.handleAuthFailure(w, r, false, "API tokens are not enabled")
+		return
+	}
+
+	profile, err := a.tokens.VerifyToken(r.Context(), token)
+	if err != nil {
+		logger.Info().
+			Str("path", r.URL.Path).
+			Err(err).
+			Msg("API token rejected")
+		a.handleAuthFailure(w, r, false, "Invalid API token")
+		return
+	}
+
+	logger.Debug().
+		Str("path", r.URL.Path).
+		Str("email", profile.Email).
+		Str("sub", profile.Sub).
+		Msg("Authenticated API token request")
+
+	next.ServeHTTP(w, r.WithContext(WithProfile(r.Context(), profile)))
+}
+
 // handleAuthFailure handles authentication failures based on the request type
 func (a *Authenticator) handleAuthFailure(w http.ResponseWriter, r *http.Request, redirectOnFail bool, message string) {
 	logger := zerolog.Ctx(r.Context())
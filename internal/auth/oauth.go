@@ -30,12 +30,29 @@ type Authenticator struct {
 	sessionStore  *sessions.CookieStore
 	callbackURL   string
 	authorizer    *authz.Authorizer // optional authorization policy enforcement
+	claims        ClaimMapping      // ID token claims copied into the profile
+	tokens        TokenVerifier     // optional API token verification for Authorization: Bearer
 }
 
 type Profile struct {
-	Sub   string `json:"sub"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Sub    string              `json:"sub"`
+	Name   string              `json:"name"`
+	Email  string              `json:"email"`
+	Groups []string            `json:"groups,omitempty"`
+	Claims map[string][]string `json:"claims,omitempty"` // custom claims selected by ClaimMapping.Claims
+	Scope  *authz.Scope        `json:"-"`                // what an API token may change; nil for sessions
+}
+
+// AuthzProfile returns the profile as seen by authorization policies
+func (p Profile) AuthzProfile() authz.Profile {
+	return authz.Profile{
+		Sub:    p.Sub,
+		Name:   p.Name,
+		Email:  p.Email,
+		Groups: p.Groups,
+		Claims: p.Claims,
+		Scope:  p.Scope,
+	}
 }
 
 type AuthenticatorInput struct {
@@ -45,7 +62,10 @@ type AuthenticatorInput struct {
 	CallbackURL  string
 	Authorizer   *authz.Authorizer
 	SessionKeys  [][]byte
-	IsLocalDev   bool // Set to true for local development (disables Secure cookie flag)
+	IsLocalDev   bool          // Set to true for local development (disables Secure cookie flag)
+	Scopes       []string      // Scopes requested in addition to openid, profile and email (e.g. "groups")
+	Claims       ClaimMapping  // ID token claims copied into the profile
+	Tokens       TokenVerifier // API token verification (optional, disables bearer tokens if nil)
 }
 
 func NewAuthenticator(ctx context.Context, input AuthenticatorInput) (*Authenticator, error) {
@@ -86,6 +106,18 @@ func NewAuthenticator(ctx context.Context, input AuthenticatorInput) (*Authentic
 
 	endpoint := oidcProvider.Endpoint()
 
+	// Generic OIDC providers log out through the discovered end session endpoint unless
+	// one is configured
+	if p, ok := oauthProvider.(*OIDCProvider); ok && p.EndSessionURL == "" {
+		var discovery struct {
+			EndSessionEndpoint string `json:"end_session_endpoint"`
+		}
+		if err := oidcProvider.Claims(&discovery); err != nil {
+			logger.Warn().Err(err).Msg("Failed to read end_session_endpoint from discovery document")
+		}
+		p.EndSessionURL = discovery.EndSessionEndpoint
+	}
+
 	// For Google CIAM, we could use discovered endpoints now since we're using accounts.google.com
 	// But we'll keep the explicit configuration for clarity
 	if oauthProvider.GetProviderType() == "google-ciam" {
@@ -105,7 +137,7 @@ func NewAuthenticator(ctx context.Context, input AuthenticatorInput) (*Authentic
 		ClientSecret: clientSecret,
 		RedirectURL:  callbackURL,
 		Endpoint:     endpoint,
-		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes:       append([]string{oidc.ScopeOpenID, "profile", "email"}, input.Scopes...),
 	}
 
 	// Use provided session keys (supports rotation - multiple valid keys)
@@ -148,6 +180,8 @@ func NewAuthenticator(ctx context.Context, input AuthenticatorInput) (*Authentic
 		sessionStore:  sessionStore,
 		callbackURL:   callbackURL,
 		authorizer:    authorizer,
+		claims:        input.Claims,
+		tokens:        input.Tokens,
 	}, nil
 }
 
@@ -328,22 +362,18 @@ func (a *Authenticator) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		Str("subject", idToken.Subject).
 		Msg("ID token verified successfully")
 
-	// Extract profile
-	var profile Profile
-	if err := idToken.Claims(&profile); err != nil {
+	// Extract profile, including the groups and custom claims selected by the claim mapping
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
 		logger.Error().Err(err).Msg("Failed to extract claims")
 		http.Error(w, "Failed to extract profile", http.StatusInternalServerError)
 		return
 	}
+	profile := a.claims.Profile(claims)
 
 	// Authorize user (if authorizer is configured)
 	if a.authorizer != nil {
-		authzProfile := authz.Profile{
-			Sub:   profile.Sub,
-			Name:  profile.Name,
-			Email: profile.Email,
-		}
-		if err := a.authorizer.Authorize(authzProfile); err != nil {
+		if err := a.authorizer.Authorize(profile.AuthzProfile()); err != nil {
 			logger.Warn().
 				Str("sub", profile.Sub).
 				Str("email", profile.Email).
@@ -371,7 +401,10 @@ func (a *Authenticator) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info().Str("sub", profile.Sub).Msg("User authenticated successfully")
+	logger.Info().
+		Str("sub", profile.Sub).
+		Strs("groups", profile.Groups).
+		Msg("User authenticated successfully")
 
 	// Redirect to home
 	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
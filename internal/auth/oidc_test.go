@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oidcStandIn is a minimal OpenID Connect provider: discovery, JWKS and a token endpoint
+// that issues an RS256 ID token with the configured claims for any authorization code
+type oidcStandIn struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+}
+
+func newOIDCStandIn(t *testing.T, claims map[string]interface{}) *oidcStandIn {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	s := &oidcStandIn{key: key, claims: claims}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                s.URL,
+			"authorization_endpoint":                s.URL + "/authorize",
+			"token_endpoint":                        s.URL + "/token",
+			"jwks_uri":                              s.URL + "/keys",
+			"end_session_endpoint":                  s.URL + "/logout",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     s.idToken(t),
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// idToken signs the stand-in's claims for the "deployer" client
+func (s *oidcStandIn) idToken(t *testing.T) string {
+	claims := map[string]interface{}{
+		"iss": s.URL,
+		"aud": "deployer",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to encode claims: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// sessionCookie returns the session cookie set by a response
+func sessionCookie(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == sessionName {
+			return cookie
+		}
+	}
+	t.Fatalf("response did not set the %s cookie", sessionName)
+	return nil
+}
+
+func TestAuthenticator_GenericOIDC(t *testing.T) {
+	ctx := context.Background()
+	idp := newOIDCStandIn(t, map[string]interface{}{
+		"sub":          "user-1",
+		"name":         "Alice",
+		"email":        "alice@example.com",
+		"groups":       []string{"sre", "payments"},
+		"realm_access": map[string]interface{}{"roles": []string{"approver"}},
+	})
+
+	authenticator, err := NewAuthenticator(ctx, AuthenticatorInput{
+		Provider:     &OIDCProvider{IssuerURL: idp.URL},
+		ClientID:     "deployer",
+		ClientSecret: "secret",
+		CallbackURL:  "http://localhost:8080/oauth/callback",
+		SessionKeys:  [][]byte{[]byte("0123456789abcdef0123456789abcdef")},
+		IsLocalDev:   true,
+		Scopes:       []string{"groups"},
+		Claims:       ClaimMapping{Claims: []string{"realm_access.roles"}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	// Login redirects to the stand-in's authorization endpoint with the extra scope
+	rec := httptest.NewRecorder()
+	authenticator.HandleLogin(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), idp.URL+"/authorize") {
+		t.Fatalf("login redirected to %q, want the authorization endpoint", rec.Header().Get("Location"))
+	}
+	if scope := location.Query().Get("scope"); scope != "openid profile email groups" {
+		t.Errorf("scope = %q, want openid profile email groups", scope)
+	}
+	state := location.Query().Get("state")
+
+	// The callback exchanges the code, verifies the ID token and stores the profile
+	req := httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state="+url.QueryEscape(state), nil)
+	req.AddCookie(sessionCookie(t, rec))
+	rec = httptest.NewRecorder()
+	authenticator.HandleCallback(rec, req)
+	if rec.Code != http.StatusTemporaryRedirect || rec.Header().Get("Location") != "/" {
+		t.Fatalf("callback = %d %q, want redirect to /: %s", rec.Code, rec.Header().Get("Location"), rec.Body.String())
+	}
+
+	// Authenticated requests carry the groups and custom claims of the ID token
+	var got Profile
+	handler := authenticator.RequireAuth(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ProfileFromContext(r.Context())
+	}))
+	req = httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.AddCookie(sessionCookie(t, rec))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := Profile{
+		Sub:    "user-1",
+		Name:   "Alice",
+		Email:  "alice@example.com",
+		Groups: []string{"sre", "payments"},
+		Claims: map[string][]string{"realm_access.roles": {"approver"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("profile = %+v, want %+v", got, want)
+	}
+
+	// Logout uses the discovered end session endpoint
+	rec = httptest.NewRecorder()
+	authenticator.HandleLogout(rec, httptest.NewRequest(http.MethodGet, "/logout", nil))
+	if logout := rec.Header().Get("Location"); !strings.HasPrefix(logout, idp.URL+"/logout?") ||
+		!strings.Contains(logout, "post_logout_redirect_uri=http%3A%2F%2Flocalhost%3A8080") {
+		t.Errorf("logout redirected to %q, want the end session endpoint", logout)
+	}
+}
+
+func TestAuthenticator_GenericOIDC_RejectsForeignToken(t *testing.T) {
+	ctx := context.Background()
+	idp := newOIDCStandIn(t, map[string]interface{}{"sub": "user-1", "aud": "someone-else"})
+
+	authenticator, err := NewAuthenticator(ctx, AuthenticatorInput{
+		Provider:     &OIDCProvider{IssuerURL: idp.URL},
+		ClientID:     "deployer",
+		ClientSecret: "secret",
+		CallbackURL:  "http://localhost:8080/oauth/callback",
+		SessionKeys:  [][]byte{[]byte("0123456789abcdef0123456789abcdef")},
+		IsLocalDev:   true,
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	authenticator.HandleLogin(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	location, _ := url.Parse(rec.Header().Get("Location"))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/callback?code=abc&state="+url.QueryEscape(location.Query().Get("state")), nil)
+	req.AddCookie(sessionCookie(t, rec))
+	rec = httptest.NewRecorder()
+	authenticator.HandleCallback(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("callback = %d, want %d for a token issued to another client", rec.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+)
+
+// OIDCProvider implements the Provider interface for any standards-compliant OpenID Connect
+// identity provider, e.g. Okta, Keycloak, Azure AD (Entra ID) or Dex.
+type OIDCProvider struct {
+	IssuerURL     string // Issuer URL serving /.well-known/openid-configuration
+	EndSessionURL string // RP-initiated logout endpoint (optional, defaults to the discovered end_session_endpoint)
+}
+
+// GetIssuerURL returns the configured issuer URL. It must match the issuer of the
+// discovery document exactly, including any trailing slash.
+func (p *OIDCProvider) GetIssuerURL() string {
+	return p.IssuerURL
+}
+
+// GetLogoutURL returns the RP-initiated logout URL of the provider, or returnTo if the
+// provider has no end session endpoint.
+func (p *OIDCProvider) GetLogoutURL(clientID, returnTo string) string {
+	if p.EndSessionURL == "" {
+		return returnTo
+	}
+
+	params := url.Values{}
+	params.Add("client_id", clientID)
+	params.Add("post_logout_redirect_uri", returnTo)
+
+	separator := "?"
+	if strings.Contains(p.EndSessionURL, "?") {
+		separator = "&"
+	}
+	return p.EndSessionURL + separator + params.Encode()
+}
+
+// GetProviderType returns "oidc".
+func (p *OIDCProvider) GetProviderType() string {
+	return "oidc"
+}
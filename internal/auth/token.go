@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TokenVerifier authenticates API tokens sent as Authorization: Bearer {token}
+type TokenVerifier interface {
+	// VerifyToken returns the profile the token acts as, or an error if the token is
+	// unknown, expired or revoked.
+	VerifyToken(ctx context.Context, token string) (Profile, error)
+}
+
+// bearerToken returns the token of an Authorization: Bearer header, if any
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savaki/aws-deployer/internal/authz"
+)
+
+type fakeVerifier map[string]Profile
+
+func (f fakeVerifier) VerifyToken(ctx context.Context, token string) (Profile, error) {
+	profile, ok := f[token]
+	if !ok {
+		return Profile{}, errors.New("unknown token")
+	}
+	return profile, nil
+}
+
+func TestRequireAuth_BearerToken(t *testing.T) {
+	tokens := fakeVerifier{
+		"adt_ci":  {Sub: "service:ci", Name: "ci", Scope: &authz.Scope{Actions: []authz.Action{authz.ActionRedeploy}}},
+		"adt_me":  {Sub: "token:1", Email: "alice@example.com"},
+		"adt_bob": {Sub: "token:2", Email: "bob@example.com"},
+	}
+	authenticator := &Authenticator{
+		oauthProvider: &OIDCProvider{IssuerURL: "https://idp.example.com"},
+		tokens:        tokens,
+		authorizer:    authz.NewAuthorizer(true, &authz.GoogleEmailPolicy{AllowedEmail: "alice@example.com", ProviderType: "oidc"}),
+	}
+
+	serve := func(authorization string) (int, Profile) {
+		var got Profile
+		handler := authenticator.RequireAuth(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = ProfileFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code, got
+	}
+
+	code, profile := serve("Bearer adt_me")
+	if code != http.StatusOK || profile.Email != "alice@example.com" {
+		t.Errorf("personal token = %d %+v, want 200 as alice", code, profile)
+	}
+
+	// The login email allowlist does not apply to tokens; scopes and role bindings limit them
+	code, profile = serve("Bearer adt_ci")
+	if code != http.StatusOK || profile.Sub != "service:ci" || profile.Scope == nil {
+		t.Errorf("service token = %d %+v, want 200 as service:ci with its scope", code, profile)
+	}
+	code, profile = serve("Bearer adt_bob")
+	if code != http.StatusOK || profile.Email != "bob@example.com" {
+		t.Errorf("personal token outside the allowlist = %d %+v, want 200 as bob", code, profile)
+	}
+
+	if code, _ := serve("Bearer adt_unknown"); code != http.StatusForbidden {
+		t.Errorf("unknown token = %d, want 403", code)
+	}
+
+	authenticator.authorizer = nil
+	code, profile = serve("bearer adt_ci")
+	if code != http.StatusOK || profile.Sub != "service:ci" || profile.Scope == nil {
+		t.Errorf("service token = %d %+v, want 200 as service:ci with its scope", code, profile)
+	}
+
+	authenticator.tokens = nil
+	if code, _ := serve("Bearer adt_me"); code != http.StatusForbidden {
+		t.Errorf("token without verifier = %d, want 403", code)
+	}
+}
@@ -8,9 +8,12 @@ import (
 // Profile represents user information needed for authorization.
 // This mirrors the auth.Profile struct but keeps packages decoupled.
 type Profile struct {
-	Sub   string
-	Name  string
-	Email string
+	Sub    string
+	Name   string
+	Email  string
+	Groups []string            // identity provider groups, matched by GroupPolicy and group role bindings
+	Claims map[string][]string // custom ID token claims, matched by claim role bindings
+	Scope  *Scope              // what an API token may change; nil for browser sessions
 }
 
 // Policy defines an authorization rule that can allow or deny access.
@@ -25,9 +28,10 @@ type Policy interface {
 // Behavior varies by OAuth provider:
 // - Auth0: Only applies to federated Google login (sub starts with "google-oauth2|")
 // - Google CIAM: Applies to all users (all users are Google users)
+// - Generic OIDC: Applies to all users
 type GoogleEmailPolicy struct {
 	AllowedEmail string
-	ProviderType string // "auth0" or "google-ciam"
+	ProviderType string // "auth0", "google-ciam" or "oidc"
 }
 
 // Name returns the policy name.
@@ -50,8 +54,9 @@ func (p *GoogleEmailPolicy) Authorize(profile Profile) error {
 		// For non-Google Auth0 providers, allow access
 		return nil
 
-	case "google-ciam":
+	case "google-ciam", "oidc":
 		// For Google CIAM: all users are Google users, always check email
+		// For generic OIDC: the identity provider is the only login, always check email
 		if profile.Email != p.AllowedEmail {
 			return fmt.Errorf("access denied: email %s is not authorized", profile.Email)
 		}
@@ -63,6 +68,28 @@ func (p *GoogleEmailPolicy) Authorize(profile Profile) error {
 	}
 }
 
+// GroupPolicy restricts access to members of at least one identity provider group.
+type GroupPolicy struct {
+	AllowedGroups []string
+}
+
+// Name returns the policy name.
+func (p *GroupPolicy) Name() string {
+	return "GroupRestriction"
+}
+
+// Authorize checks if the user belongs to one of the allowed groups.
+func (p *GroupPolicy) Authorize(profile Profile) error {
+	for _, allowed := range p.AllowedGroups {
+		for _, group := range profile.Groups {
+			if group == allowed {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("access denied: %s is not a member of an allowed group", profile.Email)
+}
+
 // Authorizer manages a collection of authorization policies.
 type Authorizer struct {
 	policies []Policy
@@ -93,7 +120,7 @@ func (a *Authorizer) Authorize(profile Profile) error {
 
 // NewGoogleEmailAuthorizer creates a preconfigured authorizer for Google email restrictions.
 // This is a convenience function for the common use case.
-// providerType should be "auth0" or "google-ciam" to determine policy behavior.
+// providerType should be "auth0", "google-ciam" or "oidc" to determine policy behavior.
 func NewGoogleEmailAuthorizer(enabled bool, allowedEmail string, providerType string) *Authorizer {
 	return NewAuthorizer(enabled, &GoogleEmailPolicy{
 		AllowedEmail: allowedEmail,
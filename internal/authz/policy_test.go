@@ -0,0 +1,22 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupPolicy(t *testing.T) {
+	policy := &GroupPolicy{AllowedGroups: []string{"deployers", "sre"}}
+
+	assert.NoError(t, policy.Authorize(Profile{Email: "alice@example.com", Groups: []string{"engineering", "sre"}}))
+	assert.Error(t, policy.Authorize(Profile{Email: "bob@example.com", Groups: []string{"engineering"}}))
+	assert.Error(t, policy.Authorize(Profile{Email: "carol@example.com"}))
+}
+
+func TestGoogleEmailPolicy_OIDC(t *testing.T) {
+	policy := &GoogleEmailPolicy{AllowedEmail: "alice@example.com", ProviderType: "oidc"}
+
+	assert.NoError(t, policy.Authorize(Profile{Email: "alice@example.com"}))
+	assert.Error(t, policy.Authorize(Profile{Email: "bob@example.com"}))
+}
@@ -0,0 +1,308 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Role grants a set of actions; each role includes the permissions of the roles below it.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // read-only access
+	RoleDeployer Role = "deployer" // redeploy and promote builds
+	RoleApprover Role = "approver" // approve and reject builds awaiting approval
+	RoleAdmin    Role = "admin"    // override deploy freezes and allow replacement of protected resources
+)
+
+// roleRank orders the roles from least to most privileged.
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleDeployer: 2,
+	RoleApprover: 3,
+	RoleAdmin:    4,
+}
+
+// ParseRole returns the role with the given name.
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("invalid role %q: expected viewer, deployer, approver or admin", s)
+	}
+	return role, nil
+}
+
+// Includes returns true if the role grants at least the permissions of other.
+func (r Role) Includes(other Role) bool {
+	return roleRank[r] > 0 && roleRank[r] >= roleRank[other]
+}
+
+// Action is an operation subject to role-based access control.
+type Action string
+
+const (
+	ActionRedeploy         Action = "redeploy"
+	ActionPromote          Action = "promote"
+	ActionApprove          Action = "approve"
+	ActionReject           Action = "reject"
+	ActionOverrideFreeze   Action = "overrideFreeze"
+	ActionAllowReplacement Action = "allowReplacement"
+)
+
+// ParseAction returns the action with the given name.
+func ParseAction(s string) (Action, error) {
+	action := Action(strings.TrimSpace(s))
+	if _, ok := requiredRoles[action]; !ok {
+		return "", fmt.Errorf("invalid action %q: expected redeploy, promote, approve, reject, overrideFreeze or allowReplacement", s)
+	}
+	return action, nil
+}
+
+// requiredRoles maps each action to the least privileged role allowed to perform it.
+var requiredRoles = map[Action]Role{
+	ActionRedeploy:         RoleDeployer,
+	ActionPromote:          RoleDeployer,
+	ActionApprove:          RoleApprover,
+	ActionReject:           RoleApprover,
+	ActionOverrideFreeze:   RoleAdmin,
+	ActionAllowReplacement: RoleAdmin,
+}
+
+// RequiredRole returns the role needed to perform action. Actions without an entry
+// require admin, so new mutations are locked down until they are mapped.
+func RequiredRole(action Action) Role {
+	if role, ok := requiredRoles[action]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+// Subject prefixes of role bindings
+const (
+	SubjectUserPrefix    = "user:"    // user:{email}
+	SubjectGroupPrefix   = "group:"   // group:{name}
+	SubjectClaimPrefix   = "claim:"   // claim:{name}={value}
+	SubjectServicePrefix = "service:" // service:{name}, the Sub of service API tokens
+)
+
+// ValidateSubject returns an error if subject is not user:{email}, group:{name},
+// claim:{name}={value} or service:{name}.
+func ValidateSubject(subject string) error {
+	if claim, ok := strings.CutPrefix(subject, SubjectClaimPrefix); ok {
+		if name, _, found := strings.Cut(claim, "="); found && name != "" {
+			return nil
+		}
+	}
+	for _, prefix := range []string{SubjectUserPrefix, SubjectGroupPrefix, SubjectServicePrefix} {
+		if name, ok := strings.CutPrefix(subject, prefix); ok && name != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid subject %q: expected user:{email}, group:{name}, claim:{name}={value} or service:{name}", subject)
+}
+
+// Binding assigns a role to a user or group for the repos and environments matching its globs.
+type Binding struct {
+	Subject string // user:{email}, group:{name}, claim:{name}={value} or service:{name}
+	Role    Role
+	Repo    string // repo glob, e.g. "*" or "payments-*" (empty matches all)
+	Env     string // environment glob, e.g. "prd" or "*" (empty matches all)
+}
+
+// AppliesTo returns true if the binding's subject is the profile's user or service, one of
+// its groups, or a value of one of its custom claims.
+func (b Binding) AppliesTo(profile Profile) bool {
+	if email, ok := strings.CutPrefix(b.Subject, SubjectUserPrefix); ok {
+		return profile.Email != "" && strings.EqualFold(email, profile.Email)
+	}
+	if strings.HasPrefix(b.Subject, SubjectServicePrefix) {
+		return b.Subject == profile.Sub
+	}
+	if group, ok := strings.CutPrefix(b.Subject, SubjectGroupPrefix); ok {
+		for _, g := range profile.Groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	if claim, ok := strings.CutPrefix(b.Subject, SubjectClaimPrefix); ok {
+		name, value, _ := strings.Cut(claim, "=")
+		for _, v := range profile.Claims[name] {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Covers returns true if the binding's globs match the repo and environment.
+func (b Binding) Covers(repo, env string) bool {
+	return globMatch(b.Repo, repo) && globMatch(b.Env, env)
+}
+
+// globMatch reports whether value matches pattern; an empty pattern matches everything
+// and a malformed pattern matches nothing.
+func globMatch(pattern, value string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
+
+// ValidateGlob returns an error if pattern is not a valid glob.
+func ValidateGlob(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// RoleStore loads role bindings.
+type RoleStore interface {
+	Bindings(ctx context.Context) ([]Binding, error)
+}
+
+// Reasons an action is denied
+const (
+	DeniedRole  = "role"  // the profile lacks the required role
+	DeniedScope = "scope" // the API token is not scoped for the action
+)
+
+// DeniedError is returned when a profile lacks the role required for an action, or an API
+// token's scope does not include it. It implements the graphql-go extensions interface so
+// denials reach clients as structured errors.
+type DeniedError struct {
+	Subject  string // email of the denied user
+	Reason   string // DeniedRole or DeniedScope
+	Action   Action
+	Repo     string
+	Env      string
+	Required Role // role required by the action
+	Role     Role // highest role held on the repo/env, empty if none
+}
+
+// Error implements error.
+func (e *DeniedError) Error() string {
+	if e.Reason == DeniedScope {
+		return fmt.Sprintf("access denied: the API token is not scoped for %s on %s/%s", e.Action, e.Repo, e.Env)
+	}
+	return fmt.Sprintf("access denied: %s requires the %s role on %s/%s", e.Action, e.Required, e.Repo, e.Env)
+}
+
+// Extensions returns the structured details of the denial for GraphQL responses.
+func (e *DeniedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":         "FORBIDDEN",
+		"reason":       e.Reason,
+		"action":       string(e.Action),
+		"repo":         e.Repo,
+		"env":          e.Env,
+		"requiredRole": string(e.Required),
+		"role":         string(e.Role),
+	}
+}
+
+// RBAC enforces role bindings on actions against a repo and environment.
+type RBAC struct {
+	store   RoleStore
+	enabled bool
+}
+
+// NewRBAC creates a role-based access checker. A disabled checker allows every action.
+func NewRBAC(enabled bool, store RoleStore) *RBAC {
+	return &RBAC{
+		store:   store,
+		enabled: enabled,
+	}
+}
+
+// Enabled returns true if role bindings are enforced.
+func (r *RBAC) Enabled() bool {
+	return r != nil && r.enabled
+}
+
+// Role returns the highest role the profile holds on the repo and environment, or an
+// empty role if it holds none.
+func (r *RBAC) Role(ctx context.Context, profile Profile, repo, env string) (Role, error) {
+	bindings, err := r.store.Bindings(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load role bindings: %w", err)
+	}
+
+	var role Role
+	for _, b := range bindings {
+		if b.AppliesTo(profile) && b.Covers(repo, env) && roleRank[b.Role] > roleRank[role] {
+			role = b.Role
+		}
+	}
+	return role, nil
+}
+
+// Check returns nil if the profile may perform action on the repo and environment, or a
+// *DeniedError if it lacks the required role.
+func (r *RBAC) Check(ctx context.Context, profile Profile, action Action, repo, env string) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	required := RequiredRole(action)
+	role, err := r.Role(ctx, profile, repo, env)
+	if err != nil {
+		return err
+	}
+	if !role.Includes(required) {
+		return &DeniedError{
+			Subject:  profile.Email,
+			Reason:   DeniedRole,
+			Action:   action,
+			Repo:     repo,
+			Env:      env,
+			Required: required,
+			Role:     role,
+		}
+	}
+	return nil
+}
+
+// Scope restricts what an API token may change. Browser sessions have no scope.
+type Scope struct {
+	Actions []Action // mutations the token may run; empty for a read-only token
+	Repo    string   // repo glob (empty matches all)
+	Env     string   // environment glob (empty matches all)
+}
+
+// Allows returns true if the scope includes action on the repo and environment.
+func (s *Scope) Allows(action Action, repo, env string) bool {
+	if s == nil {
+		return true
+	}
+	if !globMatch(s.Repo, repo) || !globMatch(s.Env, env) {
+		return false
+	}
+	for _, a := range s.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckScope returns a *DeniedError if the profile is an API token whose scope does not
+// include action on the repo and environment. Scopes apply whether or not RBAC is enabled.
+func CheckScope(profile Profile, action Action, repo, env string) error {
+	if profile.Scope.Allows(action, repo, env) {
+		return nil
+	}
+	return &DeniedError{
+		Subject:  profile.Email,
+		Reason:   DeniedScope,
+		Action:   action,
+		Repo:     repo,
+		Env:      env,
+		Required: RequiredRole(action),
+	}
+}
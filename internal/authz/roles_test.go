@@ -0,0 +1,142 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticStore []Binding
+
+func (s staticStore) Bindings(ctx context.Context) ([]Binding, error) {
+	return s, nil
+}
+
+type failingStore struct{}
+
+func (failingStore) Bindings(ctx context.Context) ([]Binding, error) {
+	return nil, errors.New("table not found")
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole(" Approver ")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleApprover, role)
+
+	_, err = ParseRole("owner")
+	assert.Error(t, err)
+}
+
+func TestRole_Includes(t *testing.T) {
+	assert.True(t, RoleAdmin.Includes(RoleDeployer))
+	assert.True(t, RoleApprover.Includes(RoleDeployer))
+	assert.True(t, RoleDeployer.Includes(RoleDeployer))
+	assert.False(t, RoleDeployer.Includes(RoleApprover))
+	assert.False(t, RoleViewer.Includes(RoleDeployer))
+	assert.False(t, Role("").Includes(RoleViewer))
+}
+
+func TestRequiredRole(t *testing.T) {
+	assert.Equal(t, RoleDeployer, RequiredRole(ActionPromote))
+	assert.Equal(t, RoleApprover, RequiredRole(ActionReject))
+	assert.Equal(t, RoleAdmin, RequiredRole(ActionAllowReplacement))
+	assert.Equal(t, RoleAdmin, RequiredRole(Action("unmapped")), "unmapped actions require admin")
+}
+
+func TestValidateSubject(t *testing.T) {
+	assert.NoError(t, ValidateSubject("user:alice@example.com"))
+	assert.NoError(t, ValidateSubject("group:platform"))
+	assert.Error(t, ValidateSubject("alice@example.com"))
+	assert.Error(t, ValidateSubject("group:"))
+	assert.NoError(t, ValidateSubject("claim:realm_access.roles=deployer"))
+	assert.Error(t, ValidateSubject("claim:tenant"))
+}
+
+func TestBinding(t *testing.T) {
+	alice := Profile{Email: "Alice@example.com", Groups: []string{"payments"}}
+
+	assert.True(t, Binding{Subject: "user:alice@example.com"}.AppliesTo(alice))
+	assert.True(t, Binding{Subject: "group:payments"}.AppliesTo(alice))
+	assert.False(t, Binding{Subject: "group:platform"}.AppliesTo(alice))
+	assert.False(t, Binding{Subject: "user:"}.AppliesTo(Profile{}))
+
+	tenant := Profile{Claims: map[string][]string{"tenant": {"acme"}}}
+	assert.True(t, Binding{Subject: "claim:tenant=acme"}.AppliesTo(tenant))
+	assert.False(t, Binding{Subject: "claim:tenant=globex"}.AppliesTo(tenant))
+
+	assert.True(t, Binding{}.Covers("my-app", "prd"))
+	assert.True(t, Binding{Repo: "payments-*", Env: "prd"}.Covers("payments-api", "prd"))
+	assert.False(t, Binding{Repo: "payments-*", Env: "prd"}.Covers("payments-api", "stg"))
+	assert.False(t, Binding{Repo: "[", Env: "*"}.Covers("my-app", "prd"), "malformed globs match nothing")
+}
+
+func TestRBAC_Check(t *testing.T) {
+	ctx := context.Background()
+	store := staticStore{
+		{Subject: "user:alice@example.com", Role: RoleDeployer, Repo: "*", Env: "*"},
+		{Subject: "user:alice@example.com", Role: RoleApprover, Repo: "*", Env: "stg"},
+		{Subject: "group:sre", Role: RoleAdmin, Repo: "*", Env: "prd"},
+		{Subject: "user:bob@example.com", Role: RoleViewer},
+	}
+	rbac := NewRBAC(true, store)
+
+	alice := Profile{Email: "alice@example.com"}
+	assert.NoError(t, rbac.Check(ctx, alice, ActionPromote, "my-app", "prd"))
+	assert.NoError(t, rbac.Check(ctx, alice, ActionApprove, "my-app", "stg"))
+
+	err := rbac.Check(ctx, alice, ActionApprove, "my-app", "prd")
+	var denied *DeniedError
+	if assert.ErrorAs(t, err, &denied) {
+		assert.Equal(t, RoleApprover, denied.Required)
+		assert.Equal(t, RoleDeployer, denied.Role)
+		assert.Equal(t, "FORBIDDEN", denied.Extensions()["code"])
+		assert.Equal(t, "prd", denied.Extensions()["env"])
+	}
+
+	sre := Profile{Email: "carol@example.com", Groups: []string{"sre"}}
+	assert.NoError(t, rbac.Check(ctx, sre, ActionOverrideFreeze, "my-app", "prd"))
+	assert.Error(t, rbac.Check(ctx, sre, ActionRedeploy, "my-app", "stg"))
+
+	bob := Profile{Email: "bob@example.com"}
+	assert.Error(t, rbac.Check(ctx, bob, ActionRedeploy, "my-app", "dev"))
+	assert.Error(t, rbac.Check(ctx, Profile{}, ActionRedeploy, "my-app", "dev"))
+
+	// A disabled checker allows everything without loading bindings
+	assert.NoError(t, NewRBAC(false, failingStore{}).Check(ctx, bob, ActionAllowReplacement, "my-app", "prd"))
+	assert.Error(t, NewRBAC(true, failingStore{}).Check(ctx, alice, ActionRedeploy, "my-app", "dev"))
+}
+
+func TestParseAction(t *testing.T) {
+	action, err := ParseAction("promote")
+	assert.NoError(t, err)
+	assert.Equal(t, ActionPromote, action)
+
+	_, err = ParseAction("deleteEverything")
+	assert.Error(t, err)
+}
+
+func TestCheckScope(t *testing.T) {
+	session := Profile{Email: "alice@example.com"}
+	assert.NoError(t, CheckScope(session, ActionAllowReplacement, "my-app", "prd"), "sessions are not scoped")
+
+	ci := Profile{Sub: "service:ci", Scope: &Scope{Actions: []Action{ActionRedeploy}, Env: "dev"}}
+	assert.NoError(t, CheckScope(ci, ActionRedeploy, "my-app", "dev"))
+	assert.Error(t, CheckScope(ci, ActionRedeploy, "my-app", "prd"))
+
+	err := CheckScope(ci, ActionPromote, "my-app", "dev")
+	var denied *DeniedError
+	if assert.ErrorAs(t, err, &denied) {
+		assert.Equal(t, DeniedScope, denied.Reason)
+		assert.Equal(t, "scope", denied.Extensions()["reason"])
+	}
+
+	readOnly := Profile{Sub: "service:dashboard", Scope: &Scope{}}
+	assert.Error(t, CheckScope(readOnly, ActionRedeploy, "my-app", "dev"))
+
+	// Service tokens hold roles through service bindings
+	rbac := NewRBAC(true, staticStore{{Subject: "service:ci", Role: RoleDeployer, Env: "dev"}})
+	assert.NoError(t, rbac.Check(context.Background(), ci, ActionRedeploy, "my-app", "dev"))
+	assert.Error(t, rbac.Check(context.Background(), Profile{Sub: "service:other"}, ActionRedeploy, "my-app", "dev"))
+}
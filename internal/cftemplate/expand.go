@@ -0,0 +1,101 @@
+package cftemplate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/rs/zerolog"
+)
+
+// expandChangeSetName is the name of the change set used to expand transforms
+const expandChangeSetName = "aws-deployer-expand"
+
+// expandPollInterval is how long to wait between change set status checks
+var expandPollInterval = 5 * time.Second
+
+// CloudFormationClient defines the CloudFormation operations needed to expand transforms
+type CloudFormationClient interface {
+	CreateChangeSet(ctx context.Context, params *cloudformation.CreateChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateChangeSetOutput, error)
+	DescribeChangeSet(ctx context.Context, params *cloudformation.DescribeChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeChangeSetOutput, error)
+	GetTemplate(ctx context.Context, params *cloudformation.GetTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error)
+	DeleteStack(ctx context.Context, params *cloudformation.DeleteStackInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DeleteStackOutput, error)
+}
+
+// Expand runs the transforms of the template stored at key, such as AWS::Serverless, and
+// returns the URL of the processed template. StackSets cannot run transforms in every target
+// account, so their templates are expanded first: CloudFormation processes the template in a
+// change set of a temporary stack, stackName, which is deleted afterwards.
+func (p *Packager) Expand(ctx context.Context, client CloudFormationClient, stackName, key string, params []types.Parameter) (string, error) {
+	logger := zerolog.Ctx(ctx)
+
+	_, err := client.CreateChangeSet(ctx, &cloudformation.CreateChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(expandChangeSetName),
+		ChangeSetType: types.ChangeSetTypeCreate,
+		TemplateURL:   aws.String(p.URL(key)),
+		Parameters:    params,
+		Capabilities: []types.Capability{
+			types.CapabilityCapabilityIam,
+			types.CapabilityCapabilityNamedIam,
+			types.CapabilityCapabilityAutoExpand,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create change set to expand %s: %w", key, err)
+	}
+
+	// The temporary stack stays in REVIEW_IN_PROGRESS; deleting it also deletes the change set
+	defer func() {
+		if _, err := client.DeleteStack(ctx, &cloudformation.DeleteStackInput{StackName: aws.String(stackName)}); err != nil {
+			logger.Warn().Err(err).Str("stack_name", stackName).Msg("Failed to delete temporary stack")
+		}
+	}()
+
+	for {
+		output, err := client.DescribeChangeSet(ctx, &cloudformation.DescribeChangeSetInput{
+			StackName:     aws.String(stackName),
+			ChangeSetName: aws.String(expandChangeSetName),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to describe change set: %w", err)
+		}
+
+		if output.Status == types.ChangeSetStatusCreateComplete {
+			break
+		}
+		if output.Status == types.ChangeSetStatusFailed {
+			return "", fmt.Errorf("failed to expand %s: %s", key, aws.ToString(output.StatusReason))
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(expandPollInterval):
+		}
+	}
+
+	processed, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(expandChangeSetName),
+		TemplateStage: types.TemplateStageProcessed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get processed template: %w", err)
+	}
+
+	expandedKey := p.prefix + packagedDir + "expanded/" + stackName + ".template"
+	if err := p.put(ctx, expandedKey, []byte(aws.ToString(processed.TemplateBody))); err != nil {
+		return "", err
+	}
+
+	logger.Info().
+		Str("stack_name", stackName).
+		Str("template_key", expandedKey).
+		Msg("Expanded template transforms")
+
+	return p.URL(expandedKey), nil
+}
@@ -0,0 +1,72 @@
+package cftemplate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCloudFormation struct {
+	calls    []string
+	status   types.ChangeSetStatus
+	reason   string
+	input    *cloudformation.CreateChangeSetInput
+	template string
+}
+
+func (f *fakeCloudFormation) CreateChangeSet(ctx context.Context, params *cloudformation.CreateChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateChangeSetOutput, error) {
+	f.calls = append(f.calls, "CreateChangeSet")
+	f.input = params
+	return &cloudformation.CreateChangeSetOutput{Id: aws.String("change-set-id")}, nil
+}
+
+func (f *fakeCloudFormation) DescribeChangeSet(ctx context.Context, params *cloudformation.DescribeChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeChangeSetOutput, error) {
+	f.calls = append(f.calls, "DescribeChangeSet")
+	return &cloudformation.DescribeChangeSetOutput{Status: f.status, StatusReason: aws.String(f.reason)}, nil
+}
+
+func (f *fakeCloudFormation) GetTemplate(ctx context.Context, params *cloudformation.GetTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error) {
+	f.calls = append(f.calls, "GetTemplate")
+	return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(f.template)}, nil
+}
+
+func (f *fakeCloudFormation) DeleteStack(ctx context.Context, params *cloudformation.DeleteStackInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DeleteStackOutput, error) {
+	f.calls = append(f.calls, "DeleteStack")
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+func TestExpand(t *testing.T) {
+	cf := &fakeCloudFormation{
+		status:   types.ChangeSetStatusCreateComplete,
+		template: `{"Resources":{"Fn":{"Type":"AWS::Lambda::Function"}}}`,
+	}
+	client := &fakeS3{objects: map[string]string{}}
+	packager := NewPackager(client, "artifacts", "my-app/main/1.abc")
+
+	params := []types.Parameter{{ParameterKey: aws.String("Env"), ParameterValue: aws.String("dev")}}
+	url, err := packager.Expand(context.Background(), cf, "dev-my-app-expand-2HFj3kLmNoPqRsTuVwXy", "my-app/main/1.abc/template.yaml", params)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://artifacts.s3.amazonaws.com/my-app/main/1.abc/packaged/expanded/dev-my-app-expand-2HFj3kLmNoPqRsTuVwXy.template", url)
+	assert.Equal(t, cf.template, client.objects["my-app/main/1.abc/packaged/expanded/dev-my-app-expand-2HFj3kLmNoPqRsTuVwXy.template"])
+
+	assert.Equal(t, "https://artifacts.s3.amazonaws.com/my-app/main/1.abc/template.yaml", aws.ToString(cf.input.TemplateURL))
+	assert.Equal(t, params, cf.input.Parameters)
+	assert.Contains(t, cf.input.Capabilities, types.CapabilityCapabilityAutoExpand)
+	assert.Equal(t, []string{"CreateChangeSet", "DescribeChangeSet", "GetTemplate", "DeleteStack"}, cf.calls)
+}
+
+func TestExpand_Failed(t *testing.T) {
+	cf := &fakeCloudFormation{
+		status: types.ChangeSetStatusFailed,
+		reason: "Transform AWS::Serverless-2016-10-31 failed with: Invalid Serverless Application Specification document",
+	}
+	packager := NewPackager(&fakeS3{objects: map[string]string{}}, "artifacts", "my-app/main/1.abc")
+
+	_, err := packager.Expand(context.Background(), cf, "dev-my-app-expand-2HFj3kLmNoPqRsTuVwXy", "my-app/main/1.abc/template.yaml", nil)
+	assert.ErrorContains(t, err, "Invalid Serverless Application Specification document")
+	assert.Equal(t, "DeleteStack", cf.calls[len(cf.calls)-1])
+}
@@ -0,0 +1,294 @@
+package cftemplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxTemplateBodySize is the largest template, in bytes, that CloudFormation accepts as
+// TemplateBody. Larger templates must be deployed from S3 with TemplateURL.
+const MaxTemplateBodySize = 51200
+
+// maxNestingDepth limits how deep nested stacks are packaged, which also stops cycles
+const maxNestingDepth = 8
+
+// packagedDir holds rewritten templates, relative to the artifact prefix of the build
+const packagedDir = "packaged/"
+
+// artifactProperty is a resource property that may refer to an artifact of the build
+type artifactProperty struct {
+	Name     string
+	Template bool // nested template, referenced by HTTPS URL rather than s3:// URI
+}
+
+// artifactProperties lists the properties rewritten by Package, by resource type
+var artifactProperties = map[string]artifactProperty{
+	"AWS::CloudFormation::Stack":   {Name: "TemplateURL", Template: true},
+	"AWS::Serverless::Application": {Name: "Location", Template: true},
+	"AWS::Serverless::Function":    {Name: "CodeUri"},
+}
+
+// S3Client reads and writes build artifacts
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Template is a template ready to deploy. Exactly one of Body and URL is set.
+type Template struct {
+	Body string
+	URL  string
+}
+
+// Packager prepares the templates of a build, uploaded under prefix in bucket, for deployment
+type Packager struct {
+	client S3Client
+	bucket string
+	prefix string
+}
+
+// NewPackager creates a new Packager for the artifacts under prefix in bucket
+func NewPackager(client S3Client, bucket, prefix string) *Packager {
+	return &Packager{
+		client: client,
+		bucket: bucket,
+		prefix: strings.TrimRight(prefix, "/") + "/",
+	}
+}
+
+// URL returns the HTTPS URL of key, the form required by TemplateURL
+func (p *Packager) URL(key string) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", p.bucket, key)
+}
+
+// Package prepares the template stored at key, whose contents are body, for deployment.
+// Relative TemplateURL, Location and CodeUri values are rewritten to the objects uploaded
+// with the build, so packaged SAM and CDK output deploys unchanged; nested templates are
+// packaged the same way. Templates larger than MaxTemplateBodySize are returned by URL.
+func (p *Packager) Package(ctx context.Context, key string, body []byte) (Template, error) {
+	packaged, changed, err := p.rewrite(ctx, key, body, 0)
+	if err != nil {
+		return Template{}, err
+	}
+
+	if len(packaged) <= MaxTemplateBodySize {
+		return Template{Body: string(packaged)}, nil
+	}
+	if !changed {
+		return Template{URL: p.URL(key)}, nil
+	}
+
+	url, err := p.upload(ctx, key, packaged)
+	if err != nil {
+		return Template{}, err
+	}
+	return Template{URL: url}, nil
+}
+
+// rewrite replaces relative artifact references in the template stored at key. Returns the
+// template unchanged if it has no relative references.
+func (p *Packager) rewrite(ctx context.Context, key string, body []byte, depth int) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse template %s: %w", key, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return body, false, nil
+	}
+
+	var changed bool
+	for _, ref := range references(doc.Content[0]) {
+		if !isRelative(ref.node) {
+			continue
+		}
+
+		target, err := p.resolve(key, ref.node.Value)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if ref.property.Template {
+			url, err := p.nested(ctx, target, depth+1)
+			if err != nil {
+				return nil, false, err
+			}
+			ref.node.Value = url
+		} else {
+			ref.node.Value = fmt.Sprintf("s3://%s/%s", p.bucket, target)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return body, false, nil
+	}
+
+	// Templates are written back as block style YAML, which CloudFormation accepts in place
+	// of JSON and which keeps short-form tags such as !Ref intact
+	blockStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, false, fmt.Errorf("failed to encode template %s: %w", key, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false, fmt.Errorf("failed to encode template %s: %w", key, err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// nested packages the nested template stored at key and returns the URL to deploy it from
+func (p *Packager) nested(ctx context.Context, key string, depth int) (string, error) {
+	if depth > maxNestingDepth {
+		return "", fmt.Errorf("nested stacks are deeper than %d levels at %s", maxNestingDepth, key)
+	}
+
+	body, err := p.download(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	packaged, changed, err := p.rewrite(ctx, key, body, depth)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		return p.URL(key), nil
+	}
+	return p.upload(ctx, key, packaged)
+}
+
+// resolve returns the key of ref, relative to the template stored at key. The result must be
+// an artifact of the build.
+func (p *Packager) resolve(key, ref string) (string, error) {
+	target := path.Join(path.Dir(key), ref)
+	if !strings.HasPrefix(target, p.prefix) {
+		return "", fmt.Errorf("%s in %s refers to %s, outside the artifacts of the build", ref, key, target)
+	}
+	return target, nil
+}
+
+// download reads the object stored at key
+func (p *Packager) download(ctx context.Context, key string) ([]byte, error) {
+	result, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s from bucket %s: %w", key, p.bucket, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer result.Body.Close()
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	return body, nil
+}
+
+// upload stores the rewritten template of key under packagedDir and returns its URL
+func (p *Packager) upload(ctx context.Context, key string, body []byte) (string, error) {
+	packagedKey := p.prefix + packagedDir + strings.TrimPrefix(key, p.prefix)
+	if err := p.put(ctx, packagedKey, body); err != nil {
+		return "", err
+	}
+	return p.URL(packagedKey), nil
+}
+
+// put writes body to key
+func (p *Packager) put(ctx context.Context, key string, body []byte) error {
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object %s to bucket %s: %w", key, p.bucket, err)
+	}
+	return nil
+}
+
+// reference is a property value that may refer to an artifact
+type reference struct {
+	node     *yaml.Node
+	property artifactProperty
+}
+
+// references returns the artifact properties of every resource in the template, including
+// the CodeUri of the SAM Globals section
+func references(root *yaml.Node) []reference {
+	var refs []reference
+
+	if node := lookup(root, "Globals", "Function", "CodeUri"); node != nil {
+		refs = append(refs, reference{node: node, property: artifactProperties["AWS::Serverless::Function"]})
+	}
+
+	resources := lookup(root, "Resources")
+	if resources == nil || resources.Kind != yaml.MappingNode {
+		return refs
+	}
+	for i := 1; i < len(resources.Content); i += 2 {
+		resource := resources.Content[i]
+
+		typ := lookup(resource, "Type")
+		if typ == nil {
+			continue
+		}
+		property, ok := artifactProperties[typ.Value]
+		if !ok {
+			continue
+		}
+		if node := lookup(resource, "Properties", property.Name); node != nil {
+			refs = append(refs, reference{node: node, property: property})
+		}
+	}
+
+	return refs
+}
+
+// lookup returns the value at keys in nested mappings, or nil if there is none
+func lookup(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// isRelative returns true if node is a plain string holding a relative path. URLs and
+// intrinsic functions are left alone.
+func isRelative(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return false
+	}
+	v := node.Value
+	return v != "" && !strings.Contains(v, "://") && !strings.HasPrefix(v, "/")
+}
+
+// blockStyle switches flow style mappings and sequences, as parsed from JSON, to block style
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
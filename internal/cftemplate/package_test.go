@@ -0,0 +1,170 @@
+package cftemplate
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeS3 struct {
+	objects map[string]string
+	puts    []string
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := f.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[aws.ToString(params.Key)] = string(body)
+	f.puts = append(f.puts, aws.ToString(params.Key))
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestPackage_Unchanged(t *testing.T) {
+	client := &fakeS3{objects: map[string]string{}}
+	packager := NewPackager(client, "artifacts", "my-app/main/1.abc")
+
+	body := `{"Resources":{"Child":{"Type":"AWS::CloudFormation::Stack","Properties":{"TemplateURL":"https://example.s3.amazonaws.com/child.template"}}}}`
+	template, err := packager.Package(context.Background(), "my-app/main/1.abc/cloudformation.template", []byte(body))
+	assert.NoError(t, err)
+	assert.Equal(t, Template{Body: body}, template)
+	assert.Empty(t, client.puts)
+}
+
+func TestPackage_RewritesRelativeReferences(t *testing.T) {
+	client := &fakeS3{objects: map[string]string{
+		"my-app/main/1.abc/nested/network.yaml": `
+Resources:
+  Vpc:
+    Type: AWS::EC2::VPC
+    Properties:
+      CidrBlock: 10.0.0.0/16
+`,
+		"my-app/main/1.abc/nested/api.yaml": `
+Resources:
+  Handler:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: ../functions/api.zip
+      Runtime: provided.al2023
+`,
+	}}
+	packager := NewPackager(client, "artifacts", "my-app/main/1.abc/")
+
+	body := `
+Transform: AWS::Serverless-2016-10-31
+Globals:
+  Function:
+    CodeUri: ./functions/default.zip
+Resources:
+  Network:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: nested/network.yaml
+  Api:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: nested/api.yaml
+      Parameters:
+        VpcId: !GetAtt Network.Outputs.VpcId
+  Worker:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: !Sub "s3://${AWS::AccountId}/worker.zip"
+`
+	template, err := packager.Package(context.Background(), "my-app/main/1.abc/cloudformation.template", []byte(body))
+	assert.NoError(t, err)
+	assert.Empty(t, template.URL)
+
+	// Nested templates without relative references are deployed as uploaded
+	assert.Contains(t, template.Body, "TemplateURL: https://artifacts.s3.amazonaws.com/my-app/main/1.abc/nested/network.yaml")
+	assert.Contains(t, template.Body, "TemplateURL: https://artifacts.s3.amazonaws.com/my-app/main/1.abc/packaged/nested/api.yaml")
+	assert.Contains(t, template.Body, "CodeUri: s3://artifacts/my-app/main/1.abc/functions/default.zip")
+	assert.Contains(t, template.Body, "VpcId: !GetAtt Network.Outputs.VpcId")
+	assert.Contains(t, template.Body, `CodeUri: !Sub "s3://${AWS::AccountId}/worker.zip"`)
+
+	assert.Equal(t, []string{"my-app/main/1.abc/packaged/nested/api.yaml"}, client.puts)
+	assert.Contains(t, client.objects["my-app/main/1.abc/packaged/nested/api.yaml"], "CodeUri: s3://artifacts/my-app/main/1.abc/functions/api.zip")
+}
+
+func TestPackage_LargeTemplate(t *testing.T) {
+	description := strings.Repeat("x", MaxTemplateBodySize)
+
+	t.Run("unchanged", func(t *testing.T) {
+		client := &fakeS3{objects: map[string]string{}}
+		packager := NewPackager(client, "artifacts", "my-app/main/1.abc")
+
+		body := `{"Description":"` + description + `","Resources":{}}`
+		template, err := packager.Package(context.Background(), "my-app/main/1.abc/cloudformation.template", []byte(body))
+		assert.NoError(t, err)
+		assert.Equal(t, Template{URL: "https://artifacts.s3.amazonaws.com/my-app/main/1.abc/cloudformation.template"}, template)
+		assert.Empty(t, client.puts)
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		client := &fakeS3{objects: map[string]string{}}
+		packager := NewPackager(client, "artifacts", "my-app/main/1.abc")
+
+		body := `{"Description":"` + description + `","Resources":{"Fn":{"Type":"AWS::Serverless::Function","Properties":{"CodeUri":"fn.zip"}}}}`
+		template, err := packager.Package(context.Background(), "my-app/main/1.abc/cloudformation.template", []byte(body))
+		assert.NoError(t, err)
+		assert.Equal(t, Template{URL: "https://artifacts.s3.amazonaws.com/my-app/main/1.abc/packaged/cloudformation.template"}, template)
+		assert.Contains(t, client.objects["my-app/main/1.abc/packaged/cloudformation.template"], "s3://artifacts/my-app/main/1.abc/fn.zip")
+	})
+}
+
+func TestPackage_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects map[string]string
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "outside prefix",
+			body:    `{"Resources":{"Fn":{"Type":"AWS::Serverless::Function","Properties":{"CodeUri":"../../other/fn.zip"}}}}`,
+			wantErr: "outside the artifacts of the build",
+		},
+		{
+			name:    "missing nested template",
+			body:    `{"Resources":{"Child":{"Type":"AWS::CloudFormation::Stack","Properties":{"TemplateURL":"child.template"}}}}`,
+			wantErr: "failed to get object my-app/main/1.abc/child.template",
+		},
+		{
+			name: "cycle",
+			objects: map[string]string{
+				"my-app/main/1.abc/child.template": `{"Resources":{"Child":{"Type":"AWS::CloudFormation::Stack","Properties":{"TemplateURL":"child.template"}}}}`,
+			},
+			body:    `{"Resources":{"Child":{"Type":"AWS::CloudFormation::Stack","Properties":{"TemplateURL":"child.template"}}}}`,
+			wantErr: "nested stacks are deeper than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := map[string]string{}
+			for k, v := range tt.objects {
+				objects[k] = v
+			}
+			packager := NewPackager(&fakeS3{objects: objects}, "artifacts", "my-app/main/1.abc")
+
+			_, err := packager.Package(context.Background(), "my-app/main/1.abc/cloudformation.template", []byte(tt.body))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
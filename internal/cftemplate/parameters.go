@@ -0,0 +1,243 @@
+// Package cftemplate inspects and prepares CloudFormation templates for deployment
+package cftemplate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Parameter types with values that can be checked before deploying. Other types, such as
+// AWS-specific parameter types, are validated by CloudFormation.
+const (
+	TypeString             = "String"
+	TypeNumber             = "Number"
+	TypeNumberList         = "List<Number>"
+	TypeCommaDelimitedList = "CommaDelimitedList"
+)
+
+// Parameter is a parameter declared in the Parameters section of a template
+type Parameter struct {
+	Type           string `yaml:"Type"`
+	Default        any    `yaml:"Default"`
+	AllowedValues  []any  `yaml:"AllowedValues"`
+	AllowedPattern string `yaml:"AllowedPattern"`
+	MinLength      any    `yaml:"MinLength"`
+	MaxLength      any    `yaml:"MaxLength"`
+	MinValue       any    `yaml:"MinValue"`
+	MaxValue       any    `yaml:"MaxValue"`
+	NoEcho         any    `yaml:"NoEcho"`
+}
+
+// HasDefault returns true if the parameter may be omitted
+func (p Parameter) HasDefault() bool {
+	return p.Default != nil
+}
+
+// Secret returns true if the parameter value must not be displayed
+func (p Parameter) Secret() bool {
+	v, _ := scalar(p.NoEcho)
+	return strings.EqualFold(v, "true")
+}
+
+// ParseParameters returns the parameters declared by a JSON or YAML template, keyed by name
+func ParseParameters(template []byte) (map[string]Parameter, error) {
+	var doc struct {
+		Parameters map[string]Parameter `yaml:"Parameters"`
+	}
+	if err := yaml.Unmarshal(template, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse template parameters: %w", err)
+	}
+	if doc.Parameters == nil {
+		return map[string]Parameter{}, nil
+	}
+	return doc.Parameters, nil
+}
+
+// Violation is a parameter value that CloudFormation would reject
+type Violation struct {
+	Parameter string
+	Message   string
+}
+
+// String returns a readable description of the violation
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Parameter, v.Message)
+}
+
+// ValidationError lists every parameter value that CloudFormation would reject
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+	return "invalid parameters: " + strings.Join(messages, "; ")
+}
+
+// ValidateParameters checks params against the parameters declared by a template. Returns a
+// *ValidationError listing unknown keys, missing required parameters, values outside
+// AllowedValues or AllowedPattern and values that do not match the declared type. Values
+// of NoEcho parameters are never included in the error.
+func ValidateParameters(declared map[string]Parameter, params []types.Parameter) error {
+	var (
+		violations []Violation
+		provided   = map[string]bool{}
+	)
+
+	for _, param := range params {
+		key := aws.ToString(param.ParameterKey)
+		provided[key] = true
+
+		def, ok := declared[key]
+		if !ok {
+			violations = append(violations, Violation{Parameter: key, Message: "not declared in template"})
+			continue
+		}
+		if aws.ToBool(param.UsePreviousValue) {
+			continue
+		}
+
+		for _, msg := range check(def, aws.ToString(param.ParameterValue)) {
+			violations = append(violations, Violation{Parameter: key, Message: msg})
+		}
+	}
+
+	for key, def := range declared {
+		if !provided[key] && !def.HasDefault() {
+			violations = append(violations, Violation{Parameter: key, Message: "required parameter has no value and no default"})
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Parameter < violations[j].Parameter
+	})
+	return &ValidationError{Violations: violations}
+}
+
+// check returns a message for each constraint of def that value violates
+func check(def Parameter, value string) []string {
+	var messages []string
+
+	// List values are checked item by item
+	items := []string{value}
+	if def.Type == TypeNumberList || def.Type == TypeCommaDelimitedList {
+		items = strings.Split(value, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+	}
+
+	switch def.Type {
+	case TypeNumber, TypeNumberList:
+		for _, item := range items {
+			n, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("%s is not a number", quote(def, item)))
+				continue
+			}
+			if lo, ok := number(def.MinValue); ok && n < lo {
+				messages = append(messages, fmt.Sprintf("%s is less than MinValue %v", quote(def, item), lo))
+			}
+			if hi, ok := number(def.MaxValue); ok && n > hi {
+				messages = append(messages, fmt.Sprintf("%s is greater than MaxValue %v", quote(def, item), hi))
+			}
+		}
+
+	case TypeString:
+		length := utf8.RuneCountInString(value)
+		if lo, ok := number(def.MinLength); ok && float64(length) < lo {
+			messages = append(messages, fmt.Sprintf("%s is shorter than MinLength %v", quote(def, value), lo))
+		}
+		if hi, ok := number(def.MaxLength); ok && float64(length) > hi {
+			messages = append(messages, fmt.Sprintf("%s is longer than MaxLength %v", quote(def, value), hi))
+		}
+		// CloudFormation patterns must match the whole value. Patterns using Java-only syntax
+		// are left for CloudFormation to check.
+		if def.AllowedPattern != "" {
+			if re, err := regexp.Compile(`^(?:` + def.AllowedPattern + `)$`); err == nil && !re.MatchString(value) {
+				messages = append(messages, fmt.Sprintf("%s does not match AllowedPattern %s", quote(def, value), def.AllowedPattern))
+			}
+		}
+	}
+
+	if len(def.AllowedValues) > 0 {
+		allowed := make([]string, 0, len(def.AllowedValues))
+		for _, v := range def.AllowedValues {
+			if s, ok := scalar(v); ok {
+				allowed = append(allowed, s)
+			}
+		}
+		for _, item := range items {
+			if !contains(allowed, item, def.Type) {
+				messages = append(messages, fmt.Sprintf("%s is not one of AllowedValues [%s]", quote(def, item), strings.Join(allowed, ", ")))
+			}
+		}
+	}
+
+	return messages
+}
+
+// quote returns value for display in a message, hiding it if the parameter is NoEcho
+func quote(def Parameter, value string) string {
+	if def.Secret() {
+		return "value"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// contains returns true if value is one of allowed. Numbers are compared by value.
+func contains(allowed []string, value, typ string) bool {
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+		if typ == TypeNumber || typ == TypeNumberList {
+			x, errX := strconv.ParseFloat(a, 64)
+			y, errY := strconv.ParseFloat(value, 64)
+			if errX == nil && errY == nil && x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scalar converts a scalar YAML value into its string form
+func scalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+// number converts a numeric YAML value, which CloudFormation also accepts as a string
+func number(v any) (float64, bool) {
+	s, ok := scalar(v)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
@@ -0,0 +1,132 @@
+package cftemplate
+
+import (
+	"testing"
+
+	"github.com/savaki/aws-deployer/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTemplate = `
+AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Env:
+    Type: String
+    AllowedValues: [dev, stg, prd]
+  Image:
+    Type: String
+    AllowedPattern: "[a-z-]+:[0-9.a-z]+"
+  Replicas:
+    Type: Number
+    Default: 2
+    MinValue: 1
+    MaxValue: "10"
+  Ports:
+    Type: List<Number>
+    Default: "80"
+  Password:
+    Type: String
+    NoEcho: true
+    MinLength: 8
+  Subnets:
+    Type: List<AWS::EC2::Subnet::Id>
+    Default: ""
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+    Properties:
+      QueueName: !Sub "${Env}-queue"
+`
+
+func TestParseParameters(t *testing.T) {
+	declared, err := ParseParameters([]byte(testTemplate))
+	assert.NoError(t, err)
+	assert.Len(t, declared, 6)
+	assert.Equal(t, TypeNumber, declared["Replicas"].Type)
+	assert.True(t, declared["Replicas"].HasDefault())
+	assert.True(t, declared["Subnets"].HasDefault())
+	assert.False(t, declared["Env"].HasDefault())
+	assert.True(t, declared["Password"].Secret())
+
+	declared, err = ParseParameters([]byte(`{"Parameters":{"Env":{"Type":"String","Default":"dev"}},"Resources":{}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", declared["Env"].Default)
+
+	declared, err = ParseParameters([]byte(`{"Resources":{}}`))
+	assert.NoError(t, err)
+	assert.Empty(t, declared)
+}
+
+func TestValidateParameters(t *testing.T) {
+	declared, err := ParseParameters([]byte(testTemplate))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   []Violation
+	}{
+		{
+			name: "valid",
+			params: map[string]string{
+				"Env":      "dev",
+				"Image":    "my-app:1.abc",
+				"Replicas": "3",
+				"Ports":    "80, 443",
+				"Password": "correct-horse",
+			},
+		},
+		{
+			name: "unknown and missing",
+			params: map[string]string{
+				"Env":      "dev",
+				"Password": "correct-horse",
+				"Extra":    "value",
+			},
+			want: []Violation{
+				{Parameter: "Extra", Message: "not declared in template"},
+				{Parameter: "Image", Message: "required parameter has no value and no default"},
+			},
+		},
+		{
+			name: "constraints",
+			params: map[string]string{
+				"Env":      "qa",
+				"Image":    "My App",
+				"Replicas": "11",
+				"Ports":    "80,http",
+				"Password": "short",
+			},
+			want: []Violation{
+				{Parameter: "Env", Message: `"qa" is not one of AllowedValues [dev, stg, prd]`},
+				{Parameter: "Image", Message: `"My App" does not match AllowedPattern [a-z-]+:[0-9.a-z]+`},
+				{Parameter: "Password", Message: "value is shorter than MinLength 8"},
+				{Parameter: "Ports", Message: `"http" is not a number`},
+				{Parameter: "Replicas", Message: `"11" is greater than MaxValue 10`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParameters(declared, utils.MergeParameters(tt.params))
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *ValidationError
+			if assert.ErrorAs(t, err, &verr) {
+				assert.Equal(t, tt.want, verr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Violations: []Violation{
+		{Parameter: "Env", Message: "not declared in template"},
+		{Parameter: "Image", Message: "required parameter has no value and no default"},
+	}}
+	assert.Equal(t, "invalid parameters: Env: not declared in template; Image: required parameter has no value and no default", err.Error())
+}
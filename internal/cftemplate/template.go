@@ -0,0 +1,185 @@
+package cftemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Filenames are the names a build may give its template, in the order they are looked up
+var Filenames = []string{"cloudformation.template", "cloudformation.yaml", "template.yaml"}
+
+// intrinsics maps the short-form tags of intrinsic functions to their long-form names
+var intrinsics = map[string]string{
+	"!And":          "Fn::And",
+	"!Base64":       "Fn::Base64",
+	"!Cidr":         "Fn::Cidr",
+	"!Condition":    "Condition",
+	"!Equals":       "Fn::Equals",
+	"!FindInMap":    "Fn::FindInMap",
+	"!GetAtt":       "Fn::GetAtt",
+	"!GetAZs":       "Fn::GetAZs",
+	"!If":           "Fn::If",
+	"!ImportValue":  "Fn::ImportValue",
+	"!Join":         "Fn::Join",
+	"!Length":       "Fn::Length",
+	"!Not":          "Fn::Not",
+	"!Or":           "Fn::Or",
+	"!Ref":          "Ref",
+	"!Select":       "Fn::Select",
+	"!Split":        "Fn::Split",
+	"!Sub":          "Fn::Sub",
+	"!ToJsonString": "Fn::ToJsonString",
+	"!Transform":    "Fn::Transform",
+}
+
+// retainPolicies are the DeletionPolicy and UpdateReplacePolicy values that keep a resource's data
+var retainPolicies = map[string]bool{
+	"Retain":               true,
+	"RetainExceptOnCreate": true,
+	"Snapshot":             true,
+}
+
+// Find downloads the template of the build, trying each of Filenames in turn. Returns the
+// key and contents of the first one that exists.
+func (p *Packager) Find(ctx context.Context) (string, []byte, error) {
+	for _, name := range Filenames {
+		key := p.prefix + name
+		body, err := p.download(ctx, key)
+		if err == nil {
+			return key, body, nil
+		}
+
+		var noSuchKey *s3types.NoSuchKey
+		if !errors.As(err, &noSuchKey) {
+			return "", nil, err
+		}
+	}
+	return "", nil, fmt.Errorf("no template found in s3://%s/%s, expected one of %s", p.bucket, p.prefix, strings.Join(Filenames, ", "))
+}
+
+// Parse decodes a JSON or YAML template. Short-form intrinsic functions such as !Ref, !Sub
+// and !GetAtt are converted to their long form, so policies see the same structure whichever
+// form the template uses.
+func Parse(body []byte) (map[string]interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudFormation template: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	longForm(&doc)
+
+	var template map[string]interface{}
+	if err := doc.Decode(&template); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudFormation template: %w", err)
+	}
+	return template, nil
+}
+
+// Transforms returns the macros, such as AWS::Serverless-2016-10-31, declared by the
+// Transform section of a parsed template
+func Transforms(template map[string]interface{}) []string {
+	switch v := template["Transform"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var transforms []string
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				transforms = append(transforms, name)
+			}
+		}
+		return transforms
+	default:
+		return nil
+	}
+}
+
+// Capabilities returns the capabilities needed to deploy a parsed template. Templates with
+// transforms need CAPABILITY_AUTO_EXPAND.
+func Capabilities(template map[string]interface{}) []types.Capability {
+	capabilities := []types.Capability{
+		types.CapabilityCapabilityIam,
+		types.CapabilityCapabilityNamedIam,
+	}
+	if len(Transforms(template)) > 0 {
+		capabilities = append(capabilities, types.CapabilityCapabilityAutoExpand)
+	}
+	return capabilities
+}
+
+// Unretained returns the sorted logical IDs of resources of a protected type that would lose
+// their data if they were replaced or removed: their DeletionPolicy or UpdateReplacePolicy is
+// not Retain or Snapshot
+func Unretained(template map[string]interface{}, protects func(resourceType string) bool) []string {
+	resources, _ := template["Resources"].(map[string]interface{})
+
+	var ids []string
+	for id, v := range resources {
+		resource, _ := v.(map[string]interface{})
+		resourceType, _ := resource["Type"].(string)
+		if !protects(resourceType) {
+			continue
+		}
+
+		deletion, _ := resource["DeletionPolicy"].(string)
+		replace, _ := resource["UpdateReplacePolicy"].(string)
+		if !retainPolicies[deletion] || !retainPolicies[replace] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// longForm replaces short-form intrinsic functions in node with their long form, e.g.
+// !GetAtt Bucket.Arn becomes {"Fn::GetAtt": ["Bucket", "Arn"]}
+func longForm(node *yaml.Node) {
+	for _, child := range node.Content {
+		longForm(child)
+	}
+
+	name, ok := intrinsics[node.Tag]
+	if !ok {
+		return
+	}
+
+	value := *node
+	switch value.Kind {
+	case yaml.ScalarNode:
+		value.Tag = "!!str"
+		if name == "Fn::GetAtt" {
+			resource, attribute, _ := strings.Cut(value.Value, ".")
+			value = yaml.Node{
+				Kind: yaml.SequenceNode,
+				Tag:  "!!seq",
+				Content: []*yaml.Node{
+					{Kind: yaml.ScalarNode, Tag: "!!str", Value: resource},
+					{Kind: yaml.ScalarNode, Tag: "!!str", Value: attribute},
+				},
+			}
+		}
+	case yaml.SequenceNode:
+		value.Tag = "!!seq"
+	case yaml.MappingNode:
+		value.Tag = "!!map"
+	}
+
+	*node = yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			&value,
+		},
+	}
+}
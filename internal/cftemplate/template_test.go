@@ -0,0 +1,131 @@
+package cftemplate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_ShortFormTags(t *testing.T) {
+	template, err := Parse([]byte(`
+Transform: AWS::Serverless-2016-10-31
+Conditions:
+  IsProd: !Equals [!Ref Env, prd]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Condition: IsProd
+    Properties:
+      BucketName: !Sub "${Env}-bucket"
+  Policy:
+    Type: AWS::S3::BucketPolicy
+    Properties:
+      Bucket: !Ref Bucket
+      PolicyDocument:
+        Statement:
+          - Resource: !GetAtt Bucket.Arn
+          - Resource: !Join ["", [!GetAtt [Bucket, Arn], "/*"]]
+`))
+	assert.NoError(t, err)
+
+	resources := template["Resources"].(map[string]interface{})
+	bucket := resources["Bucket"].(map[string]interface{})
+	assert.Equal(t, "IsProd", bucket["Condition"])
+	assert.Equal(t, map[string]interface{}{"Fn::Sub": "${Env}-bucket"}, bucket["Properties"].(map[string]interface{})["BucketName"])
+
+	policy := resources["Policy"].(map[string]interface{})["Properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"Ref": "Bucket"}, policy["Bucket"])
+
+	statements := policy["PolicyDocument"].(map[string]interface{})["Statement"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"Fn::GetAtt": []interface{}{"Bucket", "Arn"}}, statements[0].(map[string]interface{})["Resource"])
+	assert.Equal(t,
+		map[string]interface{}{"Fn::Join": []interface{}{"", []interface{}{
+			map[string]interface{}{"Fn::GetAtt": []interface{}{"Bucket", "Arn"}},
+			"/*",
+		}}},
+		statements[1].(map[string]interface{})["Resource"],
+	)
+
+	conditions := template["Conditions"].(map[string]interface{})
+	assert.Equal(t,
+		map[string]interface{}{"Fn::Equals": []interface{}{map[string]interface{}{"Ref": "Env"}, "prd"}},
+		conditions["IsProd"],
+	)
+}
+
+func TestParse_JSON(t *testing.T) {
+	template, err := Parse([]byte(`{"Resources":{"Queue":{"Type":"AWS::SQS::Queue","Properties":{"QueueName":{"Ref":"Name"}}}}}`))
+	assert.NoError(t, err)
+	queue := template["Resources"].(map[string]interface{})["Queue"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"Ref": "Name"}, queue["Properties"].(map[string]interface{})["QueueName"])
+
+	template, err = Parse([]byte(""))
+	assert.NoError(t, err)
+	assert.Empty(t, template)
+}
+
+func TestTransformsAndCapabilities(t *testing.T) {
+	sam := map[string]interface{}{"Transform": "AWS::Serverless-2016-10-31"}
+	assert.Equal(t, []string{"AWS::Serverless-2016-10-31"}, Transforms(sam))
+	assert.Contains(t, Capabilities(sam), types.CapabilityCapabilityAutoExpand)
+
+	multiple := map[string]interface{}{"Transform": []interface{}{"AWS::LanguageExtensions", "AWS::Serverless-2016-10-31"}}
+	assert.Equal(t, []string{"AWS::LanguageExtensions", "AWS::Serverless-2016-10-31"}, Transforms(multiple))
+
+	plain := map[string]interface{}{"Resources": map[string]interface{}{}}
+	assert.Empty(t, Transforms(plain))
+	assert.Equal(t, []types.Capability{types.CapabilityCapabilityIam, types.CapabilityCapabilityNamedIam}, Capabilities(plain))
+}
+
+func TestUnretained(t *testing.T) {
+	template, err := Parse([]byte(`
+Resources:
+  Table:
+    Type: AWS::DynamoDB::Table
+  Bucket:
+    Type: AWS::S3::Bucket
+    DeletionPolicy: Retain
+    UpdateReplacePolicy: Retain
+  Database:
+    Type: AWS::RDS::DBInstance
+    DeletionPolicy: Snapshot
+  Queue:
+    Type: AWS::SQS::Queue
+`))
+	assert.NoError(t, err)
+
+	protects := func(resourceType string) bool {
+		return resourceType != "AWS::SQS::Queue"
+	}
+	assert.Equal(t, []string{"Database", "Table"}, Unretained(template, protects))
+	assert.Empty(t, Unretained(map[string]interface{}{}, protects))
+}
+
+func TestFind(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		client := &fakeS3{objects: map[string]string{
+			"my-app/main/1.abc/template.yaml": "Resources: {}",
+		}}
+		key, body, err := NewPackager(client, "artifacts", "my-app/main/1.abc").Find(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "my-app/main/1.abc/template.yaml", key)
+		assert.Equal(t, "Resources: {}", string(body))
+	})
+
+	t.Run("prefers cloudformation.template", func(t *testing.T) {
+		client := &fakeS3{objects: map[string]string{
+			"my-app/main/1.abc/cloudformation.template": `{"Resources":{}}`,
+			"my-app/main/1.abc/template.yaml":           "Resources: {}",
+		}}
+		key, _, err := NewPackager(client, "artifacts", "my-app/main/1.abc").Find(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "my-app/main/1.abc/cloudformation.template", key)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, _, err := NewPackager(&fakeS3{objects: map[string]string{}}, "artifacts", "my-app/main/1.abc").Find(context.Background())
+		assert.ErrorContains(t, err, "no template found in s3://artifacts/my-app/main/1.abc/")
+	})
+}
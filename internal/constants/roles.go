@@ -13,4 +13,8 @@ const (
 	// ECRImagePromotionRoleName is the name of the role in target accounts
 	// that allows ECR image promotion (create repos, push images only)
 	ECRImagePromotionRoleName = "ECRImagePromotionRole"
+
+	// StackEventsReaderRoleName is the name of the role in target accounts
+	// that allows the server to read stack events of StackSet instances
+	StackEventsReaderRoleName = "StackEventsReaderRole"
 )
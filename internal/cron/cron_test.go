@@ -0,0 +1,74 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Matches(t *testing.T) {
+	// Friday 2025-12-19 18:30 UTC
+	friday := time.Date(2025, time.December, 19, 18, 30, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		"every minute":            {expr: "* * * * *", t: friday, want: true},
+		"exact":                   {expr: "30 18 19 12 *", t: friday, want: true},
+		"wrong minute":            {expr: "0 18 * * *", t: friday, want: false},
+		"weekday range":           {expr: "30 18 * * 1-5", t: friday, want: true},
+		"weekend":                 {expr: "30 18 * * 6,0", t: friday, want: false},
+		"sunday as 7":             {expr: "30 18 * * 7", t: friday.AddDate(0, 0, 2), want: true},
+		"step":                    {expr: "*/15 * * * *", t: friday, want: true},
+		"step miss":               {expr: "*/20 * * * *", t: friday, want: false},
+		"range step":              {expr: "0-30/10 18 * * *", t: friday, want: true},
+		"value step":              {expr: "10/20 18 * * *", t: friday, want: true},
+		"day of month or weekday": {expr: "30 18 1 * 5", t: friday, want: true},
+		"day of month only":       {expr: "30 18 1 * *", t: friday, want: false},
+		"month list":              {expr: "30 18 * 1,12 *", t: friday, want: true},
+		"ignores seconds":         {expr: "30 18 * * *", t: friday.Add(45 * time.Second), want: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			schedule, err := Parse(tc.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, schedule.Matches(tc.t))
+		})
+	}
+}
+
+func TestSchedule_MatchesLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	schedule, err := Parse("0 9 * * 1-5")
+	assert.NoError(t, err)
+
+	// 14:00 UTC on a Monday in January is 09:00 in New York
+	monday := time.Date(2026, time.January, 5, 14, 0, 0, 0, time.UTC)
+	assert.False(t, schedule.Matches(monday))
+	assert.True(t, schedule.Matches(monday.In(ny)))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
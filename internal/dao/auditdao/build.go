@@ -0,0 +1,5 @@
+package auditdao
+
+func TableName(env string) string {
+	return env + "-aws-deployer--audit"
+}
@@ -0,0 +1,208 @@
+package auditdao
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/segmentio/ksuid"
+)
+
+// dayLayout formats the partition key; events are partitioned by UTC day so a time range
+// reads one partition per day
+const dayLayout = "2006-01-02"
+
+// DefaultRange is how far back a query reads when no start time is given
+const DefaultRange = 7 * 24 * time.Hour
+
+// ActorType identifies who or what performed an action
+type ActorType string
+
+const (
+	ActorUser    ActorType = "user"    // a person signed in to the console, or a personal API token
+	ActorService ActorType = "service" // a service API token
+	ActorIAM     ActorType = "iam"     // an IAM principal running the aws-deployer CLI
+	ActorSystem  ActorType = "system"  // the deployment state machine
+)
+
+// Record is an audit event. Events are only ever appended; nothing updates or deletes them.
+type Record struct {
+	PK        string    `ddb:"hash" dynamodbav:"pk"`        // UTC day of the event (YYYY-MM-DD)
+	SK        string    `ddb:"range" dynamodbav:"sk"`       // KSUID
+	ActorType ActorType `dynamodbav:"actor_type"`           // user|service|iam|system
+	Actor     string    `dynamodbav:"actor"`                // Email, service:{name}, IAM ARN or system component
+	ActorSub  string    `dynamodbav:"actor_sub,omitempty"`  // Subject of the session or token, if any
+	Action    string    `dynamodbav:"action"`               // e.g. build.redeploy, targets.set, lock.release
+	Repo      string    `dynamodbav:"repo,omitempty"`       // Repository affected, if any
+	Env       string    `dynamodbav:"env,omitempty"`        // Environment affected, if any
+	Target    string    `dynamodbav:"target,omitempty"`     // ID of the record changed, e.g. a build ID
+	Before    string    `dynamodbav:"before,omitempty"`     // JSON of the value before the change
+	After     string    `dynamodbav:"after,omitempty"`      // JSON of the value after the change
+	Source    string    `dynamodbav:"source"`               // graphql|cli|state-machine
+	RequestID string    `dynamodbav:"request_id,omitempty"` // Request that made the change
+	CreatedAt int64     `dynamodbav:"created_at"`           // Unix epoch timestamp of the event
+}
+
+// GetID returns the event ID in format {day}:{ksuid}
+func (r *Record) GetID() string {
+	return r.PK + ":" + r.SK
+}
+
+// CreateInput contains fields for appending an event
+type CreateInput struct {
+	ActorType ActorType // user|service|iam|system
+	Actor     string    // Email, service:{name}, IAM ARN or system component
+	ActorSub  string    // Subject of the session or token (optional)
+	Action    string    // e.g. build.redeploy
+	Repo      string    // Repository affected (optional)
+	Env       string    // Environment affected (optional)
+	Target    string    // ID of the record changed (optional)
+	Before    string    // JSON of the value before the change (optional)
+	After     string    // JSON of the value after the change (optional)
+	Source    string    // graphql|cli|state-machine
+	RequestID string    // Request that made the change (optional)
+	At        time.Time // Time of the event (default now)
+}
+
+// QueryInput filters audit events. Empty fields match everything.
+type QueryInput struct {
+	Since  time.Time // Earliest event (inclusive, default Until - DefaultRange)
+	Until  time.Time // Latest event (inclusive, default now)
+	Actor  string    // Actor, case-insensitive
+	Action string    // Action, or an action prefix such as "build" for build.*
+	Repo   string
+	Env    string
+	Target string
+	Limit  int // Maximum events to return, newest first (0 for all)
+}
+
+// Matches returns true if the record passes the filters of the input, ignoring the time range
+func (in QueryInput) Matches(r Record) bool {
+	if in.Actor != "" && !strings.EqualFold(in.Actor, r.Actor) {
+		return false
+	}
+	if in.Action != "" && r.Action != in.Action && !strings.HasPrefix(r.Action, in.Action+".") {
+		return false
+	}
+	if in.Repo != "" && in.Repo != r.Repo {
+		return false
+	}
+	if in.Env != "" && in.Env != r.Env {
+		return false
+	}
+	if in.Target != "" && in.Target != r.Target {
+		return false
+	}
+	return true
+}
+
+// DAO provides data access operations for audit events
+type DAO struct {
+	db    *ddb.DDB
+	table *ddb.Table
+}
+
+// New creates a new DAO instance
+func New(client *dynamodb.Client, tableName string) *DAO {
+	db := ddb.New(client)
+	table := db.MustTable(tableName, &Record{})
+	return &DAO{
+		db:    db,
+		table: table,
+	}
+}
+
+// Append records an event
+func (d *DAO) Append(ctx context.Context, input CreateInput) (Record, error) {
+	if input.Action == "" || input.Actor == "" {
+		return Record{}, fmt.Errorf("audit events require an actor and an action")
+	}
+
+	at := input.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	id, err := ksuid.NewRandomWithTime(at)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to generate audit event ID: %w", err)
+	}
+
+	record := Record{
+		PK:        at.UTC().Format(dayLayout),
+		SK:        id.String(),
+		ActorType: input.ActorType,
+		Actor:     input.Actor,
+		ActorSub:  input.ActorSub,
+		Action:    input.Action,
+		Repo:      input.Repo,
+		Env:       input.Env,
+		Target:    input.Target,
+		Before:    input.Before,
+		After:     input.After,
+		Source:    input.Source,
+		RequestID: input.RequestID,
+		CreatedAt: at.Unix(),
+	}
+
+	if err := d.table.Put(record).RunWithContext(ctx); err != nil {
+		return Record{}, fmt.Errorf("failed to append audit event: %w", err)
+	}
+
+	return record, nil
+}
+
+// Query returns the events matching the input, newest first. It reads one partition per
+// day of the time range.
+func (d *DAO) Query(ctx context.Context, input QueryInput) ([]Record, error) {
+	until := input.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := input.Since
+	if since.IsZero() {
+		since = until.Add(-DefaultRange)
+	}
+	if since.After(until) {
+		return nil, fmt.Errorf("since (%s) is after until (%s)", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	var (
+		matched []Record
+		first   = since.UTC().Format(dayLayout)
+	)
+	for day := until.UTC(); ; day = day.AddDate(0, 0, -1) {
+		pk := day.Format(dayLayout)
+		if pk < first {
+			break
+		}
+
+		var records []Record
+		err := d.table.Query("#PK = ?", pk).
+			FindAllWithContext(ctx, &records)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query audit events: %w", err)
+		}
+
+		// KSUIDs sort by time
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].SK > records[j].SK
+		})
+
+		for _, record := range records {
+			if record.CreatedAt < since.Unix() || record.CreatedAt > until.Unix() || !input.Matches(record) {
+				continue
+			}
+			matched = append(matched, record)
+			if input.Limit > 0 && len(matched) == input.Limit {
+				return matched, nil
+			}
+		}
+	}
+
+	return matched, nil
+}
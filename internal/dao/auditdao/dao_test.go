@@ -0,0 +1,107 @@
+package auditdao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	DAO *DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client    = dynamodb.NewFromConfig(cfg)
+		db        = ddb.New(client)
+		tableName = fmt.Sprintf("audit-test-%v", ksuid.New().String())
+		table     = db.MustTable(tableName, Record{})
+		dao       = New(client, tableName)
+	)
+
+	err = table.CreateTableIfNotExists(ctx)
+	assert.NoError(t, err)
+
+	return ctx, Data{DAO: dao}, func() {
+		_ = table.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestDAO(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		dao := data.DAO
+		now := time.Now()
+
+		events := []CreateInput{
+			{ActorType: ActorUser, Actor: "alice@example.com", Action: "build.redeploy", Repo: "my-app", Env: "dev", Target: "my-app/dev:1", Source: "graphql", At: now.Add(-3 * 24 * time.Hour)},
+			{ActorType: ActorIAM, Actor: "arn:aws:iam::123456789012:user/bob", Action: "targets.set", Repo: "my-app", Env: "prd", Source: "cli", At: now.Add(-time.Hour)},
+			{ActorType: ActorSystem, Actor: "state-machine", Action: "build.status", Repo: "my-app", Env: "dev", Target: "my-app/dev:1", Before: `{"status":"IN_PROGRESS"}`, After: `{"status":"SUCCESS"}`, Source: "state-machine", At: now},
+		}
+		for _, event := range events {
+			_, err := dao.Append(ctx, event)
+			assert.NoError(t, err)
+		}
+
+		t.Run("Append_Invalid", func(t *testing.T) {
+			_, err := dao.Append(ctx, CreateInput{Action: "build.redeploy"})
+			assert.Error(t, err, "missing actor")
+		})
+
+		t.Run("Query_NewestFirst", func(t *testing.T) {
+			records, err := dao.Query(ctx, QueryInput{Since: now.Add(-4 * 24 * time.Hour)})
+			assert.NoError(t, err)
+			if assert.Len(t, records, 3) {
+				assert.Equal(t, "build.status", records[0].Action)
+				assert.Equal(t, "build.redeploy", records[2].Action)
+			}
+		})
+
+		t.Run("Query_Filters", func(t *testing.T) {
+			records, err := dao.Query(ctx, QueryInput{Since: now.Add(-4 * 24 * time.Hour), Action: "build"})
+			assert.NoError(t, err)
+			assert.Len(t, records, 2, "action prefix")
+
+			records, err = dao.Query(ctx, QueryInput{Since: now.Add(-4 * 24 * time.Hour), Actor: "Alice@example.com"})
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+
+			records, err = dao.Query(ctx, QueryInput{Since: now.Add(-4 * 24 * time.Hour), Env: "dev", Limit: 1})
+			assert.NoError(t, err)
+			if assert.Len(t, records, 1) {
+				assert.Equal(t, `{"status":"SUCCESS"}`, records[0].After)
+			}
+
+			records, err = dao.Query(ctx, QueryInput{})
+			assert.NoError(t, err)
+			assert.Len(t, records, 3, "default range covers the last week")
+
+			records, err = dao.Query(ctx, QueryInput{Since: now.Add(-2 * time.Hour)})
+			assert.NoError(t, err)
+			assert.Len(t, records, 2)
+
+			_, err = dao.Query(ctx, QueryInput{Since: now, Until: now.Add(-time.Hour)})
+			assert.Error(t, err)
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package builddao
+
+import "strings"
+
+// ApprovalDecision represents an approver's decision on a build
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApproved ApprovalDecision = "APPROVED"
+	ApprovalDecisionRejected ApprovalDecision = "REJECTED"
+)
+
+// Approval records a single approver's decision on a build awaiting approval
+type Approval struct {
+	Email     string           `json:"email" dynamodbav:"email"`
+	Name      string           `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Sub       string           `json:"sub,omitempty" dynamodbav:"sub,omitempty"`
+	Decision  ApprovalDecision `json:"decision" dynamodbav:"decision"`
+	Comment   string           `json:"comment,omitempty" dynamodbav:"comment,omitempty"`
+	CreatedAt int64            `json:"created_at" dynamodbav:"created_at"` // Unix epoch timestamp of the decision
+}
+
+// ApprovalCount returns the number of approvals recorded on the build
+func (r *Record) ApprovalCount() int {
+	var n int
+	for _, approval := range r.Approvals {
+		if approval.Decision == ApprovalDecisionApproved {
+			n++
+		}
+	}
+	return n
+}
+
+// HasDecisionFrom returns true if the given email already approved or rejected the build
+func (r *Record) HasDecisionFrom(email string) bool {
+	for _, approval := range r.Approvals {
+		if strings.EqualFold(approval.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApproved returns true once the build has collected all required approvals
+func (r *Record) IsApproved() bool {
+	return r.ApprovalCount() >= r.RequiredApprovals
+}
@@ -0,0 +1,45 @@
+package builddao
+
+// ChangeAction represents the action CloudFormation will take on a resource
+type ChangeAction string
+
+const (
+	ChangeActionAdd     ChangeAction = "Add"
+	ChangeActionModify  ChangeAction = "Modify"
+	ChangeActionRemove  ChangeAction = "Remove"
+	ChangeActionImport  ChangeAction = "Import"
+	ChangeActionDynamic ChangeAction = "Dynamic"
+)
+
+// ResourceChange describes a single resource change within a change set
+type ResourceChange struct {
+	Action             ChangeAction `json:"action" dynamodbav:"action"`
+	LogicalResourceID  string       `json:"logical_resource_id" dynamodbav:"logical_resource_id"`
+	PhysicalResourceID string       `json:"physical_resource_id,omitempty" dynamodbav:"physical_resource_id,omitempty"`
+	ResourceType       string       `json:"resource_type" dynamodbav:"resource_type"`
+	Replacement        string       `json:"replacement,omitempty" dynamodbav:"replacement,omitempty"` // True, False, or Conditional (Modify only)
+}
+
+// RequiresReplacement returns true if CloudFormation may replace the resource
+func (c ResourceChange) RequiresReplacement() bool {
+	return c.Replacement == "True" || c.Replacement == "Conditional"
+}
+
+// ChangeSet is a summary of the CloudFormation change set created for a build
+type ChangeSet struct {
+	Name    string           `json:"name" dynamodbav:"name"`
+	ID      string           `json:"id,omitempty" dynamodbav:"id,omitempty"`
+	Type    string           `json:"type" dynamodbav:"type"` // CREATE or UPDATE
+	Changes []ResourceChange `json:"changes" dynamodbav:"changes"`
+}
+
+// Count returns the number of changes with the given action
+func (c ChangeSet) Count(action ChangeAction) int {
+	var n int
+	for _, change := range c.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
@@ -2,17 +2,28 @@ package builddao
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/savaki/ddb/v2"
 	"github.com/savaki/gox/slicex"
 )
 
 const latest = "latest"
 
+// ErrNotFound is returned when a build record does not exist
+var ErrNotFound = errors.New("build record not found")
+
+// ErrNotBlocked is returned when releasing a build that is no longer BLOCKED
+var ErrNotBlocked = errors.New("build is not blocked")
+
 // PK represents a DynamoDB partition key in format {repo}/{env}
 // Example: myrepo/dev
 type PK string
@@ -65,30 +76,52 @@ func NewID(pk PK, sk string) ID {
 type BuildStatus string
 
 const (
-	BuildStatusPending    BuildStatus = "PENDING"
-	BuildStatusInProgress BuildStatus = "IN_PROGRESS"
-	BuildStatusSuccess    BuildStatus = "SUCCESS"
-	BuildStatusFailed     BuildStatus = "FAILED"
+	BuildStatusPending         BuildStatus = "PENDING"
+	BuildStatusPendingApproval BuildStatus = "PENDING_APPROVAL"
+	BuildStatusBlocked         BuildStatus = "BLOCKED" // Held by a deploy freeze or outside allowed deploy windows
+	BuildStatusInProgress      BuildStatus = "IN_PROGRESS"
+	BuildStatusSuccess         BuildStatus = "SUCCESS"
+	BuildStatusFailed          BuildStatus = "FAILED"
 )
 
 // Record represents a deployment build record in DynamoDB
 type Record struct {
-	PK           PK          `ddb:"hash" dynamodbav:"pk"`                   // {repo}/{env} - DynamoDB partition key
-	SK           string      `ddb:"range" dynamodbav:"sk"`                  // KSUID - DynamoDB sort key
-	ID           ID          `dynamodbav:"id,omitempty"`                    // ID is only used for latest entries
-	Repo         string      `dynamodbav:"repo,omitempty"`                  // Repository name
-	Env          string      `dynamodbav:"env,omitempty"`                   // Environment name (dev, staging, prod)
-	BuildNumber  string      `dynamodbav:"build_number,omitempty"`          // Build number from version
-	Branch       string      `dynamodbav:"branch,omitempty"`
-	Version      string      `dynamodbav:"version,omitempty"`
-	CommitHash   string      `dynamodbav:"commit_hash,omitempty"`
-	Status       BuildStatus `dynamodbav:"status,omitempty"`
-	StackName    string      `dynamodbav:"stack_name,omitempty"`
-	ExecutionArn *string     `dynamodbav:"execution_arn,omitempty,omitempty"` // Step Functions execution ARN
-	ErrorMsg     *string     `dynamodbav:"error_msg,omitempty,omitempty"`
-	CreatedAt    int64       `dynamodbav:"created_at,omitempty"`            // Unix epoch timestamp of creation
-	FinishedAt   *int64      `dynamodbav:"finished_at,omitempty,omitempty"` // Unix epoch timestamp of completion
-	UpdatedAt    int64       `dynamodbav:"updated_at,omitempty"`            // Unix epoch timestamp of last update
+	PK                  PK                   `ddb:"hash" dynamodbav:"pk"`          // {repo}/{env} - DynamoDB partition key
+	SK                  string               `ddb:"range" dynamodbav:"sk"`         // KSUID - DynamoDB sort key
+	ID                  ID                   `dynamodbav:"id,omitempty"`           // ID is only used for latest entries
+	Repo                string               `dynamodbav:"repo,omitempty"`         // Repository name
+	Env                 string               `dynamodbav:"env,omitempty"`          // Environment name (dev, staging, prod)
+	BuildNumber         string               `dynamodbav:"build_number,omitempty"` // Build number from version
+	Branch              string               `dynamodbav:"branch,omitempty"`
+	Version             string               `dynamodbav:"version,omitempty"`
+	CommitHash          string               `dynamodbav:"commit_hash,omitempty"`
+	Status              BuildStatus          `dynamodbav:"status,omitempty"`
+	StackName           string               `dynamodbav:"stack_name,omitempty"`
+	ExecutionArn        *string              `dynamodbav:"execution_arn,omitempty,omitempty"` // Step Functions execution ARN
+	ErrorMsg            *string              `dynamodbav:"error_msg,omitempty,omitempty"`
+	ChangeSet           *ChangeSet           `dynamodbav:"change_set,omitempty"`            // Change set preview (single-account)
+	PromotedBy          string               `dynamodbav:"promoted_by,omitempty"`           // Email of the user who promoted or redeployed this build
+	Approvals           []Approval           `dynamodbav:"approvals,omitempty"`             // Approval decisions (PENDING_APPROVAL builds)
+	RequiredApprovals   int                  `dynamodbav:"required_approvals,omitempty"`    // Approvals needed before the build deploys
+	PromotedFrom        ID                   `dynamodbav:"promoted_from,omitempty"`         // Upstream build this build was promoted from
+	StartAfter          int64                `dynamodbav:"start_after,omitempty"`           // Unix epoch timestamp before which the deployment waits (soak time)
+	RollbackOf          ID                   `dynamodbav:"rollback_of,omitempty"`           // Failed build this build rolls back (rollback builds only)
+	PolicyViolations    []PolicyViolation    `dynamodbav:"policy_violations,omitempty"`     // Template policy violations (enforced and warnings)
+	LockHolder          ID                   `dynamodbav:"lock_holder,omitempty"`           // Build holding the deployment lock while this build waits for it
+	GitHubRepo          string               `dynamodbav:"github_repo,omitempty"`           // GitHub repository (owner/repo) the build reports to
+	GitHubDeployment    int64                `dynamodbav:"github_deployment,omitempty"`     // GitHub Deployment ID of this build
+	BlockedReason       string               `dynamodbav:"blocked_reason,omitempty"`        // Why the build is held (BLOCKED builds)
+	FreezeOverride      *FreezeOverride      `dynamodbav:"freeze_override,omitempty"`       // Who deployed the build despite a freeze
+	ReplacementOverride *ReplacementOverride `dynamodbav:"replacement_override,omitempty"`  // Who allowed the build to replace or delete protected resources
+	Outputs             map[string]string    `dynamodbav:"outputs,omitempty"`               // Stack outputs once the stack finished deploying (single-account)
+	CreatedAt           int64                `dynamodbav:"created_at,omitempty"`            // Unix epoch timestamp of creation
+	FinishedAt          *int64               `dynamodbav:"finished_at,omitempty,omitempty"` // Unix epoch timestamp of completion
+	UpdatedAt           int64                `dynamodbav:"updated_at,omitempty"`            // Unix epoch timestamp of last update
+}
+
+// IsRollback returns true if the build redeploys a known-good version after a failed deployment
+func (r *Record) IsRollback() bool {
+	return r.RollbackOf != ""
 }
 
 // GetID returns the full build ID in format: {repo}/{env}:{ksuid}
@@ -101,28 +134,56 @@ func (r *Record) GetID() ID {
 
 // CreateInput contains the fields needed to create a new build record
 type CreateInput struct {
-	Repo        string // Repository name
-	Env         string // Environment (dev, staging, prod)
-	SK          string // KSUID sort key
-	BuildNumber string // Build number from version
-	Branch      string // Git branch
-	Version     string // Version string
-	CommitHash  string // Git commit hash
-	StackName   string // CloudFormation stack name
+	Repo                string               // Repository name
+	Env                 string               // Environment (dev, staging, prod)
+	SK                  string               // KSUID sort key
+	BuildNumber         string               // Build number from version
+	Branch              string               // Git branch
+	Version             string               // Version string
+	CommitHash          string               // Git commit hash
+	StackName           string               // CloudFormation stack name
+	PromotedBy          string               // Email of the user who promoted or redeployed the build (optional)
+	RequiredApprovals   int                  // Holds the build in PENDING_APPROVAL until this many approvals are recorded
+	PromotedFrom        ID                   // Upstream build ID (optional)
+	StartAfter          int64                // Unix epoch timestamp before which the deployment waits (optional)
+	RollbackOf          ID                   // Failed build this build rolls back (optional)
+	ReplacementOverride *ReplacementOverride // Allows the build to replace or delete protected resources (optional)
 }
 
 // UpdateInput contains the fields that can be updated on a build record
 type UpdateInput struct {
-	PK       PK           // Partition key (repo/env)
-	SK       string       // Sort key (KSUID)
-	Status   *BuildStatus // New status
-	ErrorMsg *string      // Error message (optional)
+	PK            PK           // Partition key (repo/env)
+	SK            string       // Sort key (KSUID)
+	Status        *BuildStatus // New status
+	ErrorMsg      *string      // Error message (optional)
+	BlockedReason *string      // Why the build is held (optional, BLOCKED builds)
+}
+
+// FreezeOverride records an emergency deploy of a build held by a deploy freeze
+type FreezeOverride struct {
+	Email     string `json:"email" dynamodbav:"email"`
+	Name      string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Sub       string `json:"sub,omitempty" dynamodbav:"sub,omitempty"`
+	Reason    string `json:"reason" dynamodbav:"reason"`
+	CreatedAt int64  `json:"created_at" dynamodbav:"created_at"` // Unix epoch timestamp of the override
+}
+
+// ReplacementOverride records who allowed a build to replace or delete resources protected
+// by the environment's stack policy
+type ReplacementOverride struct {
+	Email     string `json:"email" dynamodbav:"email"`
+	Name      string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Sub       string `json:"sub,omitempty" dynamodbav:"sub,omitempty"`
+	Reason    string `json:"reason" dynamodbav:"reason"`
+	CreatedAt int64  `json:"created_at" dynamodbav:"created_at"` // Unix epoch timestamp of the override
 }
 
 // DAO provides data access operations for build records
 type DAO struct {
-	db    *ddb.DDB
-	table *ddb.Table
+	client    *dynamodb.Client
+	tableName string
+	db        *ddb.DDB
+	table     *ddb.Table
 }
 
 // New creates a new DAO instance
@@ -130,29 +191,47 @@ func New(client *dynamodb.Client, tableName string) *DAO {
 	db := ddb.New(client)
 	table := db.MustTable(tableName, &Record{})
 	return &DAO{
-		db:    db,
-		table: table,
+		client:    client,
+		tableName: tableName,
+		db:        db,
+		table:     table,
 	}
 }
 
-// Create creates a new build record with initial status PENDING
+// Create creates a new build record with initial status PENDING, or PENDING_APPROVAL
+// when the input requires approvals
 func (d *DAO) Create(ctx context.Context, input CreateInput) (Record, error) {
 	pk := NewPK(input.Repo, input.Env)
 	now := time.Now().Unix()
 
+	status := BuildStatusPending
+	if input.RequiredApprovals > 0 {
+		status = BuildStatusPendingApproval
+	}
+
 	record := Record{
-		PK:          pk,
-		SK:          input.SK,
-		Repo:        input.Repo,
-		Env:         input.Env,
-		BuildNumber: input.BuildNumber,
-		Branch:      input.Branch,
-		Version:     input.Version,
-		CommitHash:  input.CommitHash,
-		Status:      BuildStatusPending,
-		StackName:   input.StackName,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		PK:                pk,
+		SK:                input.SK,
+		Repo:              input.Repo,
+		Env:               input.Env,
+		BuildNumber:       input.BuildNumber,
+		Branch:            input.Branch,
+		Version:           input.Version,
+		CommitHash:        input.CommitHash,
+		Status:            status,
+		StackName:         input.StackName,
+		PromotedBy:        input.PromotedBy,
+		RequiredApprovals: input.RequiredApprovals,
+		PromotedFrom:      input.PromotedFrom,
+		StartAfter:        input.StartAfter,
+		RollbackOf:        input.RollbackOf,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if input.ReplacementOverride != nil {
+		override := *input.ReplacementOverride
+		override.CreatedAt = now
+		record.ReplacementOverride = &override
 	}
 
 	err := d.table.Put(&record).RunWithContext(ctx)
@@ -181,14 +260,14 @@ func (d *DAO) Find(ctx context.Context, id ID) (Record, error) {
 		// Check if it's a "not found" error
 		errStr := err.Error()
 		if strings.Contains(errStr, "item not found") || strings.Contains(errStr, "ItemNotFound") {
-			return Record{}, fmt.Errorf("build record not found: %s", id)
+			return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
 		}
 		return Record{}, fmt.Errorf("failed to find build record: %w", err)
 	}
 
 	// If all fields are empty, item doesn't exist
 	if record.PK == "" && record.SK == "" {
-		return Record{}, fmt.Errorf("build record not found: %s", id)
+		return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
 	return record, nil
@@ -236,6 +315,10 @@ func (d *DAO) UpdateStatus(ctx context.Context, input UpdateInput) error {
 		update = update.Set("#ErrorMsg = ?", *input.ErrorMsg)
 	}
 
+	if input.BlockedReason != nil {
+		update = update.Set("#BlockedReason = ?", *input.BlockedReason)
+	}
+
 	// Create/update the "latest" magic record
 	// Parse env from PK (format: {repo}/{env})
 	repo, env, err := ParsePK(input.PK)
@@ -263,6 +346,215 @@ func (d *DAO) UpdateStatus(ctx context.Context, input UpdateInput) error {
 	return nil
 }
 
+// SetChangeSet stores the change set preview on a build record
+func (d *DAO) SetChangeSet(ctx context.Context, pk PK, sk string, changeSet ChangeSet) error {
+	err := d.table.Update(pk.String()).
+		Range(sk).
+		Set("#ChangeSet = ?", changeSet).
+		Set("#UpdatedAt = ?", time.Now().Unix()).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set change set: %w", err)
+	}
+
+	return nil
+}
+
+// SetOutputs stores the outputs of the build's stack once it finished deploying
+func (d *DAO) SetOutputs(ctx context.Context, pk PK, sk string, outputs map[string]string) error {
+	err := d.table.Update(pk.String()).
+		Range(sk).
+		Set("#Outputs = ?", outputs).
+		Set("#UpdatedAt = ?", time.Now().Unix()).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set outputs: %w", err)
+	}
+
+	return nil
+}
+
+// SetPolicyViolations stores the template policy violations found for a build
+func (d *DAO) SetPolicyViolations(ctx context.Context, pk PK, sk string, violations []PolicyViolation) error {
+	err := d.table.Update(pk.String()).
+		Range(sk).
+		Set("#PolicyViolations = ?", violations).
+		Set("#UpdatedAt = ?", time.Now().Unix()).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set policy violations: %w", err)
+	}
+
+	return nil
+}
+
+// SetLockHolder records the build holding the deployment lock this build is waiting on.
+// An empty holder clears it once the lock is acquired.
+func (d *DAO) SetLockHolder(ctx context.Context, pk PK, sk string, holder ID) error {
+	err := d.table.Update(pk.String()).
+		Range(sk).
+		Set("#LockHolder = ?", holder.String()).
+		Set("#UpdatedAt = ?", time.Now().Unix()).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set lock holder: %w", err)
+	}
+
+	return nil
+}
+
+// OverrideFreeze records who deployed a BLOCKED build despite the freeze and returns the
+// updated record. The caller starts the deployment.
+func (d *DAO) OverrideFreeze(ctx context.Context, id ID, override FreezeOverride) (Record, error) {
+	record, err := d.Find(ctx, id)
+	if err != nil {
+		return Record{}, err
+	}
+
+	if record.Status != BuildStatusBlocked {
+		return Record{}, fmt.Errorf("build %s is not blocked (status %s)", id, record.Status)
+	}
+
+	override.CreatedAt = time.Now().Unix()
+	err = d.table.Update(record.PK.String()).
+		Range(record.SK).
+		Set("#FreezeOverride = ?", override).
+		Set("#UpdatedAt = ?", override.CreatedAt).
+		RunWithContext(ctx)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to record freeze override: %w", err)
+	}
+
+	record.FreezeOverride = &override
+	record.UpdatedAt = override.CreatedAt
+	return record, nil
+}
+
+// Release returns a BLOCKED build to PENDING once the change calendar allows it to deploy and
+// returns the updated record. The status is only changed if the build is still BLOCKED, so a
+// build is released at most once; ErrNotBlocked is returned otherwise. The caller starts the
+// deployment.
+func (d *DAO) Release(ctx context.Context, id ID) (Record, error) {
+	record, err := d.Find(ctx, id)
+	if err != nil {
+		return Record{}, err
+	}
+
+	now := time.Now().Unix()
+	_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: record.PK.String()},
+			"sk": &types.AttributeValueMemberS{Value: record.SK},
+		},
+		UpdateExpression:    aws.String("SET #status = :pending, updated_at = :now"),
+		ConditionExpression: aws.String("#status = :blocked"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: string(BuildStatusPending)},
+			":blocked": &types.AttributeValueMemberS{Value: string(BuildStatusBlocked)},
+			":now":     &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+		},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return Record{}, fmt.Errorf("%w: %s", ErrNotBlocked, id)
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to release build: %w", err)
+	}
+
+	record.Status = BuildStatusPending
+	record.UpdatedAt = now
+	return record, nil
+}
+
+// SetGitHubDeployment records the GitHub repository and Deployment created for the build
+func (d *DAO) SetGitHubDeployment(ctx context.Context, pk PK, sk string, repo string, deploymentID int64) error {
+	err := d.table.Update(pk.String()).
+		Range(sk).
+		Set("#GitHubRepo = ?", repo).
+		Set("#GitHubDeployment = ?", deploymentID).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set GitHub deployment: %w", err)
+	}
+
+	return nil
+}
+
+// maxApprovalAttempts bounds how often AddApproval re-reads a build whose approvals changed
+// while the decision was being recorded
+const maxApprovalAttempts = 5
+
+// AddApproval records an approver's decision on a build awaiting approval and returns
+// the updated record. Each approver may decide on a build only once, and no decision is
+// accepted once the build has its required approvals.
+//
+// The decision is appended only if the build is still awaiting approval and its approvals
+// are unchanged since they were read, so concurrent decisions cannot overwrite each other,
+// duplicate an approver or push a build past its required approvals twice.
+func (d *DAO) AddApproval(ctx context.Context, id ID, approval Approval) (Record, error) {
+	for attempt := 1; ; attempt++ {
+		record, err := d.Find(ctx, id)
+		if err != nil {
+			return Record{}, err
+		}
+
+		if record.Status != BuildStatusPendingApproval {
+			return Record{}, fmt.Errorf("build %s is not awaiting approval (status %s)", id, record.Status)
+		}
+		if record.HasDecisionFrom(approval.Email) {
+			return Record{}, fmt.Errorf("%s has already made a decision on build %s", approval.Email, id)
+		}
+		if record.IsApproved() {
+			return Record{}, fmt.Errorf("build %s already has its required approvals", id)
+		}
+
+		now := time.Now().Unix()
+		approval.CreatedAt = now
+
+		item, err := attributevalue.Marshal([]Approval{approval})
+		if err != nil {
+			return Record{}, fmt.Errorf("failed to marshal approval: %w", err)
+		}
+
+		_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(d.tableName),
+			Key: map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: record.PK.String()},
+				"sk": &types.AttributeValueMemberS{Value: record.SK},
+			},
+			UpdateExpression:    aws.String("SET approvals = list_append(if_not_exists(approvals, :empty), :approval), updated_at = :now"),
+			ConditionExpression: aws.String("#status = :pending AND (attribute_not_exists(approvals) OR size(approvals) = :count)"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":empty":    &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+				":approval": item,
+				":now":      &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+				":pending":  &types.AttributeValueMemberS{Value: string(BuildStatusPendingApproval)},
+				":count":    &types.AttributeValueMemberN{Value: strconv.Itoa(len(record.Approvals))},
+			},
+		})
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) && attempt < maxApprovalAttempts {
+			// Another decision or a status change landed first; re-read and check again
+			continue
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("failed to add approval: %w", err)
+		}
+
+		record.Approvals = append(record.Approvals, approval)
+		record.UpdatedAt = now
+		return record, nil
+	}
+}
+
 // Query returns all builds for a given repo/env partition key
 func (d *DAO) Query(ctx context.Context, pk PK) ([]Record, error) {
 	var records []Record
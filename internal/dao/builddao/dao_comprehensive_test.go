@@ -429,5 +429,122 @@ func TestDAOComprehensive(t *testing.T) {
 			_, _, err = ParseID(ID("invalid"))
 			assert.Error(t, err)
 		})
+
+		// Test 13: SetChangeSet
+		t.Run("SetChangeSet", func(t *testing.T) {
+			sk := ksuid.New().String()
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:        "changeset-repo",
+				Env:         "dev",
+				SK:          sk,
+				BuildNumber: "600",
+				Branch:      "main",
+				Version:     "600.abc",
+				CommitHash:  "abc",
+				StackName:   "dev-changeset-repo",
+			})
+			assert.NoError(t, err)
+
+			changeSet := ChangeSet{
+				Name: "aws-deployer-" + sk,
+				Type: "UPDATE",
+				Changes: []ResourceChange{
+					{Action: ChangeActionAdd, LogicalResourceID: "Queue", ResourceType: "AWS::SQS::Queue"},
+					{Action: ChangeActionModify, LogicalResourceID: "Table", ResourceType: "AWS::DynamoDB::Table", Replacement: "True"},
+				},
+			}
+			err = dao.SetChangeSet(ctx, created.PK, sk, changeSet)
+			assert.NoError(t, err)
+
+			found, err := dao.Find(ctx, created.GetID())
+			assert.NoError(t, err)
+			assert.NotNil(t, found.ChangeSet)
+			assert.Equal(t, changeSet, *found.ChangeSet)
+			assert.Equal(t, 1, found.ChangeSet.Count(ChangeActionAdd))
+			assert.True(t, found.ChangeSet.Changes[1].RequiresReplacement())
+		})
+
+		// Test 14: SetLockHolder
+		t.Run("SetLockHolder", func(t *testing.T) {
+			sk := ksuid.New().String()
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:        "lock-repo",
+				Env:         "dev",
+				SK:          sk,
+				BuildNumber: "700",
+				Branch:      "main",
+				Version:     "700.abc",
+				CommitHash:  "abc",
+				StackName:   "dev-lock-repo",
+			})
+			assert.NoError(t, err)
+
+			holder := NewID(created.PK, ksuid.New().String())
+			err = dao.SetLockHolder(ctx, created.PK, sk, holder)
+			assert.NoError(t, err)
+
+			found, err := dao.Find(ctx, created.GetID())
+			assert.NoError(t, err)
+			assert.Equal(t, holder, found.LockHolder)
+
+			err = dao.SetLockHolder(ctx, created.PK, sk, "")
+			assert.NoError(t, err)
+
+			found, err = dao.Find(ctx, created.GetID())
+			assert.NoError(t, err)
+			assert.Empty(t, found.LockHolder)
+		})
+
+		// Test 15: SetGitHubDeployment
+		t.Run("SetGitHubDeployment", func(t *testing.T) {
+			sk := ksuid.New().String()
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:        "github-repo",
+				Env:         "dev",
+				SK:          sk,
+				BuildNumber: "800",
+				Branch:      "main",
+				Version:     "800.abc",
+				CommitHash:  "abc",
+				StackName:   "dev-github-repo",
+			})
+			assert.NoError(t, err)
+
+			err = dao.SetGitHubDeployment(ctx, created.PK, sk, "acme/github-repo", 12345)
+			assert.NoError(t, err)
+
+			found, err := dao.Find(ctx, created.GetID())
+			assert.NoError(t, err)
+			assert.Equal(t, "acme/github-repo", found.GitHubRepo)
+			assert.Equal(t, int64(12345), found.GitHubDeployment)
+			assert.Equal(t, created.Status, found.Status)
+		})
+
+		// Test 16: SetOutputs
+		t.Run("SetOutputs", func(t *testing.T) {
+			sk := ksuid.New().String()
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:        "outputs-repo",
+				Env:         "dev",
+				SK:          sk,
+				BuildNumber: "900",
+				Branch:      "main",
+				Version:     "900.abc",
+				CommitHash:  "abc",
+				StackName:   "dev-outputs-repo",
+			})
+			assert.NoError(t, err)
+
+			outputs := map[string]string{
+				"ApiUrl":   "https://api.example.com",
+				"QueueArn": "arn:aws:sqs:us-east-1:111111111111:jobs",
+			}
+			err = dao.SetOutputs(ctx, created.PK, sk, outputs)
+			assert.NoError(t, err)
+
+			found, err := dao.Find(ctx, created.GetID())
+			assert.NoError(t, err)
+			assert.Equal(t, outputs, found.Outputs)
+		})
 	})
 }
@@ -2,8 +2,10 @@ package builddao
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -875,3 +877,470 @@ func TestDAO(t *testing.T) {
 
 	})
 }
+
+func TestDAO_AddApproval(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+	sk := ksuid.New().String()
+
+	created, err := setup.dao.Create(ctx, CreateInput{
+		Repo:              "test-repo",
+		Env:               "prd",
+		SK:                sk,
+		BuildNumber:       "123",
+		Branch:            "main",
+		Version:           "123.abc123",
+		CommitHash:        "abc123",
+		StackName:         "prd-test-repo",
+		PromotedBy:        "alice@example.com",
+		RequiredApprovals: 2,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Status != BuildStatusPendingApproval {
+		t.Fatalf("created.Status = %v, want %v", created.Status, BuildStatusPendingApproval)
+	}
+
+	id := created.GetID()
+
+	record, err := setup.dao.AddApproval(ctx, id, Approval{Email: "bob@example.com", Decision: ApprovalDecisionApproved})
+	if err != nil {
+		t.Fatalf("AddApproval failed: %v", err)
+	}
+	if record.IsApproved() {
+		t.Error("build should not be approved after 1 of 2 approvals")
+	}
+
+	// The same person cannot decide twice
+	if _, err := setup.dao.AddApproval(ctx, id, Approval{Email: "BOB@example.com", Decision: ApprovalDecisionApproved}); err == nil {
+		t.Error("AddApproval should reject a second decision from the same approver")
+	}
+
+	record, err = setup.dao.AddApproval(ctx, id, Approval{Email: "carol@example.com", Decision: ApprovalDecisionApproved})
+	if err != nil {
+		t.Fatalf("AddApproval failed: %v", err)
+	}
+	if !record.IsApproved() {
+		t.Error("build should be approved after 2 of 2 approvals")
+	}
+
+	found, err := setup.dao.Find(ctx, id)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got := found.ApprovalCount(); got != 2 {
+		t.Errorf("found.ApprovalCount() = %d, want 2", got)
+	}
+	if found.PromotedBy != "alice@example.com" {
+		t.Errorf("found.PromotedBy = %v, want alice@example.com", found.PromotedBy)
+	}
+
+	// Builds that are not awaiting approval cannot be approved
+	status := BuildStatusInProgress
+	if err := setup.dao.UpdateStatus(ctx, UpdateInput{PK: found.PK, SK: found.SK, Status: &status}); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	if _, err := setup.dao.AddApproval(ctx, id, Approval{Email: "dave@example.com", Decision: ApprovalDecisionApproved}); err == nil {
+		t.Error("AddApproval should fail once the build has left PENDING_APPROVAL")
+	}
+}
+
+func TestDAO_AddApproval_Concurrent(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+
+	created, err := setup.dao.Create(ctx, CreateInput{
+		Repo:              "test-repo",
+		Env:               "prd",
+		SK:                ksuid.New().String(),
+		BuildNumber:       "123",
+		Branch:            "main",
+		Version:           "123.abc123",
+		CommitHash:        "abc123",
+		StackName:         "prd-test-repo",
+		RequiredApprovals: 2,
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Approvers racing each other must neither lose decisions nor overshoot the required approvals
+	const approvers = 5
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < approvers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			email := fmt.Sprintf("approver-%d@example.com", i)
+			if _, err := setup.dao.AddApproval(ctx, created.GetID(), Approval{Email: email, Decision: ApprovalDecisionApproved}); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded != 2 {
+		t.Errorf("succeeded = %d, want 2", succeeded)
+	}
+
+	found, err := setup.dao.Find(ctx, created.GetID())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got := len(found.Approvals); got != 2 {
+		t.Errorf("len(found.Approvals) = %d, want 2", got)
+	}
+}
+
+func TestDAO_OverrideFreeze(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+	sk := ksuid.New().String()
+
+	created, err := setup.dao.Create(ctx, CreateInput{
+		Repo:        "test-repo",
+		Env:         "prd",
+		SK:          sk,
+		BuildNumber: "123",
+		Branch:      "main",
+		Version:     "123.abc123",
+		CommitHash:  "abc123",
+		StackName:   "prd-test-repo",
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	id := created.GetID()
+
+	// Builds that are not blocked cannot be overridden
+	if _, err := setup.dao.OverrideFreeze(ctx, id, FreezeOverride{Email: "alice@example.com", Reason: "hotfix"}); err == nil {
+		t.Error("OverrideFreeze should fail for a build that is not BLOCKED")
+	}
+
+	status := BuildStatusBlocked
+	reason := "Deploy freeze: holiday"
+	if err := setup.dao.UpdateStatus(ctx, UpdateInput{PK: created.PK, SK: created.SK, Status: &status, BlockedReason: &reason}); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+
+	record, err := setup.dao.OverrideFreeze(ctx, id, FreezeOverride{Email: "alice@example.com", Reason: "hotfix"})
+	if err != nil {
+		t.Fatalf("OverrideFreeze failed: %v", err)
+	}
+	if record.FreezeOverride == nil || record.FreezeOverride.CreatedAt == 0 {
+		t.Fatalf("record.FreezeOverride = %v, want override with timestamp", record.FreezeOverride)
+	}
+
+	found, err := setup.dao.Find(ctx, id)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if found.BlockedReason != reason {
+		t.Errorf("found.BlockedReason = %v, want %v", found.BlockedReason, reason)
+	}
+	if found.FreezeOverride == nil || found.FreezeOverride.Email != "alice@example.com" || found.FreezeOverride.Reason != "hotfix" {
+		t.Errorf("found.FreezeOverride = %+v, want alice@example.com/hotfix", found.FreezeOverride)
+	}
+}
+
+func TestDAO_Release(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+
+	created, err := setup.dao.Create(ctx, CreateInput{
+		Repo:        "test-repo",
+		Env:         "prd",
+		SK:          ksuid.New().String(),
+		BuildNumber: "123",
+		Branch:      "main",
+		Version:     "123.abc123",
+		CommitHash:  "abc123",
+		StackName:   "prd-test-repo",
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	id := created.GetID()
+
+	// Builds that are not blocked cannot be released
+	if _, err := setup.dao.Release(ctx, id); !errors.Is(err, ErrNotBlocked) {
+		t.Errorf("Release of a PENDING build = %v, want ErrNotBlocked", err)
+	}
+
+	status := BuildStatusBlocked
+	reason := "Deploy freeze: holiday"
+	if err := setup.dao.UpdateStatus(ctx, UpdateInput{PK: created.PK, SK: created.SK, Status: &status, BlockedReason: &reason}); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+
+	record, err := setup.dao.Release(ctx, id)
+	if err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if record.Status != BuildStatusPending {
+		t.Errorf("record.Status = %v, want %v", record.Status, BuildStatusPending)
+	}
+
+	found, err := setup.dao.Find(ctx, id)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if found.Status != BuildStatusPending {
+		t.Errorf("found.Status = %v, want %v", found.Status, BuildStatusPending)
+	}
+
+	// A build is released only once
+	if _, err := setup.dao.Release(ctx, id); !errors.Is(err, ErrNotBlocked) {
+		t.Errorf("second Release = %v, want ErrNotBlocked", err)
+	}
+}
+
+func TestDAO_CreateWithReplacementOverride(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+
+	created, err := setup.dao.Create(ctx, CreateInput{
+		Repo:                "test-repo",
+		Env:                 "prd",
+		SK:                  ksuid.New().String(),
+		BuildNumber:         "123",
+		Branch:              "main",
+		Version:             "123.abc123",
+		CommitHash:          "abc123",
+		StackName:           "prd-test-repo",
+		ReplacementOverride: &ReplacementOverride{Email: "alice@example.com", Reason: "rename table"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.ReplacementOverride == nil || created.ReplacementOverride.CreatedAt == 0 {
+		t.Fatalf("created.ReplacementOverride = %v, want override with timestamp", created.ReplacementOverride)
+	}
+
+	found, err := setup.dao.Find(ctx, created.GetID())
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if found.ReplacementOverride == nil || found.ReplacementOverride.Email != "alice@example.com" || found.ReplacementOverride.Reason != "rename table" {
+		t.Errorf("found.ReplacementOverride = %+v, want alice@example.com/rename table", found.ReplacementOverride)
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	now := time.Now()
+	record := Record{
+		Status:     BuildStatusFailed,
+		Branch:     "main",
+		Version:    "123.abc123",
+		CommitHash: "ABC123def",
+		CreatedAt:  now.Unix(),
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "zero filter", filter: Filter{}, want: true},
+		{name: "status", filter: Filter{Statuses: []BuildStatus{BuildStatusSuccess, BuildStatusFailed}}, want: true},
+		{name: "other status", filter: Filter{Statuses: []BuildStatus{BuildStatusSuccess}}, want: false},
+		{name: "branch", filter: Filter{Branch: "main"}, want: true},
+		{name: "other branch", filter: Filter{Branch: "feature"}, want: false},
+		{name: "commit prefix ignores case", filter: Filter{CommitPrefix: "abc1"}, want: true},
+		{name: "other commit", filter: Filter{CommitPrefix: "def"}, want: false},
+		{name: "version", filter: Filter{Version: "123.abc123"}, want: true},
+		{name: "since", filter: Filter{Since: now.Add(-time.Hour)}, want: true},
+		{name: "created before since", filter: Filter{Since: now.Add(time.Hour)}, want: false},
+		{name: "created at until", filter: Filter{Until: time.Unix(now.Unix(), 0)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(record); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	sk := ksuid.New().String()
+	got, err := decodeCursor(encodeCursor(sk))
+	assert.NoError(t, err)
+	assert.Equal(t, sk, got)
+
+	_, err = decodeCursor("not a cursor!")
+	assert.Error(t, err)
+
+	// Sort keys of a time are ordered between the smallest and largest KSUID of that second
+	at := time.Now()
+	id, err := ksuid.NewRandomWithTime(at)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, sortKeyAt(at, 0x00), id.String())
+	assert.GreaterOrEqual(t, sortKeyAt(at, 0xff), id.String())
+	assert.Equal(t, ksuid.Nil.String(), sortKeyAt(time.Unix(0, 0), 0x00))
+}
+
+func TestDAO_QueryPage(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+	pk := NewPK("test-repo", "dev")
+
+	// Five builds, an hour apart; the odd ones are on a feature branch
+	start := time.Now().Add(-5 * time.Hour)
+	var sks []string
+	for i := 0; i < 5; i++ {
+		id, err := ksuid.NewRandomWithTime(start.Add(time.Duration(i) * time.Hour))
+		if err != nil {
+			t.Fatalf("NewRandomWithTime failed: %v", err)
+		}
+		branch := "main"
+		if i%2 == 1 {
+			branch = "feature"
+		}
+		_, err = setup.dao.Create(ctx, CreateInput{
+			Repo:        "test-repo",
+			Env:         "dev",
+			SK:          id.String(),
+			BuildNumber: fmt.Sprintf("%d", i),
+			Branch:      branch,
+			Version:     fmt.Sprintf("%d.abc123", i),
+			CommitHash:  "abc123",
+			StackName:   "dev-test-repo",
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		sks = append(sks, id.String())
+	}
+
+	// Pages are newest first and resume after the cursor
+	page, err := setup.dao.QueryPage(ctx, pk, PageInput{Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+	assert.Equal(t, []string{sks[4], sks[3]}, []string{page.Records[0].SK, page.Records[1].SK})
+	assert.True(t, page.HasMore)
+
+	page, err = setup.dao.QueryPage(ctx, pk, PageInput{Limit: 2, After: page.EndCursor()})
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+	assert.Equal(t, []string{sks[2], sks[1]}, []string{page.Records[0].SK, page.Records[1].SK})
+	assert.True(t, page.HasMore)
+
+	page, err = setup.dao.QueryPage(ctx, pk, PageInput{Limit: 2, After: page.EndCursor()})
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+	assert.Len(t, page.Records, 1)
+	assert.False(t, page.HasMore)
+
+	// Filters fill the page from later items
+	page, err = setup.dao.QueryPage(ctx, pk, PageInput{Limit: 2, Filter: Filter{Branch: "main"}})
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+	assert.Equal(t, []string{sks[4], sks[2]}, []string{page.Records[0].SK, page.Records[1].SK})
+	assert.True(t, page.HasMore)
+
+	page, err = setup.dao.QueryPage(ctx, pk, PageInput{Filter: Filter{Version: "0.abc123"}})
+	if err != nil {
+		t.Fatalf("QueryPage failed: %v", err)
+	}
+	assert.Len(t, page.Records, 1)
+	assert.False(t, page.HasMore)
+
+	_, err = setup.dao.QueryPage(ctx, pk, PageInput{After: "not a cursor!"})
+	assert.Error(t, err)
+}
+
+func TestDAO_QueryLatestPage(t *testing.T) {
+	setup := setupLocalDynamoDB(t)
+	t.Cleanup(func() {
+		cleanupTable(t, setup)
+	})
+
+	ctx := context.Background()
+
+	for _, repo := range []string{"repo-a", "repo-b", "repo-c"} {
+		sk := ksuid.New().String()
+		_, err := setup.dao.Create(ctx, CreateInput{
+			Repo:        repo,
+			Env:         "dev",
+			SK:          sk,
+			BuildNumber: "123",
+			Branch:      "main",
+			Version:     "123.abc123",
+			CommitHash:  "abc123",
+			StackName:   "dev-" + repo,
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		status := BuildStatusSuccess
+		if repo == "repo-b" {
+			status = BuildStatusFailed
+		}
+		if err := setup.dao.UpdateStatus(ctx, UpdateInput{PK: NewPK(repo, "dev"), SK: sk, Status: &status}); err != nil {
+			t.Fatalf("UpdateStatus failed: %v", err)
+		}
+	}
+
+	// Latest builds are ordered by repo
+	page, err := setup.dao.QueryLatestPage(ctx, "dev", PageInput{Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryLatestPage failed: %v", err)
+	}
+	assert.Equal(t, []string{"repo-a", "repo-b"}, []string{page.Records[0].Repo, page.Records[1].Repo})
+	assert.True(t, page.HasMore)
+
+	page, err = setup.dao.QueryLatestPage(ctx, "dev", PageInput{Limit: 2, After: page.EndCursor()})
+	if err != nil {
+		t.Fatalf("QueryLatestPage failed: %v", err)
+	}
+	assert.Len(t, page.Records, 1)
+	assert.Equal(t, "repo-c", page.Records[0].Repo)
+	assert.False(t, page.HasMore)
+
+	page, err = setup.dao.QueryLatestPage(ctx, "dev", PageInput{Filter: Filter{Statuses: []BuildStatus{BuildStatusFailed}}})
+	if err != nil {
+		t.Fatalf("QueryLatestPage failed: %v", err)
+	}
+	assert.Len(t, page.Records, 1)
+	assert.Equal(t, "repo-b", page.Records[0].Repo)
+}
@@ -0,0 +1,244 @@
+package builddao
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/segmentio/ksuid"
+)
+
+// DefaultPageSize is the number of builds in a page when no limit is given
+const DefaultPageSize = 50
+
+// filteredBatchSize is the number of items read per request when a filter may discard some
+const filteredBatchSize = 100
+
+// sortKeySlack widens the sort key range of a time-filtered query. Sort keys are KSUIDs
+// generated shortly before a build is created, so the key condition only narrows the read
+// and Filter.Matches decides on the build's CreatedAt.
+const sortKeySlack = time.Hour
+
+// Filter selects builds; zero values match everything
+type Filter struct {
+	Statuses     []BuildStatus // any of these statuses
+	Branch       string        // exact branch name
+	CommitPrefix string        // prefix of the commit hash, case-insensitive
+	Version      string        // exact version
+	Since        time.Time     // created at or after
+	Until        time.Time     // created before
+}
+
+// IsZero returns true if the filter matches every build
+func (f Filter) IsZero() bool {
+	return len(f.Statuses) == 0 && f.Branch == "" && f.CommitPrefix == "" && f.Version == "" &&
+		f.Since.IsZero() && f.Until.IsZero()
+}
+
+// Matches returns true if the build satisfies every condition of the filter
+func (f Filter) Matches(r Record) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, r.Status) {
+		return false
+	}
+	if f.Branch != "" && r.Branch != f.Branch {
+		return false
+	}
+	if f.CommitPrefix != "" && !strings.HasPrefix(strings.ToLower(r.CommitHash), strings.ToLower(f.CommitPrefix)) {
+		return false
+	}
+	if f.Version != "" && r.Version != f.Version {
+		return false
+	}
+	createdAt := time.Unix(r.CreatedAt, 0)
+	if !f.Since.IsZero() && createdAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !createdAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// PageInput contains the parameters of a page of builds
+type PageInput struct {
+	Limit  int    // maximum builds in the page (DefaultPageSize if zero)
+	After  string // cursor of the last build of the previous page (optional)
+	Filter Filter // builds to include (optional)
+}
+
+// Page is one page of builds
+type Page struct {
+	Records []Record
+	Cursors []string // Cursors[i] resumes the query after Records[i]
+	HasMore bool     // true if builds matching the filter follow this page
+}
+
+// EndCursor returns the cursor of the last build of the page, or an empty string if the page is empty
+func (p Page) EndCursor() string {
+	if len(p.Cursors) == 0 {
+		return ""
+	}
+	return p.Cursors[len(p.Cursors)-1]
+}
+
+// QueryPage returns a page of the builds of a repo/env partition key, newest first
+func (d *DAO) QueryPage(ctx context.Context, pk PK, input PageInput) (Page, error) {
+	keyCondition := "pk = :pk"
+	values := map[string]types.AttributeValue{
+		":pk": &types.AttributeValueMemberS{Value: pk.String()},
+	}
+
+	// Sort keys are KSUIDs, so a time range narrows the read to the sort keys of that range
+	if f := input.Filter; !f.Since.IsZero() || !f.Until.IsZero() {
+		from, to := ksuid.Nil.String(), ksuid.Max.String()
+		if !f.Since.IsZero() {
+			from = sortKeyAt(f.Since.Add(-sortKeySlack), 0x00)
+		}
+		if !f.Until.IsZero() {
+			to = sortKeyAt(f.Until.Add(sortKeySlack), 0xff)
+		}
+		keyCondition += " AND sk BETWEEN :from AND :to"
+		values[":from"] = &types.AttributeValueMemberS{Value: from}
+		values[":to"] = &types.AttributeValueMemberS{Value: to}
+	}
+
+	return d.queryPage(ctx, pk, keyCondition, values, false, input, func(item map[string]types.AttributeValue) (Record, bool, error) {
+		var record Record
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return Record{}, false, fmt.Errorf("failed to unmarshal build: %w", err)
+		}
+		return record, input.Filter.Matches(record), nil
+	})
+}
+
+// QueryLatestPage returns a page of the latest build of each repo in the given environment,
+// ordered by repo. Filters apply to the latest build, so a repo is skipped when its latest
+// build does not match.
+func (d *DAO) QueryLatestPage(ctx context.Context, env string, input PageInput) (Page, error) {
+	pk := NewPK(latest, env)
+	values := map[string]types.AttributeValue{
+		":pk": &types.AttributeValueMemberS{Value: pk.String()},
+	}
+
+	return d.queryPage(ctx, pk, "pk = :pk", values, true, input, func(item map[string]types.AttributeValue) (Record, bool, error) {
+		var latestRecord Record
+		if err := attributevalue.UnmarshalMap(item, &latestRecord); err != nil {
+			return Record{}, false, fmt.Errorf("failed to unmarshal latest build: %w", err)
+		}
+
+		record, err := d.Find(ctx, GetID(latestRecord))
+		if errors.Is(err, ErrNotFound) {
+			// Skip records that are not found (may have been deleted)
+			return Record{}, false, nil
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+		return record, input.Filter.Matches(record), nil
+	})
+}
+
+// queryPage reads the partition pk, starting after the cursor in input.After, until it has
+// collected a page of builds. load converts each item to a build and reports whether it
+// belongs on the page.
+func (d *DAO) queryPage(ctx context.Context, pk PK, keyCondition string, values map[string]types.AttributeValue, ascending bool, input PageInput, load func(item map[string]types.AttributeValue) (Record, bool, error)) (Page, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	// Read one more than the page so HasMore is known without another request
+	batch := int32(limit + 1)
+	if !input.Filter.IsZero() {
+		batch = max(batch, filteredBatchSize)
+	}
+
+	var startKey map[string]types.AttributeValue
+	if input.After != "" {
+		sk, err := decodeCursor(input.After)
+		if err != nil {
+			return Page{}, err
+		}
+		startKey = map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: pk.String()},
+			"sk": &types.AttributeValueMemberS{Value: sk},
+		}
+	}
+
+	var page Page
+	for {
+		out, err := d.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String(d.tableName),
+			KeyConditionExpression:    aws.String(keyCondition),
+			ExpressionAttributeValues: values,
+			ExclusiveStartKey:         startKey,
+			ScanIndexForward:          aws.Bool(ascending),
+			Limit:                     aws.Int32(batch),
+		})
+		if err != nil {
+			return Page{}, fmt.Errorf("failed to query builds: %w", err)
+		}
+
+		for _, item := range out.Items {
+			record, ok, err := load(item)
+			if err != nil {
+				return Page{}, err
+			}
+			if !ok {
+				continue
+			}
+			if len(page.Records) == limit {
+				page.HasMore = true
+				return page, nil
+			}
+
+			var sk string
+			if err := attributevalue.Unmarshal(item["sk"], &sk); err != nil {
+				return Page{}, fmt.Errorf("failed to unmarshal sort key: %w", err)
+			}
+			page.Records = append(page.Records, record)
+			page.Cursors = append(page.Cursors, encodeCursor(sk))
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return page, nil
+		}
+		startKey = out.LastEvaluatedKey
+	}
+}
+
+// sortKeyAt returns the KSUID at t whose payload is filled with fill; 0x00 gives the
+// smallest KSUID of that second and 0xff the largest
+func sortKeyAt(t time.Time, fill byte) string {
+	id, err := ksuid.FromParts(t, bytes.Repeat([]byte{fill}, 16))
+	if err != nil || t.Unix() < ksuid.Nil.Time().Unix() {
+		if fill == 0x00 {
+			return ksuid.Nil.String()
+		}
+		return ksuid.Max.String()
+	}
+	return id.String()
+}
+
+// encodeCursor returns the opaque cursor of a sort key
+func encodeCursor(sk string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sk))
+}
+
+// decodeCursor returns the sort key of a cursor
+func decodeCursor(cursor string) (string, error) {
+	sk, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(sk) == 0 {
+		return "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return string(sk), nil
+}
@@ -0,0 +1,8 @@
+package builddao
+
+// PolicyViolation is a template policy violation found while deploying a build
+type PolicyViolation struct {
+	Policy  string `json:"policy" dynamodbav:"policy"`   // Name of the policy that was violated
+	Mode    string `json:"mode" dynamodbav:"mode"`       // enforce or warn
+	Message string `json:"message" dynamodbav:"message"` // Violation message reported by the policy
+}
@@ -107,18 +107,19 @@ const (
 
 // Record represents a single account/region deployment state
 type Record struct {
-	PK           PK               `ddb:"hash" dynamodbav:"pk"`           // {Env}/{Repository}
-	SK           SK               `ddb:"range" dynamodbav:"sk"`          // {Account}/{Region}
-	BuildID      string           `dynamodbav:"build_id"`                // KSUID linking to build record
-	StackID      string           `dynamodbav:"stack_id,omitempty"`      // CloudFormation stack ID
-	Status       DeploymentStatus `dynamodbav:"status"`                  // PENDING|IN_PROGRESS|SUCCESS|FAILED
-	OperationID  string           `dynamodbav:"operation_id,omitempty"`  // StackSet operation ID
-	StatusReason string           `dynamodbav:"status_reason,omitempty"` // CF status reason
-	ErrorMsg     string           `dynamodbav:"error_msg,omitempty"`     // Detailed failure message
-	StackEvents  []string         `dynamodbav:"stack_events,omitempty"`  // Recent failed events
-	CreatedAt    int64            `dynamodbav:"created_at"`              // Unix timestamp
-	UpdatedAt    int64            `dynamodbav:"updated_at"`              // Unix timestamp
-	FinishedAt   int64            `dynamodbav:"finished_at,omitempty"`   // Unix timestamp
+	PK           PK                `ddb:"hash" dynamodbav:"pk"`           // {Env}/{Repository}
+	SK           SK                `ddb:"range" dynamodbav:"sk"`          // {Account}/{Region}
+	BuildID      string            `dynamodbav:"build_id"`                // KSUID linking to build record
+	StackID      string            `dynamodbav:"stack_id,omitempty"`      // CloudFormation stack ID
+	Status       DeploymentStatus  `dynamodbav:"status"`                  // PENDING|IN_PROGRESS|SUCCESS|FAILED
+	OperationID  string            `dynamodbav:"operation_id,omitempty"`  // StackSet operation ID
+	StatusReason string            `dynamodbav:"status_reason,omitempty"` // CF status reason
+	ErrorMsg     string            `dynamodbav:"error_msg,omitempty"`     // Detailed failure message
+	StackEvents  []string          `dynamodbav:"stack_events,omitempty"`  // Recent failed events
+	Outputs      map[string]string `dynamodbav:"outputs,omitempty"`       // Stack outputs of the instance once it deployed
+	CreatedAt    int64             `dynamodbav:"created_at"`              // Unix timestamp
+	UpdatedAt    int64             `dynamodbav:"updated_at"`              // Unix timestamp
+	FinishedAt   int64             `dynamodbav:"finished_at,omitempty"`   // Unix timestamp
 }
 
 // GetID returns the ID for this record
@@ -223,6 +224,7 @@ type UpdateInput struct {
 	StatusReason string
 	ErrorMsg     string
 	StackEvents  []string
+	Outputs      map[string]string
 }
 
 // UpdateStatus updates a deployment record with new status and failure information
@@ -256,6 +258,10 @@ func (d *DAO) UpdateStatus(ctx context.Context, input UpdateInput) error {
 		update = update.Set("#StackEvents = ?", input.StackEvents)
 	}
 
+	if len(input.Outputs) > 0 {
+		update = update.Set("#Outputs = ?", input.Outputs)
+	}
+
 	// Set finished_at for terminal states
 	if input.Status == StatusSuccess || input.Status == StatusFailed {
 		update = update.Set("#FinishedAt = ?", now)
@@ -151,6 +151,7 @@ func TestDAO(t *testing.T) {
 				Region:  region,
 				Status:  StatusSuccess,
 				StackID: "arn:aws:cloudformation:eu-west-1:333333333333:stack/test/id",
+				Outputs: map[string]string{"ApiUrl": "https://api.example.com"},
 			})
 			assert.NoError(t, err)
 
@@ -159,6 +160,7 @@ func TestDAO(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, StatusSuccess, record.Status)
 			assert.NotEmpty(t, record.StackID)
+			assert.Equal(t, "https://api.example.com", record.Outputs["ApiUrl"])
 			assert.NotZero(t, record.FinishedAt) // Should be set for terminal status
 		})
 
@@ -0,0 +1,5 @@
+package driftdao
+
+func TableName(env string) string {
+	return env + "-aws-deployer--drift"
+}
@@ -0,0 +1,151 @@
+package driftdao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+)
+
+// Status is the drift status of a stack or stack instance
+type Status string
+
+const (
+	StatusInSync  Status = "IN_SYNC" // every resource matches the template
+	StatusDrifted Status = "DRIFTED" // one or more resources were modified or deleted outside CloudFormation
+	StatusUnknown Status = "UNKNOWN" // drift detection failed or did not finish
+)
+
+// PK represents the partition key in format {repo}/{env}
+type PK string
+
+// NewPK creates a partition key from repo and env
+func NewPK(repo, env string) PK {
+	return PK(repo + "/" + env)
+}
+
+// String returns the string representation
+func (pk PK) String() string {
+	return string(pk)
+}
+
+// NewSK creates a sort key from the account and region of a stack
+func NewSK(account, region string) string {
+	return account + "/" + region
+}
+
+// PropertyDifference is a resource property whose actual value differs from the template
+type PropertyDifference struct {
+	PropertyPath   string `dynamodbav:"property_path"`            // JSON pointer to the property, e.g. /Properties/Tags
+	ExpectedValue  string `dynamodbav:"expected_value,omitempty"` // value in the template
+	ActualValue    string `dynamodbav:"actual_value,omitempty"`   // value of the live resource
+	DifferenceType string `dynamodbav:"difference_type"`          // ADD|REMOVE|NOT_EQUAL
+}
+
+// ResourceDrift is a resource that no longer matches the template
+type ResourceDrift struct {
+	LogicalResourceID  string               `dynamodbav:"logical_resource_id"`
+	PhysicalResourceID string               `dynamodbav:"physical_resource_id,omitempty"`
+	ResourceType       string               `dynamodbav:"resource_type"`
+	Status             string               `dynamodbav:"status"` // MODIFIED|DELETED
+	Differences        []PropertyDifference `dynamodbav:"differences,omitempty"`
+}
+
+// Record is the result of the latest drift detection of one stack, or of one StackSet
+// instance in multi-account mode
+type Record struct {
+	PK          PK              `ddb:"hash" dynamodbav:"pk"`          // {repo}/{env}
+	SK          string          `ddb:"range" dynamodbav:"sk"`         // {account}/{region}
+	Repo        string          `dynamodbav:"repo"`                   // Repository name
+	Env         string          `dynamodbav:"env"`                    // Environment name
+	StackName   string          `dynamodbav:"stack_name"`             // Stack or StackSet name
+	StackID     string          `dynamodbav:"stack_id,omitempty"`     // CloudFormation stack ID
+	AccountID   string          `dynamodbav:"account_id"`             // Account of the stack
+	Region      string          `dynamodbav:"region"`                 // Region of the stack
+	Status      Status          `dynamodbav:"status"`                 // IN_SYNC|DRIFTED|UNKNOWN
+	DetectionID string          `dynamodbav:"detection_id,omitempty"` // Drift detection ID or StackSet operation ID
+	Resources   []ResourceDrift `dynamodbav:"resources,omitempty"`    // Drifted resources
+	ErrorMsg    string          `dynamodbav:"error_msg,omitempty"`    // Why the status is UNKNOWN
+	CheckedAt   int64           `dynamodbav:"checked_at"`             // Unix timestamp of the detection
+}
+
+// Drifted returns true if any of the records has drifted
+func Drifted(records []Record) bool {
+	for _, record := range records {
+		if record.Status == StatusDrifted {
+			return true
+		}
+	}
+	return false
+}
+
+// DAO provides data access operations for drift detection results
+type DAO struct {
+	db    *ddb.DDB
+	table *ddb.Table
+}
+
+// New creates a new DAO instance
+func New(client *dynamodb.Client, tableName string) *DAO {
+	db := ddb.New(client)
+	table := db.MustTable(tableName, &Record{})
+	return &DAO{
+		db:    db,
+		table: table,
+	}
+}
+
+// Replace stores the latest drift results of repo/env and removes results of stacks that
+// were not checked, e.g. StackSet instances that no longer exist
+func (d *DAO) Replace(ctx context.Context, repo, env string, records []Record) error {
+	existing, err := d.Query(ctx, repo, env)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	keep := map[string]bool{}
+	for _, record := range records {
+		record.PK = NewPK(repo, env)
+		record.SK = NewSK(record.AccountID, record.Region)
+		record.Repo = repo
+		record.Env = env
+		if record.CheckedAt == 0 {
+			record.CheckedAt = now
+		}
+		keep[record.SK] = true
+
+		if err := d.table.Put(record).RunWithContext(ctx); err != nil {
+			return fmt.Errorf("failed to put drift result: %w", err)
+		}
+	}
+
+	for _, record := range existing {
+		if keep[record.SK] {
+			continue
+		}
+		err := d.table.Delete(record.PK.String()).
+			Range(record.SK).
+			RunWithContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete drift result: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Query returns the latest drift results of repo/env
+func (d *DAO) Query(ctx context.Context, repo, env string) ([]Record, error) {
+	var records []Record
+
+	err := d.table.Query("#PK = ?", NewPK(repo, env).String()).
+		FindAllWithContext(ctx, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query drift results: %w", err)
+	}
+
+	return records, nil
+}
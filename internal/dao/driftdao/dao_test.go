@@ -0,0 +1,109 @@
+package driftdao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	DAO *DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client    = dynamodb.NewFromConfig(cfg)
+		db        = ddb.New(client)
+		tableName = fmt.Sprintf("drift-test-%v", ksuid.New().String())
+		table     = db.MustTable(tableName, Record{})
+		dao       = New(client, tableName)
+	)
+
+	err = table.CreateTableIfNotExists(ctx)
+	assert.NoError(t, err)
+
+	return ctx, Data{DAO: dao}, func() {
+		_ = table.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestDAO(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		dao := data.DAO
+
+		t.Run("Replace_Query", func(t *testing.T) {
+			err := dao.Replace(ctx, "my-app", "prd", []Record{
+				{
+					StackName: "prd-my-app",
+					AccountID: "111111111111",
+					Region:    "us-east-1",
+					Status:    StatusDrifted,
+					Resources: []ResourceDrift{
+						{
+							LogicalResourceID: "Bucket",
+							ResourceType:      "AWS::S3::Bucket",
+							Status:            "MODIFIED",
+							Differences: []PropertyDifference{
+								{PropertyPath: "/VersioningConfiguration/Status", ExpectedValue: "Enabled", ActualValue: "Suspended", DifferenceType: "NOT_EQUAL"},
+							},
+						},
+					},
+				},
+				{
+					StackName: "prd-my-app",
+					AccountID: "111111111111",
+					Region:    "us-west-2",
+					Status:    StatusInSync,
+				},
+			})
+			assert.NoError(t, err)
+
+			records, err := dao.Query(ctx, "my-app", "prd")
+			assert.NoError(t, err)
+			assert.Len(t, records, 2)
+			assert.True(t, Drifted(records))
+			assert.Equal(t, "111111111111/us-east-1", records[0].SK)
+			assert.Equal(t, "my-app", records[0].Repo)
+			assert.NotZero(t, records[0].CheckedAt)
+			assert.Equal(t, "Suspended", records[0].Resources[0].Differences[0].ActualValue)
+		})
+
+		t.Run("Replace_RemovesUnchecked", func(t *testing.T) {
+			err := dao.Replace(ctx, "my-app", "prd", []Record{
+				{StackName: "prd-my-app", AccountID: "111111111111", Region: "us-east-1", Status: StatusInSync},
+			})
+			assert.NoError(t, err)
+
+			records, err := dao.Query(ctx, "my-app", "prd")
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+			assert.False(t, Drifted(records))
+		})
+
+		t.Run("Query_Empty", func(t *testing.T) {
+			records, err := dao.Query(ctx, "unknown", "dev")
+			assert.NoError(t, err)
+			assert.Empty(t, records)
+		})
+	})
+}
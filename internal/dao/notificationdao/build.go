@@ -0,0 +1,5 @@
+package notificationdao
+
+func TableName(env string) string {
+	return env + "-aws-deployer--notifications"
+}
@@ -0,0 +1,240 @@
+package notificationdao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/segmentio/ksuid"
+)
+
+// Wildcard subscribes to every repo or every env
+const Wildcard = "$"
+
+// Channel is the delivery mechanism of a subscription
+type Channel string
+
+const (
+	ChannelSlack   Channel = "slack"   // Slack incoming webhook
+	ChannelWebhook Channel = "webhook" // HTTP webhook signed with HMAC-SHA256
+	ChannelSNS     Channel = "sns"     // SNS topic
+)
+
+// Validate returns an error if the channel is not supported
+func (c Channel) Validate() error {
+	switch c {
+	case ChannelSlack, ChannelWebhook, ChannelSNS:
+		return nil
+	default:
+		return fmt.Errorf("invalid channel %q: expected slack, webhook or sns", c)
+	}
+}
+
+// PK represents the partition key in format {repo}/{env}, either of which may be Wildcard
+type PK string
+
+// NewPK creates a partition key from repo and env
+func NewPK(repo, env string) PK {
+	return PK(repo + "/" + env)
+}
+
+// String returns the string representation
+func (pk PK) String() string {
+	return string(pk)
+}
+
+// ID represents a subscription ID in format {repo}/{env}:{ksuid}
+type ID string
+
+// NewID creates an ID from a partition key and sort key
+func NewID(pk PK, sk string) ID {
+	return ID(pk.String() + ":" + sk)
+}
+
+// ParseID parses an ID into its partition and sort keys
+func ParseID(id ID) (pk PK, sk string, err error) {
+	before, after, ok := strings.Cut(string(id), ":")
+	if !ok || before == "" || after == "" {
+		return "", "", fmt.Errorf("invalid ID format: %s, expected {repo}/{env}:{ksuid}", id)
+	}
+	return PK(before), after, nil
+}
+
+// String returns the string representation
+func (id ID) String() string {
+	return string(id)
+}
+
+// Record is a notification subscription for a repo/env
+type Record struct {
+	PK         PK       `ddb:"hash" dynamodbav:"pk"`         // {repo}/{env}
+	SK         string   `ddb:"range" dynamodbav:"sk"`        // KSUID
+	Repo       string   `dynamodbav:"repo"`                  // Repository name or Wildcard
+	Env        string   `dynamodbav:"env"`                   // Environment name or Wildcard
+	Channel    Channel  `dynamodbav:"channel"`               // slack|webhook|sns
+	Target     string   `dynamodbav:"target,omitempty"`      // Webhook URL or SNS topic ARN
+	SecretName string   `dynamodbav:"secret_name,omitempty"` // Secrets Manager secret with the Slack webhook URL or webhook signing key
+	Events     []string `dynamodbav:"events,omitempty"`      // Events to notify (empty for all)
+	Template   string   `dynamodbav:"template,omitempty"`    // text/template overriding the default message
+	CreatedBy  string   `dynamodbav:"created_by,omitempty"`  // Who created the subscription
+	CreatedAt  int64    `dynamodbav:"created_at,omitempty"`  // Unix epoch timestamp of creation
+}
+
+// GetID returns the subscription ID
+func (r *Record) GetID() ID {
+	return NewID(r.PK, r.SK)
+}
+
+// Wants returns true if the subscription notifies the given event
+func (r *Record) Wants(event string) bool {
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, e := range r.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateInput contains fields for creating a subscription
+type CreateInput struct {
+	Repo       string   // Repository name or Wildcard
+	Env        string   // Environment name or Wildcard
+	Channel    Channel  // Delivery mechanism
+	Target     string   // Webhook URL or SNS topic ARN
+	SecretName string   // Secrets Manager secret with the Slack webhook URL or webhook signing key
+	Events     []string // Events to notify (empty for all)
+	Template   string   // Message template (optional)
+	CreatedBy  string   // Who created the subscription (optional)
+}
+
+// DAO provides data access operations for notification subscriptions
+type DAO struct {
+	db    *ddb.DDB
+	table *ddb.Table
+}
+
+// New creates a new DAO instance
+func New(client *dynamodb.Client, tableName string) *DAO {
+	db := ddb.New(client)
+	table := db.MustTable(tableName, &Record{})
+	return &DAO{
+		db:    db,
+		table: table,
+	}
+}
+
+// Create creates a new subscription
+func (d *DAO) Create(ctx context.Context, input CreateInput) (Record, error) {
+	if input.Repo == "" || input.Env == "" {
+		return Record{}, fmt.Errorf("repo and env are required (use %s for all)", Wildcard)
+	}
+	if err := input.Channel.Validate(); err != nil {
+		return Record{}, err
+	}
+	switch input.Channel {
+	case ChannelSlack:
+		if input.Target == "" && input.SecretName == "" {
+			return Record{}, fmt.Errorf("slack subscriptions require a webhook URL or secret name")
+		}
+	case ChannelWebhook, ChannelSNS:
+		if input.Target == "" {
+			return Record{}, fmt.Errorf("%s subscriptions require a target", input.Channel)
+		}
+	}
+
+	record := Record{
+		PK:         NewPK(input.Repo, input.Env),
+		SK:         ksuid.New().String(),
+		Repo:       input.Repo,
+		Env:        input.Env,
+		Channel:    input.Channel,
+		Target:     input.Target,
+		SecretName: input.SecretName,
+		Events:     input.Events,
+		Template:   input.Template,
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	if err := d.table.Put(record).RunWithContext(ctx); err != nil {
+		return Record{}, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return record, nil
+}
+
+// Delete removes a subscription
+func (d *DAO) Delete(ctx context.Context, id ID) error {
+	pk, sk, err := ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = d.table.Delete(pk.String()).
+		Range(sk).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the subscriptions stored for exactly the given repo/env
+func (d *DAO) Query(ctx context.Context, repo, env string) ([]Record, error) {
+	var records []Record
+
+	err := d.table.Query("#PK = ?", NewPK(repo, env).String()).
+		FindAllWithContext(ctx, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+
+	return records, nil
+}
+
+// QueryForBuild returns every subscription matching a build's repo and env, including
+// subscriptions for all repos and/or all envs
+func (d *DAO) QueryForBuild(ctx context.Context, repo, env string) ([]Record, error) {
+	var records []Record
+	seen := map[PK]bool{}
+	for _, r := range []string{repo, Wildcard} {
+		for _, e := range []string{env, Wildcard} {
+			pk := NewPK(r, e)
+			if seen[pk] {
+				continue
+			}
+			seen[pk] = true
+
+			found, err := d.Query(ctx, r, e)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, found...)
+		}
+	}
+	return records, nil
+}
+
+// FindAll scans all subscriptions
+func (d *DAO) FindAll(ctx context.Context) ([]Record, error) {
+	var records []Record
+	err := d.table.Scan().ConsistentRead(false).EachWithContext(ctx, func(item ddb.Item) (bool, error) {
+		var record Record
+		if err := item.Unmarshal(&record); err != nil {
+			return false, err
+		}
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan subscriptions: %w", err)
+	}
+	return records, nil
+}
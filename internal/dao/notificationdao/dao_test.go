@@ -0,0 +1,122 @@
+package notificationdao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	DAO *DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client    = dynamodb.NewFromConfig(cfg)
+		db        = ddb.New(client)
+		tableName = fmt.Sprintf("notifications-test-%v", ksuid.New().String())
+		table     = db.MustTable(tableName, Record{})
+		dao       = New(client, tableName)
+	)
+
+	err = table.CreateTableIfNotExists(ctx)
+	assert.NoError(t, err)
+
+	return ctx, Data{DAO: dao}, func() {
+		_ = table.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestDAO(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		dao := data.DAO
+
+		t.Run("Create_Query", func(t *testing.T) {
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:      "my-app",
+				Env:       "prd",
+				Channel:   ChannelSNS,
+				Target:    "arn:aws:sns:us-west-2:123456789012:deploys",
+				Events:    []string{"failed"},
+				CreatedBy: "alice@example.com",
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, PK("my-app/prd"), created.PK)
+
+			records, err := dao.Query(ctx, "my-app", "prd")
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+			assert.Equal(t, created.GetID(), records[0].GetID())
+			assert.True(t, records[0].Wants("failed"))
+			assert.False(t, records[0].Wants("succeeded"))
+		})
+
+		t.Run("QueryForBuild_Wildcards", func(t *testing.T) {
+			for _, input := range []CreateInput{
+				{Repo: "svc", Env: "stg", Channel: ChannelWebhook, Target: "https://example.com/hook"},
+				{Repo: "svc", Env: Wildcard, Channel: ChannelSlack, SecretName: "aws-deployer/dev/notifications/slack"},
+				{Repo: Wildcard, Env: "stg", Channel: ChannelSNS, Target: "arn:aws:sns:us-west-2:123456789012:stg"},
+				{Repo: Wildcard, Env: Wildcard, Channel: ChannelSNS, Target: "arn:aws:sns:us-west-2:123456789012:all"},
+				{Repo: "other", Env: "stg", Channel: ChannelSNS, Target: "arn:aws:sns:us-west-2:123456789012:other"},
+			} {
+				_, err := dao.Create(ctx, input)
+				assert.NoError(t, err)
+			}
+
+			records, err := dao.QueryForBuild(ctx, "svc", "stg")
+			assert.NoError(t, err)
+			assert.Len(t, records, 4)
+			for _, record := range records {
+				assert.NotEqual(t, "other", record.Repo)
+			}
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			created, err := dao.Create(ctx, CreateInput{
+				Repo:    "deleted",
+				Env:     "dev",
+				Channel: ChannelWebhook,
+				Target:  "https://example.com/hook",
+			})
+			assert.NoError(t, err)
+
+			err = dao.Delete(ctx, created.GetID())
+			assert.NoError(t, err)
+
+			records, err := dao.Query(ctx, "deleted", "dev")
+			assert.NoError(t, err)
+			assert.Empty(t, records)
+		})
+
+		t.Run("Create_Invalid", func(t *testing.T) {
+			_, err := dao.Create(ctx, CreateInput{Repo: "my-app", Env: "dev", Channel: "email", Target: "a@example.com"})
+			assert.Error(t, err)
+
+			_, err = dao.Create(ctx, CreateInput{Repo: "my-app", Env: "dev", Channel: ChannelWebhook})
+			assert.Error(t, err)
+
+			_, err = dao.Create(ctx, CreateInput{Repo: "my-app", Env: "dev", Channel: ChannelSlack})
+			assert.Error(t, err)
+		})
+	})
+}
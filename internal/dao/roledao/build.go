@@ -0,0 +1,5 @@
+package roledao
+
+func TableName(env string) string {
+	return env + "-aws-deployer--roles"
+}
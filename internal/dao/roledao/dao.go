@@ -0,0 +1,187 @@
+package roledao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/ddb/v2"
+	"github.com/segmentio/ksuid"
+)
+
+// ID represents a role binding ID in format {subject}/{ksuid}
+type ID string
+
+// NewID creates an ID from a subject and sort key
+func NewID(subject, sk string) ID {
+	return ID(subject + "/" + sk)
+}
+
+// ParseID parses an ID into its subject and sort key
+func ParseID(id ID) (subject, sk string, err error) {
+	i := strings.LastIndex(string(id), "/")
+	if i <= 0 || i == len(id)-1 {
+		return "", "", fmt.Errorf("invalid ID format: %s, expected {subject}/{ksuid}", id)
+	}
+	return string(id[:i]), string(id[i+1:]), nil
+}
+
+// String returns the string representation
+func (id ID) String() string {
+	return string(id)
+}
+
+// Record assigns a role to a user or group for the repos and environments matching its globs
+type Record struct {
+	PK        string     `ddb:"hash" dynamodbav:"pk"`        // user:{email}, group:{name} or claim:{name}={value}
+	SK        string     `ddb:"range" dynamodbav:"sk"`       // KSUID
+	Role      authz.Role `dynamodbav:"role"`                 // viewer|deployer|approver|admin
+	Repo      string     `dynamodbav:"repo"`                 // Repository glob
+	Env       string     `dynamodbav:"env"`                  // Environment glob
+	CreatedBy string     `dynamodbav:"created_by,omitempty"` // Who granted the role
+	CreatedAt int64      `dynamodbav:"created_at,omitempty"` // Unix epoch timestamp of the grant
+}
+
+// GetID returns the role binding ID
+func (r *Record) GetID() ID {
+	return NewID(r.PK, r.SK)
+}
+
+// Binding returns the record as an authorization role binding
+func (r *Record) Binding() authz.Binding {
+	return authz.Binding{
+		Subject: r.PK,
+		Role:    r.Role,
+		Repo:    r.Repo,
+		Env:     r.Env,
+	}
+}
+
+// CreateInput contains fields for granting a role
+type CreateInput struct {
+	Subject   string     // user:{email}, group:{name} or claim:{name}={value}
+	Role      authz.Role // Role to grant
+	Repo      string     // Repository glob (default *)
+	Env       string     // Environment glob (default *)
+	CreatedBy string     // Who granted the role (optional)
+}
+
+// DAO provides data access operations for role bindings
+type DAO struct {
+	db    *ddb.DDB
+	table *ddb.Table
+}
+
+// New creates a new DAO instance
+func New(client *dynamodb.Client, tableName string) *DAO {
+	db := ddb.New(client)
+	table := db.MustTable(tableName, &Record{})
+	return &DAO{
+		db:    db,
+		table: table,
+	}
+}
+
+// Create grants a role
+func (d *DAO) Create(ctx context.Context, input CreateInput) (Record, error) {
+	if err := authz.ValidateSubject(input.Subject); err != nil {
+		return Record{}, err
+	}
+	role, err := authz.ParseRole(string(input.Role))
+	if err != nil {
+		return Record{}, err
+	}
+
+	repo, env := input.Repo, input.Env
+	if repo == "" {
+		repo = "*"
+	}
+	if env == "" {
+		env = "*"
+	}
+	for _, glob := range []string{repo, env} {
+		if err := authz.ValidateGlob(glob); err != nil {
+			return Record{}, err
+		}
+	}
+
+	record := Record{
+		PK:        input.Subject,
+		SK:        ksuid.New().String(),
+		Role:      role,
+		Repo:      repo,
+		Env:       env,
+		CreatedBy: input.CreatedBy,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	if err := d.table.Put(record).RunWithContext(ctx); err != nil {
+		return Record{}, fmt.Errorf("failed to create role binding: %w", err)
+	}
+
+	return record, nil
+}
+
+// Delete revokes a role
+func (d *DAO) Delete(ctx context.Context, id ID) error {
+	subject, sk, err := ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = d.table.Delete(subject).
+		Range(sk).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete role binding: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the roles granted to a subject
+func (d *DAO) Query(ctx context.Context, subject string) ([]Record, error) {
+	var records []Record
+
+	err := d.table.Query("#PK = ?", subject).
+		FindAllWithContext(ctx, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role bindings: %w", err)
+	}
+
+	return records, nil
+}
+
+// FindAll scans all role bindings
+func (d *DAO) FindAll(ctx context.Context) ([]Record, error) {
+	var records []Record
+	err := d.table.Scan().ConsistentRead(false).EachWithContext(ctx, func(item ddb.Item) (bool, error) {
+		var record Record
+		if err := item.Unmarshal(&record); err != nil {
+			return false, err
+		}
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan role bindings: %w", err)
+	}
+	return records, nil
+}
+
+// Bindings returns every role binding; it implements authz.RoleStore
+func (d *DAO) Bindings(ctx context.Context) ([]authz.Binding, error) {
+	records, err := d.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := make([]authz.Binding, 0, len(records))
+	for i := range records {
+		bindings = append(bindings, records[i].Binding())
+	}
+	return bindings, nil
+}
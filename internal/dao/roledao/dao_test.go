@@ -0,0 +1,108 @@
+package roledao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	DAO *DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client    = dynamodb.NewFromConfig(cfg)
+		db        = ddb.New(client)
+		tableName = fmt.Sprintf("roles-test-%v", ksuid.New().String())
+		table     = db.MustTable(tableName, Record{})
+		dao       = New(client, tableName)
+	)
+
+	err = table.CreateTableIfNotExists(ctx)
+	assert.NoError(t, err)
+
+	return ctx, Data{DAO: dao}, func() {
+		_ = table.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	subject, sk, err := ParseID("group:/engineering/sre/2bQ5Yk8Y1cPl0Qf1rD1aJq2Z0hX")
+	assert.NoError(t, err)
+	assert.Equal(t, "group:/engineering/sre", subject)
+	assert.Equal(t, "2bQ5Yk8Y1cPl0Qf1rD1aJq2Z0hX", sk)
+
+	_, _, err = ParseID("user:alice@example.com")
+	assert.Error(t, err)
+}
+
+func TestDAO(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		dao := data.DAO
+
+		t.Run("Create_Query", func(t *testing.T) {
+			created, err := dao.Create(ctx, CreateInput{
+				Subject:   "user:alice@example.com",
+				Role:      authz.RoleApprover,
+				Env:       "prd",
+				CreatedBy: "admin",
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "*", created.Repo)
+
+			records, err := dao.Query(ctx, "user:alice@example.com")
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+			assert.Equal(t, created.GetID(), records[0].GetID())
+			assert.Equal(t, authz.RoleApprover, records[0].Role)
+		})
+
+		t.Run("Create_Invalid", func(t *testing.T) {
+			_, err := dao.Create(ctx, CreateInput{Subject: "alice@example.com", Role: authz.RoleViewer})
+			assert.Error(t, err, "subject without prefix")
+
+			_, err = dao.Create(ctx, CreateInput{Subject: "group:sre", Role: "owner"})
+			assert.Error(t, err, "unknown role")
+
+			_, err = dao.Create(ctx, CreateInput{Subject: "group:sre", Role: authz.RoleAdmin, Repo: "["})
+			assert.Error(t, err, "malformed glob")
+		})
+
+		t.Run("Bindings_Delete", func(t *testing.T) {
+			created, err := dao.Create(ctx, CreateInput{Subject: "group:sre", Role: authz.RoleAdmin, Repo: "payments-*"})
+			assert.NoError(t, err)
+
+			bindings, err := dao.Bindings(ctx)
+			assert.NoError(t, err)
+			assert.Contains(t, bindings, authz.Binding{Subject: "group:sre", Role: authz.RoleAdmin, Repo: "payments-*", Env: "*"})
+
+			assert.NoError(t, dao.Delete(ctx, created.GetID()))
+
+			records, err := dao.Query(ctx, "group:sre")
+			assert.NoError(t, err)
+			assert.Empty(t, records)
+		})
+	})
+}
@@ -0,0 +1,148 @@
+package targetdao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/savaki/aws-deployer/internal/cron"
+)
+
+// MaxWindowDuration is the longest a freeze or allowed window may last
+const MaxWindowDuration = 31 * 24 * time.Hour
+
+// Window is a recurring period that opens whenever Cron matches and stays open for Duration
+type Window struct {
+	Cron            string `json:"cron" dynamodbav:"cron"`                         // 5-field cron expression of the window start, e.g. "0 18 * * 5"
+	DurationSeconds int    `json:"duration_seconds" dynamodbav:"duration_seconds"` // how long the window stays open
+	Reason          string `json:"reason,omitempty" dynamodbav:"reason,omitempty"` // shown on builds held by a freeze window
+}
+
+// Duration returns how long the window stays open
+func (w Window) Duration() time.Duration {
+	return time.Duration(w.DurationSeconds) * time.Second
+}
+
+// Open returns true if a window started within Duration before t. t must be in the
+// location the cron expression is written for.
+func (w Window) Open(t time.Time) (bool, error) {
+	schedule, err := cron.Parse(w.Cron)
+	if err != nil {
+		return false, err
+	}
+
+	start := t.Truncate(time.Minute)
+	for m := start; t.Sub(m) < w.Duration(); m = m.Add(-time.Minute) {
+		if schedule.Matches(m) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validate returns an error if the window cannot be evaluated
+func (w Window) validate() error {
+	if _, err := cron.Parse(w.Cron); err != nil {
+		return err
+	}
+	if w.DurationSeconds < 60 || w.Duration() > MaxWindowDuration {
+		return fmt.Errorf("window %q: duration must be between 1m and %s", w.Cron, MaxWindowDuration)
+	}
+	return nil
+}
+
+// ChangeCalendar restricts when builds may deploy into an environment
+type ChangeCalendar struct {
+	Timezone string   `json:"timezone,omitempty" dynamodbav:"timezone,omitempty"` // IANA timezone of the cron expressions (default UTC)
+	Freezes  []Window `json:"freezes,omitempty" dynamodbav:"freezes,omitempty"`   // deploys are blocked while any freeze window is open
+	Allowed  []Window `json:"allowed,omitempty" dynamodbav:"allowed,omitempty"`   // when set, deploys are only allowed while an allowed window is open
+}
+
+// IsEmpty returns true if the calendar never blocks deploys
+func (c *ChangeCalendar) IsEmpty() bool {
+	return c == nil || (len(c.Freezes) == 0 && len(c.Allowed) == 0)
+}
+
+// Validate returns an error if the calendar's timezone or windows are invalid
+func (c *ChangeCalendar) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if _, err := c.location(); err != nil {
+		return err
+	}
+	for _, w := range append(append([]Window{}, c.Freezes...), c.Allowed...) {
+		if err := w.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Blocked returns the reason deploys are blocked at t, or an empty string if they are allowed
+func (c *ChangeCalendar) Blocked(t time.Time) (string, error) {
+	if c.IsEmpty() {
+		return "", nil
+	}
+
+	loc, err := c.location()
+	if err != nil {
+		return "", err
+	}
+	t = t.In(loc)
+
+	for _, w := range c.Freezes {
+		open, err := w.Open(t)
+		if err != nil {
+			return "", err
+		}
+		if open {
+			reason := w.Reason
+			if reason == "" {
+				reason = fmt.Sprintf("freeze window %q (%s)", w.Cron, w.Duration())
+			}
+			return "Deploy freeze: " + reason, nil
+		}
+	}
+
+	if len(c.Allowed) == 0 {
+		return "", nil
+	}
+	var windows []string
+	for _, w := range c.Allowed {
+		open, err := w.Open(t)
+		if err != nil {
+			return "", err
+		}
+		if open {
+			return "", nil
+		}
+		windows = append(windows, fmt.Sprintf("%q for %s", w.Cron, w.Duration()))
+	}
+	return fmt.Sprintf("Outside allowed deploy windows (%s, %s)", strings.Join(windows, ", "), loc), nil
+}
+
+func (c *ChangeCalendar) location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Blocked returns the reason deploys to repo/env are blocked at t by the environment's
+// change calendar, or an empty string if they are allowed
+func (d *DAO) Blocked(ctx context.Context, repo, env string, t time.Time) (string, error) {
+	record, err := d.GetWithDefault(ctx, repo, env)
+	if err != nil {
+		return "", err
+	}
+	if record == nil {
+		return "", nil
+	}
+	return record.Calendar.Blocked(t)
+}
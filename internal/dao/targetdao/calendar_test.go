@@ -0,0 +1,82 @@
+package targetdao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeCalendar_Blocked(t *testing.T) {
+	// Monday 2026-01-05 in New York
+	at := func(hour, minute int) time.Time {
+		ny, _ := time.LoadLocation("America/New_York")
+		return time.Date(2026, time.January, 5, hour, minute, 0, 0, ny)
+	}
+
+	t.Run("nil calendar", func(t *testing.T) {
+		var calendar *ChangeCalendar
+		reason, err := calendar.Blocked(at(12, 0))
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("freeze", func(t *testing.T) {
+		calendar := &ChangeCalendar{
+			Timezone: "America/New_York",
+			Freezes:  []Window{{Cron: "0 12 * * 1", DurationSeconds: 3600, Reason: "release"}},
+		}
+
+		reason, err := calendar.Blocked(at(12, 30))
+		assert.NoError(t, err)
+		assert.Equal(t, "Deploy freeze: release", reason)
+
+		reason, err = calendar.Blocked(at(13, 0))
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+
+		reason, err = calendar.Blocked(at(11, 59))
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("business hours", func(t *testing.T) {
+		calendar := &ChangeCalendar{
+			Timezone: "America/New_York",
+			Allowed:  []Window{{Cron: "0 9 * * 1-5", DurationSeconds: 8 * 3600}},
+		}
+
+		reason, err := calendar.Blocked(at(10, 0))
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+
+		reason, err = calendar.Blocked(at(17, 0))
+		assert.NoError(t, err)
+		assert.Contains(t, reason, "Outside allowed deploy windows")
+
+		// 15:00 UTC is 10:00 in New York
+		reason, err = calendar.Blocked(time.Date(2026, time.January, 5, 15, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("freeze inside allowed window", func(t *testing.T) {
+		calendar := &ChangeCalendar{
+			Freezes: []Window{{Cron: "0 12 * * *", DurationSeconds: 3600}},
+			Allowed: []Window{{Cron: "0 9 * * *", DurationSeconds: 8 * 3600}},
+		}
+
+		reason, err := calendar.Blocked(time.Date(2026, time.January, 5, 12, 15, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Contains(t, reason, "Deploy freeze")
+	})
+}
+
+func TestChangeCalendar_Validate(t *testing.T) {
+	assert.NoError(t, (*ChangeCalendar)(nil).Validate())
+	assert.NoError(t, (&ChangeCalendar{Allowed: []Window{{Cron: "0 9 * * 1-5", DurationSeconds: 3600}}}).Validate())
+	assert.Error(t, (&ChangeCalendar{Timezone: "Mars/Olympus"}).Validate())
+	assert.Error(t, (&ChangeCalendar{Freezes: []Window{{Cron: "0 9 * *", DurationSeconds: 3600}}}).Validate())
+	assert.Error(t, (&ChangeCalendar{Freezes: []Window{{Cron: "0 9 * * *", DurationSeconds: 0}}}).Validate())
+	assert.Error(t, (&ChangeCalendar{Freezes: []Window{{Cron: "0 9 * * *", DurationSeconds: 40 * 24 * 3600}}}).Validate())
+}
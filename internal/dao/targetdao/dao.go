@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/savaki/ddb/v2"
@@ -59,13 +60,55 @@ type Target struct {
 	Regions    []string `json:"regions" dynamodbav:"regions"`
 }
 
+// ApprovalPolicy requires manual sign-off before a build is deployed into an environment
+type ApprovalPolicy struct {
+	RequiredApprovals int      `json:"required_approvals" dynamodbav:"required_approvals"`
+	Approvers         []string `json:"approvers,omitempty" dynamodbav:"approvers,omitempty"` // allowed approver emails (empty allows any authenticated user)
+}
+
+// Required returns true if builds entering the environment must be approved
+func (p *ApprovalPolicy) Required() bool {
+	return p != nil && p.RequiredApprovals > 0
+}
+
+// CanApprove returns true if the given email is allowed to approve builds.
+// Any authenticated user may approve when no approver list is configured.
+func (p *ApprovalPolicy) CanApprove(email string) bool {
+	if email == "" {
+		return false
+	}
+	if p == nil || len(p.Approvers) == 0 {
+		return true
+	}
+	for _, approver := range p.Approvers {
+		if strings.EqualFold(approver, email) {
+			return true
+		}
+	}
+	return false
+}
+
 // Record represents a deployment target configuration
 type Record struct {
-	PK            PK       `ddb:"hash" dynamodbav:"pk"`                  // repo name (use DefaultRepo for default)
-	SK            string   `ddb:"range" dynamodbav:"sk"`                 // environment (or ConfigEnv for config)
-	Targets       []Target `dynamodbav:"targets,omitempty"`              // list of account/region targets (when SK is env)
-	InitialEnv    string   `dynamodbav:"initial_env,omitempty"`          // initial environment (when SK is ConfigEnv)
-	DownstreamEnv []string `dynamodbav:"downstream_env,omitempty"`       // downstream environments (when SK is env)
+	PK            PK                    `ddb:"hash" dynamodbav:"pk"`                   // repo name (use DefaultRepo for default)
+	SK            string                `ddb:"range" dynamodbav:"sk"`                  // environment (or ConfigEnv for config)
+	Targets       []Target              `dynamodbav:"targets,omitempty"`               // list of account/region targets (when SK is env)
+	InitialEnv    string                `dynamodbav:"initial_env,omitempty"`           // initial environment (when SK is ConfigEnv)
+	DownstreamEnv []string              `dynamodbav:"downstream_env,omitempty"`        // downstream environments (when SK is env)
+	Approval      *ApprovalPolicy       `dynamodbav:"approval,omitempty"`              // approval required to deploy into this env (when SK is env)
+	AutoPromote   bool                  `dynamodbav:"auto_promote,omitempty"`          // promote successful builds to DownstreamEnv automatically (when SK is env)
+	SoakSeconds   int                   `dynamodbav:"soak_seconds,omitempty"`          // time a build bakes in this env before auto-promotion starts downstream deploys
+	AutoRollback  bool                  `dynamodbav:"auto_rollback,omitempty"`         // redeploy the last successful build when a deploy fails (when SK is env)
+	Calendar      *ChangeCalendar       `dynamodbav:"calendar,omitempty"`              // freeze and allowed deploy windows (when SK is env)
+	Waves         []Wave                `dynamodbav:"waves,omitempty"`                 // ordered StackSet rollout (when SK is env, multi-account only)
+	Preferences   *OperationPreferences `dynamodbav:"operation_preferences,omitempty"` // StackSet operation preferences (when SK is env, multi-account only)
+	BlockOnDrift  bool                  `dynamodbav:"block_on_drift,omitempty"`        // refuse to promote builds into this env while its stack has drifted (when SK is env)
+	Protection    *Protection           `dynamodbav:"protection,omitempty"`            // termination protection and stack policy of the env's stack (when SK is env)
+}
+
+// SoakTime returns how long a successful build bakes before auto-promoted builds start deploying
+func (r *Record) SoakTime() time.Duration {
+	return time.Duration(r.SoakSeconds) * time.Second
 }
 
 // GetID returns the ID for this record
@@ -75,19 +118,37 @@ func (r *Record) GetID() ID {
 
 // CreateInput contains fields for creating a targets configuration
 type CreateInput struct {
-	Repo          string   // Repository name (use DefaultRepo for default)
-	Env           string   // Environment (or ConfigEnv for config)
-	Targets       []Target // List of account/region targets (when Env is env)
-	InitialEnv    string   // Initial environment (when Env is ConfigEnv)
-	DownstreamEnv []string // Downstream environments (when Env is env)
+	Repo          string                // Repository name (use DefaultRepo for default)
+	Env           string                // Environment (or ConfigEnv for config)
+	Targets       []Target              // List of account/region targets (when Env is env)
+	InitialEnv    string                // Initial environment (when Env is ConfigEnv)
+	DownstreamEnv []string              // Downstream environments (when Env is env)
+	Approval      *ApprovalPolicy       // Approval policy (when Env is env)
+	AutoPromote   bool                  // Promote successful builds automatically (when Env is env)
+	SoakSeconds   int                   // Soak time before auto-promoted builds deploy (when Env is env)
+	AutoRollback  bool                  // Redeploy the last successful build on failure (when Env is env)
+	Calendar      *ChangeCalendar       // Freeze and allowed deploy windows (when Env is env)
+	Waves         []Wave                // Ordered StackSet rollout (when Env is env)
+	Preferences   *OperationPreferences // StackSet operation preferences (when Env is env)
+	BlockOnDrift  bool                  // Refuse promotions while the stack has drifted (when Env is env)
+	Protection    *Protection           // Termination protection and stack policy (when Env is env)
 }
 
 // UpdateInput contains fields for updating a targets configuration
 type UpdateInput struct {
-	ID            ID       // Target configuration ID
-	Targets       []Target // New list of account/region targets
-	InitialEnv    string   // Initial environment (when updating config)
-	DownstreamEnv []string // Downstream environments (when updating env targets)
+	ID            ID                    // Target configuration ID
+	Targets       []Target              // New list of account/region targets
+	InitialEnv    string                // Initial environment (when updating config)
+	DownstreamEnv []string              // Downstream environments (when updating env targets)
+	Approval      *ApprovalPolicy       // Approval policy (when updating env targets)
+	AutoPromote   bool                  // Promote successful builds automatically (when updating env targets)
+	SoakSeconds   int                   // Soak time before auto-promoted builds deploy (when updating env targets)
+	AutoRollback  bool                  // Redeploy the last successful build on failure (when updating env targets)
+	Calendar      *ChangeCalendar       // Freeze and allowed deploy windows (when updating env targets)
+	Waves         []Wave                // Ordered StackSet rollout (when updating env targets)
+	Preferences   *OperationPreferences // StackSet operation preferences (when updating env targets)
+	BlockOnDrift  bool                  // Refuse promotions while the stack has drifted (when updating env targets)
+	Protection    *Protection           // Termination protection and stack policy (when updating env targets)
 }
 
 // DAO provides data access operations for deployment targets
@@ -173,6 +234,15 @@ func (d *DAO) Create(ctx context.Context, input CreateInput) (*Record, error) {
 		Targets:       input.Targets,
 		InitialEnv:    input.InitialEnv,
 		DownstreamEnv: input.DownstreamEnv,
+		Approval:      input.Approval,
+		AutoPromote:   input.AutoPromote,
+		SoakSeconds:   input.SoakSeconds,
+		AutoRollback:  input.AutoRollback,
+		Calendar:      input.Calendar,
+		Waves:         input.Waves,
+		Preferences:   input.Preferences,
+		BlockOnDrift:  input.BlockOnDrift,
+		Protection:    input.Protection,
 	}
 
 	err := d.table.Put(record).RunWithContext(ctx)
@@ -196,6 +266,15 @@ func (d *DAO) Update(ctx context.Context, input UpdateInput) (*Record, error) {
 		Targets:       input.Targets,
 		InitialEnv:    input.InitialEnv,
 		DownstreamEnv: input.DownstreamEnv,
+		Approval:      input.Approval,
+		AutoPromote:   input.AutoPromote,
+		SoakSeconds:   input.SoakSeconds,
+		AutoRollback:  input.AutoRollback,
+		Calendar:      input.Calendar,
+		Waves:         input.Waves,
+		Preferences:   input.Preferences,
+		BlockOnDrift:  input.BlockOnDrift,
+		Protection:    input.Protection,
 	}
 
 	err = d.table.Put(record).RunWithContext(ctx)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -423,5 +424,126 @@ func TestDAO(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, []string{"stg", "prd"}, updated.DownstreamEnv)
 		})
+
+		// Test 17: Calendar
+		t.Run("Calendar", func(t *testing.T) {
+			calendar := &ChangeCalendar{
+				Timezone: "America/New_York",
+				Freezes: []Window{
+					{Cron: "0 0 20 12 *", DurationSeconds: 14 * 24 * 60 * 60, Reason: "holiday freeze"},
+				},
+			}
+
+			_, err := dao.Create(ctx, CreateInput{
+				Repo:     "calendar-repo",
+				Env:      "prd",
+				Targets:  []Target{{AccountIDs: []string{"123456789012"}, Regions: []string{"us-east-1"}}},
+				Calendar: calendar,
+			})
+			assert.NoError(t, err)
+
+			record, err := dao.Find(ctx, NewID("calendar-repo", "prd"))
+			assert.NoError(t, err)
+			assert.Equal(t, calendar, record.Calendar)
+
+			reason, err := dao.Blocked(ctx, "calendar-repo", "prd", time.Date(2025, time.December, 24, 12, 0, 0, 0, time.UTC))
+			assert.NoError(t, err)
+			assert.Equal(t, "Deploy freeze: holiday freeze", reason)
+
+			reason, err = dao.Blocked(ctx, "calendar-repo", "prd", time.Date(2026, time.January, 5, 12, 0, 0, 0, time.UTC))
+			assert.NoError(t, err)
+			assert.Empty(t, reason)
+
+			reason, err = dao.Blocked(ctx, "no-calendar-repo", "qa", time.Date(2025, time.December, 24, 12, 0, 0, 0, time.UTC))
+			assert.NoError(t, err)
+			assert.Empty(t, reason)
+		})
+
+		// Test 18: Waves
+		t.Run("Waves", func(t *testing.T) {
+			waves := []Wave{
+				{Name: "canary", Count: 1, MaxConcurrentCount: 1, BakeSeconds: 900},
+				{Name: "rest", FailureToleranceCount: 2},
+			}
+
+			_, err := dao.Create(ctx, CreateInput{
+				Repo:    "waves-repo",
+				Env:     "prd",
+				Targets: []Target{{AccountIDs: []string{"123456789012", "210987654321"}, Regions: []string{"us-east-1"}}},
+				Waves:   waves,
+			})
+			assert.NoError(t, err)
+
+			record, err := dao.Find(ctx, NewID("waves-repo", "prd"))
+			assert.NoError(t, err)
+			assert.Equal(t, waves, record.Waves)
+		})
+
+		// Test 19: Operation preferences
+		t.Run("OperationPreferences", func(t *testing.T) {
+			preferences := &OperationPreferences{
+				RegionOrder:                []string{"us-west-2", "us-east-1"},
+				RegionConcurrencyType:      RegionConcurrencySequential,
+				MaxConcurrentPercentage:    25,
+				FailureTolerancePercentage: 10,
+			}
+
+			_, err := dao.Create(ctx, CreateInput{
+				Repo:        "preferences-repo",
+				Env:         "prd",
+				Targets:     []Target{{AccountIDs: []string{"123456789012"}, Regions: []string{"us-east-1", "us-west-2"}}},
+				Preferences: preferences,
+			})
+			assert.NoError(t, err)
+
+			record, err := dao.Find(ctx, NewID("preferences-repo", "prd"))
+			assert.NoError(t, err)
+			assert.Equal(t, preferences, record.Preferences)
+		})
+
+		// Test 20: Stack protection
+		t.Run("Protection", func(t *testing.T) {
+			protection := &Protection{
+				TerminationProtection: true,
+				ProtectResources:      true,
+				ResourceTypes:         []string{"AWS::DynamoDB::Table"},
+			}
+
+			_, err := dao.Create(ctx, CreateInput{
+				Repo:       "protection-repo",
+				Env:        "prd",
+				Targets:    []Target{{AccountIDs: []string{"123456789012"}, Regions: []string{"us-east-1"}}},
+				Protection: protection,
+			})
+			assert.NoError(t, err)
+
+			record, err := dao.Find(ctx, NewID("protection-repo", "prd"))
+			assert.NoError(t, err)
+			assert.Equal(t, protection, record.Protection)
+		})
+	})
+}
+
+func TestApprovalPolicy(t *testing.T) {
+	t.Run("nil policy", func(t *testing.T) {
+		var policy *ApprovalPolicy
+		assert.False(t, policy.Required())
+		assert.True(t, policy.CanApprove("alice@example.com"))
+		assert.False(t, policy.CanApprove(""))
+	})
+
+	t.Run("any approver", func(t *testing.T) {
+		policy := &ApprovalPolicy{RequiredApprovals: 1}
+		assert.True(t, policy.Required())
+		assert.True(t, policy.CanApprove("alice@example.com"))
+	})
+
+	t.Run("listed approvers", func(t *testing.T) {
+		policy := &ApprovalPolicy{
+			RequiredApprovals: 1,
+			Approvers:         []string{"alice@example.com"},
+		}
+		assert.True(t, policy.CanApprove("Alice@Example.com"))
+		assert.False(t, policy.CanApprove("bob@example.com"))
 	})
 }
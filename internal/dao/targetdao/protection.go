@@ -0,0 +1,109 @@
+package targetdao
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultProtectedTypes are the stateful resource types protected when a Protection lists none
+var DefaultProtectedTypes = []string{
+	"AWS::DynamoDB::GlobalTable",
+	"AWS::DynamoDB::Table",
+	"AWS::EFS::FileSystem",
+	"AWS::RDS::DBCluster",
+	"AWS::RDS::DBInstance",
+	"AWS::S3::Bucket",
+}
+
+// Protection guards an environment's stack against being deleted and against updates that
+// replace or delete stateful resources
+type Protection struct {
+	TerminationProtection bool     `json:"termination_protection,omitempty" dynamodbav:"termination_protection,omitempty"` // enable stack termination protection
+	ProtectResources      bool     `json:"protect_resources,omitempty" dynamodbav:"protect_resources,omitempty"`           // deny Update:Replace and Update:Delete on ResourceTypes
+	ResourceTypes         []string `json:"resource_types,omitempty" dynamodbav:"resource_types,omitempty"`                 // protected resource types (default DefaultProtectedTypes)
+}
+
+// IsEmpty returns true if the protection guards nothing
+func (p *Protection) IsEmpty() bool {
+	return p == nil || (!p.TerminationProtection && !p.ProtectResources)
+}
+
+// Types returns the resource types that may not be replaced or deleted
+func (p *Protection) Types() []string {
+	if p == nil || !p.ProtectResources {
+		return nil
+	}
+	if len(p.ResourceTypes) == 0 {
+		return DefaultProtectedTypes
+	}
+	return p.ResourceTypes
+}
+
+// Protects returns true if resources of the given type may not be replaced or deleted
+func (p *Protection) Protects(resourceType string) bool {
+	for _, t := range p.Types() {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the protected resource types are malformed
+func (p *Protection) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if len(p.ResourceTypes) > 0 && !p.ProtectResources {
+		return fmt.Errorf("resource types require resource protection to be enabled")
+	}
+	for _, t := range p.ResourceTypes {
+		if parts := strings.Split(t, "::"); len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return fmt.Errorf("invalid resource type %q, expected e.g. AWS::S3::Bucket", t)
+		}
+	}
+	return nil
+}
+
+// stackPolicy is a CloudFormation stack policy document
+type stackPolicy struct {
+	Statement []stackPolicyStatement `json:"Statement"`
+}
+
+type stackPolicyStatement struct {
+	Effect    string      `json:"Effect"`
+	Action    interface{} `json:"Action"`
+	Principal string      `json:"Principal"`
+	Resource  string      `json:"Resource"`
+	Condition interface{} `json:"Condition,omitempty"`
+}
+
+// StackPolicy returns the stack policy enforcing the protection: all updates are allowed except
+// replacing or deleting resources of the protected types. A build with an override deploys
+// with allowReplacement, which drops the deny; the protective policy is restored once the
+// stack finishes updating.
+func (p *Protection) StackPolicy(allowReplacement bool) (string, error) {
+	policy := stackPolicy{
+		Statement: []stackPolicyStatement{
+			{Effect: "Allow", Action: "Update:*", Principal: "*", Resource: "*"},
+		},
+	}
+	if types := p.Types(); len(types) > 0 && !allowReplacement {
+		policy.Statement = append(policy.Statement, stackPolicyStatement{
+			Effect:    "Deny",
+			Action:    []string{"Update:Replace", "Update:Delete"},
+			Principal: "*",
+			Resource:  "*",
+			Condition: map[string]interface{}{
+				"StringEquals": map[string]interface{}{"ResourceType": types},
+			},
+		})
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode stack policy: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,65 @@
+package targetdao
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtection_Protects(t *testing.T) {
+	var nilProtection *Protection
+	assert.False(t, nilProtection.Protects("AWS::S3::Bucket"))
+	assert.False(t, (&Protection{TerminationProtection: true}).Protects("AWS::S3::Bucket"))
+
+	defaults := &Protection{ProtectResources: true}
+	assert.True(t, defaults.Protects("AWS::S3::Bucket"))
+	assert.True(t, defaults.Protects("AWS::DynamoDB::Table"))
+	assert.False(t, defaults.Protects("AWS::Lambda::Function"))
+
+	custom := &Protection{ProtectResources: true, ResourceTypes: []string{"AWS::SQS::Queue"}}
+	assert.True(t, custom.Protects("AWS::SQS::Queue"))
+	assert.False(t, custom.Protects("AWS::S3::Bucket"))
+}
+
+func TestProtection_Validate(t *testing.T) {
+	var nilProtection *Protection
+	assert.NoError(t, nilProtection.Validate())
+	assert.NoError(t, (&Protection{ProtectResources: true, ResourceTypes: []string{"AWS::S3::Bucket", "Custom::Database"}}).Validate())
+
+	assert.Error(t, (&Protection{ResourceTypes: []string{"AWS::S3::Bucket"}}).Validate(), "types without protection")
+	assert.Error(t, (&Protection{ProtectResources: true, ResourceTypes: []string{"S3::Bucket"}}).Validate(), "malformed type")
+}
+
+func TestProtection_StackPolicy(t *testing.T) {
+	type statement struct {
+		Effect    string
+		Action    interface{}
+		Condition map[string]map[string][]string
+	}
+	decode := func(t *testing.T, policy string) []statement {
+		var doc struct{ Statement []statement }
+		assert.NoError(t, json.Unmarshal([]byte(policy), &doc))
+		return doc.Statement
+	}
+
+	protection := &Protection{ProtectResources: true, ResourceTypes: []string{"AWS::DynamoDB::Table", "AWS::S3::Bucket"}}
+
+	policy, err := protection.StackPolicy(false)
+	assert.NoError(t, err)
+	statements := decode(t, policy)
+	assert.Len(t, statements, 2)
+	assert.Equal(t, "Allow", statements[0].Effect)
+	assert.Equal(t, "Deny", statements[1].Effect)
+	assert.Equal(t, []interface{}{"Update:Replace", "Update:Delete"}, statements[1].Action)
+	assert.Equal(t, []string{"AWS::DynamoDB::Table", "AWS::S3::Bucket"}, statements[1].Condition["StringEquals"]["ResourceType"])
+
+	// Overrides and unprotected environments deploy with a policy that allows every update
+	policy, err = protection.StackPolicy(true)
+	assert.NoError(t, err)
+	assert.Len(t, decode(t, policy), 1)
+
+	policy, err = (&Protection{TerminationProtection: true}).StackPolicy(false)
+	assert.NoError(t, err)
+	assert.Len(t, decode(t, policy), 1)
+}
@@ -0,0 +1,175 @@
+package targetdao
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultMaxConcurrentCount is the number of accounts a StackSet operation deploys to at once
+	// when neither the wave nor the environment's operation preferences set one
+	DefaultMaxConcurrentCount = 10
+)
+
+// Region concurrency types of StackSet operations
+const (
+	RegionConcurrencySequential = "SEQUENTIAL"
+	RegionConcurrencyParallel   = "PARALLEL"
+)
+
+// OperationPreferences control how StackSet operations deploy to an environment's targets. Each
+// of concurrency and failure tolerance is set either as a count or as a percentage of accounts.
+type OperationPreferences struct {
+	RegionOrder                []string `json:"region_order,omitempty" dynamodbav:"region_order,omitempty"`                                 // regions deploy in this order (SEQUENTIAL only)
+	RegionConcurrencyType      string   `json:"region_concurrency_type,omitempty" dynamodbav:"region_concurrency_type,omitempty"`           // SEQUENTIAL (default) or PARALLEL
+	MaxConcurrentCount         int      `json:"max_concurrent_count,omitempty" dynamodbav:"max_concurrent_count,omitempty"`                 // accounts deployed at once per region
+	MaxConcurrentPercentage    int      `json:"max_concurrent_percentage,omitempty" dynamodbav:"max_concurrent_percentage,omitempty"`       // percentage of accounts deployed at once per region
+	FailureToleranceCount      int      `json:"failure_tolerance_count,omitempty" dynamodbav:"failure_tolerance_count,omitempty"`           // failed accounts tolerated per region
+	FailureTolerancePercentage int      `json:"failure_tolerance_percentage,omitempty" dynamodbav:"failure_tolerance_percentage,omitempty"` // percentage of failed accounts tolerated per region
+}
+
+// IsEmpty returns true if the preferences leave every setting at its default
+func (p *OperationPreferences) IsEmpty() bool {
+	return p == nil || (len(p.RegionOrder) == 0 && p.RegionConcurrencyType == "" &&
+		p.MaxConcurrentCount == 0 && p.MaxConcurrentPercentage == 0 &&
+		p.FailureToleranceCount == 0 && p.FailureTolerancePercentage == 0)
+}
+
+// Validate returns an error if CloudFormation would reject the preferences
+func (p *OperationPreferences) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	switch p.RegionConcurrencyType {
+	case "", RegionConcurrencySequential, RegionConcurrencyParallel:
+	default:
+		return fmt.Errorf("region concurrency type must be %s or %s, got %q", RegionConcurrencySequential, RegionConcurrencyParallel, p.RegionConcurrencyType)
+	}
+
+	switch {
+	case p.MaxConcurrentCount < 0 || p.MaxConcurrentPercentage < 0 || p.FailureToleranceCount < 0 || p.FailureTolerancePercentage < 0:
+		return fmt.Errorf("operation preferences cannot be negative")
+	case p.MaxConcurrentCount > 0 && p.MaxConcurrentPercentage > 0:
+		return fmt.Errorf("set either max concurrent count or percentage, not both")
+	case p.FailureToleranceCount > 0 && p.FailureTolerancePercentage > 0:
+		return fmt.Errorf("set either failure tolerance count or percentage, not both")
+	case p.MaxConcurrentPercentage > 100 || p.FailureTolerancePercentage > 100:
+		return fmt.Errorf("percentages cannot exceed 100")
+	}
+	return nil
+}
+
+// Wave is one step of an ordered StackSet rollout. A wave deploys to the next Count targets, or
+// to Percentage of all targets, in the order ExpandTargets lists them. The last wave deploys to
+// every remaining target and must not set Count or Percentage.
+type Wave struct {
+	Name                  string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Count                 int    `json:"count,omitempty" dynamodbav:"count,omitempty"`                                     // number of targets in the wave
+	Percentage            int    `json:"percentage,omitempty" dynamodbav:"percentage,omitempty"`                           // share of all targets in the wave, rounded up
+	MaxConcurrentCount    int    `json:"max_concurrent_count,omitempty" dynamodbav:"max_concurrent_count,omitempty"`       // accounts deployed at once (default DefaultMaxConcurrentCount)
+	FailureToleranceCount int    `json:"failure_tolerance_count,omitempty" dynamodbav:"failure_tolerance_count,omitempty"` // failed accounts tolerated before the wave fails
+	BakeSeconds           int    `json:"bake_seconds,omitempty" dynamodbav:"bake_seconds,omitempty"`                       // time to wait after the wave succeeds before the next wave starts
+}
+
+// BakeTime returns how long the rollout waits after the wave succeeds
+func (w Wave) BakeTime() time.Duration {
+	return time.Duration(w.BakeSeconds) * time.Second
+}
+
+// ValidateWaves returns an error if the waves cannot be planned
+func ValidateWaves(waves []Wave) error {
+	for i, w := range waves {
+		name := w.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+
+		switch {
+		case w.Count < 0 || w.Percentage < 0 || w.MaxConcurrentCount < 0 || w.FailureToleranceCount < 0 || w.BakeSeconds < 0:
+			return fmt.Errorf("wave %s: values cannot be negative", name)
+		case w.Count > 0 && w.Percentage > 0:
+			return fmt.Errorf("wave %s: set either count or percentage, not both", name)
+		case w.Percentage > 100:
+			return fmt.Errorf("wave %s: percentage cannot exceed 100", name)
+		case i == len(waves)-1 && (w.Count > 0 || w.Percentage > 0):
+			return fmt.Errorf("wave %s: the last wave deploys to the remaining targets and cannot set count or percentage", name)
+		case i < len(waves)-1 && w.Count == 0 && w.Percentage == 0:
+			return fmt.Errorf("wave %s: only the last wave may omit count and percentage", name)
+		}
+	}
+	return nil
+}
+
+// PlannedWave is a wave with the targets it deploys to and the operation preferences it
+// deploys them with
+type PlannedWave struct {
+	Wave
+	Targets     []struct{ AccountID, Region string }
+	Preferences OperationPreferences
+}
+
+// preferences returns the environment's operation preferences with the wave's concurrency and
+// failure tolerance applied
+func (w Wave) preferences(base *OperationPreferences) OperationPreferences {
+	var p OperationPreferences
+	if base != nil {
+		p = *base
+	}
+
+	if w.MaxConcurrentCount > 0 {
+		p.MaxConcurrentCount, p.MaxConcurrentPercentage = w.MaxConcurrentCount, 0
+	}
+	if w.FailureToleranceCount > 0 {
+		p.FailureToleranceCount, p.FailureTolerancePercentage = w.FailureToleranceCount, 0
+	}
+	if p.MaxConcurrentCount == 0 && p.MaxConcurrentPercentage == 0 {
+		p.MaxConcurrentCount = DefaultMaxConcurrentCount
+	}
+	return p
+}
+
+// PlanWaves splits the expanded targets into waves deployed with the environment's operation
+// preferences (nil for defaults). Waves left without targets are skipped, unnamed waves are
+// named wave-N and the last planned wave never bakes. Without waves, every target deploys in a
+// single wave.
+func PlanWaves(expanded []struct{ AccountID, Region string }, waves []Wave, preferences *OperationPreferences) []PlannedWave {
+	if len(waves) == 0 {
+		waves = []Wave{{}}
+	}
+
+	var planned []PlannedWave
+	remaining := expanded
+	for i, w := range waves {
+		if len(remaining) == 0 {
+			break
+		}
+
+		n := len(remaining)
+		switch {
+		case i == len(waves)-1:
+		case w.Count > 0:
+			n = w.Count
+		case w.Percentage > 0:
+			n = (len(expanded)*w.Percentage + 99) / 100
+		}
+		if n > len(remaining) {
+			n = len(remaining)
+		}
+
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("wave-%d", len(planned)+1)
+		}
+		planned = append(planned, PlannedWave{
+			Wave:        w,
+			Targets:     remaining[:n],
+			Preferences: w.preferences(preferences),
+		})
+		remaining = remaining[n:]
+	}
+
+	if len(planned) > 0 {
+		planned[len(planned)-1].BakeSeconds = 0
+	}
+	return planned
+}
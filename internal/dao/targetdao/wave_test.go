@@ -0,0 +1,107 @@
+package targetdao
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanWaves(t *testing.T) {
+	expanded := ExpandTargets([]Target{
+		{AccountIDs: []string{"111111111111", "222222222222"}, Regions: []string{"us-east-1", "us-west-2"}},
+		{AccountIDs: []string{"333333333333", "444444444444"}, Regions: []string{"us-east-1", "us-west-2"}},
+	})
+
+	sizes := func(planned []PlannedWave) []int {
+		var n []int
+		for _, w := range planned {
+			n = append(n, len(w.Targets))
+		}
+		return n
+	}
+
+	t.Run("no waves", func(t *testing.T) {
+		planned := PlanWaves(expanded, nil, nil)
+		assert.Equal(t, []int{8}, sizes(planned))
+		assert.Equal(t, "wave-1", planned[0].Name)
+		assert.Equal(t, DefaultMaxConcurrentCount, planned[0].Preferences.MaxConcurrentCount)
+		assert.Zero(t, planned[0].Preferences.FailureToleranceCount)
+	})
+
+	t.Run("canary, percentage, rest", func(t *testing.T) {
+		planned := PlanWaves(expanded, []Wave{
+			{Name: "canary", Count: 1, MaxConcurrentCount: 1, BakeSeconds: 600},
+			{Name: "early", Percentage: 25, FailureToleranceCount: 1, BakeSeconds: 300},
+			{Name: "rest", MaxConcurrentCount: 4, BakeSeconds: 60},
+		}, nil)
+		assert.Equal(t, []int{1, 2, 5}, sizes(planned))
+		assert.Equal(t, "111111111111", planned[0].Targets[0].AccountID)
+		assert.Equal(t, "us-east-1", planned[0].Targets[0].Region)
+		assert.Equal(t, 1, planned[0].Preferences.MaxConcurrentCount)
+		assert.Equal(t, 600, planned[0].BakeSeconds)
+		assert.Equal(t, 1, planned[1].Preferences.FailureToleranceCount)
+		assert.Equal(t, 4, planned[2].Preferences.MaxConcurrentCount)
+		assert.Zero(t, planned[2].BakeSeconds, "the last wave never bakes")
+	})
+
+	t.Run("skips empty waves", func(t *testing.T) {
+		planned := PlanWaves(expanded[:2], []Wave{
+			{Name: "canary", Count: 2, BakeSeconds: 600},
+			{Name: "rest"},
+		}, nil)
+		assert.Equal(t, []int{2}, sizes(planned))
+		assert.Zero(t, planned[0].BakeSeconds)
+	})
+
+	t.Run("operation preferences", func(t *testing.T) {
+		preferences := &OperationPreferences{
+			RegionOrder:                []string{"us-west-2", "us-east-1"},
+			RegionConcurrencyType:      RegionConcurrencySequential,
+			MaxConcurrentPercentage:    50,
+			FailureTolerancePercentage: 10,
+		}
+		planned := PlanWaves(expanded, []Wave{
+			{Name: "canary", Count: 1, MaxConcurrentCount: 1},
+			{Name: "rest"},
+		}, preferences)
+
+		// Wave settings override the environment's
+		assert.Equal(t, 1, planned[0].Preferences.MaxConcurrentCount)
+		assert.Zero(t, planned[0].Preferences.MaxConcurrentPercentage)
+		assert.Equal(t, 10, planned[0].Preferences.FailureTolerancePercentage)
+		assert.Equal(t, []string{"us-west-2", "us-east-1"}, planned[0].Preferences.RegionOrder)
+
+		assert.Equal(t, *preferences, planned[1].Preferences)
+	})
+}
+
+func TestOperationPreferences_IsEmpty(t *testing.T) {
+	var nilPreferences *OperationPreferences
+	assert.True(t, nilPreferences.IsEmpty())
+	assert.True(t, (&OperationPreferences{}).IsEmpty())
+	assert.False(t, (&OperationPreferences{RegionOrder: []string{"us-east-1"}}).IsEmpty())
+	assert.False(t, (&OperationPreferences{FailureTolerancePercentage: 10}).IsEmpty())
+}
+
+func TestOperationPreferences_Validate(t *testing.T) {
+	var nilPreferences *OperationPreferences
+	assert.NoError(t, nilPreferences.Validate())
+	assert.NoError(t, (&OperationPreferences{RegionConcurrencyType: RegionConcurrencyParallel, MaxConcurrentPercentage: 100}).Validate())
+
+	assert.Error(t, (&OperationPreferences{RegionConcurrencyType: "sideways"}).Validate())
+	assert.Error(t, (&OperationPreferences{MaxConcurrentCount: 1, MaxConcurrentPercentage: 10}).Validate())
+	assert.Error(t, (&OperationPreferences{FailureToleranceCount: 1, FailureTolerancePercentage: 10}).Validate())
+	assert.Error(t, (&OperationPreferences{FailureTolerancePercentage: 101}).Validate())
+	assert.Error(t, (&OperationPreferences{MaxConcurrentCount: -1}).Validate())
+}
+
+func TestValidateWaves(t *testing.T) {
+	assert.NoError(t, ValidateWaves(nil))
+	assert.NoError(t, ValidateWaves([]Wave{{Count: 1}, {Percentage: 25}, {}}))
+
+	assert.Error(t, ValidateWaves([]Wave{{Count: 1}}), "last wave with a size")
+	assert.Error(t, ValidateWaves([]Wave{{}, {}}), "earlier wave without a size")
+	assert.Error(t, ValidateWaves([]Wave{{Count: 1, Percentage: 10}, {}}), "count and percentage")
+	assert.Error(t, ValidateWaves([]Wave{{Percentage: 101}, {}}), "percentage over 100")
+	assert.Error(t, ValidateWaves([]Wave{{Count: 1, BakeSeconds: -1}, {}}), "negative bake")
+}
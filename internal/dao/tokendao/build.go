@@ -0,0 +1,5 @@
+package tokendao
+
+func TableName(env string) string {
+	return env + "-aws-deployer--tokens"
+}
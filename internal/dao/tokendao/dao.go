@@ -0,0 +1,216 @@
+package tokendao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+)
+
+// tokenSK is the sort key of every token; tokens are looked up by ID alone
+const tokenSK = "TOKEN"
+
+// Kind distinguishes tokens acting as a person from tokens acting as a service
+type Kind string
+
+const (
+	KindPersonal Kind = "personal" // acts as the owner's email, e.g. for a developer's scripts
+	KindService  Kind = "service"  // acts as service:{owner}, e.g. for CI or a chatops bot
+)
+
+// Validate returns an error if the kind is not supported
+func (k Kind) Validate() error {
+	switch k {
+	case KindPersonal, KindService:
+		return nil
+	default:
+		return fmt.Errorf("invalid token kind %q: expected personal or service", k)
+	}
+}
+
+// Record is an API token. Only a hash of the token's secret is stored.
+type Record struct {
+	PK         string   `ddb:"hash" dynamodbav:"pk"`          // Token ID (KSUID)
+	SK         string   `ddb:"range" dynamodbav:"sk"`         // Always TOKEN
+	Name       string   `dynamodbav:"name"`                   // Human-readable name, e.g. "github-actions"
+	Kind       Kind     `dynamodbav:"kind"`                   // personal|service
+	Owner      string   `dynamodbav:"owner"`                  // Owner email (personal) or service name (service)
+	Groups     []string `dynamodbav:"groups,omitempty"`       // Groups the token acts as a member of
+	Actions    []string `dynamodbav:"actions,omitempty"`      // Mutations the token may run (empty for read-only)
+	Repo       string   `dynamodbav:"repo,omitempty"`         // Repository glob the token may change (empty for all)
+	Env        string   `dynamodbav:"env,omitempty"`          // Environment glob the token may change (empty for all)
+	Hash       string   `json:"-" dynamodbav:"hash"`          // SHA-256 of the token secret, hex encoded; never exported
+	CreatedBy  string   `dynamodbav:"created_by,omitempty"`   // Who created the token
+	CreatedAt  int64    `dynamodbav:"created_at"`             // Unix epoch timestamp of creation
+	ExpiresAt  int64    `dynamodbav:"expires_at"`             // Unix epoch timestamp after which the token is rejected
+	RevokedAt  int64    `dynamodbav:"revoked_at,omitempty"`   // Unix epoch timestamp of revocation
+	RevokedBy  string   `dynamodbav:"revoked_by,omitempty"`   // Who revoked the token
+	LastUsedAt int64    `dynamodbav:"last_used_at,omitempty"` // Unix epoch timestamp of the last authenticated request
+}
+
+// GetID returns the token ID
+func (r *Record) GetID() string {
+	return r.PK
+}
+
+// IsRevoked returns true if the token was revoked
+func (r *Record) IsRevoked() bool {
+	return r.RevokedAt != 0
+}
+
+// IsExpired returns true if the token expired at or before now
+func (r *Record) IsExpired(now time.Time) bool {
+	return now.Unix() >= r.ExpiresAt
+}
+
+// CreateInput contains fields for creating a token
+type CreateInput struct {
+	ID        string        // Token ID (KSUID), embedded in the token
+	Name      string        // Human-readable name
+	Kind      Kind          // personal|service
+	Owner     string        // Owner email (personal) or service name (service)
+	Groups    []string      // Groups the token acts as a member of (optional)
+	Actions   []string      // Mutations the token may run (empty for read-only)
+	Repo      string        // Repository glob (optional)
+	Env       string        // Environment glob (optional)
+	Hash      string        // SHA-256 of the token secret, hex encoded
+	TTL       time.Duration // Lifetime of the token
+	CreatedBy string        // Who created the token (optional)
+}
+
+// DAO provides data access operations for API tokens
+type DAO struct {
+	db    *ddb.DDB
+	table *ddb.Table
+}
+
+// New creates a new DAO instance
+func New(client *dynamodb.Client, tableName string) *DAO {
+	db := ddb.New(client)
+	table := db.MustTable(tableName, &Record{})
+	return &DAO{
+		db:    db,
+		table: table,
+	}
+}
+
+// Create stores a new token
+func (d *DAO) Create(ctx context.Context, input CreateInput) (Record, error) {
+	if input.ID == "" || input.Hash == "" {
+		return Record{}, fmt.Errorf("token ID and hash are required")
+	}
+	if input.Name == "" || input.Owner == "" {
+		return Record{}, fmt.Errorf("token name and owner are required")
+	}
+	if err := input.Kind.Validate(); err != nil {
+		return Record{}, err
+	}
+	if input.Kind == KindPersonal && !strings.Contains(input.Owner, "@") {
+		return Record{}, fmt.Errorf("personal tokens must be owned by an email address, got %q", input.Owner)
+	}
+	if input.TTL <= 0 {
+		return Record{}, fmt.Errorf("tokens must expire")
+	}
+
+	now := time.Now()
+	record := Record{
+		PK:        input.ID,
+		SK:        tokenSK,
+		Name:      input.Name,
+		Kind:      input.Kind,
+		Owner:     input.Owner,
+		Groups:    input.Groups,
+		Actions:   input.Actions,
+		Repo:      input.Repo,
+		Env:       input.Env,
+		Hash:      input.Hash,
+		CreatedBy: input.CreatedBy,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(input.TTL).Unix(),
+	}
+
+	if err := d.table.Put(record).RunWithContext(ctx); err != nil {
+		return Record{}, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return record, nil
+}
+
+// Find retrieves a token by ID
+// Returns nil if not found
+func (d *DAO) Find(ctx context.Context, id string) (*Record, error) {
+	var record Record
+
+	err := d.table.Get(id).
+		Range(tokenSK).
+		ConsistentRead(true).
+		ScanWithContext(ctx, &record)
+	if err != nil {
+		errStr := err.Error()
+		if strings.Contains(errStr, "item not found") || strings.Contains(errStr, "ItemNotFound") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if record.PK == "" {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+// Revoke marks a token revoked; revoked tokens are kept so they still show in listings
+func (d *DAO) Revoke(ctx context.Context, id, revokedBy string) error {
+	existing, err := d.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("token %s not found", id)
+	}
+
+	err = d.table.Update(id).
+		Range(tokenSK).
+		Set("#RevokedAt = ?", time.Now().Unix()).
+		Set("#RevokedBy = ?", revokedBy).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// Touch records when a token was last used
+func (d *DAO) Touch(ctx context.Context, id string, at time.Time) error {
+	err := d.table.Update(id).
+		Range(tokenSK).
+		Set("#LastUsedAt = ?", at.Unix()).
+		RunWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update token last used time: %w", err)
+	}
+
+	return nil
+}
+
+// FindAll scans all tokens
+func (d *DAO) FindAll(ctx context.Context) ([]Record, error) {
+	var records []Record
+	err := d.table.Scan().ConsistentRead(false).EachWithContext(ctx, func(item ddb.Item) (bool, error) {
+		var record Record
+		if err := item.Unmarshal(&record); err != nil {
+			return false, err
+		}
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan tokens: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,116 @@
+package tokendao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	DAO *DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client    = dynamodb.NewFromConfig(cfg)
+		db        = ddb.New(client)
+		tableName = fmt.Sprintf("tokens-test-%v", ksuid.New().String())
+		table     = db.MustTable(tableName, Record{})
+		dao       = New(client, tableName)
+	)
+
+	err = table.CreateTableIfNotExists(ctx)
+	assert.NoError(t, err)
+
+	return ctx, Data{DAO: dao}, func() {
+		_ = table.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestDAO(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		dao := data.DAO
+
+		t.Run("Create_Find", func(t *testing.T) {
+			id := ksuid.New().String()
+			created, err := dao.Create(ctx, CreateInput{
+				ID:        id,
+				Name:      "github-actions",
+				Kind:      KindService,
+				Owner:     "ci",
+				Actions:   []string{"redeploy"},
+				Env:       "dev",
+				Hash:      "abc123",
+				TTL:       24 * time.Hour,
+				CreatedBy: "admin",
+			})
+			assert.NoError(t, err)
+			assert.False(t, created.IsExpired(time.Now()))
+			assert.True(t, created.IsExpired(time.Now().Add(25*time.Hour)))
+
+			found, err := dao.Find(ctx, id)
+			assert.NoError(t, err)
+			if assert.NotNil(t, found) {
+				assert.Equal(t, "abc123", found.Hash)
+				assert.Equal(t, []string{"redeploy"}, found.Actions)
+				assert.False(t, found.IsRevoked())
+			}
+
+			missing, err := dao.Find(ctx, ksuid.New().String())
+			assert.NoError(t, err)
+			assert.Nil(t, missing)
+		})
+
+		t.Run("Create_Invalid", func(t *testing.T) {
+			_, err := dao.Create(ctx, CreateInput{ID: ksuid.New().String(), Name: "scripts", Kind: KindPersonal, Owner: "alice", Hash: "h", TTL: time.Hour})
+			assert.Error(t, err, "personal token without email owner")
+
+			_, err = dao.Create(ctx, CreateInput{ID: ksuid.New().String(), Name: "bot", Kind: KindService, Owner: "bot", Hash: "h"})
+			assert.Error(t, err, "token without expiry")
+		})
+
+		t.Run("Revoke_Touch", func(t *testing.T) {
+			id := ksuid.New().String()
+			_, err := dao.Create(ctx, CreateInput{ID: id, Name: "scripts", Kind: KindPersonal, Owner: "alice@example.com", Hash: "h", TTL: time.Hour})
+			assert.NoError(t, err)
+
+			now := time.Now()
+			assert.NoError(t, dao.Touch(ctx, id, now))
+			assert.NoError(t, dao.Revoke(ctx, id, "bob"))
+			assert.Error(t, dao.Revoke(ctx, ksuid.New().String(), "bob"), "unknown token")
+
+			found, err := dao.Find(ctx, id)
+			assert.NoError(t, err)
+			if assert.NotNil(t, found) {
+				assert.True(t, found.IsRevoked())
+				assert.Equal(t, "bob", found.RevokedBy)
+				assert.Equal(t, now.Unix(), found.LastUsedAt)
+			}
+
+			records, err := dao.FindAll(ctx)
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, len(records), 2)
+		})
+	})
+}
@@ -95,6 +95,7 @@ var core = []any{
 	ProvideStepFunctions,
 	ProvideOrchestrator,
 	ProvideSignerClient,
+	ProvideECRClient,
 	ProvideS3Client,
 	services.NewDynamoDBService,
 	services.NewSecretsManagerService,
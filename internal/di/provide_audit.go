@@ -0,0 +1,10 @@
+package di
+
+import (
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+)
+
+func ProvideAuditRecorder(events *auditdao.DAO) *audit.Recorder {
+	return audit.New(events, audit.SourceGraphQL)
+}
@@ -9,8 +9,11 @@ import (
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/apitoken"
 	"github.com/savaki/aws-deployer/internal/auth"
 	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/roledao"
+	"github.com/savaki/aws-deployer/internal/dao/tokendao"
 	"github.com/savaki/aws-deployer/internal/services"
 )
 
@@ -45,7 +48,7 @@ func ProvideSessionKeys(ctx context.Context, keyService *services.SessionKeyServ
 	return keys, nil
 }
 
-func ProvideAuthenticator(ctx context.Context, secretsService *services.SecretsManagerService, authorizer *authz.Authorizer, callbackURL CallbackURL, sessionKeys [][]byte, disableAuth DisableAuth) (*auth.Authenticator, error) {
+func ProvideAuthenticator(ctx context.Context, secretsService *services.SecretsManagerService, authorizer *authz.Authorizer, tokens auth.TokenVerifier, callbackURL CallbackURL, sessionKeys [][]byte, disableAuth DisableAuth) (*auth.Authenticator, error) {
 	logger := zerolog.Ctx(ctx)
 
 	// If auth is disabled, return NoOp authenticator
@@ -71,6 +74,15 @@ func ProvideAuthenticator(ctx context.Context, secretsService *services.SecretsM
 		provider = &auth.GoogleCIAMProvider{
 			ProjectID: oauthConfig.ProjectID,
 		}
+	case "oidc":
+		// Any OpenID Connect provider: Okta, Keycloak, Azure AD, Dex, ...
+		if oauthConfig.IssuerURL == "" {
+			return nil, fmt.Errorf("issuer_url is required for the oidc provider")
+		}
+		provider = &auth.OIDCProvider{
+			IssuerURL:     oauthConfig.IssuerURL,
+			EndSessionURL: oauthConfig.EndSessionURL,
+		}
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", oauthConfig.Provider)
 	}
@@ -89,6 +101,12 @@ func ProvideAuthenticator(ctx context.Context, secretsService *services.SecretsM
 		Authorizer:   authorizer,
 		SessionKeys:  sessionKeys,
 		IsLocalDev:   isLocalDev,
+		Scopes:       oauthConfig.Scopes,
+		Claims: auth.ClaimMapping{
+			GroupsClaim: oauthConfig.GroupsClaim,
+			Claims:      oauthConfig.Claims,
+		},
+		Tokens: tokens,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create authenticator: %w", err)
@@ -97,23 +115,52 @@ func ProvideAuthenticator(ctx context.Context, secretsService *services.SecretsM
 	return authenticator, nil
 }
 
+func ProvideTokenVerifier(tokens *tokendao.DAO) auth.TokenVerifier {
+	return apitoken.NewVerifier(tokens)
+}
+
 func ProvideAuthorizer(ctx context.Context, logger zerolog.Logger, secretsService *services.SecretsManagerService, config *services.Config) *authz.Authorizer {
-	if config.AllowedEmail == "" {
-		logger.Info().Msg("Email authorization disabled - all authenticated users allowed")
-		return nil
+	var policies []authz.Policy
+
+	if len(config.AllowedGroups) > 0 {
+		logger.Info().
+			Strs("allowed_groups", config.AllowedGroups).
+			Msg("Group authorization enabled")
+		policies = append(policies, &authz.GroupPolicy{AllowedGroups: config.AllowedGroups})
 	}
 
-	// Get OAuth config to determine provider type
-	oauthConfig, err := secretsService.GetOAuthConfig(ctx)
-	if err != nil {
-		logger.Error().Err(err).Msg("Failed to get OAuth config for authorizer, disabling authorization")
+	if config.AllowedEmail != "" {
+		// Get OAuth config to determine provider type
+		oauthConfig, err := secretsService.GetOAuthConfig(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to get OAuth config for authorizer, disabling email authorization")
+		} else {
+			logger.Info().
+				Str("allowed_email", config.AllowedEmail).
+				Str("provider_type", oauthConfig.Provider).
+				Msg("Email authorization enabled")
+			policies = append(policies, &authz.GoogleEmailPolicy{
+				AllowedEmail: config.AllowedEmail,
+				ProviderType: oauthConfig.Provider,
+			})
+		}
+	}
+
+	if len(policies) == 0 {
+		logger.Info().Msg("Email and group authorization disabled - all authenticated users allowed")
 		return nil
 	}
 
-	logger.Info().
-		Str("allowed_email", config.AllowedEmail).
-		Str("provider_type", oauthConfig.Provider).
-		Msg("Email authorization enabled")
+	return authz.NewAuthorizer(true, policies...)
+}
+
+func ProvideRBAC(logger zerolog.Logger, roles *roledao.DAO, config *services.Config, disableAuth DisableAuth) *authz.RBAC {
+	// Without authentication there is no profile to check roles against
+	if bool(disableAuth) || !config.RoleBasedAccess {
+		logger.Info().Msg("Role-based access control disabled - all authenticated users may run mutations")
+		return authz.NewRBAC(false, roles)
+	}
 
-	return authz.NewGoogleEmailAuthorizer(true, config.AllowedEmail, oauthConfig.Provider)
+	logger.Info().Msg("Role-based access control enabled")
+	return authz.NewRBAC(true, roles)
 }
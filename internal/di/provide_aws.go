@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sfn"
 	"github.com/aws/aws-sdk-go-v2/service/signer"
@@ -35,6 +36,10 @@ func ProvideSignerClient(config aws.Config) *signer.Client {
 	return signer.NewFromConfig(config)
 }
 
+func ProvideECRClient(config aws.Config) *ecr.Client {
+	return ecr.NewFromConfig(config)
+}
+
 func ProvideOrchestrator(sfnClient *sfn.Client, dao *builddao.DAO, config *services.Config) (*orchestrator.Orchestrator, error) {
 	// Determine which state machine to use based on deployment mode
 	var stateMachineArn string
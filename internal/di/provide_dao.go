@@ -2,9 +2,13 @@ package di
 
 import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/roledao"
 	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/aws-deployer/internal/dao/tokendao"
 )
 
 func ProvideBuildDAO(env string, client *dynamodb.Client) *builddao.DAO {
@@ -18,3 +22,19 @@ func ProvideTargetDAO(env string, client *dynamodb.Client) *targetdao.DAO {
 func ProvideDeploymentDAO(env string, client *dynamodb.Client) *deploymentdao.DAO {
 	return deploymentdao.New(client, deploymentdao.TableName(env))
 }
+
+func ProvideDriftDAO(env string, client *dynamodb.Client) *driftdao.DAO {
+	return driftdao.New(client, driftdao.TableName(env))
+}
+
+func ProvideRoleDAO(env string, client *dynamodb.Client) *roledao.DAO {
+	return roledao.New(client, roledao.TableName(env))
+}
+
+func ProvideTokenDAO(env string, client *dynamodb.Client) *tokendao.DAO {
+	return tokendao.New(client, tokendao.TableName(env))
+}
+
+func ProvideAuditDAO(env string, client *dynamodb.Client) *auditdao.DAO {
+	return auditdao.New(client, auditdao.TableName(env))
+}
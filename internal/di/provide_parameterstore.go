@@ -49,6 +49,7 @@ func ProvideAppConfig(ctx context.Context, store services.ParameterStore) (*serv
 		Str("deployment_mode", config.DeploymentMode).
 		Str("s3_bucket", config.S3Bucket).
 		Bool("has_allowed_email", config.AllowedEmail != "").
+		Bool("role_based_access", config.RoleBasedAccess).
 		Bool("has_custom_domain", config.CustomDomain != "").
 		Msg("Configuration loaded successfully")
 
@@ -0,0 +1,20 @@
+package di
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/savaki/aws-deployer/internal/stackevents"
+)
+
+func ProvideStackEvents(awsConfig aws.Config, buildDAO *builddao.DAO, deploymentDAO *deploymentdao.DAO, config *services.Config) *stackevents.Streamer {
+	cfClient := cloudformation.NewFromConfig(awsConfig)
+
+	// StackSet instances live in target accounts; single-account builds only have their own stack
+	if config.DeploymentMode != "multi" {
+		return stackevents.New(cfClient, nil, buildDAO, nil, stackevents.DefaultInterval)
+	}
+	return stackevents.New(cfClient, stackevents.NewRoleClientFactory(awsConfig), buildDAO, deploymentDAO, stackevents.DefaultInterval)
+}
@@ -0,0 +1,48 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// buildSnapshot is the part of a build recorded in the before and after values of audit events
+type buildSnapshot struct {
+	ID        string               `json:"id"`
+	Version   string               `json:"version"`
+	Status    builddao.BuildStatus `json:"status"`
+	Approvals int                  `json:"approvals,omitempty"`
+	Reason    string               `json:"reason,omitempty"` // comment, rejection or override reason
+}
+
+// snapshot returns the audit snapshot of a build
+func snapshot(build builddao.Record) buildSnapshot {
+	return buildSnapshot{
+		ID:        build.GetID().String(),
+		Version:   build.Version,
+		Status:    build.Status,
+		Approvals: build.ApprovalCount(),
+	}
+}
+
+// record appends a change made by the authenticated user to the audit table. The mutation
+// has already been applied, so a failure to record it is logged rather than returned.
+func (r *Resolver) record(ctx context.Context, event audit.Event) {
+	if r.audit == nil {
+		return
+	}
+
+	profile, _ := auth.ProfileFromContext(ctx)
+	actor := audit.ActorFromProfile(profile)
+	if err := r.audit.Record(ctx, actor, event); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Str("actor", actor.ID).
+			Str("action", event.Action).
+			Str("target", event.Target).
+			Msg("Failed to record audit event")
+	}
+}
@@ -0,0 +1,45 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+)
+
+// authorize returns nil if the authenticated user holds the role required for action on
+// the repo and env and, for API tokens, the token is scoped for it. Denials are returned
+// unwrapped as *authz.DeniedError so that GraphQL clients receive them with structured
+// extensions.
+func (r *Resolver) authorize(ctx context.Context, action authz.Action, repo, env string) error {
+	profile, _ := auth.ProfileFromContext(ctx)
+
+	// Token scopes apply even when role-based access control is disabled
+	if err := authz.CheckScope(profile.AuthzProfile(), action, repo, env); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("sub", profile.Sub).
+			Str("action", string(action)).
+			Str("repo", repo).
+			Str("env", env).
+			Msg("Mutation denied by API token scope")
+		return err
+	}
+
+	if !r.rbac.Enabled() {
+		return nil
+	}
+
+	err := r.rbac.Check(ctx, profile.AuthzProfile(), action, repo, env)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("email", profile.Email).
+			Str("action", string(action)).
+			Str("repo", repo).
+			Str("env", env).
+			Msg("Mutation denied by role-based access control")
+	}
+	return err
+}
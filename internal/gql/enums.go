@@ -6,10 +6,12 @@ import "github.com/savaki/aws-deployer/internal/dao/builddao"
 type BuildStatus string
 
 const (
-	BuildStatusPending    BuildStatus = "PENDING"
-	BuildStatusInProgress BuildStatus = "IN_PROGRESS"
-	BuildStatusSuccess    BuildStatus = "SUCCESS"
-	BuildStatusFailed     BuildStatus = "FAILED"
+	BuildStatusPending         BuildStatus = "PENDING"
+	BuildStatusPendingApproval BuildStatus = "PENDING_APPROVAL"
+	BuildStatusBlocked         BuildStatus = "BLOCKED"
+	BuildStatusInProgress      BuildStatus = "IN_PROGRESS"
+	BuildStatusSuccess         BuildStatus = "SUCCESS"
+	BuildStatusFailed          BuildStatus = "FAILED"
 )
 
 // FromModelBuildStatus converts a builddao.BuildStatus to gql.BuildStatus
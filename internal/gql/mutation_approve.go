@@ -0,0 +1,233 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/orchestrator"
+)
+
+// Approve resolves the approve mutation - records an approval on a build awaiting approval
+// and starts the deployment once the environment's required approvals are reached
+// Returns the Query type to allow chaining queries after the mutation
+func (r *Resolver) Approve(ctx context.Context, args struct {
+	BuildId string
+	Comment *string
+}) (*Resolver, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Str("buildId", args.BuildId).Msg("Approve mutation called")
+
+	approval, build, err := r.newApproval(ctx, builddao.ID(args.BuildId), builddao.ApprovalDecisionApproved, args.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.build.AddApproval(ctx, build.GetID(), approval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+
+	logger.Info().
+		Str("repo", updated.Repo).
+		Str("env", updated.Env).
+		Str("sk", updated.SK).
+		Str("approver", approval.Email).
+		Int("approvals", updated.ApprovalCount()).
+		Int("required_approvals", updated.RequiredApprovals).
+		Msg("Recorded build approval")
+
+	after := snapshot(updated)
+	after.Reason = approval.Comment
+	r.record(ctx, audit.Event{
+		Action: audit.ActionApprove,
+		Repo:   updated.Repo,
+		Env:    updated.Env,
+		Target: updated.GetID().String(),
+		Before: snapshot(build),
+		After:  after,
+	})
+
+	if !updated.IsApproved() {
+		return r, nil
+	}
+
+	// Approved builds still wait out a deploy freeze
+	held, err := r.holdIfFrozen(ctx, updated)
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		return r, nil
+	}
+
+	if err := r.startBuild(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	// Return the root resolver to allow query chaining
+	return r, nil
+}
+
+// Reject resolves the reject mutation - records a rejection and fails a build awaiting approval
+// Returns the Query type to allow chaining queries after the mutation
+func (r *Resolver) Reject(ctx context.Context, args struct {
+	BuildId string
+	Reason  *string
+}) (*Resolver, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Str("buildId", args.BuildId).Msg("Reject mutation called")
+
+	approval, build, err := r.newApproval(ctx, builddao.ID(args.BuildId), builddao.ApprovalDecisionRejected, args.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.build.AddApproval(ctx, build.GetID(), approval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record rejection: %w", err)
+	}
+
+	errorMsg := fmt.Sprintf("Rejected by %s", approval.Email)
+	if approval.Comment != "" {
+		errorMsg = fmt.Sprintf("%s: %s", errorMsg, approval.Comment)
+	}
+
+	status := builddao.BuildStatusFailed
+	if err := r.build.UpdateStatus(ctx, builddao.UpdateInput{
+		PK:       build.PK,
+		SK:       build.SK,
+		Status:   &status,
+		ErrorMsg: &errorMsg,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update build status: %w", err)
+	}
+
+	logger.Info().
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Str("sk", build.SK).
+		Str("approver", approval.Email).
+		Msg("Rejected build")
+
+	after := snapshot(updated)
+	after.Status = status
+	after.Reason = approval.Comment
+	r.record(ctx, audit.Event{
+		Action: audit.ActionReject,
+		Repo:   build.Repo,
+		Env:    build.Env,
+		Target: build.GetID().String(),
+		Before: snapshot(build),
+		After:  after,
+	})
+
+	// Return the root resolver to allow query chaining
+	return r, nil
+}
+
+// newApproval validates that the authenticated user may decide on the build and
+// returns the approval to record along with the build
+func (r *Resolver) newApproval(
+	ctx context.Context,
+	id builddao.ID,
+	decision builddao.ApprovalDecision,
+	comment *string,
+) (builddao.Approval, builddao.Record, error) {
+	profile, ok := auth.ProfileFromContext(ctx)
+	if !ok || profile.Email == "" {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("approvals require an authenticated user with an email address")
+	}
+
+	build, err := r.build.Find(ctx, id)
+	if err != nil {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("failed to get build: %w", err)
+	}
+
+	action := authz.ActionApprove
+	if decision == builddao.ApprovalDecisionRejected {
+		action = authz.ActionReject
+	}
+	if err := r.authorize(ctx, action, build.Repo, build.Env); err != nil {
+		return builddao.Approval{}, builddao.Record{}, err
+	}
+
+	if build.Status != builddao.BuildStatusPendingApproval {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("build %s is not awaiting approval (status %s)", id, build.Status)
+	}
+
+	// The person who promoted a build cannot also sign it off
+	if strings.EqualFold(build.PromotedBy, profile.Email) {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("%s promoted this build and cannot approve or reject it", profile.Email)
+	}
+
+	targets, err := r.targetDAO.GetWithDefault(ctx, build.Repo, build.Env)
+	if err != nil {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("failed to get targets: %w", err)
+	}
+	if targets != nil && !targets.Approval.CanApprove(profile.Email) {
+		return builddao.Approval{}, builddao.Record{}, fmt.Errorf("%s is not an approver for %s/%s", profile.Email, build.Repo, build.Env)
+	}
+
+	approval := builddao.Approval{
+		Email:    profile.Email,
+		Name:     profile.Name,
+		Sub:      profile.Sub,
+		Decision: decision,
+	}
+	if comment != nil {
+		approval.Comment = *comment
+	}
+
+	return approval, build, nil
+}
+
+// startBuild starts the Step Functions execution for a build held back by trigger-build,
+// i.e. a fully approved build or a build released from a deploy freeze
+func (r *Resolver) startBuild(ctx context.Context, build builddao.Record) error {
+	logger := zerolog.Ctx(ctx)
+
+	// Construct Step Function input from build record
+	input := orchestrator.StepFunctionInput{
+		Repo:       build.Repo,
+		Env:        build.Env,
+		Branch:     build.Branch,
+		Version:    build.Version,
+		SK:         build.SK,
+		CommitHash: build.CommitHash,
+		S3Bucket:   r.appConfig.S3Bucket,
+		S3Key:      fmt.Sprintf("%s/%s/%s", build.Repo, build.Branch, build.Version),
+		StartAfter: orchestrator.StartAfter(build),
+	}
+
+	executionArn, err := r.orchestrator.StartExecution(ctx, input)
+	if err != nil {
+		status := builddao.BuildStatusFailed
+		errorMsg := fmt.Sprintf("Failed to start step function: %v", err)
+		if updateErr := r.build.UpdateStatus(ctx, builddao.UpdateInput{
+			PK:       build.PK,
+			SK:       build.SK,
+			Status:   &status,
+			ErrorMsg: &errorMsg,
+		}); updateErr != nil {
+			logger.Error().Err(updateErr).Msg("Failed to update build status")
+		}
+		return fmt.Errorf("failed to start execution: %w", err)
+	}
+
+	logger.Info().
+		Str("execution_arn", executionArn).
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Str("sk", build.SK).
+		Msg("Started execution for held build")
+
+	return nil
+}
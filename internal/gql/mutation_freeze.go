@@ -0,0 +1,121 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// OverrideFreeze resolves the overrideFreeze mutation - deploys a build held by a deploy
+// freeze and records who bypassed the freeze and why
+// Returns the Query type to allow chaining queries after the mutation
+func (r *Resolver) OverrideFreeze(ctx context.Context, args struct {
+	BuildId string
+	Reason  string
+}) (*Resolver, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Str("buildId", args.BuildId).Msg("OverrideFreeze mutation called")
+
+	profile, ok := auth.ProfileFromContext(ctx)
+	if !ok || profile.Email == "" {
+		return nil, fmt.Errorf("freeze overrides require an authenticated user with an email address")
+	}
+
+	reason := strings.TrimSpace(args.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to override a deploy freeze")
+	}
+
+	frozen, err := r.build.Find(ctx, builddao.ID(args.BuildId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build: %w", err)
+	}
+	if err := r.authorize(ctx, authz.ActionOverrideFreeze, frozen.Repo, frozen.Env); err != nil {
+		return nil, err
+	}
+
+	build, err := r.build.OverrideFreeze(ctx, builddao.ID(args.BuildId), builddao.FreezeOverride{
+		Email:  profile.Email,
+		Name:   profile.Name,
+		Sub:    profile.Sub,
+		Reason: reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to override freeze: %w", err)
+	}
+
+	logger.Warn().
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Str("sk", build.SK).
+		Str("overridden_by", profile.Email).
+		Str("blocked_reason", build.BlockedReason).
+		Str("reason", reason).
+		Msg("Deploy freeze overridden")
+
+	after := snapshot(build)
+	after.Reason = reason
+	r.record(ctx, audit.Event{
+		Action: audit.ActionOverrideFreeze,
+		Repo:   build.Repo,
+		Env:    build.Env,
+		Target: build.GetID().String(),
+		Before: snapshot(frozen),
+		After:  after,
+	})
+
+	// Builds held after their soak time already have an execution, which resumes at its next
+	// calendar check once it sees the override
+	if build.ExecutionArn != nil {
+		return r, nil
+	}
+
+	if err := r.startBuild(ctx, build); err != nil {
+		return nil, err
+	}
+
+	// Return the root resolver to allow query chaining
+	return r, nil
+}
+
+// holdIfFrozen marks the build BLOCKED if the environment's change calendar currently blocks
+// deploys and returns true if it did. Rollbacks are never held.
+func (r *Resolver) holdIfFrozen(ctx context.Context, build builddao.Record) (bool, error) {
+	if build.IsRollback() {
+		return false, nil
+	}
+
+	reason, err := r.targetDAO.Blocked(ctx, build.Repo, build.Env, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to check change calendar: %w", err)
+	}
+	if reason == "" {
+		return false, nil
+	}
+
+	status := builddao.BuildStatusBlocked
+	if err := r.build.UpdateStatus(ctx, builddao.UpdateInput{
+		PK:            build.PK,
+		SK:            build.SK,
+		Status:        &status,
+		BlockedReason: &reason,
+	}); err != nil {
+		return false, fmt.Errorf("failed to block build: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Str("sk", build.SK).
+		Str("reason", reason).
+		Msg("Build blocked by change calendar")
+	return true, nil
+}
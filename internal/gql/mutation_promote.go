@@ -5,8 +5,11 @@ import (
 	"fmt"
 
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
-	"github.com/segmentio/ksuid"
+	"github.com/savaki/aws-deployer/internal/promotion"
 )
 
 // Promote resolves the promote mutation - promotes a build to downstream environments
@@ -23,64 +26,45 @@ func (r *Resolver) Promote(ctx context.Context, args struct{ BuildId string }) (
 		return nil, fmt.Errorf("failed to get build: %w", err)
 	}
 
-	// Validate build status - only allow promotion of successful builds
-	if build.Status != builddao.BuildStatusSuccess {
-		return nil, fmt.Errorf("cannot promote build with status %s - only SUCCESS builds can be promoted", build.Status)
+	// Promotion deploys to each downstream environment, so the role (and any token scope)
+	// is required there
+	profile, _ := auth.ProfileFromContext(ctx)
+	if r.rbac.Enabled() || profile.Scope != nil {
+		targets, err := r.targetDAO.GetWithDefault(ctx, build.Repo, build.Env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get targets: %w", err)
+		}
+		if targets != nil {
+			for _, env := range targets.DownstreamEnv {
+				if err := r.authorize(ctx, authz.ActionPromote, build.Repo, env); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
-	// Get downstream environments from targetdao
-	targets, err := r.targetDAO.GetWithDefault(ctx, build.Repo, build.Env)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get targets: %w", err)
+	// Record who promoted the build so approvers can be required to be someone else
+	input := promotion.Input{
+		PromotedBy: profile.Email,
 	}
 
-	// Check if there are downstream environments configured
-	if targets == nil || len(targets.DownstreamEnv) == 0 {
-		return nil, fmt.Errorf("no downstream environments configured for %s/%s", build.Repo, build.Env)
+	promoted, err := r.promoter.Promote(ctx, build, input)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info().
-		Str("repo", build.Repo).
-		Str("env", build.Env).
-		Strs("downstreamEnvs", targets.DownstreamEnv).
-		Str("version", build.Version).
-		Msg("Promoting build to downstream environments")
-
-	// Create a new build for each downstream environment
-	for _, downstreamEnv := range targets.DownstreamEnv {
-		// Generate new KSUID for the promoted build
-		sk := ksuid.New().String()
-
-		// Create stack name for downstream environment
-		stackName := fmt.Sprintf("%s-%s", downstreamEnv, build.Repo)
-
-		// Create new build record in downstream environment
-		_, err := r.build.Create(ctx, builddao.CreateInput{
-			Repo:        build.Repo,
-			Env:         downstreamEnv,
-			SK:          sk,
-			BuildNumber: build.BuildNumber,
-			Branch:      build.Branch,
-			Version:     build.Version,
-			CommitHash:  build.CommitHash,
-			StackName:   stackName,
-		})
-		if err != nil {
-			logger.Error().
-				Err(err).
-				Str("repo", build.Repo).
-				Str("downstreamEnv", downstreamEnv).
-				Msg("Failed to create promoted build")
-			return nil, fmt.Errorf("failed to create promoted build for %s: %w", downstreamEnv, err)
-		}
-
-		logger.Info().
-			Str("repo", build.Repo).
-			Str("env", downstreamEnv).
-			Str("version", build.Version).
-			Str("sk", sk).
-			Msg("Successfully created promoted build")
+	after := make([]buildSnapshot, len(promoted))
+	for i, p := range promoted {
+		after[i] = snapshot(p)
 	}
+	r.record(ctx, audit.Event{
+		Action: audit.ActionPromote,
+		Repo:   build.Repo,
+		Env:    build.Env,
+		Target: build.GetID().String(),
+		Before: snapshot(build),
+		After:  after,
+	})
 
 	// Return the root resolver to allow query chaining
 	return r, nil
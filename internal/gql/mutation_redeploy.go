@@ -5,6 +5,9 @@ import (
 	"fmt"
 
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/orchestrator"
 	"github.com/segmentio/ksuid"
@@ -24,6 +27,25 @@ func (r *Resolver) Redeploy(ctx context.Context, args struct{ BuildId string })
 		return nil, fmt.Errorf("failed to get build: %w", err)
 	}
 
+	if err := r.authorize(ctx, authz.ActionRedeploy, build.Repo, build.Env); err != nil {
+		return nil, err
+	}
+
+	// A build awaiting approval must be approved or rejected; redeploying it would skip the vote
+	if build.Status == builddao.BuildStatusPendingApproval {
+		return nil, fmt.Errorf("build %s is awaiting approval - approve or reject it instead of redeploying", args.BuildId)
+	}
+
+	// Redeploys into an environment with an approval policy need fresh approvals, so a rejected
+	// build cannot reach the environment by being redeployed
+	requiredApprovals, err := r.promoter.RequiredApprovals(ctx, build.Repo, build.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record who redeployed the build so approvers can be required to be someone else
+	profile, _ := auth.ProfileFromContext(ctx)
+
 	// Generate new KSUID for the redeployment to avoid execution name conflicts
 	sk := ksuid.New().String()
 
@@ -37,20 +59,50 @@ func (r *Resolver) Redeploy(ctx context.Context, args struct{ BuildId string })
 
 	// Create a new build record for this redeploy with all fields from the original build
 	pk := builddao.NewPK(build.Repo, build.Env)
-	_, err = r.build.Create(ctx, builddao.CreateInput{
-		Repo:        build.Repo,
-		Env:         build.Env,
-		SK:          sk,
-		BuildNumber: build.BuildNumber,
-		Branch:      build.Branch,
-		Version:     build.Version,
-		CommitHash:  build.CommitHash,
-		StackName:   build.StackName,
+	created, err := r.build.Create(ctx, builddao.CreateInput{
+		Repo:              build.Repo,
+		Env:               build.Env,
+		SK:                sk,
+		BuildNumber:       build.BuildNumber,
+		Branch:            build.Branch,
+		Version:           build.Version,
+		CommitHash:        build.CommitHash,
+		StackName:         build.StackName,
+		PromotedBy:        profile.Email,
+		RequiredApprovals: requiredApprovals,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create build record for redeploy: %w", err)
 	}
 
+	r.record(ctx, audit.Event{
+		Action: audit.ActionRedeploy,
+		Repo:   created.Repo,
+		Env:    created.Env,
+		Target: created.GetID().String(),
+		Before: snapshot(build),
+		After:  snapshot(created),
+	})
+
+	// The deployment starts once the build collects its approvals
+	if created.Status == builddao.BuildStatusPendingApproval {
+		logger.Info().
+			Str("repo", created.Repo).
+			Str("env", created.Env).
+			Str("sk", sk).
+			Int("required_approvals", requiredApprovals).
+			Msg("Redeploy is awaiting approval")
+		return r, nil
+	}
+
+	held, err := r.holdIfFrozen(ctx, created)
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		return r, nil
+	}
+
 	// Construct Step Function input from build record
 	input := orchestrator.StepFunctionInput{
 		Repo:       build.Repo,
@@ -0,0 +1,104 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/auth"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/segmentio/ksuid"
+)
+
+// AllowReplacement resolves the allowReplacement mutation - redeploys a build that failed because
+// it would replace or delete resources protected by the environment, recording who allowed the
+// replacement and why
+// Returns the Query type to allow chaining queries after the mutation
+func (r *Resolver) AllowReplacement(ctx context.Context, args struct {
+	BuildId string
+	Reason  string
+}) (*Resolver, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Str("buildId", args.BuildId).Msg("AllowReplacement mutation called")
+
+	profile, ok := auth.ProfileFromContext(ctx)
+	if !ok || profile.Email == "" {
+		return nil, fmt.Errorf("replacement overrides require an authenticated user with an email address")
+	}
+
+	reason := strings.TrimSpace(args.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to replace protected resources")
+	}
+
+	build, err := r.build.Find(ctx, builddao.ID(args.BuildId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build: %w", err)
+	}
+	if err := r.authorize(ctx, authz.ActionAllowReplacement, build.Repo, build.Env); err != nil {
+		return nil, err
+	}
+	if build.Status != builddao.BuildStatusFailed {
+		return nil, fmt.Errorf("build %s has not failed (status %s)", args.BuildId, build.Status)
+	}
+
+	// The override is recorded on a new build, like a redeploy, so the failed build keeps its history
+	created, err := r.build.Create(ctx, builddao.CreateInput{
+		Repo:        build.Repo,
+		Env:         build.Env,
+		SK:          ksuid.New().String(),
+		BuildNumber: build.BuildNumber,
+		Branch:      build.Branch,
+		Version:     build.Version,
+		CommitHash:  build.CommitHash,
+		StackName:   build.StackName,
+		ReplacementOverride: &builddao.ReplacementOverride{
+			Email:  profile.Email,
+			Name:   profile.Name,
+			Sub:    profile.Sub,
+			Reason: reason,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build record for replacement: %w", err)
+	}
+
+	logger.Warn().
+		Str("repo", created.Repo).
+		Str("env", created.Env).
+		Str("original_sk", build.SK).
+		Str("sk", created.SK).
+		Str("overridden_by", profile.Email).
+		Str("reason", reason).
+		Msg("Replacement of protected resources allowed")
+
+	after := snapshot(created)
+	after.Reason = reason
+	r.record(ctx, audit.Event{
+		Action: audit.ActionAllowReplacement,
+		Repo:   created.Repo,
+		Env:    created.Env,
+		Target: created.GetID().String(),
+		Before: snapshot(build),
+		After:  after,
+	})
+
+	held, err := r.holdIfFrozen(ctx, created)
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		return r, nil
+	}
+
+	if err := r.startBuild(ctx, created); err != nil {
+		return nil, err
+	}
+
+	// Return the root resolver to allow query chaining
+	return r, nil
+}
@@ -0,0 +1,81 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+)
+
+const (
+	maxAuditLimit = 1000
+	maxAuditRange = 90 * 24 * time.Hour
+)
+
+// AuditFilterInput is the AuditFilter input type
+type AuditFilterInput struct {
+	Since  *DateTime
+	Until  *DateTime
+	Actor  *string
+	Action *string
+	Repo   *string
+	Env    *string
+	Target *string
+}
+
+// AuditEvents resolves the auditEvents query - lists audit events matching a filter, newest first
+func (r *Resolver) AuditEvents(ctx context.Context, args struct {
+	Filter *AuditFilterInput
+	Limit  *int32
+}) ([]*AuditEventResolver, error) {
+	input := auditdao.QueryInput{
+		Until: time.Now(),
+		Limit: 100,
+	}
+	if args.Limit != nil {
+		input.Limit = int(*args.Limit)
+	}
+	if input.Limit < 1 || input.Limit > maxAuditLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+	}
+
+	if f := args.Filter; f != nil {
+		if f.Until != nil {
+			input.Until = f.Until.Time
+		}
+		if f.Since != nil {
+			input.Since = f.Since.Time
+		}
+		input.Actor = stringValue(f.Actor)
+		input.Action = stringValue(f.Action)
+		input.Repo = stringValue(f.Repo)
+		input.Env = stringValue(f.Env)
+		input.Target = stringValue(f.Target)
+	}
+	if input.Since.IsZero() {
+		input.Since = input.Until.Add(-auditdao.DefaultRange)
+	}
+	if input.Until.Sub(input.Since) > maxAuditRange {
+		return nil, fmt.Errorf("audit queries may span at most %d days", int(maxAuditRange.Hours()/24))
+	}
+
+	records, err := r.auditDAO.Query(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*AuditEventResolver, len(records))
+	for i, record := range records {
+		resolvers[i] = &AuditEventResolver{record: record}
+	}
+	return resolvers, nil
+}
+
+// stringValue returns the value of s, or an empty string if it is nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
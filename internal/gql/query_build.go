@@ -0,0 +1,22 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// Build resolves the build query - looks up a single build by ID, or returns null if it does
+// not exist
+func (r *Resolver) Build(ctx context.Context, args struct{ Id string }) (*BuildResolver, error) {
+	record, err := r.build.Find(ctx, builddao.ID(args.Id))
+	if errors.Is(err, builddao.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newBuildResolver(record, r.targetDAO, r.deploymentDAO, r.driftDAO, r.orchestrator, ctx), nil
+}
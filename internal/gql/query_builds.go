@@ -4,20 +4,23 @@ import (
 	"context"
 )
 
-// Builds resolves the builds query - lists recent builds for a given environment
-// Note: This currently returns all builds for all repos in the environment.
-// TODO: Once we have a way to list all repos, we can query each one individually.
-func (r *Resolver) Builds(ctx context.Context, args struct{ Env string }) ([]*BuildResolver, error) {
-	records, err := r.build.QueryLatestBuilds(ctx, args.Env)
+// Builds resolves the builds query - pages through the latest build of each repo in a given
+// environment, ordered by repo
+func (r *Resolver) Builds(ctx context.Context, args struct {
+	Env    string
+	First  *int32
+	After  *string
+	Filter *BuildFilterInput
+}) (*BuildConnectionResolver, error) {
+	input, err := toPageInput(args.First, args.After, args.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	// Map records to resolvers with targetDAO, deploymentDAO and context
-	resolvers := make([]*BuildResolver, len(records))
-	for i, record := range records {
-		resolvers[i] = newBuildResolver(record, r.targetDAO, r.deploymentDAO, ctx)
+	page, err := r.build.QueryLatestPage(ctx, args.Env, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return resolvers, nil
+	return r.newBuildConnection(ctx, page), nil
 }
@@ -2,23 +2,28 @@ package gql
 
 import (
 	"context"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
 )
 
-// BuildsByRepo resolves the buildsByRepo query - lists all builds for a specific repo and environment
+// BuildsByRepo resolves the buildsByRepo query - pages through the builds of a specific repo
+// and environment, newest first
 func (r *Resolver) BuildsByRepo(ctx context.Context, args struct {
-	Repo string
-	Env  string
-}) ([]*BuildResolver, error) {
-	records, err := r.build.QueryByRepoEnv(ctx, args.Repo, args.Env)
+	Repo   string
+	Env    string
+	First  *int32
+	After  *string
+	Filter *BuildFilterInput
+}) (*BuildConnectionResolver, error) {
+	input, err := toPageInput(args.First, args.After, args.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	// Map records to resolvers with targetDAO, deploymentDAO and context
-	resolvers := make([]*BuildResolver, len(records))
-	for i, record := range records {
-		resolvers[i] = newBuildResolver(record, r.targetDAO, r.deploymentDAO, ctx)
+	page, err := r.build.QueryPage(ctx, builddao.NewPK(args.Repo, args.Env), input)
+	if err != nil {
+		return nil, err
 	}
 
-	return resolvers, nil
+	return r.newBuildConnection(ctx, page), nil
 }
@@ -69,7 +69,7 @@ func (r *Resolver) Pipelines(ctx context.Context) ([]*PipelineConfigResolver, er
 		// Sort environments by a standard order (dev, stg, prd, then alphabetical)
 		sortEnvironments(entry.environments)
 
-		resolvers = append(resolvers, newPipelineConfigResolver(repo, initialEnv, entry.environments))
+		resolvers = append(resolvers, newPipelineConfigResolver(repo, initialEnv, entry.environments, r.driftDAO))
 	}
 
 	return resolvers, nil
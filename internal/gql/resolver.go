@@ -4,11 +4,17 @@ import (
 	_ "embed"
 
 	"github.com/graph-gophers/graphql-go"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/authz"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
 	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/orchestrator"
+	"github.com/savaki/aws-deployer/internal/promotion"
 	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/savaki/aws-deployer/internal/stackevents"
 	"go.uber.org/dig"
 )
 
@@ -21,9 +27,15 @@ type Config struct {
 	Build         *builddao.DAO
 	TargetDAO     *targetdao.DAO
 	DeploymentDAO *deploymentdao.DAO
+	DriftDAO      *driftdao.DAO
 	DbService     *services.DynamoDBService
 	Orchestrator  *orchestrator.Orchestrator
+	Promoter      *promotion.Promoter
 	AppConfig     *services.Config
+	StackEvents   *stackevents.Streamer
+	RBAC          *authz.RBAC
+	AuditDAO      *auditdao.DAO
+	Audit         *audit.Recorder
 }
 
 // Resolver is the root GraphQL resolver
@@ -31,9 +43,15 @@ type Resolver struct {
 	build         *builddao.DAO
 	targetDAO     *targetdao.DAO
 	deploymentDAO *deploymentdao.DAO
+	driftDAO      *driftdao.DAO
 	dbService     *services.DynamoDBService
 	orchestrator  *orchestrator.Orchestrator
+	promoter      *promotion.Promoter
 	appConfig     *services.Config
+	stackEvents   *stackevents.Streamer
+	rbac          *authz.RBAC
+	auditDAO      *auditdao.DAO
+	audit         *audit.Recorder
 }
 
 // NewResolver creates a new root resolver with the required dependencies
@@ -42,9 +60,15 @@ func NewResolver(config Config) *Resolver {
 		build:         config.Build,
 		targetDAO:     config.TargetDAO,
 		deploymentDAO: config.DeploymentDAO,
+		driftDAO:      config.DriftDAO,
 		dbService:     config.DbService,
 		orchestrator:  config.Orchestrator,
+		promoter:      config.Promoter,
 		appConfig:     config.AppConfig,
+		stackEvents:   config.StackEvents,
+		rbac:          config.RBAC,
+		auditDAO:      config.AuditDAO,
+		audit:         config.Audit,
 	}
 }
 
@@ -0,0 +1,114 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/stackevents"
+)
+
+// StackEvents resolves the stackEvents subscription - streams CloudFormation stack events
+// of a build's stack (or StackSet instances) until the build finishes
+func (r *Resolver) StackEvents(ctx context.Context, args struct {
+	BuildId string
+	After   *DateTime
+}) (<-chan *StackEventResolver, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Str("buildId", args.BuildId).Msg("StackEvents subscription started")
+
+	var after time.Time
+	if args.After != nil {
+		after = args.After.Time
+	}
+
+	events, err := r.stackEvents.Subscribe(ctx, builddao.ID(args.BuildId), after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to stack events: %w", err)
+	}
+
+	ch := make(chan *StackEventResolver)
+	go func() {
+		defer close(ch)
+		for event := range events {
+			select {
+			case ch <- &StackEventResolver{event: event}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// StackEventResolver resolves the StackEvent GraphQL type
+type StackEventResolver struct {
+	event stackevents.Event
+}
+
+// ID resolves the id field
+func (r *StackEventResolver) ID() graphql.ID {
+	return graphql.ID(r.event.ID)
+}
+
+// StackName resolves the stackName field
+func (r *StackEventResolver) StackName() string {
+	return r.event.StackName
+}
+
+// AccountId resolves the accountId field
+func (r *StackEventResolver) AccountId() *string {
+	if r.event.Account == "" {
+		return nil
+	}
+	return &r.event.Account
+}
+
+// Region resolves the region field
+func (r *StackEventResolver) Region() *string {
+	if r.event.Region == "" {
+		return nil
+	}
+	return &r.event.Region
+}
+
+// LogicalResourceId resolves the logicalResourceId field
+func (r *StackEventResolver) LogicalResourceId() string {
+	return r.event.LogicalResourceID
+}
+
+// PhysicalResourceId resolves the physicalResourceId field
+func (r *StackEventResolver) PhysicalResourceId() *string {
+	if r.event.PhysicalResourceID == "" {
+		return nil
+	}
+	return &r.event.PhysicalResourceID
+}
+
+// ResourceType resolves the resourceType field
+func (r *StackEventResolver) ResourceType() string {
+	return r.event.ResourceType
+}
+
+// ResourceStatus resolves the resourceStatus field
+func (r *StackEventResolver) ResourceStatus() string {
+	return r.event.ResourceStatus
+}
+
+// ResourceStatusReason resolves the resourceStatusReason field
+func (r *StackEventResolver) ResourceStatusReason() *string {
+	if r.event.ResourceStatusReason == "" {
+		return nil
+	}
+	return &r.event.ResourceStatusReason
+}
+
+// Timestamp resolves the timestamp field
+func (r *StackEventResolver) Timestamp() DateTime {
+	return NewDateTime(r.event.Timestamp)
+}
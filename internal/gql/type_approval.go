@@ -0,0 +1,130 @@
+package gql
+
+import (
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+)
+
+// ApprovalResolver resolves the Approval GraphQL type
+type ApprovalResolver struct {
+	approval builddao.Approval
+}
+
+// newApprovalResolver creates a new ApprovalResolver
+func newApprovalResolver(approval builddao.Approval) *ApprovalResolver {
+	return &ApprovalResolver{
+		approval: approval,
+	}
+}
+
+// Email resolves the email field
+func (r *ApprovalResolver) Email() string {
+	return r.approval.Email
+}
+
+// Name resolves the name field
+func (r *ApprovalResolver) Name() *string {
+	if r.approval.Name == "" {
+		return nil
+	}
+	return &r.approval.Name
+}
+
+// Decision resolves the decision field
+func (r *ApprovalResolver) Decision() string {
+	return string(r.approval.Decision)
+}
+
+// Comment resolves the comment field
+func (r *ApprovalResolver) Comment() *string {
+	if r.approval.Comment == "" {
+		return nil
+	}
+	return &r.approval.Comment
+}
+
+// CreatedAt resolves the createdAt field
+func (r *ApprovalResolver) CreatedAt() DateTime {
+	return NewDateTimeFromUnix(r.approval.CreatedAt)
+}
+
+// FreezeOverrideResolver resolves the FreezeOverride GraphQL type
+type FreezeOverrideResolver struct {
+	override builddao.FreezeOverride
+}
+
+// Email resolves the email field
+func (r *FreezeOverrideResolver) Email() string {
+	return r.override.Email
+}
+
+// Name resolves the name field
+func (r *FreezeOverrideResolver) Name() *string {
+	if r.override.Name == "" {
+		return nil
+	}
+	return &r.override.Name
+}
+
+// Reason resolves the reason field
+func (r *FreezeOverrideResolver) Reason() string {
+	return r.override.Reason
+}
+
+// CreatedAt resolves the createdAt field
+func (r *FreezeOverrideResolver) CreatedAt() DateTime {
+	return NewDateTimeFromUnix(r.override.CreatedAt)
+}
+
+// ReplacementOverrideResolver resolves the ReplacementOverride GraphQL type
+type ReplacementOverrideResolver struct {
+	override builddao.ReplacementOverride
+}
+
+// Email resolves the email field
+func (r *ReplacementOverrideResolver) Email() string {
+	return r.override.Email
+}
+
+// Name resolves the name field
+func (r *ReplacementOverrideResolver) Name() *string {
+	if r.override.Name == "" {
+		return nil
+	}
+	return &r.override.Name
+}
+
+// Reason resolves the reason field
+func (r *ReplacementOverrideResolver) Reason() string {
+	return r.override.Reason
+}
+
+// CreatedAt resolves the createdAt field
+func (r *ReplacementOverrideResolver) CreatedAt() DateTime {
+	return NewDateTimeFromUnix(r.override.CreatedAt)
+}
+
+// ApprovalPolicyResolver resolves the ApprovalPolicy GraphQL type
+type ApprovalPolicyResolver struct {
+	policy targetdao.ApprovalPolicy
+}
+
+// newApprovalPolicyResolver creates a new ApprovalPolicyResolver
+func newApprovalPolicyResolver(policy targetdao.ApprovalPolicy) *ApprovalPolicyResolver {
+	return &ApprovalPolicyResolver{
+		policy: policy,
+	}
+}
+
+// RequiredApprovals resolves the requiredApprovals field
+func (r *ApprovalPolicyResolver) RequiredApprovals() int32 {
+	return int32(r.policy.RequiredApprovals)
+}
+
+// Approvers resolves the approvers field
+func (r *ApprovalPolicyResolver) Approvers() []string {
+	if r.policy.Approvers == nil {
+		return []string{}
+	}
+	return r.policy.Approvers
+}
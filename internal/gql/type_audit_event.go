@@ -0,0 +1,79 @@
+package gql
+
+import (
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+)
+
+// AuditEventResolver resolves the AuditEvent GraphQL type
+type AuditEventResolver struct {
+	record auditdao.Record
+}
+
+// ID resolves the id field
+func (r *AuditEventResolver) ID() graphql.ID {
+	return graphql.ID(r.record.GetID())
+}
+
+// ActorType resolves the actorType field
+func (r *AuditEventResolver) ActorType() string {
+	return string(r.record.ActorType)
+}
+
+// Actor resolves the actor field
+func (r *AuditEventResolver) Actor() string {
+	return r.record.Actor
+}
+
+// Action resolves the action field
+func (r *AuditEventResolver) Action() string {
+	return r.record.Action
+}
+
+// Repo resolves the repo field
+func (r *AuditEventResolver) Repo() *string {
+	return optionalString(r.record.Repo)
+}
+
+// Env resolves the env field
+func (r *AuditEventResolver) Env() *string {
+	return optionalString(r.record.Env)
+}
+
+// Target resolves the target field
+func (r *AuditEventResolver) Target() *string {
+	return optionalString(r.record.Target)
+}
+
+// Before resolves the before field
+func (r *AuditEventResolver) Before() *string {
+	return optionalString(r.record.Before)
+}
+
+// After resolves the after field
+func (r *AuditEventResolver) After() *string {
+	return optionalString(r.record.After)
+}
+
+// Source resolves the source field
+func (r *AuditEventResolver) Source() string {
+	return r.record.Source
+}
+
+// RequestId resolves the requestId field
+func (r *AuditEventResolver) RequestId() *string {
+	return optionalString(r.record.RequestID)
+}
+
+// CreatedAt resolves the createdAt field
+func (r *AuditEventResolver) CreatedAt() DateTime {
+	return NewDateTimeFromUnix(r.record.CreatedAt)
+}
+
+// optionalString returns nil for an empty string
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -2,11 +2,16 @@ package gql
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/rs/zerolog"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
 	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/aws-deployer/internal/orchestrator"
 )
 
 // BuildResolver resolves the Build GraphQL type
@@ -14,15 +19,19 @@ type BuildResolver struct {
 	build         builddao.Record
 	targetDAO     *targetdao.DAO
 	deploymentDAO *deploymentdao.DAO
+	driftDAO      *driftdao.DAO
+	orchestrator  *orchestrator.Orchestrator
 	ctx           context.Context
 }
 
 // newBuildResolver creates a new BuildResolver
-func newBuildResolver(build builddao.Record, targetDAO *targetdao.DAO, deploymentDAO *deploymentdao.DAO, ctx context.Context) *BuildResolver {
+func newBuildResolver(build builddao.Record, targetDAO *targetdao.DAO, deploymentDAO *deploymentdao.DAO, driftDAO *driftdao.DAO, orchestrator *orchestrator.Orchestrator, ctx context.Context) *BuildResolver {
 	return &BuildResolver{
 		build:         build,
 		targetDAO:     targetDAO,
 		deploymentDAO: deploymentDAO,
+		driftDAO:      driftDAO,
+		orchestrator:  orchestrator,
 		ctx:           ctx,
 	}
 }
@@ -114,6 +123,78 @@ func (r *BuildResolver) DownstreamEnvs() ([]string, error) {
 	return targets.DownstreamEnv, nil
 }
 
+// ChangeSet resolves the changeSet field
+func (r *BuildResolver) ChangeSet() *ChangeSetResolver {
+	if r.build.ChangeSet == nil {
+		return nil
+	}
+	return newChangeSetResolver(*r.build.ChangeSet)
+}
+
+// PromotedBy resolves the promotedBy field
+func (r *BuildResolver) PromotedBy() *string {
+	if r.build.PromotedBy == "" {
+		return nil
+	}
+	return &r.build.PromotedBy
+}
+
+// CommitURL resolves the commitUrl field
+func (r *BuildResolver) CommitURL() *string {
+	if r.build.GitHubRepo == "" || r.build.CommitHash == "" {
+		return nil
+	}
+	url := fmt.Sprintf("https://github.com/%s/commit/%s", r.build.GitHubRepo, r.build.CommitHash)
+	return &url
+}
+
+// GithubDeploymentID resolves the githubDeploymentId field
+func (r *BuildResolver) GithubDeploymentID() *string {
+	if r.build.GitHubDeployment == 0 {
+		return nil
+	}
+	id := strconv.FormatInt(r.build.GitHubDeployment, 10)
+	return &id
+}
+
+// RequiredApprovals resolves the requiredApprovals field
+func (r *BuildResolver) RequiredApprovals() int32 {
+	return int32(r.build.RequiredApprovals)
+}
+
+// Approvals resolves the approvals field
+func (r *BuildResolver) Approvals() []*ApprovalResolver {
+	resolvers := make([]*ApprovalResolver, len(r.build.Approvals))
+	for i, approval := range r.build.Approvals {
+		resolvers[i] = newApprovalResolver(approval)
+	}
+	return resolvers
+}
+
+// BlockedReason resolves the blockedReason field
+func (r *BuildResolver) BlockedReason() *string {
+	if r.build.BlockedReason == "" {
+		return nil
+	}
+	return &r.build.BlockedReason
+}
+
+// FreezeOverride resolves the freezeOverride field
+func (r *BuildResolver) FreezeOverride() *FreezeOverrideResolver {
+	if r.build.FreezeOverride == nil {
+		return nil
+	}
+	return &FreezeOverrideResolver{override: *r.build.FreezeOverride}
+}
+
+// ReplacementOverride resolves the replacementOverride field
+func (r *BuildResolver) ReplacementOverride() *ReplacementOverrideResolver {
+	if r.build.ReplacementOverride == nil {
+		return nil
+	}
+	return &ReplacementOverrideResolver{override: *r.build.ReplacementOverride}
+}
+
 // DeploymentErrors resolves the deploymentErrors field by fetching failed deployments
 func (r *BuildResolver) DeploymentErrors() ([]*DeploymentErrorResolver, error) {
 	// Query all deployments for this build
@@ -137,6 +218,41 @@ func (r *BuildResolver) DeploymentErrors() ([]*DeploymentErrorResolver, error) {
 	return resolvers, nil
 }
 
+// Drift resolves the drift field with the latest drift results of the build's environment
+func (r *BuildResolver) Drift() []*StackDriftResolver {
+	return queryDrift(r.ctx, r.driftDAO, r.build.Repo, r.build.Env)
+}
+
+// Outputs resolves the outputs field
+func (r *BuildResolver) Outputs() []*StackOutputResolver {
+	return newStackOutputResolvers(r.build.Outputs)
+}
+
+// InstanceOutputs resolves the instanceOutputs field from the deployments of this build
+func (r *BuildResolver) InstanceOutputs() []*StackInstanceOutputsResolver {
+	if r.deploymentDAO == nil {
+		return []*StackInstanceOutputsResolver{}
+	}
+
+	deployments, err := r.deploymentDAO.QueryByBuild(r.ctx, r.build.Env, r.build.Repo, r.build.SK)
+	if err != nil {
+		// On error, return empty array rather than failing the whole query
+		zerolog.Ctx(r.ctx).Warn().
+			Err(err).
+			Str("build_id", string(r.build.GetID())).
+			Msg("Failed to load stack instance outputs")
+		return []*StackInstanceOutputsResolver{}
+	}
+
+	resolvers := []*StackInstanceOutputsResolver{}
+	for _, deployment := range deployments {
+		if len(deployment.Outputs) > 0 {
+			resolvers = append(resolvers, &StackInstanceOutputsResolver{deployment: deployment})
+		}
+	}
+	return resolvers
+}
+
 // DeploymentErrorResolver resolves the DeploymentError GraphQL type
 type DeploymentErrorResolver struct {
 	deployment deploymentdao.Record
@@ -176,3 +292,159 @@ func (r *DeploymentErrorResolver) StackEvents() []string {
 	}
 	return r.deployment.StackEvents
 }
+
+// PromotedFrom resolves the promotedFrom field
+func (r *BuildResolver) PromotedFrom() *graphql.ID {
+	if r.build.PromotedFrom == "" {
+		return nil
+	}
+	id := graphql.ID(r.build.PromotedFrom)
+	return &id
+}
+
+// StartAfter resolves the startAfter field
+func (r *BuildResolver) StartAfter() *DateTime {
+	if r.build.StartAfter == 0 {
+		return nil
+	}
+	return NewDateTimePtrFromUnix(&r.build.StartAfter)
+}
+
+// IsRollback resolves the isRollback field
+func (r *BuildResolver) IsRollback() bool {
+	return r.build.IsRollback()
+}
+
+// RollbackOf resolves the rollbackOf field
+func (r *BuildResolver) RollbackOf() *graphql.ID {
+	if r.build.RollbackOf == "" {
+		return nil
+	}
+	id := graphql.ID(r.build.RollbackOf)
+	return &id
+}
+
+// PolicyViolations resolves the policyViolations field
+func (r *BuildResolver) PolicyViolations() []*PolicyViolationResolver {
+	resolvers := make([]*PolicyViolationResolver, len(r.build.PolicyViolations))
+	for i, violation := range r.build.PolicyViolations {
+		resolvers[i] = &PolicyViolationResolver{violation: violation}
+	}
+	return resolvers
+}
+
+// PolicyViolationResolver resolves the PolicyViolation GraphQL type
+type PolicyViolationResolver struct {
+	violation builddao.PolicyViolation
+}
+
+// Policy resolves the policy field
+func (r *PolicyViolationResolver) Policy() string {
+	return r.violation.Policy
+}
+
+// Mode resolves the mode field
+func (r *PolicyViolationResolver) Mode() string {
+	return r.violation.Mode
+}
+
+// Message resolves the message field
+func (r *PolicyViolationResolver) Message() string {
+	return r.violation.Message
+}
+
+// Timeline resolves the timeline field from the Step Functions execution history
+func (r *BuildResolver) Timeline() ([]*TimelineStepResolver, error) {
+	if r.build.ExecutionArn == nil || *r.build.ExecutionArn == "" || r.orchestrator == nil {
+		return []*TimelineStepResolver{}, nil
+	}
+
+	steps, err := r.orchestrator.Timeline(r.ctx, *r.build.ExecutionArn)
+	if err != nil {
+		// On error, return empty array rather than failing the whole query
+		zerolog.Ctx(r.ctx).Warn().
+			Err(err).
+			Str("executionArn", *r.build.ExecutionArn).
+			Msg("Failed to load build timeline")
+		return []*TimelineStepResolver{}, nil
+	}
+
+	resolvers := make([]*TimelineStepResolver, len(steps))
+	for i, step := range steps {
+		resolvers[i] = &TimelineStepResolver{step: step}
+	}
+	return resolvers, nil
+}
+
+// TimelineStepResolver resolves the TimelineStep GraphQL type
+type TimelineStepResolver struct {
+	step orchestrator.Step
+}
+
+// Name resolves the name field
+func (r *TimelineStepResolver) Name() string {
+	return r.step.Name
+}
+
+// Type resolves the type field
+func (r *TimelineStepResolver) Type() string {
+	return r.step.Type
+}
+
+// Parent resolves the parent field
+func (r *TimelineStepResolver) Parent() *string {
+	if r.step.Parent == "" {
+		return nil
+	}
+	return &r.step.Parent
+}
+
+// Iteration resolves the iteration field
+func (r *TimelineStepResolver) Iteration() *int32 {
+	return r.step.Iteration
+}
+
+// Status resolves the status field
+func (r *TimelineStepResolver) Status() string {
+	return string(r.step.Status)
+}
+
+// EnteredAt resolves the enteredAt field
+func (r *TimelineStepResolver) EnteredAt() DateTime {
+	return NewDateTime(r.step.EnteredAt)
+}
+
+// ExitedAt resolves the exitedAt field
+func (r *TimelineStepResolver) ExitedAt() *DateTime {
+	return NewDateTimePtr(r.step.ExitedAt)
+}
+
+// DurationSeconds resolves the durationSeconds field
+func (r *TimelineStepResolver) DurationSeconds() *float64 {
+	if r.step.ExitedAt == nil {
+		return nil
+	}
+	seconds := r.step.Duration().Seconds()
+	return &seconds
+}
+
+// Retries resolves the retries field
+func (r *TimelineStepResolver) Retries() int32 {
+	return int32(r.step.Retries())
+}
+
+// Error resolves the error field
+func (r *TimelineStepResolver) Error() *string {
+	if r.step.Error == "" {
+		return nil
+	}
+	return &r.step.Error
+}
+
+// Cause resolves the cause field
+func (r *TimelineStepResolver) Cause() *string {
+	if r.step.Cause == "" {
+		return nil
+	}
+	return &r.step.Cause
+}
@@ -0,0 +1,134 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+const maxBuildPageSize = 100
+
+// BuildFilterInput is the BuildFilter input type
+type BuildFilterInput struct {
+	Status       *[]BuildStatus
+	Branch       *string
+	CommitPrefix *string
+	Version      *string
+	Since        *DateTime
+	Until        *DateTime
+}
+
+// toPageInput converts the first, after and filter arguments of a build connection
+func toPageInput(first *int32, after *string, filter *BuildFilterInput) (builddao.PageInput, error) {
+	input := builddao.PageInput{
+		Limit: builddao.DefaultPageSize,
+		After: stringValue(after),
+	}
+	if first != nil {
+		input.Limit = int(*first)
+	}
+	if input.Limit < 1 || input.Limit > maxBuildPageSize {
+		return builddao.PageInput{}, fmt.Errorf("first must be between 1 and %d", maxBuildPageSize)
+	}
+
+	if f := filter; f != nil {
+		if f.Status != nil {
+			for _, status := range *f.Status {
+				input.Filter.Statuses = append(input.Filter.Statuses, status.ToModelBuildStatus())
+			}
+		}
+		input.Filter.Branch = stringValue(f.Branch)
+		input.Filter.CommitPrefix = stringValue(f.CommitPrefix)
+		input.Filter.Version = stringValue(f.Version)
+		if f.Since != nil {
+			input.Filter.Since = f.Since.Time
+		}
+		if f.Until != nil {
+			input.Filter.Until = f.Until.Time
+		}
+	}
+	return input, nil
+}
+
+// BuildConnectionResolver resolves the BuildConnection GraphQL type
+type BuildConnectionResolver struct {
+	page  builddao.Page
+	edges []*BuildEdgeResolver
+}
+
+// newBuildConnection creates a BuildConnectionResolver for a page of builds
+func (r *Resolver) newBuildConnection(ctx context.Context, page builddao.Page) *BuildConnectionResolver {
+	edges := make([]*BuildEdgeResolver, len(page.Records))
+	for i, record := range page.Records {
+		edges[i] = &BuildEdgeResolver{
+			cursor: page.Cursors[i],
+			node:   newBuildResolver(record, r.targetDAO, r.deploymentDAO, r.driftDAO, r.orchestrator, ctx),
+		}
+	}
+	return &BuildConnectionResolver{
+		page:  page,
+		edges: edges,
+	}
+}
+
+// Edges resolves the edges field
+func (r *BuildConnectionResolver) Edges() []*BuildEdgeResolver {
+	return r.edges
+}
+
+// PageInfo resolves the pageInfo field
+func (r *BuildConnectionResolver) PageInfo() *PageInfoResolver {
+	var startCursor string
+	if len(r.page.Cursors) > 0 {
+		startCursor = r.page.Cursors[0]
+	}
+	return &PageInfoResolver{
+		hasNextPage: r.page.HasMore,
+		startCursor: startCursor,
+		endCursor:   r.page.EndCursor(),
+	}
+}
+
+// BuildEdgeResolver resolves the BuildEdge GraphQL type
+type BuildEdgeResolver struct {
+	cursor string
+	node   *BuildResolver
+}
+
+// Cursor resolves the cursor field
+func (r *BuildEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+// Node resolves the node field
+func (r *BuildEdgeResolver) Node() *BuildResolver {
+	return r.node
+}
+
+// PageInfoResolver resolves the PageInfo GraphQL type
+type PageInfoResolver struct {
+	hasNextPage bool
+	startCursor string
+	endCursor   string
+}
+
+// HasNextPage resolves the hasNextPage field
+func (r *PageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+// HasPreviousPage resolves the hasPreviousPage field; connections only page forward
+func (r *PageInfoResolver) HasPreviousPage() bool {
+	return false
+}
+
+// StartCursor resolves the startCursor field
+func (r *PageInfoResolver) StartCursor() *string {
+	return optionalString(r.startCursor)
+}
+
+// EndCursor resolves the endCursor field
+func (r *PageInfoResolver) EndCursor() *string {
+	return optionalString(r.endCursor)
+}
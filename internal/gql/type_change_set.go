@@ -0,0 +1,79 @@
+package gql
+
+import (
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// ChangeSetResolver resolves the ChangeSet GraphQL type
+type ChangeSetResolver struct {
+	changeSet builddao.ChangeSet
+}
+
+// newChangeSetResolver creates a new ChangeSetResolver
+func newChangeSetResolver(changeSet builddao.ChangeSet) *ChangeSetResolver {
+	return &ChangeSetResolver{
+		changeSet: changeSet,
+	}
+}
+
+// Name resolves the name field
+func (r *ChangeSetResolver) Name() string {
+	return r.changeSet.Name
+}
+
+// Type resolves the type field
+func (r *ChangeSetResolver) Type() string {
+	return r.changeSet.Type
+}
+
+// Changes resolves the changes field
+func (r *ChangeSetResolver) Changes() []*ResourceChangeResolver {
+	resolvers := make([]*ResourceChangeResolver, len(r.changeSet.Changes))
+	for i, change := range r.changeSet.Changes {
+		resolvers[i] = newResourceChangeResolver(change)
+	}
+	return resolvers
+}
+
+// ResourceChangeResolver resolves the ResourceChange GraphQL type
+type ResourceChangeResolver struct {
+	change builddao.ResourceChange
+}
+
+// newResourceChangeResolver creates a new ResourceChangeResolver
+func newResourceChangeResolver(change builddao.ResourceChange) *ResourceChangeResolver {
+	return &ResourceChangeResolver{
+		change: change,
+	}
+}
+
+// Action resolves the action field
+func (r *ResourceChangeResolver) Action() string {
+	return string(r.change.Action)
+}
+
+// LogicalResourceId resolves the logicalResourceId field
+func (r *ResourceChangeResolver) LogicalResourceId() string {
+	return r.change.LogicalResourceID
+}
+
+// PhysicalResourceId resolves the physicalResourceId field
+func (r *ResourceChangeResolver) PhysicalResourceId() *string {
+	if r.change.PhysicalResourceID == "" {
+		return nil
+	}
+	return &r.change.PhysicalResourceID
+}
+
+// ResourceType resolves the resourceType field
+func (r *ResourceChangeResolver) ResourceType() string {
+	return r.change.ResourceType
+}
+
+// Replacement resolves the replacement field
+func (r *ResourceChangeResolver) Replacement() *string {
+	if r.change.Replacement == "" {
+		return nil
+	}
+	return &r.change.Replacement
+}
@@ -0,0 +1,147 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+)
+
+// queryDrift returns resolvers for the latest drift results of repo/env
+func queryDrift(ctx context.Context, driftDAO *driftdao.DAO, repo, env string) []*StackDriftResolver {
+	if driftDAO == nil {
+		return []*StackDriftResolver{}
+	}
+
+	records, err := driftDAO.Query(ctx, repo, env)
+	if err != nil {
+		// On error, return empty array rather than failing the whole query
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("repo", repo).
+			Str("env", env).
+			Msg("Failed to load drift results")
+		return []*StackDriftResolver{}
+	}
+
+	resolvers := make([]*StackDriftResolver, len(records))
+	for i, record := range records {
+		resolvers[i] = &StackDriftResolver{record: record}
+	}
+	return resolvers
+}
+
+// StackDriftResolver resolves the StackDrift GraphQL type
+type StackDriftResolver struct {
+	record driftdao.Record
+}
+
+// StackName resolves the stackName field
+func (r *StackDriftResolver) StackName() string {
+	return r.record.StackName
+}
+
+// AccountId resolves the accountId field
+func (r *StackDriftResolver) AccountId() string {
+	return r.record.AccountID
+}
+
+// Region resolves the region field
+func (r *StackDriftResolver) Region() string {
+	return r.record.Region
+}
+
+// Status resolves the status field
+func (r *StackDriftResolver) Status() string {
+	return string(r.record.Status)
+}
+
+// Resources resolves the resources field
+func (r *StackDriftResolver) Resources() []*ResourceDriftResolver {
+	resolvers := make([]*ResourceDriftResolver, len(r.record.Resources))
+	for i, resource := range r.record.Resources {
+		resolvers[i] = &ResourceDriftResolver{resource: resource}
+	}
+	return resolvers
+}
+
+// Error resolves the error field
+func (r *StackDriftResolver) Error() *string {
+	if r.record.ErrorMsg == "" {
+		return nil
+	}
+	return &r.record.ErrorMsg
+}
+
+// CheckedAt resolves the checkedAt field
+func (r *StackDriftResolver) CheckedAt() DateTime {
+	return NewDateTimeFromUnix(r.record.CheckedAt)
+}
+
+// ResourceDriftResolver resolves the ResourceDrift GraphQL type
+type ResourceDriftResolver struct {
+	resource driftdao.ResourceDrift
+}
+
+// LogicalResourceId resolves the logicalResourceId field
+func (r *ResourceDriftResolver) LogicalResourceId() string {
+	return r.resource.LogicalResourceID
+}
+
+// PhysicalResourceId resolves the physicalResourceId field
+func (r *ResourceDriftResolver) PhysicalResourceId() *string {
+	if r.resource.PhysicalResourceID == "" {
+		return nil
+	}
+	return &r.resource.PhysicalResourceID
+}
+
+// ResourceType resolves the resourceType field
+func (r *ResourceDriftResolver) ResourceType() string {
+	return r.resource.ResourceType
+}
+
+// Status resolves the status field
+func (r *ResourceDriftResolver) Status() string {
+	return r.resource.Status
+}
+
+// Differences resolves the differences field
+func (r *ResourceDriftResolver) Differences() []*PropertyDifferenceResolver {
+	resolvers := make([]*PropertyDifferenceResolver, len(r.resource.Differences))
+	for i, diff := range r.resource.Differences {
+		resolvers[i] = &PropertyDifferenceResolver{diff: diff}
+	}
+	return resolvers
+}
+
+// PropertyDifferenceResolver resolves the PropertyDifference GraphQL type
+type PropertyDifferenceResolver struct {
+	diff driftdao.PropertyDifference
+}
+
+// PropertyPath resolves the propertyPath field
+func (r *PropertyDifferenceResolver) PropertyPath() string {
+	return r.diff.PropertyPath
+}
+
+// ExpectedValue resolves the expectedValue field
+func (r *PropertyDifferenceResolver) ExpectedValue() *string {
+	if r.diff.ExpectedValue == "" {
+		return nil
+	}
+	return &r.diff.ExpectedValue
+}
+
+// ActualValue resolves the actualValue field
+func (r *PropertyDifferenceResolver) ActualValue() *string {
+	if r.diff.ActualValue == "" {
+		return nil
+	}
+	return &r.diff.ActualValue
+}
+
+// DifferenceType resolves the differenceType field
+func (r *PropertyDifferenceResolver) DifferenceType() string {
+	return r.diff.DifferenceType
+}
@@ -0,0 +1,55 @@
+package gql
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+)
+
+// newStackOutputResolvers returns resolvers for stack outputs, sorted by key
+func newStackOutputResolvers(outputs map[string]string) []*StackOutputResolver {
+	resolvers := make([]*StackOutputResolver, 0, len(outputs))
+	for _, key := range slices.Sorted(maps.Keys(outputs)) {
+		resolvers = append(resolvers, &StackOutputResolver{key: key, value: outputs[key]})
+	}
+	return resolvers
+}
+
+// StackOutputResolver resolves the StackOutput GraphQL type
+type StackOutputResolver struct {
+	key   string
+	value string
+}
+
+// Key resolves the key field
+func (r *StackOutputResolver) Key() string {
+	return r.key
+}
+
+// Value resolves the value field
+func (r *StackOutputResolver) Value() string {
+	return r.value
+}
+
+// StackInstanceOutputsResolver resolves the StackInstanceOutputs GraphQL type
+type StackInstanceOutputsResolver struct {
+	deployment deploymentdao.Record
+}
+
+// AccountId resolves the accountId field
+func (r *StackInstanceOutputsResolver) AccountId() string {
+	account, _, _ := deploymentdao.ParseSK(r.deployment.SK)
+	return account
+}
+
+// Region resolves the region field
+func (r *StackInstanceOutputsResolver) Region() string {
+	_, region, _ := deploymentdao.ParseSK(r.deployment.SK)
+	return region
+}
+
+// Outputs resolves the outputs field
+func (r *StackInstanceOutputsResolver) Outputs() []*StackOutputResolver {
+	return newStackOutputResolvers(r.deployment.Outputs)
+}
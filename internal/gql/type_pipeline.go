@@ -1,6 +1,9 @@
 package gql
 
 import (
+	"context"
+
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
 	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 )
 
@@ -28,13 +31,15 @@ func (r *TargetResolver) Regions() []string {
 
 // DeploymentTargetsResolver resolves the DeploymentTargets GraphQL type
 type DeploymentTargetsResolver struct {
-	record *targetdao.Record
+	record   *targetdao.Record
+	driftDAO *driftdao.DAO
 }
 
 // newDeploymentTargetsResolver creates a new DeploymentTargetsResolver
-func newDeploymentTargetsResolver(record *targetdao.Record) *DeploymentTargetsResolver {
+func newDeploymentTargetsResolver(record *targetdao.Record, driftDAO *driftdao.DAO) *DeploymentTargetsResolver {
 	return &DeploymentTargetsResolver{
-		record: record,
+		record:   record,
+		driftDAO: driftDAO,
 	}
 }
 
@@ -65,19 +70,84 @@ func (r *DeploymentTargetsResolver) DownstreamEnvs() []string {
 	return r.record.DownstreamEnv
 }
 
+// ApprovalPolicy resolves the approvalPolicy field
+func (r *DeploymentTargetsResolver) ApprovalPolicy() *ApprovalPolicyResolver {
+	if !r.record.Approval.Required() {
+		return nil
+	}
+	return newApprovalPolicyResolver(*r.record.Approval)
+}
+
+// AutoPromote resolves the autoPromote field
+func (r *DeploymentTargetsResolver) AutoPromote() bool {
+	return r.record.AutoPromote
+}
+
+// SoakSeconds resolves the soakSeconds field
+func (r *DeploymentTargetsResolver) SoakSeconds() int32 {
+	return int32(r.record.SoakSeconds)
+}
+
+// AutoRollback resolves the autoRollback field
+func (r *DeploymentTargetsResolver) AutoRollback() bool {
+	return r.record.AutoRollback
+}
+
+// BlockOnDrift resolves the blockOnDrift field
+func (r *DeploymentTargetsResolver) BlockOnDrift() bool {
+	return r.record.BlockOnDrift
+}
+
+// Protection resolves the protection field
+func (r *DeploymentTargetsResolver) Protection() *StackProtectionResolver {
+	if r.record.Protection.IsEmpty() {
+		return nil
+	}
+	return &StackProtectionResolver{protection: *r.record.Protection}
+}
+
+// StackProtectionResolver resolves the StackProtection GraphQL type
+type StackProtectionResolver struct {
+	protection targetdao.Protection
+}
+
+// TerminationProtection resolves the terminationProtection field
+func (r *StackProtectionResolver) TerminationProtection() bool {
+	return r.protection.TerminationProtection
+}
+
+// ProtectedTypes resolves the protectedTypes field
+func (r *StackProtectionResolver) ProtectedTypes() []string {
+	types := r.protection.Types()
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
+
+// Drift resolves the drift field with the latest drift results of the environment
+func (r *DeploymentTargetsResolver) Drift(ctx context.Context) []*StackDriftResolver {
+	if r.record.PK == targetdao.DefaultRepo {
+		return []*StackDriftResolver{}
+	}
+	return queryDrift(ctx, r.driftDAO, r.record.PK.String(), r.record.SK)
+}
+
 // PipelineConfigResolver resolves the PipelineConfig GraphQL type
 type PipelineConfigResolver struct {
 	repo         string
 	initialEnv   string
 	environments []*targetdao.Record
+	driftDAO     *driftdao.DAO
 }
 
 // newPipelineConfigResolver creates a new PipelineConfigResolver
-func newPipelineConfigResolver(repo, initialEnv string, environments []*targetdao.Record) *PipelineConfigResolver {
+func newPipelineConfigResolver(repo, initialEnv string, environments []*targetdao.Record, driftDAO *driftdao.DAO) *PipelineConfigResolver {
 	return &PipelineConfigResolver{
 		repo:         repo,
 		initialEnv:   initialEnv,
 		environments: environments,
+		driftDAO:     driftDAO,
 	}
 }
 
@@ -95,7 +165,7 @@ func (r *PipelineConfigResolver) InitialEnv() string {
 func (r *PipelineConfigResolver) Environments() []*DeploymentTargetsResolver {
 	resolvers := make([]*DeploymentTargetsResolver, len(r.environments))
 	for i, env := range r.environments {
-		resolvers[i] = newDeploymentTargetsResolver(env)
+		resolvers[i] = newDeploymentTargetsResolver(env, r.driftDAO)
 	}
 	return resolvers
 }
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// pollInterval is how often drift detection progress is checked
+	pollInterval = 10 * time.Second
+
+	// deadlineMargin is the time left before the lambda deadline to stop waiting for detections
+	deadlineMargin = 30 * time.Second
+)
+
+// driftedStatuses are the resource drift statuses stored in the drift table
+var driftedStatuses = []types.StackResourceDriftStatus{
+	types.StackResourceDriftStatusModified,
+	types.StackResourceDriftStatusDeleted,
+}
+
+type Handler struct {
+	cfClient     *cloudformation.Client
+	buildDAO     *builddao.DAO
+	targetDAO    *targetdao.DAO
+	driftDAO     *driftdao.DAO
+	multiAccount bool
+}
+
+// detection is a drift detection started on a stack or StackSet
+type detection struct {
+	build builddao.Record
+	id    string // drift detection ID or StackSet operation ID
+}
+
+func NewHandler(env string) (*Handler, error) {
+	ctx := context.TODO()
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// Create Parameter Store service based on DISABLE_SSM flag
+	var paramStore services.ParameterStore
+	if os.Getenv("DISABLE_SSM") == "true" {
+		paramStore = services.NewEnvParameterStore(env)
+	} else {
+		ssmClient := di.ProvideSSMClient(cfg)
+		paramStore = services.NewSSMParameterStore(ssmClient, env)
+	}
+
+	appConfig, err := paramStore.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	dynamoClient := dynamodb.NewFromConfig(cfg)
+
+	return &Handler{
+		cfClient:     cloudformation.NewFromConfig(cfg),
+		buildDAO:     builddao.New(dynamoClient, builddao.TableName(env)),
+		targetDAO:    targetdao.New(dynamoClient, targetdao.TableName(env)),
+		driftDAO:     driftdao.New(dynamoClient, driftdao.TableName(env)),
+		multiAccount: appConfig.DeploymentMode == "multi",
+	}, nil
+}
+
+// HandleDetectDrift starts drift detection on every stack the deployer manages, waits for the
+// detections to finish and stores the results. Stacks that cannot be checked are logged and
+// keep their previous results.
+func (h *Handler) HandleDetectDrift(ctx context.Context) error {
+	logger := zerolog.Ctx(ctx)
+
+	builds, err := h.managedStacks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Start every detection first so CloudFormation checks the stacks concurrently
+	var detections []detection
+	for _, build := range builds {
+		id, err := h.startDetection(ctx, build.StackName)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("repo", build.Repo).
+				Str("env", build.Env).
+				Str("stack_name", build.StackName).
+				Msg("Failed to start drift detection")
+			continue
+		}
+		if id == "" {
+			continue
+		}
+		detections = append(detections, detection{build: build, id: id})
+	}
+
+	logger.Info().
+		Int("stacks", len(builds)).
+		Int("detections", len(detections)).
+		Bool("multi_account", h.multiAccount).
+		Msg("Started drift detection")
+
+	var drifted int
+	for _, d := range detections {
+		records, err := h.collect(ctx, d)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("stack_name", d.build.StackName).
+				Str("detection_id", d.id).
+				Msg("Failed to collect drift results")
+			continue
+		}
+
+		if err := h.driftDAO.Replace(ctx, d.build.Repo, d.build.Env, records); err != nil {
+			return err
+		}
+
+		if driftdao.Drifted(records) {
+			drifted++
+			logger.Warn().
+				Str("repo", d.build.Repo).
+				Str("env", d.build.Env).
+				Str("stack_name", d.build.StackName).
+				Msg("Stack has drifted")
+		}
+	}
+
+	logger.Info().
+		Int("checked", len(detections)).
+		Int("drifted", drifted).
+		Msg("Drift detection complete")
+	return nil
+}
+
+// managedStacks returns the latest build of every repo in every environment. Stacks that are
+// being deployed are skipped since CloudFormation cannot detect drift during an update.
+func (h *Handler) managedStacks(ctx context.Context) ([]builddao.Record, error) {
+	envs, err := h.environments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var builds []builddao.Record
+	for _, env := range envs {
+		latest, err := h.buildDAO.QueryLatestBuilds(ctx, env)
+		if err != nil {
+			return nil, err
+		}
+		for _, build := range latest {
+			if build.Status == builddao.BuildStatusInProgress || build.StackName == "" {
+				continue
+			}
+			builds = append(builds, build)
+		}
+	}
+	return builds, nil
+}
+
+// environments returns the standard environments and any environment with targets configured
+func (h *Handler) environments(ctx context.Context) ([]string, error) {
+	envs := []string{"dev", "stg", "prd"}
+
+	records, err := h.targetDAO.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	for _, record := range records {
+		if record.SK == targetdao.ConfigEnv || contains(envs, record.SK) {
+			continue
+		}
+		envs = append(envs, record.SK)
+	}
+	return envs, nil
+}
+
+// startDetection starts drift detection on a stack, or on a StackSet in multi-account mode.
+// Returns an empty ID if the stack does not exist.
+func (h *Handler) startDetection(ctx context.Context, stackName string) (string, error) {
+	if h.multiAccount {
+		output, err := h.cfClient.DetectStackSetDrift(ctx, &cloudformation.DetectStackSetDriftInput{
+			StackSetName: aws.String(stackName),
+		})
+		if err != nil {
+			if isNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		return aws.ToString(output.OperationId), nil
+	}
+
+	output, err := h.cfClient.DetectStackDrift(ctx, &cloudformation.DetectStackDriftInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.ToString(output.StackDriftDetectionId), nil
+}
+
+// collect waits for a detection to finish and returns its results
+func (h *Handler) collect(ctx context.Context, d detection) ([]driftdao.Record, error) {
+	if h.multiAccount {
+		return h.collectStackSet(ctx, d)
+	}
+	record, err := h.collectStack(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	return []driftdao.Record{record}, nil
+}
+
+// collectStack waits for drift detection of a single stack and returns its drifted resources
+func (h *Handler) collectStack(ctx context.Context, d detection) (driftdao.Record, error) {
+	var status *cloudformation.DescribeStackDriftDetectionStatusOutput
+	err := wait(ctx, func() (bool, error) {
+		var err error
+		status, err = h.cfClient.DescribeStackDriftDetectionStatus(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: aws.String(d.id),
+		})
+		if err != nil {
+			return false, err
+		}
+		return status.DetectionStatus != types.StackDriftDetectionStatusDetectionInProgress, nil
+	})
+	if err != nil {
+		return driftdao.Record{}, err
+	}
+
+	region, account := parseStackID(aws.ToString(status.StackId))
+	record := driftdao.Record{
+		StackName:   d.build.StackName,
+		StackID:     aws.ToString(status.StackId),
+		AccountID:   account,
+		Region:      region,
+		Status:      stackStatus(status.DetectionStatus, status.StackDriftStatus),
+		DetectionID: d.id,
+	}
+	if record.Status == driftdao.StatusUnknown {
+		record.ErrorMsg = aws.ToString(status.DetectionStatusReason)
+	}
+	if record.Status != driftdao.StatusDrifted {
+		return record, nil
+	}
+
+	paginator := cloudformation.NewDescribeStackResourceDriftsPaginator(h.cfClient, &cloudformation.DescribeStackResourceDriftsInput{
+		StackName:                       aws.String(d.build.StackName),
+		StackResourceDriftStatusFilters: driftedStatuses,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return driftdao.Record{}, fmt.Errorf("failed to describe resource drifts: %w", err)
+		}
+		for _, drift := range page.StackResourceDrifts {
+			record.Resources = append(record.Resources, resourceDrift(
+				drift.LogicalResourceId,
+				drift.PhysicalResourceId,
+				drift.ResourceType,
+				drift.StackResourceDriftStatus,
+				drift.PropertyDifferences,
+			))
+		}
+	}
+
+	return record, nil
+}
+
+// collectStackSet waits for drift detection of a StackSet and returns the drift of each of
+// its instances
+func (h *Handler) collectStackSet(ctx context.Context, d detection) ([]driftdao.Record, error) {
+	stackSetName := d.build.StackName
+
+	var operation *types.StackSetOperation
+	err := wait(ctx, func() (bool, error) {
+		output, err := h.cfClient.DescribeStackSetOperation(ctx, &cloudformation.DescribeStackSetOperationInput{
+			StackSetName: aws.String(stackSetName),
+			OperationId:  aws.String(d.id),
+		})
+		if err != nil {
+			return false, err
+		}
+		operation = output.StackSetOperation
+		switch operation.Status {
+		case types.StackSetOperationStatusRunning, types.StackSetOperationStatusQueued, types.StackSetOperationStatusStopping:
+			return false, nil
+		default:
+			return true, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []driftdao.Record
+	paginator := cloudformation.NewListStackInstancesPaginator(h.cfClient, &cloudformation.ListStackInstancesInput{
+		StackSetName: aws.String(stackSetName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stack instances: %w", err)
+		}
+
+		for _, instance := range page.Summaries {
+			record := driftdao.Record{
+				StackName:   stackSetName,
+				StackID:     aws.ToString(instance.StackId),
+				AccountID:   aws.ToString(instance.Account),
+				Region:      aws.ToString(instance.Region),
+				Status:      instanceStatus(instance.DriftStatus),
+				DetectionID: d.id,
+			}
+			if record.Status == driftdao.StatusUnknown {
+				record.ErrorMsg = fmt.Sprintf("drift status %s (operation %s)", instance.DriftStatus, operation.Status)
+			}
+			if record.Status == driftdao.StatusDrifted {
+				record.Resources, err = h.instanceResourceDrifts(ctx, stackSetName, record.AccountID, record.Region, d.id)
+				if err != nil {
+					return nil, err
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// instanceResourceDrifts returns the drifted resources of a StackSet instance
+func (h *Handler) instanceResourceDrifts(ctx context.Context, stackSetName, account, region, operationID string) ([]driftdao.ResourceDrift, error) {
+	var resources []driftdao.ResourceDrift
+	var nextToken *string
+	for {
+		output, err := h.cfClient.ListStackInstanceResourceDrifts(ctx, &cloudformation.ListStackInstanceResourceDriftsInput{
+			StackSetName:                       aws.String(stackSetName),
+			StackInstanceAccount:               aws.String(account),
+			StackInstanceRegion:                aws.String(region),
+			OperationId:                        aws.String(operationID),
+			StackInstanceResourceDriftStatuses: driftedStatuses,
+			NextToken:                          nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stack instance resource drifts: %w", err)
+		}
+		for _, drift := range output.Summaries {
+			resources = append(resources, resourceDrift(
+				drift.LogicalResourceId,
+				drift.PhysicalResourceId,
+				drift.ResourceType,
+				drift.StackResourceDriftStatus,
+				drift.PropertyDifferences,
+			))
+		}
+		if output.NextToken == nil {
+			return resources, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+// wait polls done until it returns true, leaving deadlineMargin before the lambda deadline
+func wait(ctx context.Context, done func() (bool, error)) error {
+	deadline, ok := ctx.Deadline()
+	for {
+		finished, err := done()
+		if err != nil {
+			return err
+		}
+		if finished {
+			return nil
+		}
+		if ok && time.Until(deadline) < deadlineMargin+pollInterval {
+			return fmt.Errorf("drift detection did not finish before the lambda deadline")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// stackStatus returns the drift status of a single stack detection
+func stackStatus(detection types.StackDriftDetectionStatus, drift types.StackDriftStatus) driftdao.Status {
+	// A failed detection still reports drift on the resources it could check
+	if drift == types.StackDriftStatusDrifted {
+		return driftdao.StatusDrifted
+	}
+	if detection == types.StackDriftDetectionStatusDetectionComplete && drift == types.StackDriftStatusInSync {
+		return driftdao.StatusInSync
+	}
+	return driftdao.StatusUnknown
+}
+
+// instanceStatus returns the drift status of a StackSet instance
+func instanceStatus(drift types.StackDriftStatus) driftdao.Status {
+	switch drift {
+	case types.StackDriftStatusDrifted:
+		return driftdao.StatusDrifted
+	case types.StackDriftStatusInSync:
+		return driftdao.StatusInSync
+	default:
+		return driftdao.StatusUnknown
+	}
+}
+
+// resourceDrift converts a CloudFormation resource drift
+func resourceDrift(logicalID, physicalID, resourceType *string, status types.StackResourceDriftStatus, differences []types.PropertyDifference) driftdao.ResourceDrift {
+	drift := driftdao.ResourceDrift{
+		LogicalResourceID:  aws.ToString(logicalID),
+		PhysicalResourceID: aws.ToString(physicalID),
+		ResourceType:       aws.ToString(resourceType),
+		Status:             string(status),
+	}
+	for _, diff := range differences {
+		drift.Differences = append(drift.Differences, driftdao.PropertyDifference{
+			PropertyPath:   aws.ToString(diff.PropertyPath),
+			ExpectedValue:  aws.ToString(diff.ExpectedValue),
+			ActualValue:    aws.ToString(diff.ActualValue),
+			DifferenceType: string(diff.DifferenceType),
+		})
+	}
+	return drift
+}
+
+// parseStackID returns the region and account of a stack ARN
+// Example: arn:aws:cloudformation:us-east-1:123456789012:stack/dev-my-repo/guid
+func parseStackID(stackID string) (region, account string) {
+	parts := strings.Split(stackID, ":")
+	if len(parts) < 6 {
+		return "", ""
+	}
+	return parts[3], parts[4]
+}
+
+// isNotFound returns true if the stack or StackSet does not exist
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode() == "StackSetNotFoundException" || strings.Contains(apiErr.ErrorMessage(), "does not exist")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	logger := di.ProvideLogger().With().Str("lambda", "detect-drift").Logger()
+
+	// Get environment from ENV or ENVIRONMENT variable
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = os.Getenv("ENVIRONMENT")
+	}
+	if env == "" {
+		logger.Error().Msg("ENV or ENVIRONMENT variable is required")
+		os.Exit(1)
+	}
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		// Lambda mode
+		handler, err := NewHandler(env)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to create handler")
+			os.Exit(1)
+		}
+
+		// Wrap handler to inject logger into context
+		wrappedHandler := func(ctx context.Context) error {
+			ctx = logger.WithContext(ctx)
+			return handler.HandleDetectDrift(ctx)
+		}
+		lambda.Start(wrappedHandler)
+		return
+	}
+
+	// CLI mode
+	app := &cli.App{
+		Name:  "detect-drift",
+		Usage: "Detect drift on every stack managed by the deployer",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "disable-ssm",
+				Usage:   "Disable AWS Systems Manager Parameter Store (use environment variables)",
+				EnvVars: []string{"DISABLE_SSM"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			handler, err := NewHandler(env)
+			if err != nil {
+				return fmt.Errorf("failed to create handler: %w", err)
+			}
+
+			ctx := logger.WithContext(c.Context)
+			return handler.HandleDetectDrift(ctx)
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		logger.Error().Err(err).Msg("Application error")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackStatus(t *testing.T) {
+	assert.Equal(t, driftdao.StatusInSync, stackStatus(types.StackDriftDetectionStatusDetectionComplete, types.StackDriftStatusInSync))
+	assert.Equal(t, driftdao.StatusDrifted, stackStatus(types.StackDriftDetectionStatusDetectionComplete, types.StackDriftStatusDrifted))
+	assert.Equal(t, driftdao.StatusDrifted, stackStatus(types.StackDriftDetectionStatusDetectionFailed, types.StackDriftStatusDrifted))
+	assert.Equal(t, driftdao.StatusUnknown, stackStatus(types.StackDriftDetectionStatusDetectionFailed, types.StackDriftStatusInSync))
+}
+
+func TestInstanceStatus(t *testing.T) {
+	assert.Equal(t, driftdao.StatusInSync, instanceStatus(types.StackDriftStatusInSync))
+	assert.Equal(t, driftdao.StatusDrifted, instanceStatus(types.StackDriftStatusDrifted))
+	assert.Equal(t, driftdao.StatusUnknown, instanceStatus(types.StackDriftStatusNotChecked))
+}
+
+func TestParseStackID(t *testing.T) {
+	region, account := parseStackID("arn:aws:cloudformation:us-east-1:123456789012:stack/dev-my-repo/8f4a0e20-1234-11ee-be56-0242ac120002")
+	assert.Equal(t, "us-east-1", region)
+	assert.Equal(t, "123456789012", account)
+
+	region, account = parseStackID("dev-my-repo")
+	assert.Empty(t, region)
+	assert.Empty(t, account)
+}
+
+func TestResourceDrift(t *testing.T) {
+	drift := resourceDrift(
+		aws.String("Bucket"),
+		aws.String("my-bucket"),
+		aws.String("AWS::S3::Bucket"),
+		types.StackResourceDriftStatusModified,
+		[]types.PropertyDifference{
+			{
+				PropertyPath:   aws.String("/VersioningConfiguration/Status"),
+				ExpectedValue:  aws.String("Enabled"),
+				ActualValue:    aws.String("Suspended"),
+				DifferenceType: types.DifferenceTypeNotEqual,
+			},
+		},
+	)
+	assert.Equal(t, "Bucket", drift.LogicalResourceID)
+	assert.Equal(t, "MODIFIED", drift.Status)
+	assert.Equal(t, []driftdao.PropertyDifference{
+		{PropertyPath: "/VersioningConfiguration/Status", ExpectedValue: "Enabled", ActualValue: "Suspended", DifferenceType: "NOT_EQUAL"},
+	}, drift.Differences)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
+	"github.com/savaki/aws-deployer/internal/dao/auditdao"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/notificationdao"
+	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/notification"
+	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/urfave/cli/v2"
+)
+
+type Handler struct {
+	notifier *notification.Notifier
+	github   *notification.GitHubReporter // nil unless GitHub reporting is configured
+	audit    *audit.Recorder
+}
+
+func NewHandler(ctx context.Context, env string) (*Handler, error) {
+	logger := zerolog.Ctx(ctx)
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// Create Parameter Store service based on DISABLE_SSM flag
+	var paramStore services.ParameterStore
+	if os.Getenv("DISABLE_SSM") == "true" {
+		paramStore = services.NewEnvParameterStore(env)
+	} else {
+		ssmClient := di.ProvideSSMClient(cfg)
+		paramStore = services.NewSSMParameterStore(ssmClient, env)
+	}
+
+	appConfig, err := paramStore.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	secrets, err := services.NewSecretsManagerService()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets manager service: %w", err)
+	}
+
+	dynamoClient := dynamodb.NewFromConfig(cfg)
+	subscriptions := notificationdao.New(dynamoClient, notificationdao.TableName(env))
+
+	notifier := notification.New(
+		env,
+		subscriptions,
+		&http.Client{Timeout: 10 * time.Second},
+		services.NewSNSService(cfg),
+		secrets,
+	)
+
+	// Report builds to GitHub when an owner and PAT are configured. A PAT that can't be read
+	// disables GitHub reporting only; notifications and the audit log keep working.
+	var github *notification.GitHubReporter
+	if appConfig.GitHubOwner != "" && appConfig.GitHubTokenSecret != "" {
+		token, err := secrets.GetGitHubPAT(ctx, appConfig.GitHubTokenSecret)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("secret", appConfig.GitHubTokenSecret).
+				Msg("Failed to get GitHub token from Secrets Manager, GitHub reporting disabled")
+		} else {
+			var targetURL string
+			if appConfig.CustomDomain != "" {
+				targetURL = fmt.Sprintf("https://%s/", appConfig.CustomDomain)
+			}
+
+			buildDAO := builddao.New(dynamoClient, builddao.TableName(env))
+			github = notification.NewGitHubReporter(appConfig.GitHubOwner, targetURL, services.NewGitHubService(token), buildDAO)
+		}
+	}
+
+	return &Handler{
+		notifier: notifier,
+		github:   github,
+		audit:    audit.New(auditdao.New(dynamoClient, auditdao.TableName(env)), audit.SourceStateMachine),
+	}, nil
+}
+
+// HandleDynamoDBEvent sends notifications for build status transitions, reports them to
+// GitHub and records them in the audit table. Delivery failures are logged rather than
+// returned so a broken subscription doesn't block the stream.
+func (h *Handler) HandleDynamoDBEvent(ctx context.Context, event events.DynamoDBEvent) error {
+	logger := zerolog.Ctx(ctx)
+
+	for i := range event.Records {
+		record := &event.Records[i]
+
+		if record.EventName != "INSERT" && record.EventName != "MODIFY" {
+			continue
+		}
+
+		oldRecord, newRecord, err := decode(record)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("event_id", record.EventID).
+				Msg("Error decoding DynamoDB record")
+			continue
+		}
+
+		// Skip "latest" magic records; they mirror the build records
+		if strings.HasPrefix(newRecord.PK.String(), "latest/") {
+			continue
+		}
+
+		h.recordStatusChange(ctx, record.EventID, oldRecord, newRecord)
+
+		if err := h.notifier.Notify(ctx, oldRecord, newRecord); err != nil {
+			logger.Error().
+				Err(err).
+				Str("event_id", record.EventID).
+				Str("build_id", newRecord.GetID().String()).
+				Msg("Failed to send notifications")
+		}
+
+		if h.github != nil {
+			if err := h.github.Report(ctx, oldRecord, newRecord); err != nil {
+				logger.Error().
+					Err(err).
+					Str("event_id", record.EventID).
+					Str("build_id", newRecord.GetID().String()).
+					Msg("Failed to report build to GitHub")
+			}
+		}
+	}
+	return nil
+}
+
+// recordStatusChange records a build moving to a new status in the audit table. The
+// execution ARN, or the stream event ID before the build has one, is the request ID.
+func (h *Handler) recordStatusChange(ctx context.Context, eventID string, oldRecord, newRecord *builddao.Record) {
+	event, ok := audit.BuildStatusEvent(oldRecord, newRecord)
+	if !ok {
+		return
+	}
+
+	requestID := eventID
+	if newRecord.ExecutionArn != nil && *newRecord.ExecutionArn != "" {
+		requestID = *newRecord.ExecutionArn
+	}
+
+	if err := h.audit.Record(audit.WithRequestID(ctx, requestID), audit.SystemActor, event); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Str("event_id", eventID).
+			Str("build_id", newRecord.GetID().String()).
+			Msg("Failed to record build status change")
+	}
+}
+
+// decode returns the old (nil for INSERT) and new build records of a stream record
+func decode(record *events.DynamoDBEventRecord) (*builddao.Record, *builddao.Record, error) {
+	var newRecord builddao.Record
+	if err := attributevalue.UnmarshalMap(convertImage(record.Change.NewImage), &newRecord); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal new image: %w", err)
+	}
+
+	if len(record.Change.OldImage) == 0 {
+		return nil, &newRecord, nil
+	}
+
+	var oldRecord builddao.Record
+	if err := attributevalue.UnmarshalMap(convertImage(record.Change.OldImage), &oldRecord); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal old image: %w", err)
+	}
+	return &oldRecord, &newRecord, nil
+}
+
+func convertImage(image map[string]events.DynamoDBAttributeValue) map[string]types.AttributeValue {
+	m := make(map[string]types.AttributeValue, len(image))
+	for k, v := range image {
+		m[k] = convertDynamoDBAttributeValue(v)
+	}
+	return m
+}
+
+// convertDynamoDBAttributeValue converts events.DynamoDBAttributeValue to types.AttributeValue
+func convertDynamoDBAttributeValue(av events.DynamoDBAttributeValue) types.AttributeValue {
+	switch av.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: av.String()}
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: av.Number()}
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: av.Boolean()}
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: av.Binary()}
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: av.StringSet()}
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: av.NumberSet()}
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: av.BinarySet()}
+	case events.DataTypeList:
+		list := av.List()
+		convertedList := make([]types.AttributeValue, len(list))
+		for i, item := range list {
+			convertedList[i] = convertDynamoDBAttributeValue(item)
+		}
+		return &types.AttributeValueMemberL{Value: convertedList}
+	case events.DataTypeMap:
+		m := av.Map()
+		convertedMap := make(map[string]types.AttributeValue)
+		for k, v := range m {
+			convertedMap[k] = convertDynamoDBAttributeValue(v)
+		}
+		return &types.AttributeValueMemberM{Value: convertedMap}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
+
+func main() {
+	logger := di.ProvideLogger().With().Str("lambda", "notify").Logger()
+
+	// Get environment from ENV or ENVIRONMENT variable
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = os.Getenv("ENVIRONMENT")
+	}
+	if env == "" {
+		logger.Error().Msg("ENV or ENVIRONMENT variable is required")
+		os.Exit(1)
+	}
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		// Lambda mode
+		handler, err := NewHandler(logger.WithContext(context.Background()), env)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to create handler")
+			os.Exit(1)
+		}
+
+		// Wrap handler to inject logger into context
+		wrappedHandler := func(ctx context.Context, event events.DynamoDBEvent) error {
+			ctx = logger.WithContext(ctx)
+			return handler.HandleDynamoDBEvent(ctx, event)
+		}
+		lambda.Start(wrappedHandler)
+		return
+	}
+
+	// CLI mode
+	app := &cli.App{
+		Name:  "notify",
+		Usage: "Process DynamoDB stream events to send build notifications",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "disable-ssm",
+				Usage:   "Disable AWS Systems Manager Parameter Store (use environment variables)",
+				EnvVars: []string{"DISABLE_SSM"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			handler, err := NewHandler(logger.WithContext(c.Context), env)
+			if err != nil {
+				return fmt.Errorf("failed to create handler: %w", err)
+			}
+
+			logger.Info().
+				Str("env", env).
+				Bool("github", handler.github != nil).
+				Msg("CLI mode - handler initialized successfully")
+			return nil
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		logger.Error().Err(err).Msg("Application error")
+		os.Exit(1)
+	}
+}
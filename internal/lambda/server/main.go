@@ -13,14 +13,18 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/audit"
 	"github.com/savaki/aws-deployer/internal/auth"
 	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/promotion"
 	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/segmentio/ksuid"
 	"github.com/urfave/cli/v2"
 )
 
@@ -46,8 +50,13 @@ func loggingMiddleware(logger zerolog.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
+			// Tag the request's logs and audit events with its ID
+			id := requestID(r)
+			w.Header().Set("X-Request-Id", id)
+
 			// Inject logger into request context
-			ctx := logger.WithContext(r.Context())
+			ctx := logger.With().Str("request_id", id).Logger().WithContext(r.Context())
+			ctx = audit.WithRequestID(ctx, id)
 			r = r.WithContext(ctx)
 
 			// Create a custom response writer to capture status code
@@ -79,6 +88,14 @@ func loggingMiddleware(logger zerolog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// requestID returns the Lambda request ID of the request, or a new KSUID when serving locally
+func requestID(r *http.Request) string {
+	if lc, ok := lambdacontext.FromContext(r.Context()); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID
+	}
+	return ksuid.New().String()
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -90,6 +107,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap returns the underlying http.ResponseWriter so http.ResponseController can flush it
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // stripEnvPrefixMiddleware removes the /{env} prefix from request paths
 func stripEnvPrefixMiddleware(env string, next http.Handler) http.Handler {
 	// If env is empty, return the handler as-is
@@ -129,9 +151,18 @@ func setupContainer(env, callbackURL string, disableAuth bool) (di.Container, er
 			di.ProvideSessionKeys,
 			di.ProvideAuthenticator,
 			di.ProvideAuthorizer,
+			di.ProvideRBAC,
+			di.ProvideTokenVerifier,
 			di.ProvideBuildDAO,
 			di.ProvideTargetDAO,
 			di.ProvideDeploymentDAO,
+			di.ProvideDriftDAO,
+			di.ProvideRoleDAO,
+			di.ProvideTokenDAO,
+			di.ProvideAuditDAO,
+			di.ProvideAuditRecorder,
+			di.ProvideStackEvents,
+			promotion.New,
 			di.ProvideGraphQL,
 		),
 	)
@@ -140,11 +171,24 @@ func setupContainer(env, callbackURL string, disableAuth bool) (di.Container, er
 // handleGraphQL serves the GraphQL API
 func (h *Handler) handleGraphQL() http.Handler {
 	// Use the relay handler which provides both GraphQL endpoint and GraphiQL interface
-	return &relay.Handler{Schema: h.schema}
+	handler := &relay.Handler{Schema: h.schema}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Subscriptions are served as server-sent events
+		if acceptsEventStream(r) {
+			h.handleGraphQLStream(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
 }
 
 // handleGraphiQL serves the GraphiQL interface
 func (h *Handler) handleGraphiQL(w http.ResponseWriter, r *http.Request) {
+	if acceptsEventStream(r) {
+		h.handleGraphQLStream(w, r)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(graphiqlHTML))
@@ -214,6 +258,7 @@ func (h *Handler) setupRouter() http.Handler {
 	// GraphQL endpoints (authentication required - API mode: 403 on failure)
 	// GET /graphql serves the GraphiQL interface
 	// POST /graphql handles GraphQL queries
+	// GET or POST /graphql with Accept: text/event-stream streams subscriptions
 	requireAuthAPI := h.authenticator.RequireAuth(false) // false = return 403 for API calls
 	mux.Handle("GET /graphql", requireAuthAPI(http.HandlerFunc(h.handleGraphiQL)))
 	mux.Handle("POST /graphql", requireAuthAPI(h.handleGraphQL()))
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// streamDeadlineMargin ends a stream before the Lambda deadline so the final events are returned
+	streamDeadlineMargin = 2 * time.Second
+
+	// bufferedIdleTimeout ends a stream that cannot be flushed once events stop arriving, so
+	// buffered responses (API Gateway) return promptly and the client resubscribes
+	bufferedIdleTimeout = time.Second
+)
+
+// graphQLRequest is a GraphQL request received over HTTP
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// acceptsEventStream reports whether the client asked for server-sent events
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// handleGraphQLStream serves GraphQL subscriptions as server-sent events. Each result is sent
+// as a "next" event and the stream ends with a "complete" event. The request is read from the
+// JSON body of a POST or from the query, operationName and variables parameters of a GET (EventSource).
+func (h *Handler) handleGraphQLStream(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				h.errorResponse(w, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Query == "" {
+		h.errorResponse(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	ctx, cancel := streamContext(r.Context())
+	defer cancel()
+
+	responses, err := h.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	buffered := controller.Flush() != nil
+
+	var idle <-chan time.Time
+	for {
+		select {
+		case response, ok := <-responses:
+			if !ok {
+				_, _ = fmt.Fprint(w, "event: complete\ndata:\n\n")
+				return
+			}
+
+			data, err := json.Marshal(response)
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to marshal subscription response")
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+
+			if buffered {
+				idle = time.After(bufferedIdleTimeout)
+			} else if err := controller.Flush(); err != nil {
+				return
+			}
+
+		case <-idle:
+			_, _ = fmt.Fprint(w, "event: complete\ndata:\n\n")
+			return
+		}
+	}
+}
+
+// streamContext bounds a stream by the request deadline (the Lambda deadline in Lambda mode)
+func streamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline.Add(-streamDeadlineMargin))
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/urfave/cli/v2"
+)
+
+type Handler struct {
+	buildDAO  *builddao.DAO
+	targetDAO *targetdao.DAO
+}
+
+type CheckCalendarInput struct {
+	Repo string `json:"repo"`
+	Env  string `json:"env"`
+	SK   string `json:"sk"` // KSUID - DynamoDB sort key
+}
+
+type CheckCalendarOutput struct {
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func NewHandler(env string) (*Handler, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+	return &Handler{
+		buildDAO:  builddao.New(client, builddao.TableName(env)),
+		targetDAO: targetdao.New(client, targetdao.TableName(env)),
+	}, nil
+}
+
+// HandleCheckCalendar checks the change calendar of a build that waited out its soak time.
+// While the calendar blocks deploys the build is marked BLOCKED and the state machine waits
+// and checks again; once deploys are allowed, or the freeze was overridden, the build goes
+// back to IN_PROGRESS and the deployment continues. Rollbacks are never held.
+func (h *Handler) HandleCheckCalendar(ctx context.Context, input *CheckCalendarInput) (*CheckCalendarOutput, error) {
+	logger := zerolog.Ctx(ctx)
+
+	pk := builddao.NewPK(input.Repo, input.Env)
+	build, err := h.buildDAO.Find(ctx, builddao.NewID(pk, input.SK))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build: %w", err)
+	}
+
+	var reason string
+	if !build.IsRollback() && build.FreezeOverride == nil {
+		reason, err = h.targetDAO.Blocked(ctx, input.Repo, input.Env, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check change calendar: %w", err)
+		}
+	}
+
+	if reason != "" {
+		if build.Status != builddao.BuildStatusBlocked {
+			status := builddao.BuildStatusBlocked
+			if err := h.buildDAO.UpdateStatus(ctx, builddao.UpdateInput{
+				PK:            pk,
+				SK:            input.SK,
+				Status:        &status,
+				BlockedReason: &reason,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to block build: %w", err)
+			}
+
+			logger.Info().
+				Str("repo", input.Repo).
+				Str("env", input.Env).
+				Str("sk", input.SK).
+				Str("reason", reason).
+				Msg("Build blocked by change calendar after soak time")
+		}
+		return &CheckCalendarOutput{Blocked: true, Reason: reason}, nil
+	}
+
+	if build.Status == builddao.BuildStatusBlocked {
+		status := builddao.BuildStatusInProgress
+		if err := h.buildDAO.UpdateStatus(ctx, builddao.UpdateInput{
+			PK:     pk,
+			SK:     input.SK,
+			Status: &status,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to resume build: %w", err)
+		}
+
+		logger.Info().
+			Str("repo", input.Repo).
+			Str("env", input.Env).
+			Str("sk", input.SK).
+			Bool("overridden", build.FreezeOverride != nil).
+			Msg("Change calendar allows deploys, resuming build")
+	}
+
+	return &CheckCalendarOutput{Blocked: false}, nil
+}
+
+func main() {
+	logger := di.ProvideLogger().With().Str("lambda", "check-calendar").Logger()
+
+	// Get environment from ENV variable
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = os.Getenv("ENVIRONMENT")
+	}
+	if env == "" {
+		logger.Error().Msg("ENV or ENVIRONMENT variable is required")
+		os.Exit(1)
+	}
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		// Lambda mode
+		handler, err := NewHandler(env)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to create handler")
+			os.Exit(1)
+		}
+
+		// Wrap handler to inject logger into context
+		wrappedHandler := func(ctx context.Context, input *CheckCalendarInput) (*CheckCalendarOutput, error) {
+			ctx = logger.WithContext(ctx)
+			return handler.HandleCheckCalendar(ctx, input)
+		}
+		lambda.Start(wrappedHandler)
+		return
+	}
+
+	// CLI mode
+	handler, err := NewHandler(env)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create handler")
+		os.Exit(1)
+	}
+
+	app := &cli.App{
+		Name:  "check-calendar",
+		Usage: "Check the change calendar of a build before it deploys",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "repo",
+				Usage:    "Repository name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "env",
+				Usage:    "Environment name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "sk",
+				Usage:    "Sort key (KSUID)",
+				Required: true,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			output, err := handler.HandleCheckCalendar(logger.WithContext(context.Background()), &CheckCalendarInput{
+				Repo: c.String("repo"),
+				Env:  c.String("env"),
+				SK:   c.String("sk"),
+			})
+			if err != nil {
+				return err
+			}
+
+			logger.Info().
+				Bool("blocked", output.Blocked).
+				Str("reason", output.Reason).
+				Msg("Checked change calendar")
+			return nil
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		logger.Error().Err(err).Msg("Application error")
+		os.Exit(1)
+	}
+}
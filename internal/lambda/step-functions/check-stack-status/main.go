@@ -12,22 +12,36 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
 	"github.com/savaki/aws-deployer/internal/errors"
 	"github.com/savaki/aws-deployer/internal/models"
+	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/savaki/aws-deployer/internal/utils"
 	"github.com/urfave/cli/v2"
 )
 
 type Handler struct {
-	cfClient *cloudformation.Client
+	cfClient  *cloudformation.Client
+	dbService *services.DynamoDBService
+	targets   *targetdao.DAO
+}
+
+// operationNoop is the deploy operation of an empty change set
+const operationNoop = "NOOP"
+
+// DeployResult is the result of the deploy-cloudformation task
+type DeployResult struct {
+	StackName string `json:"stack_name"`
+	StackID   string `json:"stack_id"`
+	Operation string `json:"operation"` // CREATE, UPDATE, or NOOP
 }
 
 type CheckStatusInput struct {
 	*models.StepFunctionInput
 	DeployResult struct {
-		StackName string `json:"stack_name"`
-		StackID   string `json:"stack_id"`
-		Operation string `json:"operation"`
+		Payload DeployResult `json:"Payload"` // lambda:invoke wraps the task result in Payload
 	} `json:"deployResult"`
 }
 
@@ -37,14 +51,21 @@ type StackStatusResult struct {
 	StackName    string  `json:"stack_name"`
 }
 
-func NewHandler() (*Handler, error) {
+func NewHandler(env string) (*Handler, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
+	dbService, err := services.NewDynamoDBService(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
+	}
+
 	return &Handler{
-		cfClient: cloudformation.NewFromConfig(cfg),
+		cfClient:  cloudformation.NewFromConfig(cfg),
+		dbService: dbService,
+		targets:   targetdao.New(dbService.GetClient(), targetdao.TableName(env)),
 	}, nil
 }
 
@@ -91,7 +112,19 @@ func (h *Handler) HandleCheckStackStatus(ctx context.Context, input *CheckStatus
 			Msg("Stack status reason")
 	}
 
-	if h.isFailedStatus(types.StackStatus(status)) {
+	// An empty change set leaves the stack as it was, so its outputs and protection are
+	// recorded even if the stack is still in the state of an earlier rolled-back update
+	noop := input.DeployResult.Payload.Operation == operationNoop
+
+	if h.isCompleteStatus(stack.StackStatus) || noop {
+		h.recordOutputs(ctx, input, stack.Outputs)
+	}
+
+	if h.isCompleteStatus(stack.StackStatus) || h.isFailedStatus(stack.StackStatus) || noop {
+		h.protectStack(ctx, input, stackName)
+	}
+
+	if !noop && h.isFailedStatus(types.StackStatus(status)) {
 		events, err := h.getStackEvents(ctx, stackName)
 		if err != nil {
 			logger.Error().Err(err).Msg("Failed to get stack events")
@@ -113,6 +146,64 @@ func (h *Handler) HandleCheckStackStatus(ctx context.Context, input *CheckStatus
 	return statusResult, nil
 }
 
+// isCompleteStatus returns true if the stack finished deploying successfully
+func (h *Handler) isCompleteStatus(status types.StackStatus) bool {
+	return status == types.StackStatusCreateComplete || status == types.StackStatusUpdateComplete
+}
+
+// recordOutputs stores the stack outputs on the build so other repos can reference them.
+// Failures are logged rather than failing the deployment.
+func (h *Handler) recordOutputs(ctx context.Context, input *CheckStatusInput, outputs []types.Output) {
+	logger := zerolog.Ctx(ctx)
+
+	m := utils.StackOutputs(outputs)
+	if len(m) == 0 {
+		return
+	}
+
+	pk := builddao.NewPK(input.Repo, input.Env)
+	if err := h.dbService.SetBuildOutputs(ctx, pk, input.SK, m); err != nil {
+		logger.Warn().
+			Err(err).
+			Stringer("id", builddao.NewID(pk, input.SK)).
+			Msg("Failed to record stack outputs")
+		return
+	}
+
+	logger.Info().
+		Stringer("id", builddao.NewID(pk, input.SK)).
+		Int("outputs", len(m)).
+		Msg("Recorded stack outputs")
+}
+
+// protectStack applies the environment's termination protection and stack policy once the
+// stack stops updating. This protects newly created stacks and restores the protective policy
+// after a build that was allowed to replace protected resources. Failures are logged rather
+// than failing the deployment.
+func (h *Handler) protectStack(ctx context.Context, input *CheckStatusInput, stackName string) {
+	logger := zerolog.Ctx(ctx)
+
+	record, err := h.targets.GetWithDefault(ctx, input.Repo, input.Env)
+	if err != nil {
+		logger.Warn().Err(err).Str("stack_name", stackName).Msg("Failed to get stack protection")
+		return
+	}
+	if record == nil || record.Protection == nil {
+		return
+	}
+
+	if err := utils.ProtectStack(ctx, h.cfClient, stackName, record.Protection, false); err != nil {
+		logger.Warn().Err(err).Str("stack_name", stackName).Msg("Failed to protect stack")
+		return
+	}
+
+	logger.Info().
+		Str("stack_name", stackName).
+		Bool("termination_protection", record.Protection.TerminationProtection).
+		Strs("protected_types", record.Protection.Types()).
+		Msg("Applied stack protection")
+}
+
 func (h *Handler) isFailedStatus(status types.StackStatus) bool {
 	failedStatuses := []types.StackStatus{
 		types.StackStatusCreateFailed,
@@ -161,7 +252,12 @@ func (h *Handler) getStackEvents(ctx context.Context, stackName string) ([]types
 func main() {
 	logger := di.ProvideLogger().With().Str("lambda", "check-stack-status").Logger()
 
-	handler, err := NewHandler()
+	env := os.Getenv("ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	handler, err := NewHandler(env)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create handler")
 		os.Exit(1)
@@ -218,7 +314,7 @@ func main() {
 			},
 			&cli.StringFlag{
 				Name:     "operation",
-				Usage:    "Deploy operation (CREATE or UPDATE)",
+				Usage:    "Deploy operation (CREATE, UPDATE or NOOP)",
 				Required: true,
 			},
 		},
@@ -231,15 +327,11 @@ func main() {
 					SK:         c.String("sk"),
 					CommitHash: c.String("commit-hash"),
 				},
-				DeployResult: struct {
-					StackName string `json:"stack_name"`
-					StackID   string `json:"stack_id"`
-					Operation string `json:"operation"`
-				}{
-					StackName: c.String("stack-name"),
-					StackID:   c.String("stack-id"),
-					Operation: c.String("operation"),
-				},
+			}
+			input.DeployResult.Payload = DeployResult{
+				StackName: c.String("stack-name"),
+				StackID:   c.String("stack-id"),
+				Operation: c.String("operation"),
 			}
 
 			result, err := handler.HandleCheckStackStatus(context.Background(), input)
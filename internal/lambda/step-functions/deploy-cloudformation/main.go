@@ -16,23 +16,58 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/smithy-go"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/cftemplate"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
 	"github.com/savaki/aws-deployer/internal/models"
+	"github.com/savaki/aws-deployer/internal/paramref"
 	"github.com/savaki/aws-deployer/internal/policy"
 	"github.com/savaki/aws-deployer/internal/services"
 	"github.com/savaki/aws-deployer/internal/utils"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 )
 
+// CloudFormationClient defines the CloudFormation operations needed to deploy a stack
+type CloudFormationClient interface {
+	DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error)
+	CreateChangeSet(ctx context.Context, params *cloudformation.CreateChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateChangeSetOutput, error)
+	DescribeChangeSet(ctx context.Context, params *cloudformation.DescribeChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeChangeSetOutput, error)
+	DeleteChangeSet(ctx context.Context, params *cloudformation.DeleteChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DeleteChangeSetOutput, error)
+	ExecuteChangeSet(ctx context.Context, params *cloudformation.ExecuteChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.ExecuteChangeSetOutput, error)
+	utils.StackProtector
+}
+
+// S3Client abstracts the S3 operations on build artifacts for testing
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// BuildStore defines the build record operations used during deployment
+type BuildStore interface {
+	GetBuild(ctx context.Context, repo, env, ksuid string) (builddao.Record, error)
+	UpdateBuildStatus(ctx context.Context, input builddao.UpdateInput) (builddao.Record, error)
+	SetBuildChangeSet(ctx context.Context, pk builddao.PK, sk string, changeSet builddao.ChangeSet) error
+	SetBuildPolicyViolations(ctx context.Context, pk builddao.PK, sk string, violations []builddao.PolicyViolation) error
+}
+
+// TargetStore defines the target configuration lookups used during deployment
+type TargetStore interface {
+	GetWithDefault(ctx context.Context, repo, env string) (*targetdao.Record, error)
+}
+
 type Handler struct {
-	cfClient  *cloudformation.Client
-	s3Client  *s3.Client
-	dbService *services.DynamoDBService
-	validator *policy.Validator
+	cfClient  CloudFormationClient
+	s3Client  S3Client
+	dbService BuildStore
+	targets   TargetStore // nil deploys without stack protection
+	policies  *policy.Set
+	params    *paramref.Resolver
 }
 
 type DownloadResult struct {
@@ -41,10 +76,16 @@ type DownloadResult struct {
 	S3Objects              []string          `json:"s3_objects"`
 }
 
+// OperationNoop is returned when the change set contained no changes
+const OperationNoop = "NOOP"
+
+// changeSetPollInterval is how long to wait between change set status checks
+const changeSetPollInterval = 5 * time.Second
+
 type DeployResult struct {
 	StackName string `json:"stack_name"`
 	StackID   string `json:"stack_id"`
-	Operation string `json:"operation"`
+	Operation string `json:"operation"` // CREATE, UPDATE, or NOOP
 }
 
 func NewHandler(env string) (*Handler, error) {
@@ -58,20 +99,43 @@ func NewHandler(env string) (*Handler, error) {
 		return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
 	}
 
-	// DISABLED: Rego policy validation temporarily disabled
-	// validator, err := policy.NewValidator()
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to create policy validator: %w", err)
-	// }
+	s3Client := s3.NewFromConfig(cfg)
+	ssmClient := ssm.NewFromConfig(cfg)
+
+	// POLICY_SOURCE is optional; without it no policies are evaluated
+	loader := policy.NewLoader(env, s3Client, ssmClient)
+	policies, err := loader.Load(context.TODO(), os.Getenv("POLICY_SOURCE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	// Parameter references are resolved against stack outputs, SSM and Secrets Manager
+	params := paramref.New(env,
+		paramref.WithOutputs(paramref.BuildOutputs(dbService.QueryBuildsByRepo)),
+		paramref.WithSSM(ssmClient),
+		paramref.WithSecretsManager(secretsmanager.NewFromConfig(cfg)),
+	)
 
 	return &Handler{
 		cfClient:  cloudformation.NewFromConfig(cfg),
-		s3Client:  s3.NewFromConfig(cfg),
+		s3Client:  s3Client,
 		dbService: dbService,
-		validator: nil, // Disabled for now
+		targets:   targetdao.New(dbService.GetClient(), targetdao.TableName(env)),
+		policies:  policies,
+		params:    params,
 	}, nil
 }
 
+// NewHandlerWithDeps creates a Handler with injected dependencies (for testing)
+func NewHandlerWithDeps(cfClient CloudFormationClient, s3Client S3Client, dbService BuildStore) *Handler {
+	return &Handler{
+		cfClient:  cfClient,
+		s3Client:  s3Client,
+		dbService: dbService,
+		params:    paramref.New(""),
+	}
+}
+
 func (h *Handler) HandleDeployCloudFormation(
 	ctx context.Context,
 	input *models.StepFunctionInput,
@@ -90,28 +154,60 @@ func (h *Handler) HandleDeployCloudFormation(
 	logger.Info().Msg("Step 1: Downloading S3 content")
 	prefix := strings.TrimRight(input.S3Key, "/") + "/"
 
-	template, err := h.downloadCloudFormationTemplate(ctx, input.S3Bucket, prefix+"cloudformation.template")
+	// The template may be named cloudformation.template, cloudformation.yaml or template.yaml
+	packager := cftemplate.NewPackager(h.s3Client, input.S3Bucket, prefix)
+	templateKey, body, err := packager.Find(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download CloudFormation template: %w", err)
 	}
 
+	logger.Info().
+		Str("template_key", templateKey).
+		Msg("Downloaded CloudFormation template")
+
+	doc, err := cftemplate.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
 	// Download and merge base + env-specific parameters
 	params, err := h.downloadAndParseParams(ctx, input.S3Bucket, prefix+"cloudformation-params.json", input.Env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download and parse params: %w", err)
 	}
 
-	// Step 1.5: Validate CloudFormation template against policy
-	// DISABLED: Rego policy validation temporarily disabled
-	// logger.Info().Msg("Step 1.5: Validating CloudFormation template against policy")
-	// err = h.validateTemplate(ctx, template, input.Env, input.Repo)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("CloudFormation template policy validation failed: %w", err)
-	// }
+	// Replace references (stack outputs, SSM parameters, secrets, build fields) with their values
+	resolved, err := h.params.Resolve(ctx, paramref.Build{Version: input.Version, CommitHash: input.CommitHash}, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve parameter references: %w", err)
+	}
+	params = resolved.Parameters
+
+	// Errors end up on the build record, which must never hold secret values
+	defer func() {
+		err = resolved.MaskErr(err)
+	}()
+
+	// Check the parameters against the template so mistakes fail the build before any
+	// CloudFormation call
+	declared, err := cftemplate.ParseParameters(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := cftemplate.ValidateParameters(declared, params); err != nil {
+		return nil, err
+	}
+
+	pk := builddao.NewPK(input.Repo, input.Env)
+
+	// Step 1.5: Evaluate CloudFormation template against policies
+	logger.Info().Msg("Step 1.5: Evaluating CloudFormation template against policies")
+	if err := h.validateTemplate(ctx, pk, input.SK, doc, input.Env, input.Repo); err != nil {
+		return nil, fmt.Errorf("CloudFormation template policy validation failed: %w", err)
+	}
 
 	// Step 2: Update build status to IN_PROGRESS
 	logger.Info().Msg("Step 2: Updating build status to IN_PROGRESS")
-	pk := builddao.NewPK(input.Repo, input.Env)
 	status := builddao.BuildStatusInProgress
 	_, err = h.dbService.UpdateBuildStatus(ctx, builddao.UpdateInput{
 		PK:     pk,
@@ -123,8 +219,8 @@ func (h *Handler) HandleDeployCloudFormation(
 		// Continue with deployment even if status update fails
 	}
 
-	// Step 3: Deploy CloudFormation stack
-	logger.Info().Msg("Step 3: Deploying CloudFormation stack")
+	// Step 3: Create a change set and store a preview on the build record
+	logger.Info().Msg("Step 3: Creating CloudFormation change set")
 
 	stackName := fmt.Sprintf("%s-%s", input.Env, input.Repo)
 
@@ -134,23 +230,55 @@ func (h *Handler) HandleDeployCloudFormation(
 		Str("version", input.Version).
 		Msg("Deploying stack")
 
-	stackExists, err := h.stackExists(ctx, stackName)
+	changeSetType, err := h.changeSetType(ctx, stackName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check if stack exists: %w", err)
 	}
 
-	if stackExists {
-		result, err = h.updateStack(ctx, stackName, template, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update stack: %w", err)
-		}
-		result.Operation = "UPDATE"
-	} else {
-		result, err = h.createStack(ctx, stackName, template, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create stack: %w", err)
-		}
-		result.Operation = "CREATE"
+	// Relative artifact references are rewritten and large templates are deployed from S3
+	packaged, err := packager.Package(ctx, templateKey, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to package CloudFormation template: %w", err)
+	}
+
+	changeSet, err := h.createChangeSet(ctx, stackName, changeSetName(input.SK), changeSetType, packaged, cftemplate.Capabilities(doc), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create change set: %w", err)
+	}
+
+	if err := h.dbService.SetBuildChangeSet(ctx, pk, input.SK, *changeSet); err != nil {
+		return nil, fmt.Errorf("failed to store change set preview: %w", err)
+	}
+
+	logger.Info().
+		Str("stack_name", stackName).
+		Str("change_set", changeSet.Name).
+		Int("add", changeSet.Count(builddao.ChangeActionAdd)).
+		Int("modify", changeSet.Count(builddao.ChangeActionModify)).
+		Int("remove", changeSet.Count(builddao.ChangeActionRemove)).
+		Msg("Stored change set preview")
+
+	if len(changeSet.Changes) == 0 {
+		logger.Info().Str("stack_name", stackName).Msg("No updates needed for stack")
+		return &DeployResult{
+			StackName: stackName,
+			StackID:   stackName,
+			Operation: OperationNoop,
+		}, nil
+	}
+
+	// Step 3.5: Refuse to replace or delete protected resources and protect the stack
+	logger.Info().Msg("Step 3.5: Checking stack protection")
+	if err := h.protect(ctx, input, stackName, changeSetType, changeSet); err != nil {
+		h.deleteChangeSet(ctx, stackName, changeSet.Name)
+		return nil, err
+	}
+
+	// Step 4: Execute the change set
+	logger.Info().Msg("Step 4: Executing CloudFormation change set")
+	result, err = h.executeChangeSet(ctx, stackName, changeSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute change set: %w", err)
 	}
 
 	logger.Info().
@@ -160,35 +288,134 @@ func (h *Handler) HandleDeployCloudFormation(
 	return result, nil
 }
 
-func (h *Handler) stackExists(ctx context.Context, stackName string) (bool, error) {
-	_, err := h.cfClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+// protect fails the build if the change set replaces or deletes resources protected by the
+// environment, unless the build carries a replacement override. Existing stacks get the
+// environment's termination protection and stack policy before the change set executes; new
+// stacks are protected by check-stack-status once they are created.
+func (h *Handler) protect(
+	ctx context.Context,
+	input *models.StepFunctionInput,
+	stackName string,
+	changeSetType types.ChangeSetType,
+	changeSet *builddao.ChangeSet,
+) error {
+	logger := zerolog.Ctx(ctx)
+
+	if h.targets == nil {
+		return nil
+	}
+
+	record, err := h.targets.GetWithDefault(ctx, input.Repo, input.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get targets: %w", err)
+	}
+	if record == nil || record.Protection == nil {
+		return nil
+	}
+	protection := record.Protection
+
+	protected := protectedChanges(protection, changeSet.Changes)
+	if len(protected) > 0 {
+		build, err := h.dbService.GetBuild(ctx, input.Repo, input.Env, input.SK)
+		if err != nil {
+			return fmt.Errorf("failed to get build: %w", err)
+		}
+
+		descriptions := make([]string, 0, len(protected))
+		for _, change := range protected {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", change.LogicalResourceID, change.ResourceType))
+		}
+
+		override := build.ReplacementOverride
+		if override == nil {
+			return fmt.Errorf("change set replaces or deletes protected resources: %s; allow the replacement with an override to deploy it",
+				strings.Join(descriptions, ", "))
+		}
+
+		logger.Warn().
+			Str("stack_name", stackName).
+			Strs("resources", descriptions).
+			Str("overridden_by", override.Email).
+			Str("reason", override.Reason).
+			Msg("Replacing protected resources with an override")
+	}
+
+	if changeSetType == types.ChangeSetTypeCreate {
+		return nil
+	}
+
+	if err := utils.ProtectStack(ctx, h.cfClient, stackName, protection, len(protected) > 0); err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("stack_name", stackName).
+		Bool("termination_protection", protection.TerminationProtection).
+		Strs("protected_types", protection.Types()).
+		Msg("Applied stack protection")
+	return nil
+}
+
+// protectedChanges returns the changes that replace or delete resources of a protected type
+func protectedChanges(protection *targetdao.Protection, changes []builddao.ResourceChange) []builddao.ResourceChange {
+	var protected []builddao.ResourceChange
+	for _, change := range changes {
+		if !protection.Protects(change.ResourceType) {
+			continue
+		}
+		if change.Action == builddao.ChangeActionRemove || change.RequiresReplacement() {
+			protected = append(protected, change)
+		}
+	}
+	return protected
+}
+
+// changeSetName returns the change set name for a build; names must start with a letter
+func changeSetName(sk string) string {
+	return "aws-deployer-" + sk
+}
+
+// changeSetType returns CREATE if the stack does not exist yet (or only exists as the
+// REVIEW_IN_PROGRESS placeholder left by an earlier change set) and UPDATE otherwise
+func (h *Handler) changeSetType(ctx context.Context, stackName string) (types.ChangeSetType, error) {
+	result, err := h.cfClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
 		StackName: aws.String(stackName),
 	})
 	if err != nil {
 		var apiErr smithy.APIError
 		if errors.As(err, &apiErr) {
 			if apiErr.ErrorCode() == "ValidationError" || strings.Contains(apiErr.ErrorMessage(), "does not exist") {
-				return false, nil
+				return types.ChangeSetTypeCreate, nil
 			}
 		}
-		return false, err
+		return "", err
 	}
-	return true, nil
+
+	if len(result.Stacks) == 0 || result.Stacks[0].StackStatus == types.StackStatusReviewInProgress {
+		return types.ChangeSetTypeCreate, nil
+	}
+	return types.ChangeSetTypeUpdate, nil
 }
 
-func (h *Handler) createStack(
+// createChangeSet creates a change set, waits for CloudFormation to compute it, and
+// returns a summary of the resource changes. An empty change set is deleted and
+// returned with no changes.
+func (h *Handler) createChangeSet(
 	ctx context.Context,
-	stackName, template string,
+	stackName, name string,
+	changeSetType types.ChangeSetType,
+	template cftemplate.Template,
+	capabilities []types.Capability,
 	parameters []types.Parameter,
-) (*DeployResult, error) {
-	input := &cloudformation.CreateStackInput{
-		StackName:    aws.String(stackName),
-		TemplateBody: aws.String(template),
-		Parameters:   parameters,
-		Capabilities: []types.Capability{
-			types.CapabilityCapabilityIam,
-			types.CapabilityCapabilityNamedIam,
-		},
+) (*builddao.ChangeSet, error) {
+	logger := zerolog.Ctx(ctx)
+
+	input := &cloudformation.CreateChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(name),
+		ChangeSetType: changeSetType,
+		Parameters:    parameters,
+		Capabilities:  capabilities,
 		Tags: []types.Tag{
 			{
 				Key:   aws.String("ManagedBy"),
@@ -196,55 +423,160 @@ func (h *Handler) createStack(
 			},
 		},
 	}
+	if template.URL != "" {
+		logger.Info().
+			Str("stack_name", stackName).
+			Str("template_url", template.URL).
+			Msg("Deploying template from S3")
+		input.TemplateURL = aws.String(template.URL)
+	} else {
+		input.TemplateBody = aws.String(template.Body)
+	}
 
-	result, err := h.cfClient.CreateStack(ctx, input)
+	created, err := h.cfClient.CreateChangeSet(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DeployResult{
-		StackName: stackName,
-		StackID:   *result.StackId,
-	}, nil
+	changeSet := &builddao.ChangeSet{
+		Name:    name,
+		ID:      aws.ToString(created.Id),
+		Type:    string(changeSetType),
+		Changes: []builddao.ResourceChange{},
+	}
+
+	for {
+		output, err := h.cfClient.DescribeChangeSet(ctx, &cloudformation.DescribeChangeSetInput{
+			StackName:     aws.String(stackName),
+			ChangeSetName: aws.String(name),
+		})
+		if err != nil {
+			h.deleteChangeSet(ctx, stackName, name)
+			return nil, fmt.Errorf("failed to describe change set: %w", err)
+		}
+
+		switch output.Status {
+		case types.ChangeSetStatusCreateComplete:
+			changes, err := h.describeChanges(ctx, stackName, name, output)
+			if err != nil {
+				h.deleteChangeSet(ctx, stackName, name)
+				return nil, err
+			}
+			changeSet.Changes = changes
+			return changeSet, nil
+
+		case types.ChangeSetStatusFailed:
+			// Failed change sets are never executed, so always clean them up; otherwise they
+			// accumulate on the stack (including the REVIEW_IN_PROGRESS placeholder of a first deploy)
+			h.deleteChangeSet(ctx, stackName, name)
+
+			reason := aws.ToString(output.StatusReason)
+			if isEmptyChangeSet(reason) {
+				return changeSet, nil
+			}
+			return nil, fmt.Errorf("change set %s failed: %s", name, reason)
+		}
+
+		logger.Info().
+			Str("change_set", name).
+			Str("status", string(output.Status)).
+			Msg("Waiting for change set")
+
+		select {
+		case <-ctx.Done():
+			h.deleteChangeSet(context.WithoutCancel(ctx), stackName, name)
+			return nil, ctx.Err()
+		case <-time.After(changeSetPollInterval):
+		}
+	}
 }
 
-func (h *Handler) updateStack(
-	ctx context.Context,
-	stackName, template string,
-	parameters []types.Parameter,
-) (*DeployResult, error) {
+// deleteChangeSet removes a change set that will not be executed. Failures are logged
+// rather than returned so they never mask the original error.
+func (h *Handler) deleteChangeSet(ctx context.Context, stackName, name string) {
 	logger := zerolog.Ctx(ctx)
 
-	input := &cloudformation.UpdateStackInput{
-		StackName:    aws.String(stackName),
-		TemplateBody: aws.String(template),
-		Parameters:   parameters,
-		Capabilities: []types.Capability{
-			types.CapabilityCapabilityIam,
-			types.CapabilityCapabilityNamedIam,
-		},
+	if _, err := h.cfClient.DeleteChangeSet(ctx, &cloudformation.DeleteChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(name),
+	}); err != nil {
+		logger.Warn().Err(err).Str("change_set", name).Msg("Failed to delete change set")
 	}
+}
 
-	result, err := h.cfClient.UpdateStack(ctx, input)
-	if err != nil {
-		var apiErr smithy.APIError
-		if errors.As(err, &apiErr) {
-			if apiErr.ErrorCode() == "ValidationError" &&
-				(strings.Contains(apiErr.ErrorMessage(), "No updates are to be performed") ||
-					strings.Contains(apiErr.ErrorMessage(), "No updates to be performed")) {
-				logger.Info().Str("stack_name", stackName).Msg("No updates needed for stack")
-				return &DeployResult{
-					StackName: stackName,
-					StackID:   stackName,
-				}, nil
-			}
+// describeChanges collects all resource changes from a completed change set, following pagination
+func (h *Handler) describeChanges(
+	ctx context.Context,
+	stackName, name string,
+	output *cloudformation.DescribeChangeSetOutput,
+) ([]builddao.ResourceChange, error) {
+	changes := summarizeChanges(output.Changes)
+	for output.NextToken != nil {
+		var err error
+		output, err = h.cfClient.DescribeChangeSet(ctx, &cloudformation.DescribeChangeSetInput{
+			StackName:     aws.String(stackName),
+			ChangeSetName: aws.String(name),
+			NextToken:     output.NextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe change set: %w", err)
 		}
+		changes = append(changes, summarizeChanges(output.Changes)...)
+	}
+	return changes, nil
+}
+
+// summarizeChanges converts CloudFormation change set entries into build record changes
+func summarizeChanges(changes []types.Change) []builddao.ResourceChange {
+	summary := make([]builddao.ResourceChange, 0, len(changes))
+	for _, change := range changes {
+		rc := change.ResourceChange
+		if rc == nil {
+			continue
+		}
+		summary = append(summary, builddao.ResourceChange{
+			Action:             builddao.ChangeAction(rc.Action),
+			LogicalResourceID:  aws.ToString(rc.LogicalResourceId),
+			PhysicalResourceID: aws.ToString(rc.PhysicalResourceId),
+			ResourceType:       aws.ToString(rc.ResourceType),
+			Replacement:        string(rc.Replacement),
+		})
+	}
+	return summary
+}
+
+// isEmptyChangeSet returns true if a change set failed only because there was nothing to change
+func isEmptyChangeSet(reason string) bool {
+	return strings.Contains(reason, "didn't contain changes") ||
+		strings.Contains(reason, "No updates are to be performed") ||
+		strings.Contains(reason, "No updates to be performed")
+}
+
+func (h *Handler) executeChangeSet(ctx context.Context, stackName string, changeSet *builddao.ChangeSet) (*DeployResult, error) {
+	_, err := h.cfClient.ExecuteChangeSet(ctx, &cloudformation.ExecuteChangeSetInput{
+		StackName:     aws.String(stackName),
+		ChangeSetName: aws.String(changeSet.Name),
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	result, err := h.cfClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack: %w", err)
+	}
+
+	stackID := stackName
+	if len(result.Stacks) > 0 && result.Stacks[0].StackId != nil {
+		stackID = *result.Stacks[0].StackId
+	}
+
 	return &DeployResult{
 		StackName: stackName,
-		StackID:   *result.StackId,
+		StackID:   stackID,
+		Operation: changeSet.Type,
 	}, nil
 }
 
@@ -324,17 +656,6 @@ func replaceFilename(key, newFilename string) string {
 	return newFilename
 }
 
-func (h *Handler) downloadCloudFormationTemplate(ctx context.Context, bucket, key string) (s string, err error) {
-	logger := zerolog.Ctx(ctx)
-
-	logger.Info().
-		Str("bucket", bucket).
-		Str("key", key).
-		Msg("Downloading CloudFormation template")
-
-	return h.downloadS3Object(ctx, bucket, key)
-}
-
 func (h *Handler) downloadS3Object(ctx context.Context, bucket, key string) (s string, err error) {
 	logger := zerolog.Ctx(ctx)
 
@@ -366,36 +687,62 @@ func (h *Handler) downloadS3Object(ctx context.Context, bucket, key string) (s s
 	return string(content), nil
 }
 
-func (h *Handler) validateTemplate(ctx context.Context, templateString, env, repo string) error {
+// validateTemplate evaluates the template against the policies for repo/env and stores any
+// violations on the build. Returns an error if a policy in enforce mode was violated.
+func (h *Handler) validateTemplate(ctx context.Context, pk builddao.PK, sk string, template map[string]interface{}, env, repo string) error {
 	logger := zerolog.Ctx(ctx)
 
-	var template map[string]interface{}
-	if err := yaml.Unmarshal([]byte(templateString), &template); err != nil {
-		return fmt.Errorf("failed to parse CloudFormation template: %w", err)
-	}
-
-	result, err := h.validator.ValidateTemplate(template, env, repo)
+	violations, err := h.policies.Evaluate(ctx, template, repo, env)
 	if err != nil {
 		return fmt.Errorf("policy validation error: %w", err)
 	}
 
-	if !result.Allowed {
-		violationsStr := strings.Join(result.Violations, "; ")
-		logger.Error().
+	if len(violations) == 0 {
+		logger.Info().
 			Str("repo", repo).
 			Str("env", env).
-			Str("violations", violationsStr).
-			Msg("CloudFormation template policy validation failed")
-		return fmt.Errorf("policy violations: %s", violationsStr)
+			Msg("CloudFormation template validated successfully")
+		return nil
+	}
+
+	if err := h.dbService.SetBuildPolicyViolations(ctx, pk, sk, toPolicyViolations(violations)); err != nil {
+		return fmt.Errorf("failed to store policy violations: %w", err)
+	}
+
+	for _, v := range violations {
+		logger.Warn().
+			Str("repo", repo).
+			Str("env", env).
+			Str("policy", v.Policy).
+			Str("mode", string(v.Mode)).
+			Str("violation", v.Message).
+			Msg("CloudFormation template policy violation")
+	}
+
+	if enforced := policy.Enforced(violations); len(enforced) > 0 {
+		messages := make([]string, 0, len(enforced))
+		for _, v := range enforced {
+			messages = append(messages, fmt.Sprintf("%s: %s", v.Policy, v.Message))
+		}
+		return fmt.Errorf("policy violations: %s", strings.Join(messages, "; "))
 	}
 
-	logger.Info().
-		Str("repo", repo).
-		Str("env", env).
-		Msg("CloudFormation template validated successfully")
 	return nil
 }
 
+// toPolicyViolations converts policy violations into build record violations
+func toPolicyViolations(violations []policy.Violation) []builddao.PolicyViolation {
+	records := make([]builddao.PolicyViolation, 0, len(violations))
+	for _, v := range violations {
+		records = append(records, builddao.PolicyViolation{
+			Policy:  v.Policy,
+			Mode:    string(v.Mode),
+			Message: v.Message,
+		})
+	}
+	return records
+}
+
 func main() {
 	logger := di.ProvideLogger().With().Str("lambda", "deploy-cloudformation").Logger()
 
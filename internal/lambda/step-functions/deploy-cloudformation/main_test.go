@@ -1,9 +1,173 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/savaki/aws-deployer/internal/cftemplate"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/aws-deployer/internal/models"
+	"github.com/savaki/aws-deployer/internal/policy"
 )
 
+// Mock implementations
+
+type mockCloudFormationClient struct {
+	calls                 []string
+	parameters            []types.Parameter
+	templateBody          string
+	templateURL           string
+	capabilities          []types.Capability
+	stackPolicy           string
+	describeChangeSetFunc func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error)
+}
+
+func (m *mockCloudFormationClient) DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	m.calls = append(m.calls, "DescribeStacks")
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []types.Stack{
+			{
+				StackName:   params.StackName,
+				StackId:     aws.String("arn:aws:cloudformation:us-east-1:123456789012:stack/dev-myapp/abc"),
+				StackStatus: types.StackStatusUpdateComplete,
+			},
+		},
+	}, nil
+}
+
+func (m *mockCloudFormationClient) CreateChangeSet(ctx context.Context, params *cloudformation.CreateChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateChangeSetOutput, error) {
+	m.calls = append(m.calls, "CreateChangeSet")
+	m.parameters = params.Parameters
+	m.templateBody = aws.ToString(params.TemplateBody)
+	m.templateURL = aws.ToString(params.TemplateURL)
+	m.capabilities = params.Capabilities
+	return &cloudformation.CreateChangeSetOutput{Id: aws.String("change-set-id")}, nil
+}
+
+func (m *mockCloudFormationClient) DescribeChangeSet(ctx context.Context, params *cloudformation.DescribeChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeChangeSetOutput, error) {
+	m.calls = append(m.calls, "DescribeChangeSet")
+	return m.describeChangeSetFunc(ctx, params)
+}
+
+func (m *mockCloudFormationClient) DeleteChangeSet(ctx context.Context, params *cloudformation.DeleteChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DeleteChangeSetOutput, error) {
+	m.calls = append(m.calls, "DeleteChangeSet")
+	return &cloudformation.DeleteChangeSetOutput{}, nil
+}
+
+func (m *mockCloudFormationClient) ExecuteChangeSet(ctx context.Context, params *cloudformation.ExecuteChangeSetInput, optFns ...func(*cloudformation.Options)) (*cloudformation.ExecuteChangeSetOutput, error) {
+	m.calls = append(m.calls, "ExecuteChangeSet")
+	return &cloudformation.ExecuteChangeSetOutput{}, nil
+}
+
+func (m *mockCloudFormationClient) UpdateTerminationProtection(ctx context.Context, params *cloudformation.UpdateTerminationProtectionInput, optFns ...func(*cloudformation.Options)) (*cloudformation.UpdateTerminationProtectionOutput, error) {
+	m.calls = append(m.calls, "UpdateTerminationProtection")
+	return &cloudformation.UpdateTerminationProtectionOutput{}, nil
+}
+
+func (m *mockCloudFormationClient) SetStackPolicy(ctx context.Context, params *cloudformation.SetStackPolicyInput, optFns ...func(*cloudformation.Options)) (*cloudformation.SetStackPolicyOutput, error) {
+	m.calls = append(m.calls, "SetStackPolicy")
+	m.stackPolicy = aws.ToString(params.StackPolicyBody)
+	return &cloudformation.SetStackPolicyOutput{}, nil
+}
+
+type mockS3Client struct {
+	objects map[string]string
+}
+
+func (m *mockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := m.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil
+}
+
+func (m *mockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	content, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[aws.ToString(params.Key)] = string(content)
+	return &s3.PutObjectOutput{}, nil
+}
+
+type mockBuildStore struct {
+	cf         *mockCloudFormationClient
+	build      builddao.Record
+	changeSets []builddao.ChangeSet
+	violations []builddao.PolicyViolation
+}
+
+func (m *mockBuildStore) GetBuild(ctx context.Context, repo, env, ksuid string) (builddao.Record, error) {
+	return m.build, nil
+}
+
+func (m *mockBuildStore) UpdateBuildStatus(ctx context.Context, input builddao.UpdateInput) (builddao.Record, error) {
+	return builddao.Record{PK: input.PK, SK: input.SK}, nil
+}
+
+func (m *mockBuildStore) SetBuildChangeSet(ctx context.Context, pk builddao.PK, sk string, changeSet builddao.ChangeSet) error {
+	// Record the store in the CloudFormation call log so tests can assert ordering
+	m.cf.calls = append(m.cf.calls, "SetBuildChangeSet")
+	m.changeSets = append(m.changeSets, changeSet)
+	return nil
+}
+
+func (m *mockBuildStore) SetBuildPolicyViolations(ctx context.Context, pk builddao.PK, sk string, violations []builddao.PolicyViolation) error {
+	m.violations = violations
+	return nil
+}
+
+type mockTargetStore struct {
+	record *targetdao.Record
+}
+
+func (m *mockTargetStore) GetWithDefault(ctx context.Context, repo, env string) (*targetdao.Record, error) {
+	return m.record, nil
+}
+
+func newTestHandler(describe func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error)) (*Handler, *mockCloudFormationClient, *mockBuildStore) {
+	cf := &mockCloudFormationClient{describeChangeSetFunc: describe}
+	store := &mockBuildStore{cf: cf}
+	s3Client := &mockS3Client{
+		objects: map[string]string{
+			"myapp/main/1.abc/cloudformation.template":    `{"Parameters":{"Env":{"Type":"String"}},"Resources":{}}`,
+			"myapp/main/1.abc/cloudformation-params.json": `{"Env":"dev"}`,
+		},
+	}
+	return NewHandlerWithDeps(cf, s3Client, store), cf, store
+}
+
+func testInput() *models.StepFunctionInput {
+	return &models.StepFunctionInput{
+		Repo:     "myapp",
+		Env:      "dev",
+		Version:  "1.abc",
+		SK:       "2HFj3kLmNoPqRsTuVwXy",
+		S3Bucket: "artifacts",
+		S3Key:    "myapp/main/1.abc",
+	}
+}
+
+func indexOf(calls []string, name string) int {
+	for i, call := range calls {
+		if call == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func TestReplaceFilename(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -119,3 +283,538 @@ func TestStackNameGeneration(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeSetName(t *testing.T) {
+	got := changeSetName("2HFj3kLmNoPqRsTuVwXy")
+	want := "aws-deployer-2HFj3kLmNoPqRsTuVwXy"
+	if got != want {
+		t.Errorf("changeSetName() = %q, want %q", got, want)
+	}
+}
+
+func TestIsEmptyChangeSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		want   bool
+	}{
+		{
+			name:   "no changes",
+			reason: "The submitted information didn't contain changes. Submit different information to create a change set.",
+			want:   true,
+		},
+		{
+			name:   "no updates",
+			reason: "No updates are to be performed.",
+			want:   true,
+		},
+		{
+			name:   "template error",
+			reason: "Template format error: Unresolved resource dependencies [Bucket] in the Resources block of the template",
+			want:   false,
+		},
+		{
+			name:   "empty reason",
+			reason: "",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmptyChangeSet(tt.reason); got != tt.want {
+				t.Errorf("isEmptyChangeSet(%q) = %v, want %v", tt.reason, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	changes := []types.Change{
+		{
+			Type: types.ChangeTypeResource,
+			ResourceChange: &types.ResourceChange{
+				Action:            types.ChangeActionAdd,
+				LogicalResourceId: aws.String("Queue"),
+				ResourceType:      aws.String("AWS::SQS::Queue"),
+			},
+		},
+		{
+			Type: types.ChangeTypeResource,
+			ResourceChange: &types.ResourceChange{
+				Action:             types.ChangeActionModify,
+				LogicalResourceId:  aws.String("Table"),
+				PhysicalResourceId: aws.String("dev-myapp-table"),
+				ResourceType:       aws.String("AWS::DynamoDB::Table"),
+				Replacement:        types.ReplacementTrue,
+			},
+		},
+		{
+			Type: types.ChangeTypeResource,
+		},
+	}
+
+	got := summarizeChanges(changes)
+	if len(got) != 2 {
+		t.Fatalf("summarizeChanges() returned %d changes, want 2", len(got))
+	}
+
+	if got[0].Action != builddao.ChangeActionAdd || got[0].LogicalResourceID != "Queue" || got[0].RequiresReplacement() {
+		t.Errorf("summarizeChanges()[0] = %+v", got[0])
+	}
+	if got[1].Action != builddao.ChangeActionModify || got[1].PhysicalResourceID != "dev-myapp-table" || !got[1].RequiresReplacement() {
+		t.Errorf("summarizeChanges()[1] = %+v", got[1])
+	}
+}
+
+func TestHandleDeployCloudFormation_StoresChangeSetBeforeExecuting(t *testing.T) {
+	handler, cf, store := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{
+			Status: types.ChangeSetStatusCreateComplete,
+			Changes: []types.Change{
+				{
+					Type: types.ChangeTypeResource,
+					ResourceChange: &types.ResourceChange{
+						Action:            types.ChangeActionAdd,
+						LogicalResourceId: aws.String("Queue"),
+						ResourceType:      aws.String("AWS::SQS::Queue"),
+					},
+				},
+			},
+		}, nil
+	})
+
+	result, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	if result.Operation != string(types.ChangeSetTypeUpdate) {
+		t.Errorf("Operation = %q, want %q", result.Operation, types.ChangeSetTypeUpdate)
+	}
+
+	stored := indexOf(cf.calls, "SetBuildChangeSet")
+	executed := indexOf(cf.calls, "ExecuteChangeSet")
+	if stored == -1 || executed == -1 {
+		t.Fatalf("expected change set to be stored and executed, calls = %v", cf.calls)
+	}
+	if stored > executed {
+		t.Errorf("change set executed before preview was stored, calls = %v", cf.calls)
+	}
+
+	if len(store.changeSets) != 1 || len(store.changeSets[0].Changes) != 1 {
+		t.Fatalf("stored change sets = %+v, want one change set with one change", store.changeSets)
+	}
+	if got := store.changeSets[0].Name; got != changeSetName(testInput().SK) {
+		t.Errorf("stored change set name = %q, want %q", got, changeSetName(testInput().SK))
+	}
+}
+
+func TestHandleDeployCloudFormation_EmptyChangeSetIsNoop(t *testing.T) {
+	handler, cf, store := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{
+			Status:       types.ChangeSetStatusFailed,
+			StatusReason: aws.String("The submitted information didn't contain changes. Submit different information to create a change set."),
+		}, nil
+	})
+
+	result, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	if result.Operation != OperationNoop {
+		t.Errorf("Operation = %q, want %q", result.Operation, OperationNoop)
+	}
+	if indexOf(cf.calls, "ExecuteChangeSet") != -1 {
+		t.Errorf("empty change set should not be executed, calls = %v", cf.calls)
+	}
+	if indexOf(cf.calls, "DeleteChangeSet") == -1 {
+		t.Errorf("empty change set should be deleted, calls = %v", cf.calls)
+	}
+	if len(store.changeSets) != 1 || len(store.changeSets[0].Changes) != 0 {
+		t.Errorf("stored change sets = %+v, want one empty change set", store.changeSets)
+	}
+}
+
+func TestHandleDeployCloudFormation_FailedChangeSetIsDeleted(t *testing.T) {
+	handler, cf, store := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{
+			Status:       types.ChangeSetStatusFailed,
+			StatusReason: aws.String("Template format error: Unresolved resource dependencies [Bucket]"),
+		}, nil
+	})
+
+	_, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+	if err == nil {
+		t.Fatal("HandleDeployCloudFormation() expected error for failed change set")
+	}
+
+	if indexOf(cf.calls, "DeleteChangeSet") == -1 {
+		t.Errorf("failed change set should be deleted, calls = %v", cf.calls)
+	}
+	if indexOf(cf.calls, "ExecuteChangeSet") != -1 {
+		t.Errorf("failed change set should not be executed, calls = %v", cf.calls)
+	}
+	if len(store.changeSets) != 0 {
+		t.Errorf("failed change set should not be stored, got %+v", store.changeSets)
+	}
+}
+
+func TestHandleDeployCloudFormation_ProtectedResources(t *testing.T) {
+	describe := func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{
+			Status: types.ChangeSetStatusCreateComplete,
+			Changes: []types.Change{
+				{
+					Type: types.ChangeTypeResource,
+					ResourceChange: &types.ResourceChange{
+						Action:            types.ChangeActionModify,
+						LogicalResourceId: aws.String("Table"),
+						ResourceType:      aws.String("AWS::DynamoDB::Table"),
+						Replacement:       types.ReplacementTrue,
+					},
+				},
+				{
+					Type: types.ChangeTypeResource,
+					ResourceChange: &types.ResourceChange{
+						Action:            types.ChangeActionModify,
+						LogicalResourceId: aws.String("Function"),
+						ResourceType:      aws.String("AWS::Lambda::Function"),
+						Replacement:       types.ReplacementTrue,
+					},
+				},
+			},
+		}, nil
+	}
+	targets := &mockTargetStore{record: &targetdao.Record{
+		Protection: &targetdao.Protection{TerminationProtection: true, ProtectResources: true},
+	}}
+
+	t.Run("replacement fails without override", func(t *testing.T) {
+		handler, cf, _ := newTestHandler(describe)
+		handler.targets = targets
+
+		_, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+		if err == nil || !strings.Contains(err.Error(), "protected resources: Table (AWS::DynamoDB::Table)") {
+			t.Fatalf("HandleDeployCloudFormation() error = %v, want protected resources error", err)
+		}
+		if strings.Contains(err.Error(), "Function") {
+			t.Errorf("error = %v, unprotected resources should not be listed", err)
+		}
+		if indexOf(cf.calls, "ExecuteChangeSet") != -1 {
+			t.Errorf("change set should not be executed, calls = %v", cf.calls)
+		}
+		if indexOf(cf.calls, "DeleteChangeSet") == -1 {
+			t.Errorf("refused change set should be deleted, calls = %v", cf.calls)
+		}
+	})
+
+	t.Run("override allows replacement", func(t *testing.T) {
+		handler, cf, store := newTestHandler(describe)
+		handler.targets = targets
+		store.build = builddao.Record{ReplacementOverride: &builddao.ReplacementOverride{Email: "alice@example.com", Reason: "rename table"}}
+
+		if _, err := handler.HandleDeployCloudFormation(context.Background(), testInput()); err != nil {
+			t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+		}
+		policySet := indexOf(cf.calls, "SetStackPolicy")
+		if policySet == -1 || policySet > indexOf(cf.calls, "ExecuteChangeSet") {
+			t.Errorf("stack policy should be set before executing, calls = %v", cf.calls)
+		}
+		if strings.Contains(cf.stackPolicy, "Deny") {
+			t.Errorf("stack policy = %s, override should not deny replacement", cf.stackPolicy)
+		}
+	})
+
+	t.Run("protective policy applied", func(t *testing.T) {
+		handler, cf, _ := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+			return &cloudformation.DescribeChangeSetOutput{
+				Status: types.ChangeSetStatusCreateComplete,
+				Changes: []types.Change{
+					{
+						Type: types.ChangeTypeResource,
+						ResourceChange: &types.ResourceChange{
+							Action:            types.ChangeActionModify,
+							LogicalResourceId: aws.String("Table"),
+							ResourceType:      aws.String("AWS::DynamoDB::Table"),
+							Replacement:       types.ReplacementFalse,
+						},
+					},
+				},
+			}, nil
+		})
+		handler.targets = targets
+
+		if _, err := handler.HandleDeployCloudFormation(context.Background(), testInput()); err != nil {
+			t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+		}
+		if indexOf(cf.calls, "UpdateTerminationProtection") == -1 {
+			t.Errorf("termination protection should be updated, calls = %v", cf.calls)
+		}
+		if !strings.Contains(cf.stackPolicy, "Deny") {
+			t.Errorf("stack policy = %s, want deny on protected resources", cf.stackPolicy)
+		}
+	})
+}
+
+func TestHandleDeployCloudFormation_ResolvesParameterReferences(t *testing.T) {
+	handler, cf, _ := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{Status: types.ChangeSetStatusCreateComplete}, nil
+	})
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation.template"] = `{"Parameters":{"Env":{"Type":"String"},"Image":{"Type":"String"}},"Resources":{}}`
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation-params.json"] = `{"Env":"dev","Image":"myapp:{{build:version}}"}`
+
+	input := testInput()
+	if _, err := handler.HandleDeployCloudFormation(context.Background(), input); err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	for _, param := range cf.parameters {
+		if aws.ToString(param.ParameterKey) == "Image" {
+			if got, want := aws.ToString(param.ParameterValue), "myapp:"+input.Version; got != want {
+				t.Errorf("Image = %q, want %q", got, want)
+			}
+			return
+		}
+	}
+	t.Errorf("Image parameter not passed to CreateChangeSet, got %+v", cf.parameters)
+}
+
+func TestHandleDeployCloudFormation_UnresolvedReferenceFailsBeforeDeploying(t *testing.T) {
+	handler, cf, _ := newTestHandler(nil)
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation-params.json"] = `{"Password":"{{secret:db#password}}"}`
+
+	_, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+	if err == nil || !strings.Contains(err.Error(), "parameter Password") {
+		t.Fatalf("HandleDeployCloudFormation() error = %v, want unresolved parameter Password", err)
+	}
+	if len(cf.calls) != 0 {
+		t.Errorf("expected no CloudFormation calls, got %v", cf.calls)
+	}
+}
+
+func TestHandleDeployCloudFormation_InvalidParametersFailBeforeDeploying(t *testing.T) {
+	handler, cf, _ := newTestHandler(nil)
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation.template"] = `
+Parameters:
+  Env:
+    Type: String
+    AllowedValues: [dev, prd]
+  Replicas:
+    Type: Number
+  Password:
+    Type: String
+    NoEcho: true
+    MinLength: 12
+Resources: {}
+`
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation-params.json"] = `{"Env":"qa","Image":"myapp:1","Password":"hunter2"}`
+
+	_, err := handler.HandleDeployCloudFormation(context.Background(), testInput())
+	if err == nil {
+		t.Fatal("HandleDeployCloudFormation() expected error for invalid parameters")
+	}
+	for _, want := range []string{
+		`Env: "qa" is not one of AllowedValues [dev, prd]`,
+		"Image: not declared in template",
+		"Password: value is shorter than MinLength 12",
+		"Replicas: required parameter has no value and no default",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error must not contain NoEcho values, got %v", err)
+	}
+	if len(cf.calls) != 0 {
+		t.Errorf("expected no CloudFormation calls, got %v", cf.calls)
+	}
+}
+
+func TestHandleDeployCloudFormation_PackagesTemplate(t *testing.T) {
+	handler, cf, _ := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{Status: types.ChangeSetStatusCreateComplete}, nil
+	})
+	objects := handler.s3Client.(*mockS3Client).objects
+	objects["myapp/main/1.abc/cloudformation.template"] = `{"Parameters":{"Env":{"Type":"String"}},"Resources":{"Network":{"Type":"AWS::CloudFormation::Stack","Properties":{"TemplateURL":"nested/network.template"}}}}`
+	objects["myapp/main/1.abc/nested/network.template"] = `{"Resources":{}}`
+
+	if _, err := handler.HandleDeployCloudFormation(context.Background(), testInput()); err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	want := "TemplateURL: https://artifacts.s3.amazonaws.com/myapp/main/1.abc/nested/network.template"
+	if !strings.Contains(cf.templateBody, want) {
+		t.Errorf("TemplateBody = %q, want it to contain %q", cf.templateBody, want)
+	}
+}
+
+func TestHandleDeployCloudFormation_LargeTemplateUsesTemplateURL(t *testing.T) {
+	handler, cf, _ := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{Status: types.ChangeSetStatusCreateComplete}, nil
+	})
+	description := strings.Repeat("x", cftemplate.MaxTemplateBodySize)
+	handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation.template"] = `{"Description":"` + description + `","Parameters":{"Env":{"Type":"String"}},"Resources":{}}`
+
+	if _, err := handler.HandleDeployCloudFormation(context.Background(), testInput()); err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	if want := "https://artifacts.s3.amazonaws.com/myapp/main/1.abc/cloudformation.template"; cf.templateURL != want {
+		t.Errorf("TemplateURL = %q, want %q", cf.templateURL, want)
+	}
+	if cf.templateBody != "" {
+		t.Errorf("TemplateBody should not be set when deploying from S3")
+	}
+}
+
+func TestHandleDeployCloudFormation_YAMLTemplateWithTransform(t *testing.T) {
+	handler, cf, store := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+		return &cloudformation.DescribeChangeSetOutput{Status: types.ChangeSetStatusCreateComplete}, nil
+	})
+	objects := handler.s3Client.(*mockS3Client).objects
+	delete(objects, "myapp/main/1.abc/cloudformation.template")
+	objects["myapp/main/1.abc/template.yaml"] = `
+Transform: AWS::Serverless-2016-10-31
+Parameters:
+  Env:
+    Type: String
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+    Properties:
+      QueueName: !Sub "${Env}-queue"
+`
+
+	const queueNamePolicy = `package cloudformation
+
+import rego.v1
+
+violations contains msg if {
+	some name, resource in input.Resources
+	not resource.Properties.QueueName["Fn::Sub"]
+	msg := sprintf("queue %s must use Fn::Sub for its name", [name])
+}
+`
+	policies, err := policy.NewSet([]policy.Policy{{Name: "queue-names", Mode: policy.ModeEnforce, Rego: queueNamePolicy}})
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	handler.policies = policies
+
+	if _, err := handler.HandleDeployCloudFormation(context.Background(), testInput()); err != nil {
+		t.Fatalf("HandleDeployCloudFormation() error = %v", err)
+	}
+
+	if len(store.violations) != 0 {
+		t.Errorf("short-form !Sub should be evaluated as Fn::Sub, got violations %+v", store.violations)
+	}
+	if !strings.Contains(cf.templateBody, "Transform: AWS::Serverless-2016-10-31") {
+		t.Errorf("TemplateBody = %q, want the YAML template", cf.templateBody)
+	}
+
+	var autoExpand bool
+	for _, capability := range cf.capabilities {
+		autoExpand = autoExpand || capability == types.CapabilityCapabilityAutoExpand
+	}
+	if !autoExpand {
+		t.Errorf("Capabilities = %v, want CAPABILITY_AUTO_EXPAND for a template with a transform", cf.capabilities)
+	}
+}
+
+func TestStateMachine_NoopRoutesToSuccess(t *testing.T) {
+	data, err := os.ReadFile("../../../../step-function-definition.json")
+	if err != nil {
+		t.Fatalf("failed to read state machine definition: %v", err)
+	}
+
+	var definition struct {
+		States map[string]struct {
+			Type    string `json:"Type"`
+			Next    string `json:"Next"`
+			Default string `json:"Default"`
+			Choices []struct {
+				Variable     string `json:"Variable"`
+				StringEquals string `json:"StringEquals"`
+				Next         string `json:"Next"`
+			} `json:"Choices"`
+		} `json:"States"`
+	}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		t.Fatalf("failed to parse state machine definition: %v", err)
+	}
+
+	deploy := definition.States["DeployCloudFormation"]
+	choice, ok := definition.States[deploy.Next]
+	if !ok || choice.Type != "Choice" {
+		t.Fatalf("DeployCloudFormation.Next = %q, want a Choice state", deploy.Next)
+	}
+
+	var routed string
+	for _, c := range choice.Choices {
+		if c.Variable == "$.deployResult.Payload.operation" && c.StringEquals == OperationNoop {
+			routed = c.Next
+		}
+	}
+	if routed != "HandleSuccess" {
+		t.Errorf("NOOP operation routes to %q, want HandleSuccess", routed)
+	}
+	if choice.Default != "WaitForStackCompletion" {
+		t.Errorf("default route = %q, want WaitForStackCompletion", choice.Default)
+	}
+}
+
+func TestHandleDeployCloudFormation_PolicyViolations(t *testing.T) {
+	const queuePolicy = `package cloudformation
+
+import rego.v1
+
+violations contains msg if {
+	some name, resource in input.Resources
+	resource.Type == "AWS::SQS::Queue"
+	msg := sprintf("queue %s is not allowed", [name])
+}
+`
+
+	tests := []struct {
+		name        string
+		mode        policy.Mode
+		expectError bool
+	}{
+		{name: "enforce", mode: policy.ModeEnforce, expectError: true},
+		{name: "warn", mode: policy.ModeWarn, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, cf, store := newTestHandler(func(ctx context.Context, params *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+				return &cloudformation.DescribeChangeSetOutput{
+					Status:       types.ChangeSetStatusFailed,
+					StatusReason: aws.String("The submitted information didn't contain changes."),
+				}, nil
+			})
+			handler.s3Client.(*mockS3Client).objects["myapp/main/1.abc/cloudformation.template"] = `{"Parameters":{"Env":{"Type":"String"}},"Resources":{"Queue":{"Type":"AWS::SQS::Queue"}}}`
+
+			policies, err := policy.NewSet([]policy.Policy{{Name: "queues", Mode: tt.mode, Rego: queuePolicy}})
+			if err != nil {
+				t.Fatalf("NewSet() error = %v", err)
+			}
+			handler.policies = policies
+
+			_, err = handler.HandleDeployCloudFormation(context.Background(), testInput())
+			if tt.expectError != (err != nil) {
+				t.Fatalf("HandleDeployCloudFormation() error = %v, expectError %v", err, tt.expectError)
+			}
+
+			want := []builddao.PolicyViolation{{Policy: "queues", Mode: string(tt.mode), Message: "queue Queue is not allowed"}}
+			if len(store.violations) != 1 || store.violations[0] != want[0] {
+				t.Errorf("stored violations = %+v, want %+v", store.violations, want)
+			}
+			if tt.expectError && indexOf(cf.calls, "CreateChangeSet") != -1 {
+				t.Errorf("enforced violation should stop the deployment, calls = %v", cf.calls)
+			}
+		})
+	}
+}
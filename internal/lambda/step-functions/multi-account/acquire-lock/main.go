@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/lockdao"
 	"github.com/savaki/aws-deployer/internal/di"
 	"github.com/urfave/cli/v2"
@@ -22,7 +23,8 @@ const (
 )
 
 type Handler struct {
-	lockDAO *lockdao.DAO
+	lockDAO  *lockdao.DAO
+	buildDAO *builddao.DAO
 }
 
 type Input struct {
@@ -40,17 +42,17 @@ type Output struct {
 	Message      string `json:"message"`
 }
 
-func NewHandler(tableName string) (*Handler, error) {
+func NewHandler(env string) (*Handler, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
 	client := dynamodb.NewFromConfig(cfg)
-	lockDAO := lockdao.New(client, tableName)
 
 	return &Handler{
-		lockDAO: lockDAO,
+		lockDAO:  lockdao.New(client, lockdao.TableName(env)),
+		buildDAO: builddao.New(client, builddao.TableName(env)),
 	}, nil
 }
 
@@ -75,6 +77,8 @@ func (h *Handler) HandleAcquireLock(ctx context.Context, input *Input) (*Output,
 		return nil, fmt.Errorf("failed to try acquire lock: %w", err)
 	}
 
+	pk := builddao.NewPK(input.Repo, input.Env)
+
 	if acquired {
 		logger.Info().
 			Str("env", input.Env).
@@ -82,6 +86,13 @@ func (h *Handler) HandleAcquireLock(ctx context.Context, input *Input) (*Output,
 			Str("build_id", input.SK).
 			Msg("Lock acquired successfully")
 
+		// The build is no longer waiting once it holds the lock
+		if input.RetryCount > 0 {
+			if err := h.buildDAO.SetLockHolder(ctx, pk, input.SK, ""); err != nil {
+				logger.Warn().Err(err).Str("build_id", input.SK).Msg("Failed to clear lock holder")
+			}
+		}
+
 		return &Output{
 			LockAcquired: true,
 			RetryCount:   input.RetryCount,
@@ -119,6 +130,13 @@ func (h *Handler) HandleAcquireLock(ctx context.Context, input *Input) (*Output,
 		return nil, fmt.Errorf("failed to acquire lock after %d retries (held by build %s)", maxRetries, lockHolder)
 	}
 
+	// Record the holder on the first wait so subscribers are notified that the build is queued
+	if input.RetryCount == 0 && currentLock != nil {
+		if err := h.buildDAO.SetLockHolder(ctx, pk, input.SK, builddao.NewID(pk, currentLock.BuildID)); err != nil {
+			logger.Warn().Err(err).Str("build_id", input.SK).Msg("Failed to record lock holder")
+		}
+	}
+
 	return &Output{
 		LockAcquired: false,
 		RetryCount:   retryCount,
@@ -129,8 +147,7 @@ func (h *Handler) HandleAcquireLock(ctx context.Context, input *Input) (*Output,
 
 func lambdaAction(c *cli.Context) error {
 	logger := di.ProvideLogger().With().Str("lambda", "acquire-lock").Logger()
-	tableName := lockdao.TableName(c.String("env"))
-	handler, err := NewHandler(tableName)
+	handler, err := NewHandler(c.String("env"))
 	if err != nil {
 		return fmt.Errorf("failed to create handler: %w", err)
 	}
@@ -146,8 +163,7 @@ func lambdaAction(c *cli.Context) error {
 func runAction(c *cli.Context) error {
 	logger := di.ProvideLogger().With().Str("lambda", "acquire-lock").Logger()
 
-	tableName := lockdao.TableName(c.String("env"))
-	handler, err := NewHandler(tableName)
+	handler, err := NewHandler(c.String("env"))
 	if err != nil {
 		return fmt.Errorf("failed to create handler: %w", err)
 	}
@@ -9,11 +9,16 @@ import (
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/rs/zerolog"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/promotion"
+	"github.com/savaki/aws-deployer/internal/rollback"
 	"github.com/savaki/aws-deployer/internal/services"
 	"github.com/urfave/cli/v2"
 )
@@ -21,6 +26,8 @@ import (
 type Handler struct {
 	deploymentDAO *deploymentdao.DAO
 	dbService     *services.DynamoDBService
+	promoter      *promotion.Promoter
+	rollback      *rollback.Manager
 }
 
 type Input struct {
@@ -57,9 +64,15 @@ func NewHandler(env string, deploymentsTableName string) (*Handler, error) {
 		return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
 	}
 
+	buildDAO := builddao.New(dbClient, builddao.TableName(env))
+	targetDAO := targetdao.New(dbClient, targetdao.TableName(env))
+	driftDAO := driftdao.New(dbClient, driftdao.TableName(env))
+
 	return &Handler{
 		deploymentDAO: deploymentDAO,
 		dbService:     dbService,
+		promoter:      promotion.New(buildDAO, targetDAO, driftDAO),
+		rollback:      rollback.New(buildDAO, targetDAO, rollback.NewCloudFormationInspector(cloudformation.NewFromConfig(cfg))),
 	}, nil
 }
 
@@ -135,7 +148,7 @@ func (h *Handler) HandleAggregateResults(ctx context.Context, input *Input) (*Ou
 	// Build update with new multi-account fields
 	// Note: We'll need to extend builddao.UpdateInput to support these new fields
 	// For now, just update status
-	_, err = h.dbService.UpdateBuildStatus(ctx, builddao.UpdateInput{
+	record, err := h.dbService.UpdateBuildStatus(ctx, builddao.UpdateInput{
 		PK:     pk,
 		SK:     input.SK,
 		Status: &buildStatus,
@@ -149,6 +162,16 @@ func (h *Handler) HandleAggregateResults(ctx context.Context, input *Input) (*Ou
 	if err != nil {
 		logger.Warn().Err(err).Msg("Failed to update build status")
 		// Continue - we'll still return the aggregated results
+	} else if buildStatus == builddao.BuildStatusSuccess {
+		if _, err := h.promoter.AutoPromote(ctx, record); err != nil {
+			logger.Error().Err(err).Msg("Failed to auto-promote build")
+			// Continue - the deployments themselves succeeded
+		}
+	} else if buildStatus == builddao.BuildStatusFailed {
+		// Instances left OUTDATED by a failed operation are restored from the last known-good build
+		if _, err := h.rollback.Rollback(ctx, record); err != nil {
+			logger.Error().Err(err).Msg("Failed to roll back build")
+		}
 	}
 
 	return &Output{
@@ -10,18 +10,24 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/constants"
 	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
 	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/utils"
 	"github.com/savaki/gox/slicex"
 	"github.com/urfave/cli/v2"
 )
 
 type Handler struct {
+	cfg           aws.Config
 	cfClient      *cloudformation.Client
+	stsClient     *sts.Client
 	deploymentDAO *deploymentdao.DAO
 }
 
@@ -39,13 +45,14 @@ type Input struct {
 }
 
 type DeploymentStatus struct {
-	AccountID      string   `json:"account_id"`
-	Region         string   `json:"region"`
-	Status         string   `json:"status"`          // CURRENT, OUTDATED, INOPERABLE
-	DetailedStatus string   `json:"detailed_status"` // PENDING, RUNNING, SUCCEEDED, FAILED, etc.
-	StatusReason   string   `json:"status_reason,omitempty"`
-	StackID        string   `json:"stack_id,omitempty"`
-	StackEvents    []string `json:"stack_events,omitempty"`
+	AccountID      string            `json:"account_id"`
+	Region         string            `json:"region"`
+	Status         string            `json:"status"`          // CURRENT, OUTDATED, INOPERABLE
+	DetailedStatus string            `json:"detailed_status"` // PENDING, RUNNING, SUCCEEDED, FAILED, etc.
+	StatusReason   string            `json:"status_reason,omitempty"`
+	StackID        string            `json:"stack_id,omitempty"`
+	StackEvents    []string          `json:"stack_events,omitempty"`
+	Outputs        map[string]string `json:"outputs,omitempty"`
 }
 
 type Output struct {
@@ -66,7 +73,9 @@ func NewHandler(deploymentsTableName string) (*Handler, error) {
 	deploymentDAO := deploymentdao.New(dbClient, deploymentsTableName)
 
 	return &Handler{
+		cfg:           cfg,
 		cfClient:      cfClient,
+		stsClient:     sts.NewFromConfig(cfg),
 		deploymentDAO: deploymentDAO,
 	}, nil
 }
@@ -159,6 +168,7 @@ func (h *Handler) HandleCheckStackSetStatus(ctx context.Context, input *Input) (
 			StackID:      status.StackID,
 			StatusReason: status.StatusReason,
 			StackEvents:  status.StackEvents,
+			Outputs:      status.Outputs,
 		})
 		if err != nil {
 			logger.Warn().
@@ -259,9 +269,44 @@ func (h *Handler) getInstanceStatus(ctx context.Context, stackSetName, account,
 		deploymentStatus.StackEvents = events
 	}
 
+	// Once the instance is deployed, record its outputs so other repos can reference them
+	if status == "CURRENT" && isTerminalStatus(detailedStatus) && stackID != "" {
+		outputs, err := h.getStackOutputs(ctx, account, region, stackID)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("account", account).
+				Str("region", region).
+				Msg("Failed to read stack outputs")
+		} else {
+			deploymentStatus.Outputs = outputs
+		}
+	}
+
 	return deploymentStatus, nil
 }
 
+// getStackOutputs reads the outputs of a stack instance by assuming the read-only
+// constants.StackEventsReaderRoleName role in the target account
+func (h *Handler) getStackOutputs(ctx context.Context, account, region, stackID string) (map[string]string, error) {
+	roleARN := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, constants.StackEventsReaderRoleName)
+	targetCfg := h.cfg.Copy()
+	targetCfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(h.stsClient, roleARN))
+	targetCfg.Region = region
+
+	result, err := cloudformation.NewFromConfig(targetCfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack %s: %w", stackID, err)
+	}
+	if len(result.Stacks) == 0 {
+		return nil, nil
+	}
+
+	return utils.StackOutputs(result.Stacks[0].Outputs), nil
+}
+
 // getFailedStackEvents retrieves recent failed stack events
 func (h *Handler) getFailedStackEvents(ctx context.Context, stackID string, logger *zerolog.Logger) []string {
 	if stackID == "" {
@@ -8,6 +8,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/lambda"
@@ -17,11 +19,18 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/smithy-go"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/cftemplate"
 	"github.com/savaki/aws-deployer/internal/constants"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/paramref"
+	"github.com/savaki/aws-deployer/internal/policy"
 	"github.com/savaki/aws-deployer/internal/utils"
 	"github.com/urfave/cli/v2"
 )
@@ -29,6 +38,10 @@ import (
 type Handler struct {
 	cfClient              *cloudformation.Client
 	s3Client              *s3.Client
+	build                 *builddao.DAO
+	targets               *targetdao.DAO
+	policies              *policy.Set
+	params                *paramref.Resolver
 	administrationRoleARN string
 }
 
@@ -45,7 +58,7 @@ type Output struct {
 	Operation    string `json:"operation"` // "CREATE" or "UPDATE"
 }
 
-func NewHandler() (*Handler, error) {
+func NewHandler(env string, build *builddao.DAO, deployments *deploymentdao.DAO, targets *targetdao.DAO) (*Handler, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
@@ -57,37 +70,118 @@ func NewHandler() (*Handler, error) {
 		return nil, fmt.Errorf("ADMINISTRATION_ROLE_ARN environment variable is required")
 	}
 
+	s3Client := s3.NewFromConfig(cfg)
+	ssmClient := ssm.NewFromConfig(cfg)
+
+	// POLICY_SOURCE is optional; without it no policies are evaluated
+	loader := policy.NewLoader(env, s3Client, ssmClient)
+	policies, err := loader.Load(context.TODO(), os.Getenv("POLICY_SOURCE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	// Parameter references are resolved against stack outputs, SSM and Secrets Manager
+	params := paramref.New(env,
+		paramref.WithOutputs(paramref.DeploymentOutputs(deployments.QueryByPK)),
+		paramref.WithSSM(ssmClient),
+		paramref.WithSecretsManager(secretsmanager.NewFromConfig(cfg)),
+	)
+
 	return &Handler{
 		cfClient:              cloudformation.NewFromConfig(cfg),
-		s3Client:              s3.NewFromConfig(cfg),
+		s3Client:              s3Client,
+		build:                 build,
+		targets:               targets,
+		policies:              policies,
+		params:                params,
 		administrationRoleARN: administrationRoleARN,
 	}, nil
 }
 
-func (h *Handler) HandleCreateStackSet(ctx context.Context, input *Input) (*Output, error) {
+func (h *Handler) HandleCreateStackSet(ctx context.Context, input *Input) (_ *Output, err error) {
 	logger := zerolog.Ctx(ctx)
 
 	stackSetName := fmt.Sprintf("%s-%s", input.Env, input.Repo)
 
-	templateURL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s%s",
-		input.S3Bucket,
-		strings.TrimRight(input.S3Key, "/")+"/",
-		"cloudformation.template")
+	// The template may be named cloudformation.template, cloudformation.yaml or template.yaml
+	packager := cftemplate.NewPackager(h.s3Client, input.S3Bucket, input.S3Key)
+	templateKey, template, err := packager.Find(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download CloudFormation template: %w", err)
+	}
+	templateURL := packager.URL(templateKey)
 
 	logger.Info().
 		Str("stack_set_name", stackSetName).
 		Str("template_url", templateURL).
 		Msg("Creating or updating StackSet")
 
+	doc, err := cftemplate.Parse(template)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evaluate the template against policies before touching the StackSet
+	if err := h.validateTemplate(ctx, input, doc); err != nil {
+		return nil, fmt.Errorf("CloudFormation template policy validation failed: %w", err)
+	}
+
 	// Fetch parameters from S3
 	parameters, err := h.fetchParametersFromS3(ctx, input.S3Bucket, input.S3Key, input.Env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch parameters from S3: %w", err)
 	}
 
+	// Replace references (stack outputs, SSM parameters, secrets, build fields) with their values
+	record, err := h.build.Find(ctx, builddao.NewID(builddao.NewPK(input.Repo, input.Env), input.SK))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find build: %w", err)
+	}
+	resolved, err := h.params.Resolve(ctx, paramref.Build{Version: record.Version, CommitHash: record.CommitHash}, parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve parameter references: %w", err)
+	}
+	parameters = resolved.Parameters
+
+	// Errors end up on the build record, which must never hold secret values
+	defer func() {
+		err = resolved.MaskErr(err)
+	}()
+
+	// Stack policies and termination protection cannot be set on StackSet instances, so
+	// protected resources must keep their data when they are replaced or removed
+	if err := h.checkProtection(ctx, input, record, doc); err != nil {
+		return nil, err
+	}
+
 	// Inject/override the Environment parameter to ensure it matches the deployment environment
 	parameters = injectEnvironmentParameter(parameters, input.Env)
 
+	// Check the parameters against the template so mistakes fail the build before any
+	// StackSet operation starts
+	declared, err := cftemplate.ParseParameters(template)
+	if err != nil {
+		return nil, err
+	}
+	if err := cftemplate.ValidateParameters(declared, parameters); err != nil {
+		return nil, err
+	}
+
+	// StackSet instances cannot run transforms such as AWS::Serverless in every target account,
+	// so the template is expanded here and the StackSet deploys the processed template
+	if transforms := cftemplate.Transforms(doc); len(transforms) > 0 {
+		logger.Info().
+			Str("stack_set_name", stackSetName).
+			Strs("transforms", transforms).
+			Msg("Expanding template transforms")
+
+		expandStackName := fmt.Sprintf("%s-expand-%s", stackSetName, input.SK)
+		templateURL, err = packager.Expand(ctx, h.cfClient, expandStackName, templateKey, parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand template transforms: %w", err)
+		}
+	}
+
 	// Check if StackSet exists
 	logger.Info().
 		Str("stack_set_name", stackSetName).
@@ -100,15 +194,27 @@ func (h *Handler) HandleCreateStackSet(ctx context.Context, input *Input) (*Outp
 	stackSetExists := err == nil
 
 	if stackSetExists {
-		// Update existing StackSet (template only, no instance updates)
-		// Removing OperationPreferences ensures only the template is updated
-		// Instance updates will be handled by DeployStackInstances step
+		// Without accounts and regions UpdateStackSet would update every instance at once,
+		// ahead of the canary and bake times of the waves. Only the instances of the first wave
+		// are updated here; the rest are left OUTDATED and DeployWaves rolls them out.
+		var (
+			accounts, regions []string
+			preferences       targetdao.OperationPreferences
+		)
+		accounts, regions, preferences, err = h.firstInstances(ctx, input, stackSetName)
+		if err != nil {
+			return nil, err
+		}
+
 		logger.Info().
 			Str("stack_set_name", stackSetName).
 			Str("template_url", templateURL).
 			Str("administration_role_arn", h.administrationRoleARN).
 			Str("execution_role_name", constants.ExecutionRoleName).
 			Int("parameter_count", len(parameters)).
+			Strs("accounts", accounts).
+			Strs("regions", regions).
+			Interface("preferences", preferences).
 			Msg("Calling UpdateStackSet API")
 
 		_, err = h.cfClient.UpdateStackSet(ctx, &cloudformation.UpdateStackSetInput{
@@ -121,8 +227,9 @@ func (h *Handler) HandleCreateStackSet(ctx context.Context, input *Input) (*Outp
 				types.CapabilityCapabilityIam,
 				types.CapabilityCapabilityNamedIam,
 			},
-			// NOTE: No OperationPreferences - this ensures we only update the StackSet template
-			// and don't trigger instance updates, which would conflict with DeployStackInstances
+			Accounts:             accounts,
+			Regions:              regions,
+			OperationPreferences: operationPreferences(preferences, regions),
 		})
 
 		if err != nil {
@@ -196,6 +303,207 @@ func (h *Handler) HandleCreateStackSet(ctx context.Context, input *Input) (*Outp
 	}, nil
 }
 
+// firstInstances returns the accounts and regions of the existing stack instances of the
+// earliest wave that has any, limited to accounts deployed to the same regions so that the
+// operation touches no other instance, and the operation preferences of that wave. Returns no
+// accounts when none of the targets has an instance, in which case UpdateStackSet updates
+// every instance with the preferences of the first wave.
+func (h *Handler) firstInstances(ctx context.Context, input *Input, stackSetName string) (accounts, regions []string, preferences targetdao.OperationPreferences, err error) {
+	record, err := h.targets.GetWithDefault(ctx, input.Repo, input.Env)
+	if err != nil {
+		return nil, nil, preferences, fmt.Errorf("failed to get targets: %w", err)
+	}
+	if record == nil {
+		return nil, nil, preferences, nil
+	}
+
+	existing := make(map[string]bool)
+	paginator := cloudformation.NewListStackInstancesPaginator(h.cfClient, &cloudformation.ListStackInstancesInput{
+		StackSetName: aws.String(stackSetName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, preferences, fmt.Errorf("failed to list stack instances: %w", err)
+		}
+		for _, instance := range page.Summaries {
+			existing[aws.ToString(instance.Account)+"/"+aws.ToString(instance.Region)] = true
+		}
+	}
+
+	expanded := targetdao.ExpandTargets(record.Targets)
+	waves := targetdao.PlanWaves(expanded, record.Waves, record.Preferences)
+	for _, wave := range waves {
+		regionsByAccount := make(map[string][]string)
+		for _, target := range wave.Targets {
+			if !existing[target.AccountID+"/"+target.Region] {
+				continue
+			}
+			if _, ok := regionsByAccount[target.AccountID]; !ok {
+				accounts = append(accounts, target.AccountID)
+			}
+			regionsByAccount[target.AccountID] = append(regionsByAccount[target.AccountID], target.Region)
+		}
+		if len(accounts) == 0 {
+			continue
+		}
+
+		regions = regionsByAccount[accounts[0]]
+		key := strings.Join(sorted(regions), ",")
+		same := accounts[:0]
+		for _, account := range accounts {
+			if strings.Join(sorted(regionsByAccount[account]), ",") == key {
+				same = append(same, account)
+			}
+		}
+		return same, regions, wave.Preferences, nil
+	}
+
+	if len(waves) > 0 {
+		preferences = waves[0].Preferences
+	}
+	return nil, nil, preferences, nil
+}
+
+// operationPreferences returns the StackSet operation preferences for an operation updating
+// instances in regions, defaulting the concurrency. Regions missing from the region order
+// update last.
+func operationPreferences(p targetdao.OperationPreferences, regions []string) *types.StackSetOperationPreferences {
+	prefs := &types.StackSetOperationPreferences{}
+
+	switch {
+	case p.MaxConcurrentPercentage > 0:
+		prefs.MaxConcurrentPercentage = aws.Int32(int32(p.MaxConcurrentPercentage))
+	case p.MaxConcurrentCount > 0:
+		prefs.MaxConcurrentCount = aws.Int32(int32(p.MaxConcurrentCount))
+	default:
+		prefs.MaxConcurrentCount = aws.Int32(targetdao.DefaultMaxConcurrentCount)
+	}
+
+	if p.FailureTolerancePercentage > 0 {
+		prefs.FailureTolerancePercentage = aws.Int32(int32(p.FailureTolerancePercentage))
+	} else {
+		prefs.FailureToleranceCount = aws.Int32(int32(p.FailureToleranceCount))
+	}
+
+	if p.RegionConcurrencyType != "" {
+		prefs.RegionConcurrencyType = types.RegionConcurrencyType(p.RegionConcurrencyType)
+	}
+
+	// CloudFormation only accepts the regions of the operation in the region order
+	if len(p.RegionOrder) > 0 && len(regions) > 0 {
+		for _, region := range p.RegionOrder {
+			if slices.Contains(regions, region) {
+				prefs.RegionOrder = appendUnique(prefs.RegionOrder, region)
+			}
+		}
+		for _, region := range regions {
+			prefs.RegionOrder = appendUnique(prefs.RegionOrder, region)
+		}
+	}
+
+	return prefs
+}
+
+// appendUnique appends a string to a slice only if it's not already present
+func appendUnique(slice []string, item string) []string {
+	if slices.Contains(slice, item) {
+		return slice
+	}
+	return append(slice, item)
+}
+
+// sorted returns a sorted copy of values
+func sorted(values []string) []string {
+	values = append([]string(nil), values...)
+	sort.Strings(values)
+	return values
+}
+
+// checkProtection fails the build if a resource protected by the environment would lose its
+// data when replaced or removed, unless the build carries a replacement override
+func (h *Handler) checkProtection(ctx context.Context, input *Input, build builddao.Record, template map[string]interface{}) error {
+	logger := zerolog.Ctx(ctx)
+
+	targets, err := h.targets.GetWithDefault(ctx, input.Repo, input.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get targets: %w", err)
+	}
+	if targets == nil || targets.Protection == nil {
+		return nil
+	}
+
+	unretained := cftemplate.Unretained(template, targets.Protection.Protects)
+	if len(unretained) == 0 {
+		return nil
+	}
+
+	override := build.ReplacementOverride
+	if override == nil {
+		return fmt.Errorf("protected resources must set DeletionPolicy and UpdateReplacePolicy to Retain or Snapshot: %s; allow the replacement with an override to deploy it",
+			strings.Join(unretained, ", "))
+	}
+
+	logger.Warn().
+		Str("repo", input.Repo).
+		Str("env", input.Env).
+		Strs("resources", unretained).
+		Str("overridden_by", override.Email).
+		Str("reason", override.Reason).
+		Msg("Deploying protected resources without a retain policy with an override")
+	return nil
+}
+
+// validateTemplate evaluates the StackSet template against the policies for repo/env and stores
+// any violations on the build. Returns an error if a policy in enforce mode was violated.
+func (h *Handler) validateTemplate(ctx context.Context, input *Input, template map[string]interface{}) error {
+	logger := zerolog.Ctx(ctx)
+
+	if len(h.policies.Select(input.Repo, input.Env)) == 0 {
+		return nil
+	}
+
+	violations, err := h.policies.Evaluate(ctx, template, input.Repo, input.Env)
+	if err != nil {
+		return fmt.Errorf("policy validation error: %w", err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	records := make([]builddao.PolicyViolation, 0, len(violations))
+	for _, v := range violations {
+		logger.Warn().
+			Str("repo", input.Repo).
+			Str("env", input.Env).
+			Str("policy", v.Policy).
+			Str("mode", string(v.Mode)).
+			Str("violation", v.Message).
+			Msg("CloudFormation template policy violation")
+
+		records = append(records, builddao.PolicyViolation{
+			Policy:  v.Policy,
+			Mode:    string(v.Mode),
+			Message: v.Message,
+		})
+	}
+
+	pk := builddao.NewPK(input.Repo, input.Env)
+	if err := h.build.SetPolicyViolations(ctx, pk, input.SK, records); err != nil {
+		return fmt.Errorf("failed to store policy violations: %w", err)
+	}
+
+	if enforced := policy.Enforced(violations); len(enforced) > 0 {
+		messages := make([]string, 0, len(enforced))
+		for _, v := range enforced {
+			messages = append(messages, fmt.Sprintf("%s: %s", v.Policy, v.Message))
+		}
+		return fmt.Errorf("policy violations: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
 // fetchParametersFromS3 reads CloudFormation params from S3 and returns CloudFormation parameters
 // It first loads the base params, then loads env-specific overrides and merges them
 // Returns empty parameters if no files exist (parameters are optional)
@@ -271,6 +579,8 @@ func lambdaAction(c *cli.Context) error {
 		di.WithProviders(
 			di.ProvideLogger,
 			di.ProvideBuildDAO,
+			di.ProvideDeploymentDAO,
+			di.ProvideTargetDAO,
 		),
 	)
 	if err != nil {
@@ -278,11 +588,13 @@ func lambdaAction(c *cli.Context) error {
 	}
 
 	var (
-		logger = di.MustGet[zerolog.Logger](container).With().Str("lambda", "create-stackset").Logger()
-		build  = di.MustGet[*builddao.DAO](container)
+		logger      = di.MustGet[zerolog.Logger](container).With().Str("lambda", "create-stackset").Logger()
+		build       = di.MustGet[*builddao.DAO](container)
+		deployments = di.MustGet[*deploymentdao.DAO](container)
+		targets     = di.MustGet[*targetdao.DAO](container)
 	)
 
-	handler, err := NewHandler()
+	handler, err := NewHandler(c.String("env"), build, deployments, targets)
 	if err != nil {
 		return fmt.Errorf("failed to create handler: %w", err)
 	}
@@ -329,9 +641,26 @@ func withFailBuildOnError(handler HandlerFunc, build *builddao.DAO) HandlerFunc
 }
 
 func runAction(c *cli.Context) error {
-	logger := di.ProvideLogger().With().Str("lambda", "create-stackset").Logger()
+	container, err := di.New(c.String("env"),
+		di.WithProviders(
+			di.ProvideLogger,
+			di.ProvideBuildDAO,
+			di.ProvideDeploymentDAO,
+			di.ProvideTargetDAO,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	var (
+		logger      = di.MustGet[zerolog.Logger](container).With().Str("lambda", "create-stackset").Logger()
+		build       = di.MustGet[*builddao.DAO](container)
+		deployments = di.MustGet[*deploymentdao.DAO](container)
+		targets     = di.MustGet[*targetdao.DAO](container)
+	)
 
-	handler, err := NewHandler()
+	handler, err := NewHandler(c.String("env"), build, deployments, targets)
 	if err != nil {
 		return fmt.Errorf("failed to create handler: %w", err)
 	}
@@ -8,6 +8,9 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/smithy-go"
 	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
 	"github.com/urfave/cli/v2"
 )
@@ -29,15 +33,67 @@ type DeploymentTarget struct {
 	Region    string `json:"region"`
 }
 
+// Preferences are the StackSet operation preferences of the wave being deployed
+type Preferences struct {
+	RegionOrder                []string `json:"region_order,omitempty"`
+	RegionConcurrencyType      string   `json:"region_concurrency_type,omitempty"`
+	MaxConcurrentCount         int32    `json:"max_concurrent_count,omitempty"`
+	MaxConcurrentPercentage    int32    `json:"max_concurrent_percentage,omitempty"`
+	FailureToleranceCount      int32    `json:"failure_tolerance_count,omitempty"`
+	FailureTolerancePercentage int32    `json:"failure_tolerance_percentage,omitempty"`
+}
+
+// operationPreferences returns the StackSet operation preferences for an operation deploying to
+// regions, defaulting the concurrency. Regions missing from the region order deploy last.
+func (p Preferences) operationPreferences(regions []string) *types.StackSetOperationPreferences {
+	prefs := &types.StackSetOperationPreferences{}
+
+	switch {
+	case p.MaxConcurrentPercentage > 0:
+		prefs.MaxConcurrentPercentage = aws.Int32(p.MaxConcurrentPercentage)
+	case p.MaxConcurrentCount > 0:
+		prefs.MaxConcurrentCount = aws.Int32(p.MaxConcurrentCount)
+	default:
+		prefs.MaxConcurrentCount = aws.Int32(targetdao.DefaultMaxConcurrentCount)
+	}
+
+	if p.FailureTolerancePercentage > 0 {
+		prefs.FailureTolerancePercentage = aws.Int32(p.FailureTolerancePercentage)
+	} else {
+		prefs.FailureToleranceCount = aws.Int32(p.FailureToleranceCount)
+	}
+
+	if p.RegionConcurrencyType != "" {
+		prefs.RegionConcurrencyType = types.RegionConcurrencyType(p.RegionConcurrencyType)
+	}
+
+	// CloudFormation only accepts the regions of the operation in the region order
+	if len(p.RegionOrder) > 0 {
+		for _, region := range p.RegionOrder {
+			if slices.Contains(regions, region) {
+				prefs.RegionOrder = appendUnique(prefs.RegionOrder, region)
+			}
+		}
+		for _, region := range regions {
+			prefs.RegionOrder = appendUnique(prefs.RegionOrder, region)
+		}
+	}
+
+	return prefs
+}
+
 type Input struct {
 	StackSetName string             `json:"stack_set_name"`
 	Targets      []DeploymentTarget `json:"targets"`
+	Preferences  Preferences        `json:"preferences"`
 }
 
 type Output struct {
-	OperationID string   `json:"operation_id"`
-	AccountIDs  []string `json:"account_ids"`
-	Regions     []string `json:"regions"`
+	OperationID string             `json:"operation_id"`
+	AccountIDs  []string           `json:"account_ids"`
+	Regions     []string           `json:"regions"`
+	Targets     []DeploymentTarget `json:"targets"`   // targets deployed by this operation
+	Remaining   []DeploymentTarget `json:"remaining"` // targets of the wave left for a later operation
 }
 
 func NewHandler() (*Handler, error) {
@@ -54,11 +110,30 @@ func NewHandler() (*Handler, error) {
 func (h *Handler) HandleDeployStackInstances(ctx context.Context, input *Input) (*Output, error) {
 	logger := zerolog.Ctx(ctx)
 
+	// A StackSet operation deploys to every account in every region it is given, so deploy one
+	// accounts x regions batch at a time to avoid deploying to targets of a later wave
+	batch, remaining := nextBatch(input.Targets)
+
+	existingInstances, err := h.getExistingInstances(ctx, input.StackSetName)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get existing instances, will attempt creation anyway")
+		existingInstances = make(map[string]bool)
+	}
+
+	// A StackSet runs one operation at a time, so a batch mixing new and existing targets
+	// creates the new targets first and leaves the existing ones for a later operation
+	create, update := splitExisting(batch, existingInstances)
+	if len(create) > 0 && len(update) > 0 {
+		var rest []DeploymentTarget
+		batch, rest = nextBatch(create)
+		remaining = slices.Concat(rest, update, remaining)
+	}
+
 	// Extract unique accounts and regions
 	accountSet := make(map[string]bool)
 	regionSet := make(map[string]bool)
 
-	for _, target := range input.Targets {
+	for _, target := range batch {
 		accountSet[target.AccountID] = true
 		regionSet[target.Region] = true
 	}
@@ -77,11 +152,21 @@ func (h *Handler) HandleDeployStackInstances(ctx context.Context, input *Input)
 		Str("stack_set_name", input.StackSetName).
 		Int("account_count", len(accounts)).
 		Int("region_count", len(regions)).
-		Int("total_instances", len(input.Targets)).
+		Int("total_instances", len(batch)).
+		Int("remaining_instances", len(remaining)).
+		Interface("preferences", input.Preferences).
 		Msg("Deploying stack instances")
 
 	// Create or update stack instances with retry on OperationInProgressException
-	operationID, err := h.createStackInstancesWithRetry(ctx, input.StackSetName, accounts, regions)
+	var operationID string
+	if len(create) == 0 {
+		logger.Info().
+			Str("stack_set_name", input.StackSetName).
+			Msg("All instances already exist, updating instead of creating")
+		operationID, err = h.updateStackInstancesWithRetry(ctx, input.StackSetName, accounts, regions, input.Preferences)
+	} else {
+		operationID, err = h.createStackInstancesWithRetry(ctx, input.StackSetName, accounts, regions, input.Preferences)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -89,65 +174,62 @@ func (h *Handler) HandleDeployStackInstances(ctx context.Context, input *Input)
 	logger.Info().
 		Str("stack_set_name", input.StackSetName).
 		Str("operation_id", operationID).
-		Int("total_instances", len(input.Targets)).
+		Int("total_instances", len(batch)).
 		Msg("Stack instances deployment initiated")
 
 	return &Output{
 		OperationID: operationID,
 		AccountIDs:  accounts,
 		Regions:     regions,
+		Targets:     batch,
+		Remaining:   remaining,
 	}, nil
 }
 
-// createStackInstancesWithRetry attempts to create stack instances
-func (h *Handler) createStackInstancesWithRetry(ctx context.Context, stackSetName string, accounts, regions []string) (string, error) {
-	logger := zerolog.Ctx(ctx)
+// nextBatch returns the targets of the accounts deployed to the same regions as the first
+// target's account, which a single StackSet operation can deploy, and the targets left over
+func nextBatch(targets []DeploymentTarget) (batch, remaining []DeploymentTarget) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
 
-	// First, check which instances already exist
-	existingInstances, err := h.getExistingInstances(ctx, stackSetName, accounts, regions)
-	if err != nil {
-		logger.Warn().Err(err).Msg("Failed to get existing instances, will attempt creation anyway")
-		existingInstances = make(map[string]bool)
+	regionsByAccount := make(map[string][]string)
+	for _, target := range targets {
+		regionsByAccount[target.AccountID] = append(regionsByAccount[target.AccountID], target.Region)
 	}
 
-	// Filter out accounts/regions that already have instances
-	newAccounts := make([]string, 0)
-	newRegions := make([]string, 0)
+	regionKey := func(account string) string {
+		regions := append([]string(nil), regionsByAccount[account]...)
+		sort.Strings(regions)
+		return strings.Join(regions, ",")
+	}
 
-	for _, account := range accounts {
-		for _, region := range regions {
-			key := fmt.Sprintf("%s/%s", account, region)
-			if !existingInstances[key] {
-				// Only add account/region pairs that don't exist
-				newAccounts = appendUnique(newAccounts, account)
-				newRegions = appendUnique(newRegions, region)
-			}
+	key := regionKey(targets[0].AccountID)
+	for _, target := range targets {
+		if regionKey(target.AccountID) == key {
+			batch = append(batch, target)
+		} else {
+			remaining = append(remaining, target)
 		}
 	}
+	return batch, remaining
+}
 
-	// If no new instances to create, just update existing ones
-	if len(newAccounts) == 0 || len(newRegions) == 0 {
-		logger.Info().
-			Str("stack_set_name", stackSetName).
-			Msg("All instances already exist, updating instead of creating")
-		return h.updateStackInstancesWithRetry(ctx, stackSetName, accounts, regions)
-	}
+// createStackInstancesWithRetry creates stack instances for targets that have none
+func (h *Handler) createStackInstancesWithRetry(ctx context.Context, stackSetName string, accounts, regions []string, preferences Preferences) (string, error) {
+	logger := zerolog.Ctx(ctx)
 
 	logger.Info().
 		Str("stack_set_name", stackSetName).
-		Int("new_accounts", len(newAccounts)).
-		Int("new_regions", len(newRegions)).
-		Int("existing_instances", len(existingInstances)).
+		Int("account_count", len(accounts)).
+		Int("region_count", len(regions)).
 		Msg("Calling CreateStackInstances API")
 
 	result, err := h.cfClient.CreateStackInstances(ctx, &cloudformation.CreateStackInstancesInput{
-		StackSetName: aws.String(stackSetName),
-		Accounts:     newAccounts,
-		Regions:      newRegions,
-		OperationPreferences: &types.StackSetOperationPreferences{
-			MaxConcurrentCount:    aws.Int32(10),
-			FailureToleranceCount: aws.Int32(0), // Fail fast - we want to track all failures
-		},
+		StackSetName:         aws.String(stackSetName),
+		Accounts:             accounts,
+		Regions:              regions,
+		OperationPreferences: preferences.operationPreferences(regions),
 	})
 
 	if err == nil {
@@ -182,7 +264,7 @@ func (h *Handler) createStackInstancesWithRetry(ctx context.Context, stackSetNam
 }
 
 // updateStackInstancesWithRetry updates existing stack instances
-func (h *Handler) updateStackInstancesWithRetry(ctx context.Context, stackSetName string, accounts, regions []string) (string, error) {
+func (h *Handler) updateStackInstancesWithRetry(ctx context.Context, stackSetName string, accounts, regions []string, preferences Preferences) (string, error) {
 	logger := zerolog.Ctx(ctx)
 
 	logger.Info().
@@ -192,13 +274,10 @@ func (h *Handler) updateStackInstancesWithRetry(ctx context.Context, stackSetNam
 		Msg("Calling UpdateStackInstances API")
 
 	result, err := h.cfClient.UpdateStackInstances(ctx, &cloudformation.UpdateStackInstancesInput{
-		StackSetName: aws.String(stackSetName),
-		Accounts:     accounts,
-		Regions:      regions,
-		OperationPreferences: &types.StackSetOperationPreferences{
-			MaxConcurrentCount:    aws.Int32(10),
-			FailureToleranceCount: aws.Int32(0),
-		},
+		StackSetName:         aws.String(stackSetName),
+		Accounts:             accounts,
+		Regions:              regions,
+		OperationPreferences: preferences.operationPreferences(regions),
 	})
 
 	if err == nil {
@@ -232,7 +311,7 @@ func (h *Handler) updateStackInstancesWithRetry(ctx context.Context, stackSetNam
 }
 
 // getExistingInstances returns a map of account/region keys for existing instances
-func (h *Handler) getExistingInstances(ctx context.Context, stackSetName string, accounts, regions []string) (map[string]bool, error) {
+func (h *Handler) getExistingInstances(ctx context.Context, stackSetName string) (map[string]bool, error) {
 	logger := zerolog.Ctx(ctx)
 	existing := make(map[string]bool)
 
@@ -277,6 +356,18 @@ func (h *Handler) getExistingInstances(ctx context.Context, stackSetName string,
 	return existing, nil
 }
 
+// splitExisting splits targets into those without a stack instance and those with one
+func splitExisting(targets []DeploymentTarget, existing map[string]bool) (create, update []DeploymentTarget) {
+	for _, target := range targets {
+		if existing[fmt.Sprintf("%s/%s", target.AccountID, target.Region)] {
+			update = append(update, target)
+		} else {
+			create = append(create, target)
+		}
+	}
+	return create, update
+}
+
 // appendUnique appends a string to a slice only if it's not already present
 func appendUnique(slice []string, item string) []string {
 	for _, existing := range slice {
@@ -330,6 +421,14 @@ func runAction(c *cli.Context) error {
 	input := &Input{
 		StackSetName: c.String("stack-set-name"),
 		Targets:      targets,
+		Preferences: Preferences{
+			RegionOrder:                c.StringSlice("region-order"),
+			RegionConcurrencyType:      c.String("region-concurrency-type"),
+			MaxConcurrentCount:         int32(c.Int("max-concurrent-count")),
+			MaxConcurrentPercentage:    int32(c.Int("max-concurrent-percentage")),
+			FailureToleranceCount:      int32(c.Int("failure-tolerance-count")),
+			FailureTolerancePercentage: int32(c.Int("failure-tolerance-percentage")),
+		},
 	}
 
 	ctx := logger.WithContext(context.Background())
@@ -370,6 +469,30 @@ func main() {
 						EnvVars:  []string{"TARGETS"},
 						Required: true,
 					},
+					&cli.StringSliceFlag{
+						Name:  "region-order",
+						Usage: "Order to deploy regions in",
+					},
+					&cli.StringFlag{
+						Name:  "region-concurrency-type",
+						Usage: "SEQUENTIAL or PARALLEL",
+					},
+					&cli.IntFlag{
+						Name:  "max-concurrent-count",
+						Usage: "Accounts to deploy to at once (default 10)",
+					},
+					&cli.IntFlag{
+						Name:  "max-concurrent-percentage",
+						Usage: "Percentage of accounts to deploy to at once",
+					},
+					&cli.IntFlag{
+						Name:  "failure-tolerance-count",
+						Usage: "Failed accounts tolerated before the operation fails",
+					},
+					&cli.IntFlag{
+						Name:  "failure-tolerance-percentage",
+						Usage: "Percentage of failed accounts tolerated before the operation fails",
+					},
 				},
 				Action: runAction,
 			},
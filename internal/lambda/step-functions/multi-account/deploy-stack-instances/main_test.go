@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBatch(t *testing.T) {
+	a1 := DeploymentTarget{AccountID: "111111111111", Region: "us-east-1"}
+	a2 := DeploymentTarget{AccountID: "111111111111", Region: "us-west-2"}
+	b1 := DeploymentTarget{AccountID: "222222222222", Region: "us-east-1"}
+	b2 := DeploymentTarget{AccountID: "222222222222", Region: "us-west-2"}
+	c1 := DeploymentTarget{AccountID: "333333333333", Region: "us-east-1"}
+
+	t.Run("grid", func(t *testing.T) {
+		batch, remaining := nextBatch([]DeploymentTarget{a1, a2, b1, b2})
+		assert.Equal(t, []DeploymentTarget{a1, a2, b1, b2}, batch)
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("accounts with different regions", func(t *testing.T) {
+		// Deploying a2 and c1 in one operation would also deploy to a1 and c2
+		batch, remaining := nextBatch([]DeploymentTarget{a2, c1})
+		assert.Equal(t, []DeploymentTarget{a2}, batch)
+		assert.Equal(t, []DeploymentTarget{c1}, remaining)
+
+		batch, remaining = nextBatch(remaining)
+		assert.Equal(t, []DeploymentTarget{c1}, batch)
+		assert.Empty(t, remaining)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		batch, remaining := nextBatch(nil)
+		assert.Empty(t, batch)
+		assert.Empty(t, remaining)
+	})
+}
+
+func TestSplitExisting(t *testing.T) {
+	a1 := DeploymentTarget{AccountID: "111111111111", Region: "us-east-1"}
+	a2 := DeploymentTarget{AccountID: "111111111111", Region: "us-west-2"}
+	b1 := DeploymentTarget{AccountID: "222222222222", Region: "us-east-1"}
+	b2 := DeploymentTarget{AccountID: "222222222222", Region: "us-west-2"}
+
+	existing := map[string]bool{
+		"111111111111/us-east-1": true,
+		"222222222222/us-east-1": true,
+	}
+
+	create, update := splitExisting([]DeploymentTarget{a1, a2, b1, b2}, existing)
+	assert.Equal(t, []DeploymentTarget{a2, b2}, create)
+	assert.Equal(t, []DeploymentTarget{a1, b1}, update)
+
+	create, update = splitExisting([]DeploymentTarget{a2, b2}, existing)
+	assert.Equal(t, []DeploymentTarget{a2, b2}, create)
+	assert.Empty(t, update)
+}
+
+func TestPreferences_operationPreferences(t *testing.T) {
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1"}
+
+	prefs := Preferences{}.operationPreferences(regions)
+	assert.Equal(t, int32(10), *prefs.MaxConcurrentCount)
+	assert.Equal(t, int32(0), *prefs.FailureToleranceCount)
+	assert.Empty(t, prefs.RegionConcurrencyType)
+	assert.Nil(t, prefs.RegionOrder)
+
+	prefs = Preferences{MaxConcurrentCount: 1, FailureToleranceCount: 2}.operationPreferences(regions)
+	assert.Equal(t, int32(1), *prefs.MaxConcurrentCount)
+	assert.Equal(t, int32(2), *prefs.FailureToleranceCount)
+
+	prefs = Preferences{
+		RegionOrder:                []string{"eu-west-1", "ap-southeast-1", "us-east-1"},
+		RegionConcurrencyType:      "SEQUENTIAL",
+		MaxConcurrentPercentage:    25,
+		FailureTolerancePercentage: 10,
+	}.operationPreferences(regions)
+	assert.Nil(t, prefs.MaxConcurrentCount)
+	assert.Equal(t, int32(25), *prefs.MaxConcurrentPercentage)
+	assert.Nil(t, prefs.FailureToleranceCount)
+	assert.Equal(t, int32(10), *prefs.FailureTolerancePercentage)
+	assert.Equal(t, types.RegionConcurrencyTypeSequential, prefs.RegionConcurrencyType)
+	assert.Equal(t, []string{"eu-west-1", "us-east-1", "us-west-2"}, prefs.RegionOrder)
+}
@@ -31,9 +31,28 @@ type DeploymentTarget struct {
 	Region    string `json:"region"`
 }
 
+// Preferences are the StackSet operation preferences of a wave
+type Preferences struct {
+	RegionOrder                []string `json:"region_order,omitempty"`
+	RegionConcurrencyType      string   `json:"region_concurrency_type,omitempty"`
+	MaxConcurrentCount         int      `json:"max_concurrent_count,omitempty"`
+	MaxConcurrentPercentage    int      `json:"max_concurrent_percentage,omitempty"`
+	FailureToleranceCount      int      `json:"failure_tolerance_count,omitempty"`
+	FailureTolerancePercentage int      `json:"failure_tolerance_percentage,omitempty"`
+}
+
+// Wave is a group of targets deployed by one StackSet operation before the next wave starts
+type Wave struct {
+	Name        string             `json:"name"`
+	Targets     []DeploymentTarget `json:"targets"`
+	Preferences Preferences        `json:"preferences"`
+	BakeSeconds int                `json:"bake_seconds"` // wait after the wave succeeds (0 for the last wave)
+}
+
 type Output struct {
 	Targets []DeploymentTarget `json:"targets"`
 	Count   int                `json:"count"`
+	Waves   []Wave             `json:"waves"` // ordered rollout of Targets
 }
 
 func NewHandler(tableName string) (*Handler, error) {
@@ -79,15 +98,42 @@ func (h *Handler) HandleFetchTargets(ctx context.Context, input *Input) (*Output
 		}
 	}
 
+	// Split the targets into ordered rollout waves
+	var waves []Wave
+	for _, planned := range targetdao.PlanWaves(expanded, record.Waves, record.Preferences) {
+		wave := Wave{
+			Name:    planned.Name,
+			Targets: make([]DeploymentTarget, len(planned.Targets)),
+			Preferences: Preferences{
+				RegionOrder:                planned.Preferences.RegionOrder,
+				RegionConcurrencyType:      planned.Preferences.RegionConcurrencyType,
+				MaxConcurrentCount:         planned.Preferences.MaxConcurrentCount,
+				MaxConcurrentPercentage:    planned.Preferences.MaxConcurrentPercentage,
+				FailureToleranceCount:      planned.Preferences.FailureToleranceCount,
+				FailureTolerancePercentage: planned.Preferences.FailureTolerancePercentage,
+			},
+			BakeSeconds: planned.BakeSeconds,
+		}
+		for j, t := range planned.Targets {
+			wave.Targets[j] = DeploymentTarget{
+				AccountID: t.AccountID,
+				Region:    t.Region,
+			}
+		}
+		waves = append(waves, wave)
+	}
+
 	logger.Info().
 		Str("env", input.Env).
 		Str("repo", input.Repo).
 		Int("target_count", len(targets)).
+		Int("wave_count", len(waves)).
 		Msg("Deployment targets fetched successfully")
 
 	return &Output{
 		Targets: targets,
 		Count:   len(targets),
+		Waves:   waves,
 	}, nil
 }
 
@@ -6,15 +6,23 @@ import (
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/rs/zerolog"
 	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
 	"github.com/savaki/aws-deployer/internal/di"
+	"github.com/savaki/aws-deployer/internal/promotion"
+	"github.com/savaki/aws-deployer/internal/rollback"
 	"github.com/savaki/aws-deployer/internal/services"
 	"github.com/urfave/cli/v2"
 )
 
 type Handler struct {
 	dbService *services.DynamoDBService
+	promoter  *promotion.Promoter
+	rollback  *rollback.Manager
 }
 
 type UpdateStatusInput struct {
@@ -26,13 +34,25 @@ type UpdateStatusInput struct {
 }
 
 func NewHandler(env string) (*Handler, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
 	dbService, err := services.NewDynamoDBService(env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
 	}
 
+	client := dbService.GetClient()
+	buildDAO := builddao.New(client, builddao.TableName(env))
+	targetDAO := targetdao.New(client, targetdao.TableName(env))
+	driftDAO := driftdao.New(client, driftdao.TableName(env))
+
 	return &Handler{
 		dbService: dbService,
+		promoter:  promotion.New(buildDAO, targetDAO, driftDAO),
+		rollback:  rollback.New(buildDAO, targetDAO, rollback.NewCloudFormationInspector(cloudformation.NewFromConfig(cfg))),
 	}, nil
 }
 
@@ -49,7 +69,7 @@ func (h *Handler) HandleUpdateBuildStatus(ctx context.Context, input *UpdateStat
 	pk := builddao.NewPK(input.Repo, input.Env)
 	status := builddao.BuildStatus(input.Status)
 
-	_, err := h.dbService.UpdateBuildStatus(ctx, builddao.UpdateInput{
+	record, err := h.dbService.UpdateBuildStatus(ctx, builddao.UpdateInput{
 		PK:       pk,
 		SK:       input.SK,
 		Status:   &status,
@@ -65,6 +85,31 @@ func (h *Handler) HandleUpdateBuildStatus(ctx context.Context, input *UpdateStat
 		Str("sk", input.SK).
 		Msg("Successfully updated build status")
 
+	// The deployment itself succeeded, so a failed auto-promotion is logged rather than returned
+	if status == builddao.BuildStatusSuccess && h.promoter != nil {
+		if _, err := h.promoter.AutoPromote(ctx, record); err != nil {
+			logger.Error().
+				Err(err).
+				Str("repo", input.Repo).
+				Str("env", input.Env).
+				Str("sk", input.SK).
+				Msg("Failed to auto-promote build")
+		}
+	}
+
+	// Environments with auto_rollback redeploy their last known-good build when the failed
+	// deployment left the stack rolled back
+	if status == builddao.BuildStatusFailed && h.rollback != nil {
+		if _, err := h.rollback.Rollback(ctx, record); err != nil {
+			logger.Error().
+				Err(err).
+				Str("repo", input.Repo).
+				Str("env", input.Env).
+				Str("sk", input.SK).
+				Msg("Failed to roll back build")
+		}
+	}
+
 	return nil
 }
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/signer"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -55,7 +56,7 @@ func NewHandler(env string) (*Handler, error) {
 	signerClient := signer.NewFromConfig(cfg)
 	ssmClient := ssm.NewFromConfig(cfg)
 
-	verifier := services.NewSignatureVerifier(signerClient, s3Client, logger)
+	verifier := services.NewSignatureVerifier(env, signerClient, s3Client, ecr.NewFromConfig(cfg), ssmClient, logger)
 	metadataParser := services.NewContainerMetadataParser(s3Client, logger)
 
 	// Get account ID and region from STS
@@ -2,8 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -65,16 +70,17 @@ func NewHandler(env string) (*Handler, error) {
 	// Create single-account orchestrator
 	singleAccountOrch := orchestrator.New(sfnClient, appConfig.StateMachineArn, dao)
 
+	// The targets table holds change calendars in every mode and targets in multi mode
+	targetDAO := targetdao.New(dynamoClient, targetdao.TableName(env))
+
 	// Create multi-account orchestrator if in multi mode
 	var multiAccountOrch *orchestrator.Orchestrator
-	var targetDAO *targetdao.DAO
 	if appConfig.DeploymentMode == "multi" {
 		if appConfig.MultiAccountStateMachineArn == "" {
 			return nil, fmt.Errorf("MULTI_ACCOUNT_STATE_MACHINE_ARN required in multi deployment mode")
 		}
 
 		multiAccountOrch = orchestrator.New(sfnClient, appConfig.MultiAccountStateMachineArn, dao)
-		targetDAO = targetdao.New(dynamoClient, targetdao.TableName(env))
 	}
 
 	return &Handler{
@@ -134,6 +140,38 @@ func (h *Handler) processRecord(ctx context.Context, record *events.DynamoDBEven
 		return nil
 	}
 
+	// Builds awaiting approval are started by the approve mutation once fully approved
+	if buildRecord.Status == builddao.BuildStatusPendingApproval {
+		logger.Info().
+			Str("repo", buildRecord.Repo).
+			Str("env", buildRecord.Env).
+			Str("sk", buildRecord.SK).
+			Msg("Skipping build awaiting approval")
+		return nil
+	}
+
+	// Builds held by a freeze are started by the overrideFreeze mutation or released by
+	// HandleReleaseBlocked once the freeze ends
+	if buildRecord.Status == builddao.BuildStatusBlocked {
+		logger.Info().
+			Str("repo", buildRecord.Repo).
+			Str("env", buildRecord.Env).
+			Str("sk", buildRecord.SK).
+			Msg("Skipping build blocked by deploy freeze")
+		return nil
+	}
+
+	// Hold builds created during a freeze or outside allowed windows; rollbacks always proceed
+	if !buildRecord.IsRollback() {
+		held, err := h.holdIfFrozen(ctx, buildRecord)
+		if err != nil {
+			return err
+		}
+		if held {
+			return nil
+		}
+	}
+
 	logger.Info().
 		Str("repo", buildRecord.Repo).
 		Str("env", buildRecord.Env).
@@ -141,6 +179,11 @@ func (h *Handler) processRecord(ctx context.Context, record *events.DynamoDBEven
 		Str("version", buildRecord.Version).
 		Msg("Processing new build record")
 
+	return h.deploy(ctx, buildRecord)
+}
+
+// deploy starts the deployment execution of a build
+func (h *Handler) deploy(ctx context.Context, buildRecord builddao.Record) error {
 	// Construct Step Function input from build record
 	input := orchestrator.StepFunctionInput{
 		Repo:       buildRecord.Repo,
@@ -151,6 +194,7 @@ func (h *Handler) processRecord(ctx context.Context, record *events.DynamoDBEven
 		CommitHash: buildRecord.CommitHash,
 		S3Bucket:   h.config.S3Bucket,
 		S3Key:      fmt.Sprintf("%s/%s/%s", buildRecord.Repo, buildRecord.Branch, buildRecord.Version),
+		StartAfter: orchestrator.StartAfter(buildRecord),
 	}
 
 	// Route to appropriate deployment handler based on mode
@@ -160,6 +204,132 @@ func (h *Handler) processRecord(ctx context.Context, record *events.DynamoDBEven
 	return h.processSingleAccountDeployment(ctx, buildRecord, input)
 }
 
+// HandleReleaseBlocked starts the BLOCKED builds whose change calendar now allows them to deploy.
+// It runs on a schedule so builds held by a freeze or outside an allowed window deploy once the
+// window opens. Only the latest build of each repo/env is released, and builds held by a running
+// execution are left to that execution, which re-checks the calendar itself.
+func (h *Handler) HandleReleaseBlocked(ctx context.Context) error {
+	logger := zerolog.Ctx(ctx)
+
+	envs, err := h.environments(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var released int
+	for _, env := range envs {
+		latest, err := h.dao.QueryLatestBuilds(ctx, env)
+		if err != nil {
+			return err
+		}
+
+		for _, build := range latest {
+			if build.Status != builddao.BuildStatusBlocked || build.ExecutionArn != nil {
+				continue
+			}
+
+			reason, err := h.targetDAO.Blocked(ctx, build.Repo, build.Env, now)
+			if err != nil {
+				logger.Warn().
+					Err(err).
+					Str("repo", build.Repo).
+					Str("env", build.Env).
+					Str("sk", build.SK).
+					Msg("Failed to check change calendar")
+				continue
+			}
+			if reason != "" {
+				continue
+			}
+
+			record, err := h.dao.Release(ctx, build.GetID())
+			if errors.Is(err, builddao.ErrNotBlocked) {
+				continue // released or overridden concurrently
+			}
+			if err != nil {
+				logger.Warn().
+					Err(err).
+					Str("repo", build.Repo).
+					Str("env", build.Env).
+					Str("sk", build.SK).
+					Msg("Failed to release blocked build")
+				continue
+			}
+
+			logger.Info().
+				Str("repo", record.Repo).
+				Str("env", record.Env).
+				Str("sk", record.SK).
+				Str("blocked_reason", record.BlockedReason).
+				Msg("Releasing build after change calendar window opened")
+
+			if err := h.deploy(ctx, record); err != nil {
+				logger.Error().
+					Err(err).
+					Str("repo", record.Repo).
+					Str("env", record.Env).
+					Str("sk", record.SK).
+					Msg("Failed to start released build")
+				continue
+			}
+			released++
+		}
+	}
+
+	logger.Info().
+		Int("released", released).
+		Msg("Released blocked builds")
+	return nil
+}
+
+// environments returns the standard environments and any environment with targets configured
+func (h *Handler) environments(ctx context.Context) ([]string, error) {
+	envs := []string{"dev", "stg", "prd"}
+
+	records, err := h.targetDAO.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	for _, record := range records {
+		if record.SK == targetdao.ConfigEnv || slices.Contains(envs, record.SK) {
+			continue
+		}
+		envs = append(envs, record.SK)
+	}
+	return envs, nil
+}
+
+// holdIfFrozen marks the build BLOCKED if the environment's change calendar currently blocks
+// deploys and returns true if it did
+func (h *Handler) holdIfFrozen(ctx context.Context, buildRecord builddao.Record) (bool, error) {
+	reason, err := h.targetDAO.Blocked(ctx, buildRecord.Repo, buildRecord.Env, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to check change calendar: %w", err)
+	}
+	if reason == "" {
+		return false, nil
+	}
+
+	status := builddao.BuildStatusBlocked
+	if err := h.dao.UpdateStatus(ctx, builddao.UpdateInput{
+		PK:            builddao.NewPK(buildRecord.Repo, buildRecord.Env),
+		SK:            buildRecord.SK,
+		Status:        &status,
+		BlockedReason: &reason,
+	}); err != nil {
+		return false, fmt.Errorf("failed to block build: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("repo", buildRecord.Repo).
+		Str("env", buildRecord.Env).
+		Str("sk", buildRecord.SK).
+		Str("reason", reason).
+		Msg("Build blocked by change calendar")
+	return true, nil
+}
+
 func (h *Handler) processSingleAccountDeployment(ctx context.Context, buildRecord builddao.Record, input orchestrator.StepFunctionInput) error {
 	logger := zerolog.Ctx(ctx)
 
@@ -349,6 +519,23 @@ func unmarshalMap(m map[string]types.AttributeValue, out interface{}) error {
 				buildRecord.CommitHash = s.Value
 			}
 		}
+		if v, exists := m["status"]; exists {
+			if s, ok := v.(*types.AttributeValueMemberS); ok {
+				buildRecord.Status = builddao.BuildStatus(s.Value)
+			}
+		}
+		if v, exists := m["rollback_of"]; exists {
+			if s, ok := v.(*types.AttributeValueMemberS); ok {
+				buildRecord.RollbackOf = builddao.ID(s.Value)
+			}
+		}
+		if v, exists := m["start_after"]; exists {
+			if n, ok := v.(*types.AttributeValueMemberN); ok {
+				if startAfter, err := strconv.ParseInt(n.Value, 10, 64); err == nil {
+					buildRecord.StartAfter = startAfter
+				}
+			}
+		}
 		return nil
 	}
 
@@ -377,9 +564,20 @@ func main() {
 			os.Exit(1)
 		}
 
-		// Wrap handler to inject logger into context
-		wrappedHandler := func(ctx context.Context, event events.DynamoDBEvent) error {
+		// Wrap handler to inject logger into context. The function is invoked by the builds
+		// table stream and by a schedule that releases blocked builds.
+		wrappedHandler := func(ctx context.Context, payload json.RawMessage) error {
 			ctx = logger.WithContext(ctx)
+
+			var scheduled events.CloudWatchEvent
+			if err := json.Unmarshal(payload, &scheduled); err == nil && scheduled.Source == "aws.events" {
+				return handler.HandleReleaseBlocked(ctx)
+			}
+
+			var event events.DynamoDBEvent
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return fmt.Errorf("failed to parse event: %w", err)
+			}
 			return handler.HandleDynamoDBEvent(ctx, event)
 		}
 		lambda.Start(wrappedHandler)
@@ -396,6 +594,10 @@ func main() {
 				Usage:   "Disable AWS Systems Manager Parameter Store (use environment variables)",
 				EnvVars: []string{"DISABLE_SSM"},
 			},
+			&cli.BoolFlag{
+				Name:  "release-blocked",
+				Usage: "Start the blocked builds whose change calendar now allows them to deploy",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			handler, err := NewHandler(env)
@@ -403,6 +605,10 @@ func main() {
 				return fmt.Errorf("failed to create handler: %w", err)
 			}
 
+			if c.Bool("release-blocked") {
+				return handler.HandleReleaseBlocked(logger.WithContext(c.Context))
+			}
+
 			logger.Info().
 				Str("env", env).
 				Str("deployment_mode", handler.config.DeploymentMode).
@@ -535,3 +535,33 @@ func TestHandleDynamoDBEvent_SkipsNonInsertFromRealJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleDynamoDBEvent_SkipsPendingApproval(t *testing.T) {
+	// Orchestrators are nil, so any attempt to start an execution would panic
+	handler := &Handler{}
+
+	event := loadTestEvent(t, "pending_approval_event.json")
+
+	err := handler.HandleDynamoDBEvent(context.Background(), event)
+	if err != nil {
+		t.Errorf("Expected no error for build awaiting approval, got %v", err)
+	}
+}
+
+func TestUnmarshalPendingApprovalEvent(t *testing.T) {
+	event := loadTestEvent(t, "pending_approval_event.json")
+
+	newImage := make(map[string]types.AttributeValue)
+	for k, v := range event.Records[0].Change.NewImage {
+		newImage[k] = convertDynamoDBAttributeValue(v)
+	}
+
+	var buildRecord builddao.Record
+	if err := unmarshalMap(newImage, &buildRecord); err != nil {
+		t.Fatalf("Failed to unmarshal build record: %v", err)
+	}
+
+	if buildRecord.Status != builddao.BuildStatusPendingApproval {
+		t.Errorf("Expected Status '%s', got '%s'", builddao.BuildStatusPendingApproval, buildRecord.Status)
+	}
+}
@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/services"
+)
+
+// GitHubClient creates GitHub Deployments and statuses
+type GitHubClient interface {
+	CreateDeployment(ctx context.Context, owner, repo string, input services.GitHubDeploymentRequest) (*services.GitHubDeployment, error)
+	CreateDeploymentStatus(ctx context.Context, owner, repo string, deploymentID int64, input services.GitHubDeploymentStatusRequest) error
+	CreateCommitStatus(ctx context.Context, owner, repo, sha string, input services.GitHubCommitStatusRequest) error
+}
+
+// DeploymentStore records the GitHub Deployment created for a build
+type DeploymentStore interface {
+	SetGitHubDeployment(ctx context.Context, pk builddao.PK, sk string, repo string, deploymentID int64) error
+}
+
+// maxDescription is the longest description GitHub accepts on statuses
+const maxDescription = 140
+
+// gitHubStates maps events to GitHub deployment and commit status states
+var gitHubStates = map[Event]struct {
+	deployment string
+	commit     string
+}{
+	EventPendingApproval: {deployment: "queued", commit: "pending"},
+	EventBlocked:         {deployment: "queued", commit: "pending"},
+	EventWaitingOnLock:   {deployment: "queued", commit: "pending"},
+	EventStarted:         {deployment: "in_progress", commit: "pending"},
+	EventSucceeded:       {deployment: "success", commit: "success"},
+	EventFailed:          {deployment: "failure", commit: "failure"},
+}
+
+// GitHubReporter reports builds to GitHub as Deployments of the build's commit to its
+// environment, with a commit status per environment
+type GitHubReporter struct {
+	owner     string
+	targetURL string
+	client    GitHubClient
+	store     DeploymentStore
+
+	mu          sync.Mutex
+	deployments map[builddao.ID]int64 // Deployments created by this reporter, which may not be in stream images yet
+}
+
+// NewGitHubReporter creates a reporter for repositories owned by owner. targetURL, if set,
+// is linked from commit statuses.
+func NewGitHubReporter(owner, targetURL string, client GitHubClient, store DeploymentStore) *GitHubReporter {
+	return &GitHubReporter{
+		owner:       owner,
+		targetURL:   targetURL,
+		client:      client,
+		store:       store,
+		deployments: map[builddao.ID]int64{},
+	}
+}
+
+// Report updates GitHub for the transition of a build record from old to new. The GitHub
+// Deployment is created on the first reported event and its ID saved on the build.
+func (g *GitHubReporter) Report(ctx context.Context, old, new *builddao.Record) error {
+	if new == nil || new.CommitHash == "" {
+		return nil
+	}
+
+	var errs []error
+	for _, event := range Detect(old, new) {
+		if err := g.report(ctx, event, new); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", event, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *GitHubReporter) report(ctx context.Context, event Event, record *builddao.Record) error {
+	states, ok := gitHubStates[event]
+	if !ok {
+		return nil
+	}
+
+	msg := NewMessage(event, *record)
+	description, err := Render("", msg)
+	if err != nil {
+		return err
+	}
+	if event == EventFailed && msg.ErrorMsg != "" {
+		description = msg.ErrorMsg
+	}
+	if len(description) > maxDescription {
+		description = description[:maxDescription-3] + "..."
+	}
+
+	deploymentID, err := g.deployment(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	err = g.client.CreateDeploymentStatus(ctx, g.owner, record.Repo, deploymentID, services.GitHubDeploymentStatusRequest{
+		State:       states.deployment,
+		Description: description,
+		LogURL:      g.targetURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = g.client.CreateCommitStatus(ctx, g.owner, record.Repo, record.CommitHash, services.GitHubCommitStatusRequest{
+		State:       states.commit,
+		TargetURL:   g.targetURL,
+		Description: description,
+		Context:     "aws-deployer/" + record.Env,
+	})
+	if err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("build_id", msg.BuildID).
+		Str("event", string(event)).
+		Int64("deployment_id", deploymentID).
+		Msg("Reported build to GitHub")
+	return nil
+}
+
+// deployment returns the build's GitHub Deployment, creating it if needed
+func (g *GitHubReporter) deployment(ctx context.Context, record *builddao.Record) (int64, error) {
+	id := record.GetID()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if record.GitHubDeployment != 0 {
+		return record.GitHubDeployment, nil
+	}
+	if deploymentID, ok := g.deployments[id]; ok {
+		return deploymentID, nil
+	}
+
+	deployment, err := g.client.CreateDeployment(ctx, g.owner, record.Repo, services.GitHubDeploymentRequest{
+		Ref:              record.CommitHash,
+		Environment:      record.Env,
+		Description:      fmt.Sprintf("Deploy %s %s to %s", record.Repo, record.Version, record.Env),
+		AutoMerge:        false,
+		RequiredContexts: []string{},
+		Payload: map[string]string{
+			"build_id": id.String(),
+			"version":  record.Version,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	g.deployments[id] = deployment.ID
+
+	repo := g.owner + "/" + record.Repo
+	if err := g.store.SetGitHubDeployment(ctx, record.PK, record.SK, repo, deployment.ID); err != nil {
+		return 0, err
+	}
+
+	return deployment.ID, nil
+}
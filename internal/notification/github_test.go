@@ -0,0 +1,91 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGitHub struct {
+	deployments      []services.GitHubDeploymentRequest
+	deploymentStates []string
+	commitStatuses   []services.GitHubCommitStatusRequest
+}
+
+func (f *fakeGitHub) CreateDeployment(_ context.Context, owner, repo string, input services.GitHubDeploymentRequest) (*services.GitHubDeployment, error) {
+	f.deployments = append(f.deployments, input)
+	return &services.GitHubDeployment{ID: int64(100 + len(f.deployments))}, nil
+}
+
+func (f *fakeGitHub) CreateDeploymentStatus(_ context.Context, owner, repo string, deploymentID int64, input services.GitHubDeploymentStatusRequest) error {
+	f.deploymentStates = append(f.deploymentStates, input.State)
+	return nil
+}
+
+func (f *fakeGitHub) CreateCommitStatus(_ context.Context, owner, repo, sha string, input services.GitHubCommitStatusRequest) error {
+	f.commitStatuses = append(f.commitStatuses, input)
+	return nil
+}
+
+type fakeDeploymentStore map[string]int64
+
+func (f fakeDeploymentStore) SetGitHubDeployment(_ context.Context, pk builddao.PK, sk string, repo string, deploymentID int64) error {
+	f[repo+"@"+pk.String()+":"+sk] = deploymentID
+	return nil
+}
+
+func TestGitHubReporter_Report(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeGitHub{}
+	store := fakeDeploymentStore{}
+	reporter := NewGitHubReporter("acme", "https://deployer.example.com/", client, store)
+
+	pending := newRecord(builddao.BuildStatusPending)
+	started := newRecord(builddao.BuildStatusInProgress)
+	failed := newRecord(builddao.BuildStatusFailed)
+	errorMsg := strings.Repeat("x", 200)
+	failed.ErrorMsg = &errorMsg
+
+	assert.NoError(t, reporter.Report(ctx, pending, started))
+	assert.NoError(t, reporter.Report(ctx, started, failed))
+
+	// One deployment per build, reused across events
+	assert.Len(t, client.deployments, 1)
+	assert.Equal(t, "abc1234def5678", client.deployments[0].Ref)
+	assert.Equal(t, "prd", client.deployments[0].Environment)
+	assert.Equal(t, "my-app/prd:2HFj3kLmNoPqRsTuVwXy", client.deployments[0].Payload["build_id"])
+	assert.Equal(t, fakeDeploymentStore{"acme/my-app@my-app/prd:2HFj3kLmNoPqRsTuVwXy": 101}, store)
+
+	assert.Equal(t, []string{"in_progress", "failure"}, client.deploymentStates)
+	assert.Len(t, client.commitStatuses, 2)
+	assert.Equal(t, "pending", client.commitStatuses[0].State)
+	assert.Equal(t, "aws-deployer/prd", client.commitStatuses[0].Context)
+	assert.Equal(t, "https://deployer.example.com/", client.commitStatuses[0].TargetURL)
+	assert.Equal(t, "failure", client.commitStatuses[1].State)
+	assert.Len(t, client.commitStatuses[1].Description, maxDescription)
+
+	t.Run("uses stored deployment", func(t *testing.T) {
+		client := &fakeGitHub{}
+		reporter := NewGitHubReporter("acme", "", client, fakeDeploymentStore{})
+
+		succeeded := newRecord(builddao.BuildStatusSuccess)
+		succeeded.GitHubDeployment = 42
+		assert.NoError(t, reporter.Report(ctx, started, succeeded))
+		assert.Empty(t, client.deployments)
+		assert.Equal(t, []string{"success"}, client.deploymentStates)
+	})
+
+	t.Run("no commit", func(t *testing.T) {
+		client := &fakeGitHub{}
+		reporter := NewGitHubReporter("acme", "", client, fakeDeploymentStore{})
+
+		noCommit := newRecord(builddao.BuildStatusSuccess)
+		noCommit.CommitHash = ""
+		assert.NoError(t, reporter.Report(ctx, started, noCommit))
+		assert.Empty(t, client.deploymentStates)
+	})
+}
@@ -0,0 +1,358 @@
+// Package notification tells subscribers about build status transitions via Slack, signed
+// webhooks and SNS
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/notificationdao"
+)
+
+// Event is a build transition subscribers can be notified about
+type Event string
+
+const (
+	EventPendingApproval Event = "pending_approval" // Build is waiting for approval
+	EventBlocked         Event = "blocked"          // Build is held by a deploy freeze
+	EventWaitingOnLock   Event = "waiting_on_lock"  // Build is queued behind another build's deployment lock
+	EventStarted         Event = "started"          // Step Functions execution started
+	EventSucceeded       Event = "succeeded"        // Deployment succeeded
+	EventFailed          Event = "failed"           // Deployment failed
+)
+
+// Events lists every event, in the order they occur
+var Events = []Event{EventPendingApproval, EventBlocked, EventWaitingOnLock, EventStarted, EventSucceeded, EventFailed}
+
+// ParseEvents parses a comma separated list of events
+func ParseEvents(s string) ([]string, error) {
+	var events []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		valid := false
+		for _, known := range Events {
+			if Event(e) == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid event %q: expected one of %v", e, Events)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// Detect returns the events caused by a build record changing from old to new. old is nil
+// when the record was just created.
+func Detect(old, new *builddao.Record) []Event {
+	if new == nil {
+		return nil
+	}
+
+	var events []Event
+	if old == nil || old.Status != new.Status {
+		switch new.Status {
+		case builddao.BuildStatusPendingApproval:
+			events = append(events, EventPendingApproval)
+		case builddao.BuildStatusBlocked:
+			events = append(events, EventBlocked)
+		case builddao.BuildStatusInProgress:
+			events = append(events, EventStarted)
+		case builddao.BuildStatusSuccess:
+			events = append(events, EventSucceeded)
+		case builddao.BuildStatusFailed:
+			events = append(events, EventFailed)
+		}
+	}
+
+	if new.LockHolder != "" && (old == nil || old.LockHolder != new.LockHolder) {
+		events = append(events, EventWaitingOnLock)
+	}
+
+	return events
+}
+
+// Message describes a build transition. It is the data passed to message templates and the
+// JSON body of webhook notifications.
+type Message struct {
+	Event         Event     `json:"event"`
+	BuildID       string    `json:"build_id"`
+	Repo          string    `json:"repo"`
+	Env           string    `json:"env"`
+	Version       string    `json:"version"`
+	Branch        string    `json:"branch,omitempty"`
+	CommitHash    string    `json:"commit_hash,omitempty"`
+	Status        string    `json:"status"`
+	PromotedBy    string    `json:"promoted_by,omitempty"`
+	RollbackOf    string    `json:"rollback_of,omitempty"`
+	LockHolder    string    `json:"lock_holder,omitempty"`
+	BlockedReason string    `json:"blocked_reason,omitempty"`
+	ErrorMsg      string    `json:"error_msg,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// NewMessage creates the message for an event on a build
+func NewMessage(event Event, record builddao.Record) Message {
+	msg := Message{
+		Event:         event,
+		BuildID:       record.GetID().String(),
+		Repo:          record.Repo,
+		Env:           record.Env,
+		Version:       record.Version,
+		Branch:        record.Branch,
+		CommitHash:    record.CommitHash,
+		Status:        string(record.Status),
+		PromotedBy:    record.PromotedBy,
+		RollbackOf:    record.RollbackOf.String(),
+		LockHolder:    record.LockHolder.String(),
+		BlockedReason: record.BlockedReason,
+		Time:          time.Unix(record.UpdatedAt, 0).UTC(),
+	}
+	if record.ErrorMsg != nil {
+		msg.ErrorMsg = *record.ErrorMsg
+	}
+	return msg
+}
+
+// ShortCommit returns the first 7 characters of the commit hash
+func (m Message) ShortCommit() string {
+	if len(m.CommitHash) > 7 {
+		return m.CommitHash[:7]
+	}
+	return m.CommitHash
+}
+
+// defaultTemplates are used by subscriptions without a template of their own
+var defaultTemplates = map[Event]string{
+	EventPendingApproval: `{{.Repo}} {{.Version}} is waiting for approval to deploy to {{.Env}}{{if .PromotedBy}} (promoted by {{.PromotedBy}}){{end}}`,
+	EventBlocked:         `{{.Repo}} {{.Version}} is blocked from deploying to {{.Env}}{{if .BlockedReason}}: {{.BlockedReason}}{{end}}`,
+	EventWaitingOnLock:   `{{.Repo}} {{.Version}} is waiting for {{.LockHolder}} to finish deploying to {{.Env}}`,
+	EventStarted:         `{{.Repo}} {{.Version}} ({{.ShortCommit}}) started deploying to {{.Env}}{{if .PromotedBy}} (promoted by {{.PromotedBy}}){{end}}{{if .RollbackOf}} to roll back {{.RollbackOf}}{{end}}`,
+	EventSucceeded:       `{{.Repo}} {{.Version}} ({{.ShortCommit}}) deployed to {{.Env}}{{if .PromotedBy}} (promoted by {{.PromotedBy}}){{end}}`,
+	EventFailed:          `{{.Repo}} {{.Version}} ({{.ShortCommit}}) failed to deploy to {{.Env}}{{if .ErrorMsg}}: {{.ErrorMsg}}{{end}}`,
+}
+
+// Render renders a message with the given template, or the event's default template if empty
+func Render(tmpl string, msg Message) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultTemplates[msg.Event]
+	}
+
+	t, err := template.New(string(msg.Event)).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// SubscriptionStore finds the subscriptions matching a build
+type SubscriptionStore interface {
+	QueryForBuild(ctx context.Context, repo, env string) ([]notificationdao.Record, error)
+}
+
+// HTTPDoer sends HTTP requests
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Publisher publishes messages to SNS topics
+type Publisher interface {
+	Publish(ctx context.Context, topicArn, subject, message string) error
+}
+
+// SecretGetter reads secrets from Secrets Manager
+type SecretGetter interface {
+	GetSecret(ctx context.Context, secretPath string) (string, error)
+}
+
+// Header names of signed webhook requests
+const (
+	HeaderEvent     = "X-Deployer-Event"
+	HeaderTimestamp = "X-Deployer-Timestamp"
+	HeaderSignature = "X-Deployer-Signature"
+)
+
+// Sign returns the signature of a webhook body: "sha256=" followed by the hex HMAC-SHA256 of
+// "{timestamp}.{body}" keyed with the subscription's signing key
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier delivers notifications for build transitions to matching subscriptions
+type Notifier struct {
+	env           string
+	subscriptions SubscriptionStore
+	httpClient    HTTPDoer
+	publisher     Publisher
+	secrets       SecretGetter
+	now           func() time.Time
+}
+
+// New creates a new Notifier. Secrets must be stored under aws-deployer/{env}/notifications/.
+func New(env string, subscriptions SubscriptionStore, httpClient HTTPDoer, publisher Publisher, secrets SecretGetter) *Notifier {
+	return &Notifier{
+		env:           env,
+		subscriptions: subscriptions,
+		httpClient:    httpClient,
+		publisher:     publisher,
+		secrets:       secrets,
+		now:           time.Now,
+	}
+}
+
+// Notify sends notifications for the transition of a build record from old to new. Every
+// subscription is attempted; failures are returned together.
+func (n *Notifier) Notify(ctx context.Context, old, new *builddao.Record) error {
+	events := Detect(old, new)
+	if len(events) == 0 {
+		return nil
+	}
+
+	subscriptions, err := n.subscriptions.QueryForBuild(ctx, new.Repo, new.Env)
+	if err != nil {
+		return fmt.Errorf("failed to find subscriptions: %w", err)
+	}
+
+	logger := zerolog.Ctx(ctx)
+
+	var errs []error
+	for _, event := range events {
+		msg := NewMessage(event, *new)
+		for _, sub := range subscriptions {
+			if !sub.Wants(string(event)) {
+				continue
+			}
+
+			if err := n.send(ctx, sub, msg); err != nil {
+				errs = append(errs, fmt.Errorf("subscription %s: %w", sub.GetID(), err))
+				continue
+			}
+
+			logger.Info().
+				Str("subscription", sub.GetID().String()).
+				Str("channel", string(sub.Channel)).
+				Str("event", string(event)).
+				Str("build_id", msg.BuildID).
+				Msg("Sent notification")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) send(ctx context.Context, sub notificationdao.Record, msg Message) error {
+	text, err := Render(sub.Template, msg)
+	if err != nil {
+		return err
+	}
+
+	switch sub.Channel {
+	case notificationdao.ChannelSlack:
+		webhookURL := sub.Target
+		if sub.SecretName != "" {
+			if webhookURL, err = n.secret(ctx, sub.SecretName); err != nil {
+				return err
+			}
+		}
+		body, err := json.Marshal(map[string]string{"text": text})
+		if err != nil {
+			return err
+		}
+		return n.post(ctx, webhookURL, body, nil)
+
+	case notificationdao.ChannelWebhook:
+		payload := struct {
+			Message
+			Text string `json:"text"`
+		}{Message: msg, Text: text}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		headers := map[string]string{HeaderEvent: string(msg.Event)}
+		if sub.SecretName != "" {
+			key, err := n.secret(ctx, sub.SecretName)
+			if err != nil {
+				return err
+			}
+			timestamp := strconv.FormatInt(n.now().Unix(), 10)
+			headers[HeaderTimestamp] = timestamp
+			headers[HeaderSignature] = Sign([]byte(key), timestamp, body)
+		}
+		return n.post(ctx, sub.Target, body, headers)
+
+	case notificationdao.ChannelSNS:
+		subject := fmt.Sprintf("[%s] %s %s %s", msg.Env, msg.Repo, msg.Version, msg.Event)
+		return n.publisher.Publish(ctx, sub.Target, subject, text)
+
+	default:
+		return sub.Channel.Validate()
+	}
+}
+
+// secret reads a secret, which must belong to this deployer's environment
+func (n *Notifier) secret(ctx context.Context, name string) (string, error) {
+	prefix := fmt.Sprintf("aws-deployer/%s/notifications/", n.env)
+	if !strings.HasPrefix(name, prefix) {
+		return "", fmt.Errorf("secret %s must be under %s", name, prefix)
+	}
+	return n.secrets.GetSecret(ctx, name)
+}
+
+func (n *Notifier) post(ctx context.Context, target string, body []byte, headers map[string]string) error {
+	if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+		return fmt.Errorf("invalid webhook URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		// The URL may be a secret (Slack), so it is left out of the error
+		return fmt.Errorf("failed to send webhook: %w", errors.Unwrap(err))
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
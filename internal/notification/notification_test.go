@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/notificationdao"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore []notificationdao.Record
+
+func (f fakeStore) QueryForBuild(_ context.Context, _, _ string) ([]notificationdao.Record, error) {
+	return f, nil
+}
+
+type fakeSecrets map[string]string
+
+func (f fakeSecrets) GetSecret(_ context.Context, path string) (string, error) {
+	return f[path], nil
+}
+
+type published struct {
+	topicArn, subject, message string
+}
+
+type fakePublisher struct {
+	messages []published
+}
+
+func (f *fakePublisher) Publish(_ context.Context, topicArn, subject, message string) error {
+	f.messages = append(f.messages, published{topicArn: topicArn, subject: subject, message: message})
+	return nil
+}
+
+func newRecord(status builddao.BuildStatus) *builddao.Record {
+	return &builddao.Record{
+		PK:         builddao.NewPK("my-app", "prd"),
+		SK:         "2HFj3kLmNoPqRsTuVwXy",
+		Repo:       "my-app",
+		Env:        "prd",
+		Version:    "42.abc1234",
+		CommitHash: "abc1234def5678",
+		Status:     status,
+		PromotedBy: "alice@example.com",
+	}
+}
+
+func TestDetect(t *testing.T) {
+	waiting := newRecord(builddao.BuildStatusInProgress)
+	waiting.LockHolder = "my-app/prd:other"
+
+	testCases := map[string]struct {
+		old, new *builddao.Record
+		want     []Event
+	}{
+		"insert pending": {
+			new:  newRecord(builddao.BuildStatusPending),
+			want: nil,
+		},
+		"insert pending approval": {
+			new:  newRecord(builddao.BuildStatusPendingApproval),
+			want: []Event{EventPendingApproval},
+		},
+		"blocked": {
+			old:  newRecord(builddao.BuildStatusPending),
+			new:  newRecord(builddao.BuildStatusBlocked),
+			want: []Event{EventBlocked},
+		},
+		"started": {
+			old:  newRecord(builddao.BuildStatusPending),
+			new:  newRecord(builddao.BuildStatusInProgress),
+			want: []Event{EventStarted},
+		},
+		"succeeded": {
+			old:  newRecord(builddao.BuildStatusInProgress),
+			new:  newRecord(builddao.BuildStatusSuccess),
+			want: []Event{EventSucceeded},
+		},
+		"failed": {
+			old:  newRecord(builddao.BuildStatusInProgress),
+			new:  newRecord(builddao.BuildStatusFailed),
+			want: []Event{EventFailed},
+		},
+		"unchanged": {
+			old:  newRecord(builddao.BuildStatusInProgress),
+			new:  newRecord(builddao.BuildStatusInProgress),
+			want: nil,
+		},
+		"waiting on lock": {
+			old:  newRecord(builddao.BuildStatusInProgress),
+			new:  waiting,
+			want: []Event{EventWaitingOnLock},
+		},
+		"still waiting on lock": {
+			old:  waiting,
+			new:  waiting,
+			want: nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Detect(tc.old, tc.new))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	failed := newRecord(builddao.BuildStatusFailed)
+	errorMsg := "stack rolled back"
+	failed.ErrorMsg = &errorMsg
+
+	got, err := Render("", NewMessage(EventFailed, *failed))
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app 42.abc1234 (abc1234) failed to deploy to prd: stack rolled back", got)
+
+	got, err = Render("{{.Env}}/{{.Repo}} by {{.PromotedBy}}", NewMessage(EventStarted, *failed))
+	assert.NoError(t, err)
+	assert.Equal(t, "prd/my-app by alice@example.com", got)
+
+	blocked := newRecord(builddao.BuildStatusBlocked)
+	blocked.BlockedReason = "Deploy freeze: holiday"
+	got, err = Render("", NewMessage(EventBlocked, *blocked))
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app 42.abc1234 is blocked from deploying to prd: Deploy freeze: holiday", got)
+
+	_, err = Render("{{.Unknown}}", NewMessage(EventStarted, *failed))
+	assert.Error(t, err)
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := ParseEvents("started, failed")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"started", "failed"}, events)
+
+	events, err = ParseEvents("")
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = ParseEvents("started,exploded")
+	assert.Error(t, err)
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	type request struct {
+		path    string
+		headers http.Header
+		body    []byte
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{path: r.URL.Path, headers: r.Header, body: body})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := fakeStore{
+		{PK: "my-app/prd", SK: "1", Channel: notificationdao.ChannelSlack, SecretName: "aws-deployer/dev/notifications/slack"},
+		{PK: "$/prd", SK: "2", Channel: notificationdao.ChannelWebhook, Target: server.URL + "/webhook", SecretName: "aws-deployer/dev/notifications/key"},
+		{PK: "$/$", SK: "3", Channel: notificationdao.ChannelSNS, Target: "arn:aws:sns:us-east-1:123456789012:deploys", Events: []string{"failed"}},
+	}
+	secrets := fakeSecrets{
+		"aws-deployer/dev/notifications/slack": server.URL + "/slack",
+		"aws-deployer/dev/notifications/key":   "signing-key",
+	}
+	publisher := &fakePublisher{}
+
+	notifier := New("dev", store, server.Client(), publisher, secrets)
+	notifier.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	ctx := context.Background()
+
+	t.Run("succeeded", func(t *testing.T) {
+		requests = nil
+		err := notifier.Notify(ctx, newRecord(builddao.BuildStatusInProgress), newRecord(builddao.BuildStatusSuccess))
+		assert.NoError(t, err)
+		assert.Len(t, requests, 2)
+		assert.Empty(t, publisher.messages, "sns subscription only wants failures")
+
+		// Slack
+		assert.Equal(t, "/slack", requests[0].path)
+		var slack map[string]string
+		assert.NoError(t, json.Unmarshal(requests[0].body, &slack))
+		assert.Equal(t, "my-app 42.abc1234 (abc1234) deployed to prd (promoted by alice@example.com)", slack["text"])
+
+		// Signed webhook
+		webhook := requests[1]
+		assert.Equal(t, "/webhook", webhook.path)
+		assert.Equal(t, "succeeded", webhook.headers.Get(HeaderEvent))
+		assert.Equal(t, "1700000000", webhook.headers.Get(HeaderTimestamp))
+		assert.Equal(t, Sign([]byte("signing-key"), "1700000000", webhook.body), webhook.headers.Get(HeaderSignature))
+
+		var msg Message
+		assert.NoError(t, json.Unmarshal(webhook.body, &msg))
+		assert.Equal(t, EventSucceeded, msg.Event)
+		assert.Equal(t, "my-app/prd:2HFj3kLmNoPqRsTuVwXy", msg.BuildID)
+		assert.Equal(t, "abc1234def5678", msg.CommitHash)
+		assert.Equal(t, "alice@example.com", msg.PromotedBy)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		requests = nil
+		failed := newRecord(builddao.BuildStatusFailed)
+		errorMsg := "stack rolled back"
+		failed.ErrorMsg = &errorMsg
+
+		err := notifier.Notify(ctx, newRecord(builddao.BuildStatusInProgress), failed)
+		assert.NoError(t, err)
+		assert.Len(t, requests, 2)
+		assert.Len(t, publisher.messages, 1)
+		assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:deploys", publisher.messages[0].topicArn)
+		assert.Equal(t, "[prd] my-app 42.abc1234 failed", publisher.messages[0].subject)
+		assert.Contains(t, publisher.messages[0].message, "stack rolled back")
+	})
+
+	t.Run("secret outside env", func(t *testing.T) {
+		bad := New("dev", fakeStore{
+			{PK: "my-app/prd", SK: "4", Channel: notificationdao.ChannelSlack, SecretName: "aws-deployer/prd/secrets"},
+		}, server.Client(), publisher, secrets)
+
+		err := bad.Notify(ctx, newRecord(builddao.BuildStatusInProgress), newRecord(builddao.BuildStatusSuccess))
+		assert.Error(t, err)
+	})
+}
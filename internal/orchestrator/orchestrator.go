@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sfn"
@@ -12,14 +13,24 @@ import (
 
 // StepFunctionInput represents the input payload for Step Functions executions
 type StepFunctionInput struct {
-	Repo       string `json:"repo"`        // Repository name
-	Env        string `json:"env"`         // Environment name (dev, staging, prod)
-	Branch     string `json:"branch"`      // Git branch
-	Version    string `json:"version"`     // Version string
-	SK         string `json:"sk"`          // KSUID - DynamoDB sort key
-	CommitHash string `json:"commit_hash"` // Git commit hash
-	S3Bucket   string `json:"s3_bucket"`   // S3 bucket containing artifacts
-	S3Key      string `json:"s3_key"`      // S3 key prefix for artifacts
+	Repo       string `json:"repo"`                  // Repository name
+	Env        string `json:"env"`                   // Environment name (dev, staging, prod)
+	Branch     string `json:"branch"`                // Git branch
+	Version    string `json:"version"`               // Version string
+	SK         string `json:"sk"`                    // KSUID - DynamoDB sort key
+	CommitHash string `json:"commit_hash"`           // Git commit hash
+	S3Bucket   string `json:"s3_bucket"`             // S3 bucket containing artifacts
+	S3Key      string `json:"s3_key"`                // S3 key prefix for artifacts
+	StartAfter string `json:"start_after,omitempty"` // RFC3339 time the execution waits for before deploying (soak time)
+}
+
+// StartAfter returns the RFC3339 timestamp a build must wait for before deploying,
+// or an empty string if the build may deploy immediately
+func StartAfter(build builddao.Record) string {
+	if build.StartAfter <= time.Now().Unix() {
+		return ""
+	}
+	return time.Unix(build.StartAfter, 0).UTC().Format(time.RFC3339)
 }
 
 // Orchestrator manages Step Functions execution lifecycle
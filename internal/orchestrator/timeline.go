@@ -0,0 +1,252 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+// StepStatus is the outcome of a state in an execution timeline
+type StepStatus string
+
+const (
+	StepStatusInProgress StepStatus = "IN_PROGRESS"
+	StepStatusSucceeded  StepStatus = "SUCCEEDED"
+	StepStatusFailed     StepStatus = "FAILED"
+)
+
+// Step is a single visit to a state of a Step Functions execution. States visited more than
+// once (such as the AcquireLock/WaitForLock loop) appear once per visit.
+type Step struct {
+	Name      string     // State name
+	Type      string     // State type (Task, Choice, Wait, Pass, Map, Parallel, Succeed, Fail)
+	Parent    string     // Enclosing Map or Parallel state, empty for top-level states
+	Iteration *int32     // Map iteration index, nil outside a Map iteration
+	Status    StepStatus // Outcome of the visit
+	EnteredAt time.Time  // Time the state was entered
+	ExitedAt  *time.Time // Time the state was exited, nil while in progress
+	Attempts  int        // Number of times a Task state ran its resource (1 + retries)
+	Error     string     // Error name of the last failure
+	Cause     string     // Error cause of the last failure
+}
+
+// Duration returns how long the state ran, or zero while it is still in progress
+func (s Step) Duration() time.Duration {
+	if s.ExitedAt == nil {
+		return 0
+	}
+	return s.ExitedAt.Sub(s.EnteredAt)
+}
+
+// Retries returns the number of times a Task state was retried
+func (s Step) Retries() int {
+	if s.Attempts <= 1 {
+		return 0
+	}
+	return s.Attempts - 1
+}
+
+// Timeline returns the states visited by an execution, in the order they were entered
+func (o *Orchestrator) Timeline(ctx context.Context, executionArn string) ([]Step, error) {
+	var events []types.HistoryEvent
+	var nextToken *string
+	for {
+		output, err := o.sfnClient.GetExecutionHistory(ctx, &sfn.GetExecutionHistoryInput{
+			ExecutionArn:         aws.String(executionArn),
+			IncludeExecutionData: aws.Bool(false),
+			MaxResults:           1000,
+			NextToken:            nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get execution history: %w", err)
+		}
+		events = append(events, output.Events...)
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	return BuildTimeline(events), nil
+}
+
+// BuildTimeline converts execution history events, oldest first, into a timeline of states.
+//
+// Events are attributed to states by following PreviousEventId, which links each event to the
+// preceding event on the same branch, so states inside concurrent Map iterations are tracked
+// independently.
+func BuildTimeline(events []types.HistoryEvent) []Step {
+	var (
+		steps   []Step
+		parents []int               // parents[i] is the index of the Map/Parallel step enclosing steps[i], or -1
+		stepOf  = map[int64]int{}   // event ID -> index of the step the event belongs to, or -1
+		indexOf = map[int64]int32{} // MapIterationStarted event ID -> iteration index
+	)
+
+	// open returns the nearest step at or above i that has not exited yet, or -1
+	open := func(i int) int {
+		for i >= 0 && steps[i].ExitedAt != nil {
+			i = parents[i]
+		}
+		return i
+	}
+
+	// fail records a failure on the step owning an event
+	fail := func(i int, errorName, cause *string) {
+		if i < 0 {
+			return
+		}
+		steps[i].Error = aws.ToString(errorName)
+		steps[i].Cause = aws.ToString(cause)
+		steps[i].Status = StepStatusFailed
+	}
+
+	for _, event := range events {
+		owner, ok := stepOf[event.PreviousEventId]
+		if !ok {
+			owner = -1
+		}
+		timestamp := aws.ToTime(event.Timestamp)
+
+		switch {
+		case event.StateEnteredEventDetails != nil:
+			parent := -1
+			var iteration *int32
+			if owner >= 0 {
+				if steps[owner].ExitedAt == nil {
+					// Entered from inside an open Map/Parallel state
+					parent = owner
+				} else {
+					parent = parents[owner]
+					iteration = steps[owner].Iteration
+				}
+			}
+			if pending, ok := indexOf[event.PreviousEventId]; ok {
+				iteration = &pending
+			}
+
+			step := Step{
+				Name:      aws.ToString(event.StateEnteredEventDetails.Name),
+				Type:      stateType(event.Type),
+				Iteration: iteration,
+				Status:    StepStatusInProgress,
+				EnteredAt: timestamp,
+			}
+			if parent >= 0 {
+				step.Parent = steps[parent].Name
+			}
+
+			steps = append(steps, step)
+			parents = append(parents, parent)
+			stepOf[event.Id] = len(steps) - 1
+			continue
+
+		case event.StateExitedEventDetails != nil:
+			// Climb out of nested states until reaching the open state being exited
+			name := aws.ToString(event.StateExitedEventDetails.Name)
+			i := open(owner)
+			for i >= 0 && steps[i].Name != name {
+				i = open(parents[i])
+			}
+			if i >= 0 {
+				exitedAt := timestamp
+				steps[i].ExitedAt = &exitedAt
+				if steps[i].Status == StepStatusInProgress {
+					steps[i].Status = StepStatusSucceeded
+				}
+			}
+			stepOf[event.Id] = i
+			continue
+		}
+
+		current := open(owner)
+		stepOf[event.Id] = current
+
+		switch event.Type {
+		case types.HistoryEventTypeTaskScheduled,
+			types.HistoryEventTypeLambdaFunctionScheduled,
+			types.HistoryEventTypeActivityScheduled:
+			if current >= 0 {
+				steps[current].Attempts++
+				steps[current].Status = StepStatusInProgress
+			}
+
+		case types.HistoryEventTypeMapIterationStarted:
+			if d := event.MapIterationStartedEventDetails; d != nil && d.Index != nil {
+				indexOf[event.Id] = *d.Index
+			}
+
+		case types.HistoryEventTypeTaskFailed:
+			if d := event.TaskFailedEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeTaskTimedOut:
+			if d := event.TaskTimedOutEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeTaskStartFailed:
+			if d := event.TaskStartFailedEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeTaskSubmitFailed:
+			if d := event.TaskSubmitFailedEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeLambdaFunctionFailed:
+			if d := event.LambdaFunctionFailedEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeLambdaFunctionTimedOut:
+			if d := event.LambdaFunctionTimedOutEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeActivityFailed:
+			if d := event.ActivityFailedEventDetails; d != nil {
+				fail(current, d.Error, d.Cause)
+			}
+		case types.HistoryEventTypeMapStateFailed, types.HistoryEventTypeParallelStateFailed:
+			fail(current, aws.String("States.BranchFailed"), nil)
+
+		case types.HistoryEventTypeExecutionFailed,
+			types.HistoryEventTypeExecutionAborted,
+			types.HistoryEventTypeExecutionTimedOut:
+			errorName, cause := executionError(event)
+			// States still open when the execution ends never exit
+			for i := range steps {
+				if steps[i].ExitedAt == nil {
+					exitedAt := timestamp
+					steps[i].ExitedAt = &exitedAt
+					steps[i].Status = StepStatusFailed
+					if steps[i].Error == "" {
+						steps[i].Error, steps[i].Cause = errorName, cause
+					}
+				}
+			}
+		}
+	}
+
+	return steps
+}
+
+// stateType derives the state type from a StateEntered event type (e.g. TaskStateEntered -> Task)
+func stateType(eventType types.HistoryEventType) string {
+	return strings.TrimSuffix(string(eventType), "StateEntered")
+}
+
+// executionError returns the error and cause recorded when an execution stops
+func executionError(event types.HistoryEvent) (string, string) {
+	switch {
+	case event.ExecutionFailedEventDetails != nil:
+		return aws.ToString(event.ExecutionFailedEventDetails.Error), aws.ToString(event.ExecutionFailedEventDetails.Cause)
+	case event.ExecutionAbortedEventDetails != nil:
+		return aws.ToString(event.ExecutionAbortedEventDetails.Error), aws.ToString(event.ExecutionAbortedEventDetails.Cause)
+	case event.ExecutionTimedOutEventDetails != nil:
+		return aws.ToString(event.ExecutionTimedOutEventDetails.Error), aws.ToString(event.ExecutionTimedOutEventDetails.Cause)
+	}
+	return string(event.Type), ""
+}
@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+// history builds execution history events with sequential IDs, one second apart
+type history struct {
+	start  time.Time
+	events []types.HistoryEvent
+}
+
+func (h *history) add(prev int64, event types.HistoryEvent) int64 {
+	id := int64(len(h.events) + 1)
+	event.Id = id
+	event.PreviousEventId = prev
+	event.Timestamp = aws.Time(h.start.Add(time.Duration(id) * time.Second))
+	h.events = append(h.events, event)
+	return id
+}
+
+func (h *history) enter(prev int64, eventType types.HistoryEventType, name string) int64 {
+	return h.add(prev, types.HistoryEvent{
+		Type:                     eventType,
+		StateEnteredEventDetails: &types.StateEnteredEventDetails{Name: aws.String(name)},
+	})
+}
+
+func (h *history) exit(prev int64, eventType types.HistoryEventType, name string) int64 {
+	return h.add(prev, types.HistoryEvent{
+		Type:                    eventType,
+		StateExitedEventDetails: &types.StateExitedEventDetails{Name: aws.String(name)},
+	})
+}
+
+func (h *history) task(prev int64, name string, failures ...string) int64 {
+	id := h.enter(prev, types.HistoryEventTypeTaskStateEntered, name)
+	for _, cause := range failures {
+		id = h.add(id, types.HistoryEvent{Type: types.HistoryEventTypeTaskScheduled})
+		id = h.add(id, types.HistoryEvent{
+			Type:                   types.HistoryEventTypeTaskFailed,
+			TaskFailedEventDetails: &types.TaskFailedEventDetails{Error: aws.String("Lambda.Unknown"), Cause: aws.String(cause)},
+		})
+	}
+	id = h.add(id, types.HistoryEvent{Type: types.HistoryEventTypeTaskScheduled})
+	id = h.add(id, types.HistoryEvent{Type: types.HistoryEventTypeTaskSucceeded})
+	return h.exit(id, types.HistoryEventTypeTaskStateExited, name)
+}
+
+func TestBuildTimeline_SingleAccount(t *testing.T) {
+	h := &history{start: time.Unix(1700000000, 0)}
+	id := h.add(0, types.HistoryEvent{Type: types.HistoryEventTypeExecutionStarted})
+	id = h.task(id, "CheckVersion")
+	id = h.task(id, "DeployCloudFormation", "throttled")
+	id = h.enter(id, types.HistoryEventTypeTaskStateEntered, "CheckStackStatus")
+	id = h.add(id, types.HistoryEvent{Type: types.HistoryEventTypeTaskScheduled})
+	h.add(id, types.HistoryEvent{
+		Type:                        types.HistoryEventTypeExecutionFailed,
+		ExecutionFailedEventDetails: &types.ExecutionFailedEventDetails{Error: aws.String("States.Timeout"), Cause: aws.String("execution timed out")},
+	})
+
+	steps := BuildTimeline(h.events)
+	if len(steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %d: %+v", len(steps), steps)
+	}
+
+	tests := []struct {
+		name    string
+		status  StepStatus
+		retries int
+		error   string
+		cause   string
+	}{
+		{name: "CheckVersion", status: StepStatusSucceeded},
+		{name: "DeployCloudFormation", status: StepStatusSucceeded, retries: 1, error: "Lambda.Unknown", cause: "throttled"},
+		{name: "CheckStackStatus", status: StepStatusFailed, error: "States.Timeout", cause: "execution timed out"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := steps[i]
+			if step.Name != tt.name || step.Type != "Task" {
+				t.Errorf("Expected Task %s, got %s %s", tt.name, step.Type, step.Name)
+			}
+			if step.Status != tt.status {
+				t.Errorf("Expected status %s, got %s", tt.status, step.Status)
+			}
+			if step.Retries() != tt.retries {
+				t.Errorf("Expected %d retries, got %d", tt.retries, step.Retries())
+			}
+			if step.Error != tt.error || step.Cause != tt.cause {
+				t.Errorf("Expected error %q/%q, got %q/%q", tt.error, tt.cause, step.Error, step.Cause)
+			}
+			if step.ExitedAt == nil || step.Duration() <= 0 {
+				t.Errorf("Expected step to have exited with a duration, got %+v", step)
+			}
+			if step.Parent != "" || step.Iteration != nil {
+				t.Errorf("Expected top-level step, got parent %q", step.Parent)
+			}
+		})
+	}
+}
+
+func TestBuildTimeline_MultiAccount(t *testing.T) {
+	h := &history{start: time.Unix(1700000000, 0)}
+	id := h.add(0, types.HistoryEvent{Type: types.HistoryEventTypeExecutionStarted})
+
+	// Lock is held by another build on the first attempt
+	for i := 0; i < 2; i++ {
+		id = h.task(id, "AcquireLock")
+		id = h.enter(id, types.HistoryEventTypeChoiceStateEntered, "CheckLockAcquired")
+		id = h.exit(id, types.HistoryEventTypeChoiceStateExited, "CheckLockAcquired")
+		if i == 0 {
+			id = h.enter(id, types.HistoryEventTypeWaitStateEntered, "WaitForLock")
+			id = h.exit(id, types.HistoryEventTypeWaitStateExited, "WaitForLock")
+		}
+	}
+
+	mapID := h.enter(id, types.HistoryEventTypeMapStateEntered, "DeployToTargets")
+	id = h.add(mapID, types.HistoryEvent{Type: types.HistoryEventTypeMapStateStarted})
+	first := h.add(id, types.HistoryEvent{
+		Type:                            types.HistoryEventTypeMapIterationStarted,
+		MapIterationStartedEventDetails: &types.MapIterationStartedEventDetails{Name: aws.String("DeployToTargets"), Index: aws.Int32(0)},
+	})
+	second := h.add(id, types.HistoryEvent{
+		Type:                            types.HistoryEventTypeMapIterationStarted,
+		MapIterationStartedEventDetails: &types.MapIterationStartedEventDetails{Name: aws.String("DeployToTargets"), Index: aws.Int32(1)},
+	})
+
+	// Iterations interleave, linked to their own branch by PreviousEventId
+	a := h.enter(first, types.HistoryEventTypeTaskStateEntered, "DeployStackInstances")
+	b := h.enter(second, types.HistoryEventTypeTaskStateEntered, "DeployStackInstances")
+	a = h.add(a, types.HistoryEvent{Type: types.HistoryEventTypeTaskScheduled})
+	b = h.add(b, types.HistoryEvent{Type: types.HistoryEventTypeTaskScheduled})
+	b = h.add(b, types.HistoryEvent{
+		Type:                   types.HistoryEventTypeTaskFailed,
+		TaskFailedEventDetails: &types.TaskFailedEventDetails{Error: aws.String("OperationInProgressException"), Cause: aws.String("stack set busy")},
+	})
+	a = h.add(a, types.HistoryEvent{Type: types.HistoryEventTypeTaskSucceeded})
+	a = h.exit(a, types.HistoryEventTypeTaskStateExited, "DeployStackInstances")
+	b = h.exit(b, types.HistoryEventTypeTaskStateExited, "DeployStackInstances")
+	a = h.task(a, "CheckInstanceStatus")
+	a = h.add(a, types.HistoryEvent{Type: types.HistoryEventTypeMapIterationSucceeded})
+	b = h.add(b, types.HistoryEvent{Type: types.HistoryEventTypeMapIterationFailed})
+	id = h.add(b, types.HistoryEvent{Type: types.HistoryEventTypeMapStateFailed})
+	id = h.exit(id, types.HistoryEventTypeMapStateExited, "DeployToTargets")
+	id = h.task(id, "ReleaseLock")
+	h.add(id, types.HistoryEvent{Type: types.HistoryEventTypeExecutionSucceeded})
+
+	steps := BuildTimeline(h.events)
+
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	want := []string{
+		"AcquireLock", "CheckLockAcquired", "WaitForLock",
+		"AcquireLock", "CheckLockAcquired",
+		"DeployToTargets", "DeployStackInstances", "DeployStackInstances", "CheckInstanceStatus",
+		"ReleaseLock",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("Expected steps %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Expected steps %v, got %v", want, names)
+		}
+	}
+
+	if steps[2].Type != "Wait" || steps[1].Type != "Choice" {
+		t.Errorf("Expected Choice and Wait types, got %s and %s", steps[1].Type, steps[2].Type)
+	}
+
+	mapStep := steps[5]
+	if mapStep.Type != "Map" || mapStep.Status != StepStatusFailed || mapStep.ExitedAt == nil {
+		t.Errorf("Expected failed, exited Map step, got %+v", mapStep)
+	}
+
+	for i, index := range map[int]int32{6: 0, 7: 1, 8: 0} {
+		step := steps[i]
+		if step.Parent != "DeployToTargets" {
+			t.Errorf("Expected %s to be inside DeployToTargets, got %q", step.Name, step.Parent)
+		}
+		if step.Iteration == nil || *step.Iteration != index {
+			t.Errorf("Expected %s iteration %d, got %v", step.Name, index, step.Iteration)
+		}
+	}
+
+	if steps[6].Status != StepStatusSucceeded {
+		t.Errorf("Expected first iteration to succeed, got %s", steps[6].Status)
+	}
+	if steps[7].Status != StepStatusFailed || steps[7].Cause != "stack set busy" {
+		t.Errorf("Expected second iteration to fail with cause, got %+v", steps[7])
+	}
+
+	release := steps[9]
+	if release.Parent != "" || release.Iteration != nil || release.Status != StepStatusSucceeded {
+		t.Errorf("Expected top-level ReleaseLock after Map, got %+v", release)
+	}
+}
@@ -0,0 +1,92 @@
+package paramref
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSMClient abstracts the SSM GetParameter operation
+type SSMClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// SecretsManagerClient abstracts the Secrets Manager GetSecretValue operation
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// getParameter reads an SSM parameter. SecureString values are decrypted and marked secret.
+func getParameter(ctx context.Context, client SSMClient, name string) (value, error) {
+	result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return value{}, fmt.Errorf("ssm parameter %s: %w", name, ErrNotFound)
+		}
+		return value{}, fmt.Errorf("failed to get ssm parameter %s: %w", name, err)
+	}
+	if result.Parameter == nil {
+		return value{}, fmt.Errorf("ssm parameter %s: %w", name, ErrNotFound)
+	}
+
+	return value{
+		text:   aws.ToString(result.Parameter.Value),
+		secret: result.Parameter.Type == ssmtypes.ParameterTypeSecureString,
+	}, nil
+}
+
+// getSecret reads a secret string, or one key of a secret holding a JSON object when ref is
+// in the form <name>#<key>
+func getSecret(ctx context.Context, client SecretsManagerClient, ref string) (string, error) {
+	name, key, hasKey := strings.Cut(ref, "#")
+	if name == "" || (hasKey && key == "") {
+		return "", fmt.Errorf("invalid secret reference %q, expected <name> or <name>#<key>", ref)
+	}
+
+	result, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		var notFound *smtypes.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", fmt.Errorf("secret %s: %w", name, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	if result.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", name)
+	}
+
+	if !hasKey {
+		return *result.SecretString, nil
+	}
+
+	// Errors deliberately omit the parse error, which may quote the secret
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*result.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", name)
+	}
+
+	raw, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key %s of secret %s: %w", key, name, ErrNotFound)
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil // numbers and booleans as written
+}
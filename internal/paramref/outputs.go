@@ -0,0 +1,77 @@
+package paramref
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+)
+
+// BuildOutputs reads outputs from the build records of single-account deployments. It
+// returns the builds of repo/env, e.g. DynamoDBService.QueryBuildsByRepo.
+type BuildOutputs func(ctx context.Context, repo, env string) ([]builddao.Record, error)
+
+// Output returns the output of the latest successful build of repo/env that recorded
+// outputs. Builds without changes finish without recording outputs, so older builds are
+// used until the stack changes again.
+func (fn BuildOutputs) Output(ctx context.Context, repo, env, key string) (string, error) {
+	builds, err := fn(ctx, repo, env)
+	if err != nil {
+		return "", fmt.Errorf("failed to query builds of %s/%s: %w", repo, env, err)
+	}
+
+	// Builds are sorted by KSUID, oldest first
+	for i := len(builds) - 1; i >= 0; i-- {
+		build := builds[i]
+		if build.Status != builddao.BuildStatusSuccess || build.Outputs == nil {
+			continue
+		}
+
+		value, ok := build.Outputs[key]
+		if !ok {
+			return "", fmt.Errorf("output %s of %s/%s: %w", key, repo, env, ErrNotFound)
+		}
+		return value, nil
+	}
+
+	return "", fmt.Errorf("outputs of %s/%s: %w", repo, env, ErrNotFound)
+}
+
+// DeploymentOutputs reads outputs from the deployment records of multi-account deployments.
+// It returns the deployments of env/repo, e.g. deploymentdao.DAO.QueryByPK.
+type DeploymentOutputs func(ctx context.Context, env, repo string) ([]deploymentdao.Record, error)
+
+// Output returns the output of the successfully deployed stack instances of repo/env. StackSet
+// parameters apply to every instance, so the output must have the same value in every account
+// and region.
+func (fn DeploymentOutputs) Output(ctx context.Context, repo, env, key string) (string, error) {
+	deployments, err := fn(ctx, env, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to query deployments of %s/%s: %w", repo, env, err)
+	}
+
+	var (
+		value string
+		found bool
+	)
+	for _, deployment := range deployments {
+		if deployment.Status != deploymentdao.StatusSuccess {
+			continue
+		}
+
+		v, ok := deployment.Outputs[key]
+		if !ok {
+			continue
+		}
+		if found && v != value {
+			return "", fmt.Errorf("output %s of %s/%s differs between accounts or regions", key, repo, env)
+		}
+		value, found = v, true
+	}
+
+	if !found {
+		return "", fmt.Errorf("output %s of %s/%s: %w", key, repo, env, ErrNotFound)
+	}
+	return value, nil
+}
@@ -0,0 +1,261 @@
+// Package paramref resolves references in CloudFormation parameter values at deploy time
+//
+// A parameter value may contain references that are replaced before the stack or StackSet
+// is deployed:
+//
+//	{{output:<repo>:<OutputKey>}}        output of repo deployed to the same environment
+//	{{output:<repo>/<env>:<OutputKey>}}  output of repo deployed to env
+//	{{ssm:/path/to/parameter}}           SSM parameter (SecureString values are decrypted)
+//	{{secret:<name>}}                    Secrets Manager secret string
+//	{{secret:<name>#<key>}}              key of a Secrets Manager secret holding a JSON object
+//	{{build:version}}                    version of the build being deployed
+//	{{build:commit}}                     commit hash of the build being deployed
+//
+// References may be embedded in a longer value, e.g. "https://{{output:api:Domain}}/v1".
+// Values read from Secrets Manager and SecureString parameters are secret: use
+// Resolved.Mask before logging or storing anything that may contain them.
+package paramref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// ErrNotFound is returned when a referenced value does not exist
+var ErrNotFound = errors.New("not found")
+
+// Reference kinds
+const (
+	KindOutput = "output" // stack output of another repo
+	KindSSM    = "ssm"    // SSM parameter
+	KindSecret = "secret" // Secrets Manager secret
+	KindBuild  = "build"  // field of the build being deployed
+)
+
+// Mask replaces secret values in logs and error messages
+const Mask = "****"
+
+// pattern matches {{kind:ref}} references of the supported kinds. Other {{...}} values, such
+// as CloudFormation dynamic references, are left untouched.
+var pattern = regexp.MustCompile(`\{\{\s*(` + strings.Join([]string{KindOutput, KindSSM, KindSecret, KindBuild}, "|") + `):([^{}]+?)\s*\}\}`)
+
+// OutputSource looks up a stack output of the latest deployment of repo to env
+type OutputSource interface {
+	Output(ctx context.Context, repo, env, key string) (string, error)
+}
+
+// Build identifies the build being deployed, for {{build:...}} references
+type Build struct {
+	Version    string
+	CommitHash string
+}
+
+// Option configures a Resolver
+type Option func(*Resolver)
+
+// WithOutputs enables {{output:...}} references
+func WithOutputs(outputs OutputSource) Option {
+	return func(r *Resolver) {
+		r.outputs = outputs
+	}
+}
+
+// WithSSM enables {{ssm:...}} references
+func WithSSM(client SSMClient) Option {
+	return func(r *Resolver) {
+		r.ssm = client
+	}
+}
+
+// WithSecretsManager enables {{secret:...}} references
+func WithSecretsManager(client SecretsManagerClient) Option {
+	return func(r *Resolver) {
+		r.secrets = client
+	}
+}
+
+// Resolver resolves references in the parameters of a deployment to env
+type Resolver struct {
+	env     string
+	outputs OutputSource
+	ssm     SSMClient
+	secrets SecretsManagerClient
+}
+
+// New creates a new Resolver for deployments to env. Reference kinds whose source was not
+// configured fail to resolve, except {{build:...}} which is always available.
+func New(env string, opts ...Option) *Resolver {
+	r := &Resolver{env: env}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolved holds parameters whose references were replaced by their values
+type Resolved struct {
+	Parameters []types.Parameter
+	secrets    []string // secret values, longest first
+}
+
+// Mask replaces every secret value in s with Mask
+func (r *Resolved) Mask(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// MaskErr returns err with every secret value in its message masked. Returns nil if err is nil.
+func (r *Resolved) MaskErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if masked := r.Mask(err.Error()); masked != err.Error() {
+		return errors.New(masked)
+	}
+	return err
+}
+
+// Masked returns a copy of the parameters with secret values masked, for logging
+func (r *Resolved) Masked() []types.Parameter {
+	masked := make([]types.Parameter, len(r.Parameters))
+	for i, param := range r.Parameters {
+		param.ParameterValue = aws.String(r.Mask(aws.ToString(param.ParameterValue)))
+		masked[i] = param
+	}
+	return masked
+}
+
+// value is a resolved reference
+type value struct {
+	text   string
+	secret bool
+}
+
+// Resolve replaces every reference in params. All unresolvable references are reported in a
+// single error; errors never contain resolved values.
+func (r *Resolver) Resolve(ctx context.Context, build Build, params []types.Parameter) (*Resolved, error) {
+	var (
+		errs     []error
+		cache    = map[string]value{}
+		secrets  = map[string]bool{}
+		resolved = make([]types.Parameter, 0, len(params))
+	)
+
+	for _, param := range params {
+		key := aws.ToString(param.ParameterKey)
+
+		var paramErrs []error
+		v := pattern.ReplaceAllStringFunc(aws.ToString(param.ParameterValue), func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			ref := groups[1] + ":" + groups[2]
+
+			got, ok := cache[ref]
+			if !ok {
+				var err error
+				got, err = r.lookup(ctx, build, groups[1], groups[2])
+				if err != nil {
+					paramErrs = append(paramErrs, fmt.Errorf("{{%s}}: %w", ref, err))
+					return match
+				}
+				cache[ref] = got
+			}
+
+			if got.secret && got.text != "" {
+				secrets[got.text] = true
+			}
+			return got.text
+		})
+		if len(paramErrs) > 0 {
+			errs = append(errs, fmt.Errorf("parameter %s: %w", key, errors.Join(paramErrs...)))
+			continue
+		}
+
+		param.ParameterValue = aws.String(v)
+		resolved = append(resolved, param)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	result := &Resolved{Parameters: resolved}
+	for secret := range secrets {
+		result.secrets = append(result.secrets, secret)
+	}
+	sort.Slice(result.secrets, func(i, j int) bool {
+		return len(result.secrets[i]) > len(result.secrets[j])
+	})
+	return result, nil
+}
+
+// lookup returns the value of a single reference
+func (r *Resolver) lookup(ctx context.Context, build Build, kind, ref string) (value, error) {
+	switch kind {
+	case KindOutput:
+		repo, env, key, err := r.parseOutputRef(ref)
+		if err != nil {
+			return value{}, err
+		}
+		if r.outputs == nil {
+			return value{}, fmt.Errorf("output references are not supported")
+		}
+		v, err := r.outputs.Output(ctx, repo, env, key)
+		return value{text: v}, err
+
+	case KindSSM:
+		if r.ssm == nil {
+			return value{}, fmt.Errorf("ssm references are not supported")
+		}
+		return getParameter(ctx, r.ssm, ref)
+
+	case KindSecret:
+		if r.secrets == nil {
+			return value{}, fmt.Errorf("secret references are not supported")
+		}
+		v, err := getSecret(ctx, r.secrets, ref)
+		return value{text: v, secret: true}, err
+
+	case KindBuild:
+		switch ref {
+		case "version":
+			return value{text: build.Version}, nil
+		case "commit":
+			return value{text: build.CommitHash}, nil
+		default:
+			return value{}, fmt.Errorf("unknown build field: %s, expected version or commit", ref)
+		}
+
+	default:
+		return value{}, fmt.Errorf("unknown reference type: %s", kind)
+	}
+}
+
+// parseOutputRef parses <repo>:<key> or <repo>/<env>:<key>
+func (r *Resolver) parseOutputRef(ref string) (repo, env, key string, err error) {
+	target, key, ok := strings.Cut(ref, ":")
+	if !ok || target == "" || key == "" {
+		return "", "", "", fmt.Errorf("invalid output reference %q, expected <repo>:<OutputKey> or <repo>/<env>:<OutputKey>", ref)
+	}
+
+	repo, env, ok = strings.Cut(target, "/")
+	if !ok {
+		env = r.env
+	}
+	if repo == "" || env == "" {
+		return "", "", "", fmt.Errorf("invalid output reference %q, expected <repo>:<OutputKey> or <repo>/<env>:<OutputKey>", ref)
+	}
+
+	return repo, env, key, nil
+}
@@ -0,0 +1,168 @@
+package paramref
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOutputs map[string]string
+
+func (f fakeOutputs) Output(_ context.Context, repo, env, key string) (string, error) {
+	v, ok := f[repo+"/"+env+":"+key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+type fakeSSM map[string]ssmtypes.Parameter
+
+func (f fakeSSM) GetParameter(_ context.Context, params *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	p, ok := f[aws.ToString(params.Name)]
+	if !ok {
+		return nil, &ssmtypes.ParameterNotFound{}
+	}
+	return &ssm.GetParameterOutput{Parameter: &p}, nil
+}
+
+type fakeSecrets map[string]string
+
+func (f fakeSecrets) GetSecretValue(_ context.Context, params *secretsmanager.GetSecretValueInput, _ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	s, ok := f[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, &smtypes.ResourceNotFoundException{}
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(s)}, nil
+}
+
+func param(key, value string) types.Parameter {
+	return types.Parameter{ParameterKey: aws.String(key), ParameterValue: aws.String(value)}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	resolver := New("stg",
+		WithOutputs(fakeOutputs{
+			"api/stg:Domain":   "api.stg.example.com",
+			"queue/prd:JobArn": "arn:aws:sqs:us-east-1:111111111111:jobs",
+		}),
+		WithSSM(fakeSSM{
+			"/stg/vpc-id":  {Value: aws.String("vpc-123"), Type: ssmtypes.ParameterTypeString},
+			"/stg/api-key": {Value: aws.String("s3cr3t-key"), Type: ssmtypes.ParameterTypeSecureString},
+		}),
+		WithSecretsManager(fakeSecrets{
+			"db":    `{"username":"admin","password":"hunter2","port":5432}`,
+			"token": "plain-token",
+		}),
+	)
+
+	resolved, err := resolver.Resolve(context.Background(), Build{Version: "42.abc123", CommitHash: "abc123"}, []types.Parameter{
+		param("ApiUrl", "https://{{output:api:Domain}}/v1"),
+		param("QueueArn", "{{ output:queue/prd:JobArn }}"),
+		param("VpcId", "{{ssm:/stg/vpc-id}}"),
+		param("ApiKey", "{{ssm:/stg/api-key}}"),
+		param("DbUrl", "postgres://{{secret:db#username}}:{{secret:db#password}}@db:{{secret:db#port}}"),
+		param("Token", "{{secret:token}}"),
+		param("Version", "{{build:version}}"),
+		param("Commit", "{{build:commit}}"),
+		param("Plain", "value"),
+		param("Dynamic", "{{resolve:ssm:/my/param}}"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Parameter{
+		param("ApiUrl", "https://api.stg.example.com/v1"),
+		param("QueueArn", "arn:aws:sqs:us-east-1:111111111111:jobs"),
+		param("VpcId", "vpc-123"),
+		param("ApiKey", "s3cr3t-key"),
+		param("DbUrl", "postgres://admin:hunter2@db:5432"),
+		param("Token", "plain-token"),
+		param("Version", "42.abc123"),
+		param("Commit", "abc123"),
+		param("Plain", "value"),
+		param("Dynamic", "{{resolve:ssm:/my/param}}"),
+	}, resolved.Parameters)
+
+	masked := resolved.Masked()
+	assert.Equal(t, "vpc-123", aws.ToString(masked[2].ParameterValue))
+	assert.Equal(t, Mask, aws.ToString(masked[3].ParameterValue))
+	assert.Equal(t, "postgres://****:****@db:****", aws.ToString(masked[4].ParameterValue))
+	assert.Equal(t, Mask, aws.ToString(masked[5].ParameterValue))
+	assert.Equal(t, "s3cr3t-key", aws.ToString(resolved.Parameters[3].ParameterValue), "Masked must not modify Parameters")
+
+	assert.Equal(t, "value **** is invalid", resolved.Mask("value hunter2 is invalid"))
+	assert.EqualError(t, resolved.MaskErr(errors.New("bad token plain-token")), "bad token ****")
+	assert.NoError(t, resolved.MaskErr(nil))
+}
+
+func TestResolver_ResolveErrors(t *testing.T) {
+	resolver := New("stg", WithOutputs(fakeOutputs{}), WithSecretsManager(fakeSecrets{"db": `{"password":"hunter2"}`}))
+
+	_, err := resolver.Resolve(context.Background(), Build{}, []types.Parameter{
+		param("Missing", "{{output:api:Domain}}"),
+		param("Invalid", "{{output:api}}"),
+		param("MissingKey", "{{secret:db#username}}"),
+		param("Unsupported", "{{ssm:/stg/vpc-id}}"),
+		param("Field", "{{build:branch}}"),
+	})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorContains(t, err, "parameter Missing")
+	assert.ErrorContains(t, err, "parameter Invalid")
+	assert.ErrorContains(t, err, "parameter MissingKey")
+	assert.ErrorContains(t, err, "parameter Unsupported: {{ssm:/stg/vpc-id}}: ssm references are not supported")
+	assert.ErrorContains(t, err, "parameter Field")
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestBuildOutputs(t *testing.T) {
+	builds := []builddao.Record{
+		{Status: builddao.BuildStatusSuccess, Outputs: map[string]string{"Domain": "old.example.com"}},
+		{Status: builddao.BuildStatusSuccess, Outputs: map[string]string{"Domain": "api.example.com"}},
+		{Status: builddao.BuildStatusSuccess}, // no changes, outputs not recorded
+		{Status: builddao.BuildStatusFailed, Outputs: map[string]string{"Domain": "failed.example.com"}},
+	}
+	source := BuildOutputs(func(context.Context, string, string) ([]builddao.Record, error) {
+		return builds, nil
+	})
+
+	value, err := source.Output(context.Background(), "api", "dev", "Domain")
+	assert.NoError(t, err)
+	assert.Equal(t, "api.example.com", value)
+
+	_, err = source.Output(context.Background(), "api", "dev", "Unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	builds = nil
+	_, err = source.Output(context.Background(), "api", "dev", "Domain")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDeploymentOutputs(t *testing.T) {
+	deployments := []deploymentdao.Record{
+		{Status: deploymentdao.StatusSuccess, Outputs: map[string]string{"Domain": "api.example.com", "Bucket": "bucket-1"}},
+		{Status: deploymentdao.StatusSuccess, Outputs: map[string]string{"Domain": "api.example.com", "Bucket": "bucket-2"}},
+		{Status: deploymentdao.StatusFailed, Outputs: map[string]string{"Domain": "failed.example.com"}},
+	}
+	source := DeploymentOutputs(func(context.Context, string, string) ([]deploymentdao.Record, error) {
+		return deployments, nil
+	})
+
+	value, err := source.Output(context.Background(), "api", "dev", "Domain")
+	assert.NoError(t, err)
+	assert.Equal(t, "api.example.com", value)
+
+	_, err = source.Output(context.Background(), "api", "dev", "Bucket")
+	assert.ErrorContains(t, err, "differs between accounts or regions")
+
+	_, err = source.Output(context.Background(), "api", "dev", "Unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// S3Getter abstracts S3 GetObject operations for loading policies
+type S3Getter interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// SSMGetter abstracts SSM GetParameter operations for loading policies
+type SSMGetter interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// Loader reads policy manifests from S3 or SSM Parameter Store
+type Loader struct {
+	env       string
+	s3Client  S3Getter
+	ssmClient SSMGetter
+}
+
+// NewLoader creates a new Loader. SSM sources must live under /{env}/aws-deployer/.
+func NewLoader(env string, s3Client S3Getter, ssmClient SSMGetter) *Loader {
+	return &Loader{
+		env:       env,
+		s3Client:  s3Client,
+		ssmClient: ssmClient,
+	}
+}
+
+// Load reads the manifest at source and returns the resulting Set. Source is one of:
+//
+//	s3://bucket/policies/manifest.json  - policy keys are relative to the manifest's folder
+//	ssm:/{env}/aws-deployer/policies    - policy keys are parameter names relative to the manifest
+//
+// An empty source returns an empty Set, which evaluates no policies.
+func (l *Loader) Load(ctx context.Context, source string) (*Set, error) {
+	if source == "" {
+		return NewSet(nil)
+	}
+
+	var read func(ctx context.Context, key string) (string, error)
+	var manifestKey string
+
+	switch {
+	case strings.HasPrefix(source, "s3://"):
+		bucket, key, ok := strings.Cut(strings.TrimPrefix(source, "s3://"), "/")
+		if !ok || bucket == "" || key == "" {
+			return nil, fmt.Errorf("invalid policy source %s: expected s3://bucket/key", source)
+		}
+		manifestKey = key
+		read = func(ctx context.Context, key string) (string, error) {
+			return l.readS3(ctx, bucket, key)
+		}
+
+	case strings.HasPrefix(source, "ssm:"):
+		// Policies are deployment configuration, so keep them with the rest of the env's parameters
+		prefix := fmt.Sprintf("/%s/aws-deployer/", l.env)
+		manifestKey = strings.TrimPrefix(source, "ssm:")
+		read = func(ctx context.Context, name string) (string, error) {
+			if !strings.HasPrefix(name, prefix) {
+				return "", fmt.Errorf("parameter %s must be under %s", name, prefix)
+			}
+			return l.readSSM(ctx, name)
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid policy source %s: expected s3:// or ssm: prefix", source)
+	}
+
+	content, err := read(ctx, manifestKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse policy manifest %s: %w", source, err)
+	}
+
+	for i, p := range manifest.Policies {
+		if p.Key == "" || p.Rego != "" {
+			continue
+		}
+		key := path.Join(path.Dir(manifestKey), p.Key)
+		module, err := read(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy %s: %w", p.Name, err)
+		}
+		manifest.Policies[i].Rego = module
+	}
+
+	return NewSet(manifest.Policies)
+}
+
+func (l *Loader) readS3(ctx context.Context, bucket, key string) (string, error) {
+	result, err := l.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get object %s from bucket %s: %w", key, bucket, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer result.Body.Close()
+
+	content, err := io.ReadAll(result.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read object content: %w", err)
+	}
+	return string(content), nil
+}
+
+func (l *Loader) readSSM(ctx context.Context, name string) (string, error) {
+	result, err := l.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get parameter %s: %w", name, err)
+	}
+	if result.Parameter == nil || result.Parameter.Value == nil {
+		return "", fmt.Errorf("parameter %s not found", name)
+	}
+	return *result.Parameter.Value, nil
+}
@@ -0,0 +1,235 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+// Mode controls what happens when a template violates a policy
+type Mode string
+
+const (
+	ModeEnforce Mode = "enforce" // violations fail the deployment
+	ModeWarn    Mode = "warn"    // violations are recorded but the deployment continues
+	ModeOff     Mode = "off"     // the policy is not evaluated
+)
+
+// Policy is a rego module and the scope it applies to. Modules use the same contract as the
+// built-in cloudformation.rego: package cloudformation with a violations set of strings.
+//
+// Policies are layered by scope: global (no repo or env), per-repo, per-env, then per repo and env.
+// When several entries share a name, the most specific one decides the mode, and the module comes
+// from the most specific entry that has one, so a repo can switch a global policy to warn or off
+// without repeating its source. A policy with no module at any layer uses the built-in policy.
+type Policy struct {
+	Name string `json:"name"`
+	Repo string `json:"repo,omitempty"` // empty applies to every repo
+	Env  string `json:"env,omitempty"`  // empty applies to every env
+	Mode Mode   `json:"mode,omitempty"` // empty inherits from a less specific entry (enforce by default)
+	Rego string `json:"rego,omitempty"` // inline module source
+	Key  string `json:"key,omitempty"`  // module location, relative to the manifest (resolved by Load)
+}
+
+// specificity orders the layers: global < repo < env < repo and env
+func (p Policy) specificity() int {
+	n := 0
+	if p.Repo != "" {
+		n++
+	}
+	if p.Env != "" {
+		n += 2
+	}
+	return n
+}
+
+// appliesTo returns true if the policy is in scope for repo and env
+func (p Policy) appliesTo(repo, env string) bool {
+	return (p.Repo == "" || p.Repo == repo) && (p.Env == "" || p.Env == env)
+}
+
+// Manifest is the document listing the policies to load
+type Manifest struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Violation is a single policy violation found in a template
+type Violation struct {
+	Policy  string `json:"policy"`
+	Mode    Mode   `json:"mode"`
+	Message string `json:"message"`
+}
+
+// Set is a layered collection of policies
+type Set struct {
+	policies []Policy
+}
+
+// NewSet validates the policies and returns a Set. Every module is compiled up front so a
+// broken policy fails at startup rather than on the first deployment.
+func NewSet(policies []Policy) (*Set, error) {
+	for _, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("policy name is required")
+		}
+		switch p.Mode {
+		case "", ModeEnforce, ModeWarn, ModeOff:
+		default:
+			return nil, fmt.Errorf("policy %s: invalid mode %q (expected enforce, warn, or off)", p.Name, p.Mode)
+		}
+		if p.Rego != "" {
+			if _, err := prepare(context.Background(), p.Name, p.Rego, nil); err != nil {
+				return nil, fmt.Errorf("policy %s: %w", p.Name, err)
+			}
+		}
+	}
+
+	return &Set{policies: policies}, nil
+}
+
+// Select resolves the layered policies that apply to repo and env, sorted by name
+func (s *Set) Select(repo, env string) []Policy {
+	if s == nil {
+		return nil
+	}
+
+	var applicable []Policy
+	for _, p := range s.policies {
+		if p.appliesTo(repo, env) {
+			applicable = append(applicable, p)
+		}
+	}
+
+	// Apply less specific layers first so more specific layers override them
+	sort.SliceStable(applicable, func(i, j int) bool {
+		return applicable[i].specificity() < applicable[j].specificity()
+	})
+
+	resolved := map[string]Policy{}
+	for _, p := range applicable {
+		current, ok := resolved[p.Name]
+		if !ok {
+			resolved[p.Name] = p
+			continue
+		}
+		if p.Mode != "" {
+			current.Mode = p.Mode
+		}
+		if p.Rego != "" {
+			current.Rego = p.Rego
+		}
+		current.Repo, current.Env = p.Repo, p.Env
+		resolved[p.Name] = current
+	}
+
+	selected := make([]Policy, 0, len(resolved))
+	for _, p := range resolved {
+		if p.Mode == "" {
+			p.Mode = ModeEnforce
+		}
+		if p.Rego == "" {
+			p.Rego = policyContent
+		}
+		selected = append(selected, p)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+
+	return selected
+}
+
+// Evaluate runs every policy that applies to repo and env against template and returns the
+// violations found. Policies in off mode are skipped.
+func (s *Set) Evaluate(ctx context.Context, template map[string]interface{}, repo, env string) ([]Violation, error) {
+	input := map[string]interface{}{
+		"Resources": template["Resources"],
+	}
+	data := map[string]interface{}{
+		"env":  env,
+		"repo": repo,
+	}
+
+	var violations []Violation
+	for _, p := range s.Select(repo, env) {
+		if p.Mode == ModeOff {
+			continue
+		}
+
+		query, err := prepare(ctx, p.Name, p.Rego, data)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", p.Name, err)
+		}
+
+		results, err := query.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: failed to evaluate: %w", p.Name, err)
+		}
+
+		for _, message := range violationMessages(results) {
+			violations = append(violations, Violation{
+				Policy:  p.Name,
+				Mode:    p.Mode,
+				Message: message,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// Enforced returns the violations of policies in enforce mode
+func Enforced(violations []Violation) []Violation {
+	var enforced []Violation
+	for _, v := range violations {
+		if v.Mode == ModeEnforce {
+			enforced = append(enforced, v)
+		}
+	}
+	return enforced
+}
+
+// prepare compiles a policy module for the violations query
+func prepare(ctx context.Context, name, module string, data map[string]interface{}) (rego.PreparedEvalQuery, error) {
+	options := []func(*rego.Rego){
+		rego.Query("data.cloudformation.violations"),
+		rego.Module(name+".rego", module),
+	}
+	if data != nil {
+		options = append(options, rego.Store(inmem.NewFromObject(data)))
+	}
+
+	query, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, fmt.Errorf("failed to prepare policy query: %w", err)
+	}
+	return query, nil
+}
+
+// violationMessages extracts the violation strings from a violations query result
+func violationMessages(results rego.ResultSet) []string {
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return nil
+	}
+
+	var messages []string
+	switch v := results[0].Expressions[0].Value.(type) {
+	case []interface{}:
+		for _, violation := range v {
+			if str, ok := violation.(string); ok {
+				messages = append(messages, str)
+			}
+		}
+	case map[string]interface{}:
+		// Handle set type from Rego
+		for violation := range v {
+			messages = append(messages, violation)
+		}
+	}
+
+	sort.Strings(messages)
+	return messages
+}
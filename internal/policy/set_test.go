@@ -0,0 +1,260 @@
+package policy
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+const noBucketsPolicy = `package cloudformation
+
+import rego.v1
+
+violations contains msg if {
+	some name, resource in input.Resources
+	resource.Type == "AWS::S3::Bucket"
+	msg := sprintf("S3 bucket '%s' is not allowed in %s", [name, data.env])
+}
+`
+
+var bucketTemplate = map[string]interface{}{
+	"Resources": map[string]interface{}{
+		"Assets": map[string]interface{}{
+			"Type": "AWS::S3::Bucket",
+		},
+	},
+}
+
+func TestSet_Select(t *testing.T) {
+	set, err := NewSet([]Policy{
+		{Name: "buckets", Rego: noBucketsPolicy},
+		{Name: "buckets", Repo: "legacy", Mode: ModeWarn},
+		{Name: "buckets", Env: "dev", Mode: ModeOff},
+		{Name: "buckets", Repo: "legacy", Env: "prd", Mode: ModeEnforce},
+		{Name: "builtin", Repo: "strict"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create set: %v", err)
+	}
+
+	tests := []struct {
+		repo  string
+		env   string
+		modes map[string]Mode
+	}{
+		{repo: "app", env: "stg", modes: map[string]Mode{"buckets": ModeEnforce}},
+		{repo: "app", env: "dev", modes: map[string]Mode{"buckets": ModeOff}},
+		{repo: "legacy", env: "stg", modes: map[string]Mode{"buckets": ModeWarn}},
+		{repo: "legacy", env: "dev", modes: map[string]Mode{"buckets": ModeOff}},
+		{repo: "legacy", env: "prd", modes: map[string]Mode{"buckets": ModeEnforce}},
+		{repo: "strict", env: "stg", modes: map[string]Mode{"buckets": ModeEnforce, "builtin": ModeEnforce}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repo+"/"+tt.env, func(t *testing.T) {
+			selected := set.Select(tt.repo, tt.env)
+			if len(selected) != len(tt.modes) {
+				t.Fatalf("Expected %d policies, got %d", len(tt.modes), len(selected))
+			}
+			for _, p := range selected {
+				if p.Mode != tt.modes[p.Name] {
+					t.Errorf("Expected policy %s mode %s, got %s", p.Name, tt.modes[p.Name], p.Mode)
+				}
+				if p.Rego == "" {
+					t.Errorf("Expected policy %s to have a module", p.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSet_Evaluate(t *testing.T) {
+	ctx := context.Background()
+
+	set, err := NewSet([]Policy{
+		{Name: "buckets", Rego: noBucketsPolicy},
+		{Name: "buckets", Env: "dev", Mode: ModeWarn},
+		{Name: "buckets", Env: "sandbox", Mode: ModeOff},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create set: %v", err)
+	}
+
+	t.Run("Enforce", func(t *testing.T) {
+		violations, err := set.Evaluate(ctx, bucketTemplate, "app", "prd")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 1 {
+			t.Fatalf("Expected 1 violation, got %v", violations)
+		}
+		if got := violations[0]; got.Policy != "buckets" || got.Mode != ModeEnforce || got.Message != "S3 bucket 'Assets' is not allowed in prd" {
+			t.Errorf("Unexpected violation: %+v", got)
+		}
+		if len(Enforced(violations)) != 1 {
+			t.Errorf("Expected violation to be enforced")
+		}
+	})
+
+	t.Run("Warn", func(t *testing.T) {
+		violations, err := set.Evaluate(ctx, bucketTemplate, "app", "dev")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 1 || violations[0].Mode != ModeWarn {
+			t.Fatalf("Expected 1 warn violation, got %v", violations)
+		}
+		if len(Enforced(violations)) != 0 {
+			t.Errorf("Expected no enforced violations")
+		}
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		violations, err := set.Evaluate(ctx, bucketTemplate, "app", "sandbox")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 0 {
+			t.Errorf("Expected no violations, got %v", violations)
+		}
+	})
+
+	t.Run("Builtin", func(t *testing.T) {
+		builtin, err := NewSet([]Policy{{Name: "builtin"}})
+		if err != nil {
+			t.Fatalf("Failed to create set: %v", err)
+		}
+		template := map[string]interface{}{
+			"Resources": map[string]interface{}{
+				"Server": map[string]interface{}{
+					"Type": "AWS::EC2::Instance",
+				},
+			},
+		}
+		violations, err := builtin.Evaluate(ctx, template, "app", "prd")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 1 || violations[0].Message != "Resource type 'AWS::EC2::Instance' is not allowed" {
+			t.Errorf("Expected built-in resource type violation, got %v", violations)
+		}
+	})
+}
+
+func TestNewSet_Invalid(t *testing.T) {
+	tests := map[string]Policy{
+		"missing name": {Rego: noBucketsPolicy},
+		"invalid mode": {Name: "buckets", Mode: "audit"},
+		"invalid rego": {Name: "buckets", Rego: "package cloudformation\n\nviolations contains"},
+	}
+
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewSet([]Policy{p}); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+type mockS3 struct {
+	objects map[string]string
+}
+
+func (m *mockS3) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil
+}
+
+type mockSSM struct {
+	parameters map[string]string
+}
+
+func (m *mockSSM) GetParameter(_ context.Context, params *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	value, ok := m.parameters[aws.ToString(params.Name)]
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(value)}}, nil
+}
+
+func TestLoader_Load(t *testing.T) {
+	ctx := context.Background()
+
+	s3Client := &mockS3{objects: map[string]string{
+		"policies/prd/manifest.json":     `{"policies": [{"name": "buckets", "key": "rego/buckets.rego"}, {"name": "buckets", "repo": "legacy", "mode": "warn"}]}`,
+		"policies/prd/rego/buckets.rego": noBucketsPolicy,
+	}}
+	ssmClient := &mockSSM{parameters: map[string]string{
+		"/prd/aws-deployer/policies":         `{"policies": [{"name": "buckets", "key": "buckets"}]}`,
+		"/prd/aws-deployer/buckets":          noBucketsPolicy,
+		"/stg/aws-deployer/policies":         `{"policies": []}`,
+		"/prd/aws-deployer/invalid-manifest": `{"policies": [{"name": "buckets", "mode": "audit"}]}`,
+	}}
+	loader := NewLoader("prd", s3Client, ssmClient)
+
+	t.Run("Empty", func(t *testing.T) {
+		set, err := loader.Load(ctx, "")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(set.Select("app", "prd")) != 0 {
+			t.Errorf("Expected no policies")
+		}
+	})
+
+	t.Run("S3", func(t *testing.T) {
+		set, err := loader.Load(ctx, "s3://policies/prd/manifest.json")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		violations, err := set.Evaluate(ctx, bucketTemplate, "legacy", "prd")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 1 || violations[0].Mode != ModeWarn {
+			t.Errorf("Expected 1 warn violation, got %v", violations)
+		}
+	})
+
+	t.Run("SSM", func(t *testing.T) {
+		set, err := loader.Load(ctx, "ssm:/prd/aws-deployer/policies")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		violations, err := set.Evaluate(ctx, bucketTemplate, "app", "prd")
+		if err != nil {
+			t.Fatalf("Evaluate failed: %v", err)
+		}
+		if len(violations) != 1 || violations[0].Mode != ModeEnforce {
+			t.Errorf("Expected 1 enforced violation, got %v", violations)
+		}
+	})
+
+	t.Run("SSMOutsideEnv", func(t *testing.T) {
+		if _, err := loader.Load(ctx, "ssm:/stg/aws-deployer/policies"); err == nil {
+			t.Errorf("Expected error for parameter outside env prefix")
+		}
+	})
+
+	t.Run("InvalidManifest", func(t *testing.T) {
+		if _, err := loader.Load(ctx, "ssm:/prd/aws-deployer/invalid-manifest"); err == nil {
+			t.Errorf("Expected error for invalid manifest")
+		}
+	})
+
+	t.Run("InvalidSource", func(t *testing.T) {
+		if _, err := loader.Load(ctx, "https://example.com/policies.json"); err == nil {
+			t.Errorf("Expected error for unsupported source")
+		}
+	})
+}
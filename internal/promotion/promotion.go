@@ -0,0 +1,199 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/segmentio/ksuid"
+)
+
+// Promoter creates builds in the downstream environments of a successful build
+type Promoter struct {
+	build     *builddao.DAO
+	targetDAO *targetdao.DAO
+	driftDAO  *driftdao.DAO
+}
+
+// New creates a new Promoter instance
+func New(build *builddao.DAO, targetDAO *targetdao.DAO, driftDAO *driftdao.DAO) *Promoter {
+	return &Promoter{
+		build:     build,
+		targetDAO: targetDAO,
+		driftDAO:  driftDAO,
+	}
+}
+
+// Input contains the options for promoting a build
+type Input struct {
+	PromotedBy string    // Email of the user promoting the build (empty for automatic promotion)
+	StartAfter time.Time // Promoted builds wait until this time before deploying (optional)
+}
+
+// Promote creates a new build record in each downstream environment of build.
+// The DynamoDB stream picks up the new records and starts their deployments.
+func (p *Promoter) Promote(ctx context.Context, build builddao.Record, input Input) ([]builddao.Record, error) {
+	logger := zerolog.Ctx(ctx)
+
+	// Validate build status - only allow promotion of successful builds
+	if build.Status != builddao.BuildStatusSuccess {
+		return nil, fmt.Errorf("cannot promote build with status %s - only SUCCESS builds can be promoted", build.Status)
+	}
+
+	// Get downstream environments from targetdao
+	targets, err := p.targetDAO.GetWithDefault(ctx, build.Repo, build.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+
+	// Check if there are downstream environments configured
+	if targets == nil || len(targets.DownstreamEnv) == 0 {
+		return nil, fmt.Errorf("no downstream environments configured for %s/%s", build.Repo, build.Env)
+	}
+
+	logger.Info().
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Strs("downstreamEnvs", targets.DownstreamEnv).
+		Str("version", build.Version).
+		Msg("Promoting build to downstream environments")
+
+	// Environments with block_on_drift refuse promotions until their drift is resolved
+	for _, downstreamEnv := range targets.DownstreamEnv {
+		if err := p.checkDrift(ctx, build.Repo, downstreamEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	var startAfter int64
+	if !input.StartAfter.IsZero() {
+		startAfter = input.StartAfter.Unix()
+	}
+
+	// Create a new build for each downstream environment
+	var promoted []builddao.Record
+	for _, downstreamEnv := range targets.DownstreamEnv {
+		// Generate new KSUID for the promoted build
+		sk := ksuid.New().String()
+
+		// Create stack name for downstream environment
+		stackName := fmt.Sprintf("%s-%s", downstreamEnv, build.Repo)
+
+		// Environments with an approval policy hold the build in PENDING_APPROVAL
+		requiredApprovals, err := p.RequiredApprovals(ctx, build.Repo, downstreamEnv)
+		if err != nil {
+			return promoted, err
+		}
+
+		// Create new build record in downstream environment
+		record, err := p.build.Create(ctx, builddao.CreateInput{
+			Repo:              build.Repo,
+			Env:               downstreamEnv,
+			SK:                sk,
+			BuildNumber:       build.BuildNumber,
+			Branch:            build.Branch,
+			Version:           build.Version,
+			CommitHash:        build.CommitHash,
+			StackName:         stackName,
+			PromotedBy:        input.PromotedBy,
+			RequiredApprovals: requiredApprovals,
+			PromotedFrom:      build.GetID(),
+			StartAfter:        startAfter,
+		})
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("repo", build.Repo).
+				Str("downstreamEnv", downstreamEnv).
+				Msg("Failed to create promoted build")
+			return promoted, fmt.Errorf("failed to create promoted build for %s: %w", downstreamEnv, err)
+		}
+
+		logger.Info().
+			Str("repo", build.Repo).
+			Str("env", downstreamEnv).
+			Str("version", build.Version).
+			Str("sk", sk).
+			Int("required_approvals", requiredApprovals).
+			Msg("Successfully created promoted build")
+
+		promoted = append(promoted, record)
+	}
+
+	return promoted, nil
+}
+
+// AutoPromote promotes build when its environment has auto_promote enabled, holding the
+// downstream deployments for the environment's soak time.
+// Returns no builds and no error when auto-promotion is not configured.
+func (p *Promoter) AutoPromote(ctx context.Context, build builddao.Record) ([]builddao.Record, error) {
+	logger := zerolog.Ctx(ctx)
+
+	// Rollbacks restore an older version, which must not flow downstream
+	if build.Status != builddao.BuildStatusSuccess || build.IsRollback() {
+		return nil, nil
+	}
+
+	targets, err := p.targetDAO.GetWithDefault(ctx, build.Repo, build.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+	if targets == nil || !targets.AutoPromote || len(targets.DownstreamEnv) == 0 {
+		return nil, nil
+	}
+
+	var input Input
+	if soak := targets.SoakTime(); soak > 0 {
+		input.StartAfter = time.Now().Add(soak)
+	}
+
+	logger.Info().
+		Str("repo", build.Repo).
+		Str("env", build.Env).
+		Str("sk", build.SK).
+		Dur("soak", targets.SoakTime()).
+		Msg("Auto-promoting build")
+
+	return p.Promote(ctx, build, input)
+}
+
+// RequiredApprovals returns the number of approvals needed to deploy into env
+func (p *Promoter) RequiredApprovals(ctx context.Context, repo, env string) (int, error) {
+	targets, err := p.targetDAO.GetWithDefault(ctx, repo, env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get targets for %s: %w", env, err)
+	}
+	if targets == nil || !targets.Approval.Required() {
+		return 0, nil
+	}
+	return targets.Approval.RequiredApprovals, nil
+}
+
+// checkDrift returns an error if env blocks promotions on drift and its stack has drifted
+func (p *Promoter) checkDrift(ctx context.Context, repo, env string) error {
+	targets, err := p.targetDAO.GetWithDefault(ctx, repo, env)
+	if err != nil {
+		return fmt.Errorf("failed to get targets for %s: %w", env, err)
+	}
+	if targets == nil || !targets.BlockOnDrift {
+		return nil
+	}
+
+	records, err := p.driftDAO.Query(ctx, repo, env)
+	if err != nil {
+		return fmt.Errorf("failed to get drift for %s: %w", env, err)
+	}
+	if !driftdao.Drifted(records) {
+		return nil
+	}
+
+	var resources int
+	for _, record := range records {
+		resources += len(record.Resources)
+	}
+	return fmt.Errorf("cannot promote %s to %s: its stack has drifted (%d resources) - resolve the drift and wait for the next drift check", repo, env, resources)
+}
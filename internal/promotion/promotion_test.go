@@ -0,0 +1,244 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/driftdao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Data struct {
+	Promoter  *Promoter
+	BuildDAO  *builddao.DAO
+	TargetDAO *targetdao.DAO
+	DriftDAO  *driftdao.DAO
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client          = dynamodb.NewFromConfig(cfg)
+		db              = ddb.New(client)
+		suffix          = ksuid.New().String()
+		buildTableName  = fmt.Sprintf("builds-test-%v", suffix)
+		buildTable      = db.MustTable(buildTableName, builddao.Record{})
+		targetTableName = fmt.Sprintf("targets-test-%v", suffix)
+		targetTable     = db.MustTable(targetTableName, targetdao.Record{})
+		driftTableName  = fmt.Sprintf("drift-test-%v", suffix)
+		driftTable      = db.MustTable(driftTableName, driftdao.Record{})
+		buildDAO        = builddao.New(client, buildTableName)
+		targetDAO       = targetdao.New(client, targetTableName)
+		driftDAO        = driftdao.New(client, driftTableName)
+	)
+
+	assert.NoError(t, buildTable.CreateTableIfNotExists(ctx))
+	assert.NoError(t, targetTable.CreateTableIfNotExists(ctx))
+	assert.NoError(t, driftTable.CreateTableIfNotExists(ctx))
+
+	data = Data{
+		Promoter:  New(buildDAO, targetDAO, driftDAO),
+		BuildDAO:  buildDAO,
+		TargetDAO: targetDAO,
+		DriftDAO:  driftDAO,
+	}
+
+	return ctx, data, func() {
+		_ = buildTable.DeleteTableIfExists(ctx)
+		_ = targetTable.DeleteTableIfExists(ctx)
+		_ = driftTable.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestPromoter(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		createBuild := func(t *testing.T, repo, env string) builddao.Record {
+			build, err := data.BuildDAO.Create(ctx, builddao.CreateInput{
+				Repo:        repo,
+				Env:         env,
+				SK:          ksuid.New().String(),
+				BuildNumber: "123",
+				Branch:      "main",
+				Version:     "123.abc123",
+				CommitHash:  "abc123",
+				StackName:   fmt.Sprintf("%s-%s", env, repo),
+			})
+			assert.NoError(t, err)
+			build.Status = builddao.BuildStatusSuccess
+			return build
+		}
+
+		t.Run("Promote_RequiresSuccess", func(t *testing.T) {
+			build := createBuild(t, "promote-pending", "dev")
+			build.Status = builddao.BuildStatusFailed
+
+			_, err := data.Promoter.Promote(ctx, build, Input{})
+			assert.Error(t, err)
+		})
+
+		t.Run("Promote_NoDownstream", func(t *testing.T) {
+			build := createBuild(t, "promote-none", "dev")
+
+			_, err := data.Promoter.Promote(ctx, build, Input{})
+			assert.Error(t, err)
+		})
+
+		t.Run("Promote_ApprovalRequired", func(t *testing.T) {
+			repo := "promote-approval"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{Repo: repo, Env: "stg", DownstreamEnv: []string{"prd"}})
+			assert.NoError(t, err)
+			_, err = data.TargetDAO.Create(ctx, targetdao.CreateInput{
+				Repo:     repo,
+				Env:      "prd",
+				Approval: &targetdao.ApprovalPolicy{RequiredApprovals: 1},
+			})
+			assert.NoError(t, err)
+
+			build := createBuild(t, repo, "stg")
+			promoted, err := data.Promoter.Promote(ctx, build, Input{PromotedBy: "alice@example.com"})
+			assert.NoError(t, err)
+			assert.Len(t, promoted, 1)
+			assert.Equal(t, "prd", promoted[0].Env)
+			assert.Equal(t, builddao.BuildStatusPendingApproval, promoted[0].Status)
+			assert.Equal(t, "alice@example.com", promoted[0].PromotedBy)
+			assert.Equal(t, build.GetID(), promoted[0].PromotedFrom)
+		})
+
+		t.Run("Promote_BlockOnDrift", func(t *testing.T) {
+			repo := "promote-drift"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{Repo: repo, Env: "stg", DownstreamEnv: []string{"prd"}})
+			assert.NoError(t, err)
+			_, err = data.TargetDAO.Create(ctx, targetdao.CreateInput{Repo: repo, Env: "prd", BlockOnDrift: true})
+			assert.NoError(t, err)
+
+			drifted := driftdao.Record{
+				StackName: "prd-" + repo,
+				AccountID: "123456789012",
+				Region:    "us-west-2",
+				Status:    driftdao.StatusDrifted,
+				Resources: []driftdao.ResourceDrift{{LogicalResourceID: "Bucket", ResourceType: "AWS::S3::Bucket", Status: "MODIFIED"}},
+			}
+			assert.NoError(t, data.DriftDAO.Replace(ctx, repo, "prd", []driftdao.Record{drifted}))
+
+			_, err = data.Promoter.Promote(ctx, createBuild(t, repo, "stg"), Input{})
+			assert.ErrorContains(t, err, "drifted")
+
+			// Once the drift is resolved the build promotes
+			drifted.Status, drifted.Resources = driftdao.StatusInSync, nil
+			assert.NoError(t, data.DriftDAO.Replace(ctx, repo, "prd", []driftdao.Record{drifted}))
+
+			promoted, err := data.Promoter.Promote(ctx, createBuild(t, repo, "stg"), Input{})
+			assert.NoError(t, err)
+			assert.Len(t, promoted, 1)
+		})
+
+		t.Run("AutoPromote_Disabled", func(t *testing.T) {
+			repo := "auto-disabled"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{Repo: repo, Env: "dev", DownstreamEnv: []string{"stg"}})
+			assert.NoError(t, err)
+
+			promoted, err := data.Promoter.AutoPromote(ctx, createBuild(t, repo, "dev"))
+			assert.NoError(t, err)
+			assert.Empty(t, promoted)
+		})
+
+		t.Run("AutoPromote_Enabled", func(t *testing.T) {
+			repo := "auto-enabled"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{
+				Repo:          repo,
+				Env:           "dev",
+				DownstreamEnv: []string{"stg", "qa"},
+				AutoPromote:   true,
+			})
+			assert.NoError(t, err)
+
+			build := createBuild(t, repo, "dev")
+			promoted, err := data.Promoter.AutoPromote(ctx, build)
+			assert.NoError(t, err)
+			assert.Len(t, promoted, 2)
+
+			for _, record := range promoted {
+				found, err := data.BuildDAO.Find(ctx, record.GetID())
+				assert.NoError(t, err)
+				assert.Equal(t, builddao.BuildStatusPending, found.Status)
+				assert.Equal(t, build.Version, found.Version)
+				assert.Equal(t, build.GetID(), found.PromotedFrom)
+				assert.Empty(t, found.PromotedBy)
+				assert.Zero(t, found.StartAfter)
+			}
+		})
+
+		t.Run("AutoPromote_SoakTime", func(t *testing.T) {
+			repo := "auto-soak"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{
+				Repo:          repo,
+				Env:           "stg",
+				DownstreamEnv: []string{"prd"},
+				AutoPromote:   true,
+				SoakSeconds:   3600,
+			})
+			assert.NoError(t, err)
+
+			before := time.Now().Add(time.Hour).Unix()
+			promoted, err := data.Promoter.AutoPromote(ctx, createBuild(t, repo, "stg"))
+			assert.NoError(t, err)
+			assert.Len(t, promoted, 1)
+			assert.GreaterOrEqual(t, promoted[0].StartAfter, before)
+		})
+
+		t.Run("AutoPromote_IgnoresRollbacks", func(t *testing.T) {
+			repo := "auto-rollback"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{
+				Repo:          repo,
+				Env:           "dev",
+				DownstreamEnv: []string{"stg"},
+				AutoPromote:   true,
+			})
+			assert.NoError(t, err)
+
+			build := createBuild(t, repo, "dev")
+			build.RollbackOf = builddao.NewID(build.PK, ksuid.New().String())
+			promoted, err := data.Promoter.AutoPromote(ctx, build)
+			assert.NoError(t, err)
+			assert.Empty(t, promoted)
+		})
+
+		t.Run("AutoPromote_IgnoresFailedBuilds", func(t *testing.T) {
+			repo := "auto-failed"
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{
+				Repo:          repo,
+				Env:           "dev",
+				DownstreamEnv: []string{"stg"},
+				AutoPromote:   true,
+			})
+			assert.NoError(t, err)
+
+			build := createBuild(t, repo, "dev")
+			build.Status = builddao.BuildStatusFailed
+			promoted, err := data.Promoter.AutoPromote(ctx, build)
+			assert.NoError(t, err)
+			assert.Empty(t, promoted)
+		})
+	})
+}
@@ -0,0 +1,177 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+)
+
+// Inspector reports whether a failed build left its deployment in a state that a rollback repairs
+type Inspector interface {
+	NeedsRollback(ctx context.Context, failed builddao.Record) (bool, error)
+}
+
+// CloudFormationInspector inspects the stack or StackSet a build deployed to. A rollback is
+// needed only when the build changed the deployment and CloudFormation left it behind: a stack
+// in UPDATE_ROLLBACK_COMPLETE, or StackSet instances left OUTDATED. Builds that failed before
+// they touched the stack - policy or parameter validation, rejected approvals - need none.
+type CloudFormationInspector struct {
+	client *cloudformation.Client
+}
+
+// NewCloudFormationInspector creates a new CloudFormationInspector instance
+func NewCloudFormationInspector(client *cloudformation.Client) *CloudFormationInspector {
+	return &CloudFormationInspector{
+		client: client,
+	}
+}
+
+// NeedsRollback inspects the StackSet named after the build's stack, or the stack itself when
+// there is no StackSet (single-account mode)
+func (i *CloudFormationInspector) NeedsRollback(ctx context.Context, failed builddao.Record) (bool, error) {
+	if failed.StackName == "" {
+		return false, nil
+	}
+
+	_, err := i.client.DescribeStackSet(ctx, &cloudformation.DescribeStackSetInput{
+		StackSetName: aws.String(failed.StackName),
+	})
+	if err == nil {
+		return i.stackSetNeedsRollback(ctx, failed)
+	}
+	if !isNotFound(err) {
+		return false, fmt.Errorf("failed to describe StackSet: %w", err)
+	}
+
+	return i.stackNeedsRollback(ctx, failed)
+}
+
+// stackNeedsRollback returns true if this build's update of the stack failed and was rolled back
+func (i *CloudFormationInspector) stackNeedsRollback(ctx context.Context, failed builddao.Record) (bool, error) {
+	logger := zerolog.Ctx(ctx)
+
+	output, err := i.client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(failed.StackName),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to describe stack: %w", err)
+	}
+	if len(output.Stacks) == 0 {
+		return false, nil
+	}
+
+	stack := output.Stacks[0]
+	if stack.StackStatus != types.StackStatusUpdateRollbackComplete {
+		logger.Info().
+			Str("stack_name", failed.StackName).
+			Str("stack_status", string(stack.StackStatus)).
+			Msg("Stack was not rolled back by CloudFormation, no rollback needed")
+		return false, nil
+	}
+
+	// A stack left in UPDATE_ROLLBACK_COMPLETE by an earlier build is not this build's doing
+	if !updatedBy(stack, failed) {
+		logger.Info().
+			Str("stack_name", failed.StackName).
+			Msg("Build failed before updating the stack, no rollback needed")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// stackSetNeedsRollback returns true if this build ran a StackSet operation and instances of
+// the StackSet are OUTDATED
+func (i *CloudFormationInspector) stackSetNeedsRollback(ctx context.Context, failed builddao.Record) (bool, error) {
+	logger := zerolog.Ctx(ctx)
+
+	touched, err := i.operatedSince(ctx, failed.StackName, failed.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if !touched {
+		logger.Info().
+			Str("stack_set_name", failed.StackName).
+			Msg("Build failed before updating the StackSet, no rollback needed")
+		return false, nil
+	}
+
+	paginator := cloudformation.NewListStackInstancesPaginator(i.client, &cloudformation.ListStackInstancesInput{
+		StackSetName: aws.String(failed.StackName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to list stack instances: %w", err)
+		}
+		for _, instance := range page.Summaries {
+			if instance.Status == types.StackInstanceStatusOutdated {
+				return true, nil
+			}
+		}
+	}
+
+	logger.Info().
+		Str("stack_set_name", failed.StackName).
+		Msg("No stack instances are OUTDATED, no rollback needed")
+	return false, nil
+}
+
+// operatedSince returns true if a StackSet operation started at or after the given Unix timestamp
+func (i *CloudFormationInspector) operatedSince(ctx context.Context, stackSetName string, since int64) (bool, error) {
+	paginator := cloudformation.NewListStackSetOperationsPaginator(i.client, &cloudformation.ListStackSetOperationsInput{
+		StackSetName: aws.String(stackSetName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to list StackSet operations: %w", err)
+		}
+		for _, operation := range page.Summaries {
+			if touchedSince(operation.CreationTimestamp, since) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// updatedBy returns true if the stack's last update was made by the build. Change sets are
+// named after the build's sort key (see deploy-cloudformation), so the last executed change
+// set identifies the build; stacks without one fall back to the time of the last update.
+func updatedBy(stack types.Stack, build builddao.Record) bool {
+	if changeSetID := aws.ToString(stack.ChangeSetId); changeSetID != "" {
+		return strings.Contains(changeSetID, ":changeSet/aws-deployer-"+build.SK+"/")
+	}
+	return touchedSince(stack.LastUpdatedTime, build.CreatedAt)
+}
+
+// touchedSince returns true if t is at or after the given Unix timestamp
+func touchedSince(t *time.Time, since int64) bool {
+	return t != nil && !t.Before(time.Unix(since, 0))
+}
+
+// isNotFound returns true if the stack or StackSet does not exist
+func isNotFound(err error) bool {
+	var notFound *types.StackSetNotFoundException
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return strings.Contains(apiErr.ErrorMessage(), "does not exist")
+}
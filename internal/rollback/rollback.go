@@ -0,0 +1,129 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/segmentio/ksuid"
+)
+
+// Manager redeploys the last known-good build of an environment after a failed deployment
+type Manager struct {
+	build     *builddao.DAO
+	targetDAO *targetdao.DAO
+	inspector Inspector
+}
+
+// New creates a new Manager instance
+func New(build *builddao.DAO, targetDAO *targetdao.DAO, inspector Inspector) *Manager {
+	return &Manager{
+		build:     build,
+		targetDAO: targetDAO,
+		inspector: inspector,
+	}
+}
+
+// Rollback creates a rollback build for failed when its environment has auto_rollback enabled
+// and the failed deployment left the stack rolled back or StackSet instances OUTDATED.
+// The rollback build redeploys the most recent SUCCESS build for the same repo/env and is picked
+// up by the DynamoDB stream like any other new build.
+// Returns nil without error when no rollback is needed or possible.
+func (m *Manager) Rollback(ctx context.Context, failed builddao.Record) (*builddao.Record, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if failed.Status != builddao.BuildStatusFailed {
+		return nil, nil
+	}
+
+	// Never roll back a rollback - that would loop if the known-good version no longer deploys
+	if failed.IsRollback() {
+		logger.Warn().
+			Str("repo", failed.Repo).
+			Str("env", failed.Env).
+			Str("sk", failed.SK).
+			Str("rollback_of", string(failed.RollbackOf)).
+			Msg("Rollback build failed, not rolling back again")
+		return nil, nil
+	}
+
+	targets, err := m.targetDAO.GetWithDefault(ctx, failed.Repo, failed.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+	if targets == nil || !targets.AutoRollback {
+		return nil, nil
+	}
+
+	// Failures before the deployment touched the stack leave nothing to repair
+	needsRollback, err := m.inspector.NeedsRollback(ctx, failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect deployment: %w", err)
+	}
+	if !needsRollback {
+		return nil, nil
+	}
+
+	lastGood, err := m.lastSuccessfulBuild(ctx, failed)
+	if err != nil {
+		return nil, err
+	}
+	if lastGood == nil {
+		logger.Warn().
+			Str("repo", failed.Repo).
+			Str("env", failed.Env).
+			Str("sk", failed.SK).
+			Msg("No successful build to roll back to")
+		return nil, nil
+	}
+
+	sk := ksuid.New().String()
+	record, err := m.build.Create(ctx, builddao.CreateInput{
+		Repo:        lastGood.Repo,
+		Env:         lastGood.Env,
+		SK:          sk,
+		BuildNumber: lastGood.BuildNumber,
+		Branch:      lastGood.Branch,
+		Version:     lastGood.Version,
+		CommitHash:  lastGood.CommitHash,
+		StackName:   lastGood.StackName,
+		RollbackOf:  failed.GetID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rollback build: %w", err)
+	}
+
+	logger.Info().
+		Str("repo", failed.Repo).
+		Str("env", failed.Env).
+		Str("failed_sk", failed.SK).
+		Str("failed_version", failed.Version).
+		Str("rollback_sk", sk).
+		Str("rollback_version", lastGood.Version).
+		Msg("Created rollback build")
+
+	return &record, nil
+}
+
+// lastSuccessfulBuild returns the most recent SUCCESS build created before failed, or nil if there is none
+func (m *Manager) lastSuccessfulBuild(ctx context.Context, failed builddao.Record) (*builddao.Record, error) {
+	records, err := m.build.Query(ctx, failed.PK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query builds: %w", err)
+	}
+
+	// Sort keys are KSUIDs, so records are returned oldest first
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.SK >= failed.SK {
+			continue
+		}
+		if record.Status == builddao.BuildStatusSuccess {
+			return &record, nil
+		}
+	}
+
+	return nil, nil
+}
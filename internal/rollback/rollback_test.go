@@ -0,0 +1,189 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+	"github.com/savaki/ddb/v2"
+	"github.com/savaki/ddb/v2/ddbtest"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubInspector reports the same answer for every build
+type stubInspector struct {
+	needsRollback bool
+}
+
+func (s *stubInspector) NeedsRollback(context.Context, builddao.Record) (bool, error) {
+	return s.needsRollback, nil
+}
+
+type Data struct {
+	Manager   *Manager
+	BuildDAO  *builddao.DAO
+	TargetDAO *targetdao.DAO
+	Inspector *stubInspector
+}
+
+func setup(t *testing.T) (ctx context.Context, data Data, cleanup func()) {
+	ctx = context.Background()
+
+	cfg, err := config.LoadDefaultConfig(
+		ctx,
+		config.WithRegion("us-west-2"),
+		config.WithBaseEndpoint("http://localhost:8000"),
+		config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider("blah", "blah", ""),
+		),
+	)
+	assert.NoError(t, err)
+
+	var (
+		client          = dynamodb.NewFromConfig(cfg)
+		db              = ddb.New(client)
+		suffix          = ksuid.New().String()
+		buildTableName  = fmt.Sprintf("builds-test-%v", suffix)
+		buildTable      = db.MustTable(buildTableName, builddao.Record{})
+		targetTableName = fmt.Sprintf("targets-test-%v", suffix)
+		targetTable     = db.MustTable(targetTableName, targetdao.Record{})
+		buildDAO        = builddao.New(client, buildTableName)
+		targetDAO       = targetdao.New(client, targetTableName)
+	)
+
+	assert.NoError(t, buildTable.CreateTableIfNotExists(ctx))
+	assert.NoError(t, targetTable.CreateTableIfNotExists(ctx))
+
+	inspector := &stubInspector{needsRollback: true}
+	data = Data{
+		Manager:   New(buildDAO, targetDAO, inspector),
+		BuildDAO:  buildDAO,
+		TargetDAO: targetDAO,
+		Inspector: inspector,
+	}
+
+	return ctx, data, func() {
+		_ = buildTable.DeleteTableIfExists(ctx)
+		_ = targetTable.DeleteTableIfExists(ctx)
+	}
+}
+
+func TestManager(t *testing.T) {
+	ddbtest.WithTable[Data](t, setup, func(t *testing.T, ctx context.Context, data Data) {
+		// KSUIDs only order by second, so space the builds out to keep them in creation order
+		var (
+			base = time.Now()
+			n    int
+		)
+		createBuild := func(t *testing.T, repo, version string, status builddao.BuildStatus) builddao.Record {
+			n++
+			sk, err := ksuid.NewRandomWithTime(base.Add(time.Duration(n) * time.Second))
+			assert.NoError(t, err)
+
+			build, err := data.BuildDAO.Create(ctx, builddao.CreateInput{
+				Repo:        repo,
+				Env:         "prd",
+				SK:          sk.String(),
+				BuildNumber: version,
+				Branch:      "main",
+				Version:     version,
+				CommitHash:  "abc123",
+				StackName:   "prd-" + repo,
+			})
+			assert.NoError(t, err)
+
+			err = data.BuildDAO.UpdateStatus(ctx, builddao.UpdateInput{PK: build.PK, SK: build.SK, Status: &status})
+			assert.NoError(t, err)
+			build.Status = status
+			return build
+		}
+
+		enable := func(t *testing.T, repo string) {
+			_, err := data.TargetDAO.Create(ctx, targetdao.CreateInput{Repo: repo, Env: "prd", AutoRollback: true})
+			assert.NoError(t, err)
+		}
+
+		t.Run("Disabled", func(t *testing.T) {
+			repo := "rollback-disabled"
+			createBuild(t, repo, "1", builddao.BuildStatusSuccess)
+			failed := createBuild(t, repo, "2", builddao.BuildStatusFailed)
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+
+		t.Run("RedeploysLastSuccess", func(t *testing.T) {
+			repo := "rollback-enabled"
+			enable(t, repo)
+			createBuild(t, repo, "1", builddao.BuildStatusSuccess)
+			lastGood := createBuild(t, repo, "2", builddao.BuildStatusSuccess)
+			createBuild(t, repo, "3", builddao.BuildStatusFailed)
+			failed := createBuild(t, repo, "4", builddao.BuildStatusFailed)
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			if assert.NotNil(t, record) {
+				assert.Equal(t, lastGood.Version, record.Version)
+				assert.Equal(t, lastGood.StackName, record.StackName)
+				assert.Equal(t, failed.GetID(), record.RollbackOf)
+				assert.True(t, record.IsRollback())
+				assert.Equal(t, builddao.BuildStatusPending, record.Status)
+			}
+		})
+
+		t.Run("DeploymentUntouched", func(t *testing.T) {
+			repo := "rollback-untouched"
+			enable(t, repo)
+			createBuild(t, repo, "1", builddao.BuildStatusSuccess)
+			failed := createBuild(t, repo, "2", builddao.BuildStatusFailed)
+
+			data.Inspector.needsRollback = false
+			defer func() { data.Inspector.needsRollback = true }()
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+
+		t.Run("NoSuccessfulBuild", func(t *testing.T) {
+			repo := "rollback-none"
+			enable(t, repo)
+			failed := createBuild(t, repo, "1", builddao.BuildStatusFailed)
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+
+		t.Run("IgnoresNewerSuccess", func(t *testing.T) {
+			repo := "rollback-newer"
+			enable(t, repo)
+			failed := createBuild(t, repo, "1", builddao.BuildStatusFailed)
+			createBuild(t, repo, "2", builddao.BuildStatusSuccess)
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+
+		t.Run("DoesNotRollBackRollback", func(t *testing.T) {
+			repo := "rollback-loop"
+			enable(t, repo)
+			createBuild(t, repo, "1", builddao.BuildStatusSuccess)
+			failed := createBuild(t, repo, "2", builddao.BuildStatusFailed)
+			failed.RollbackOf = builddao.NewID(failed.PK, ksuid.New().String())
+
+			record, err := data.Manager.Rollback(ctx, failed)
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+		})
+	})
+}
@@ -0,0 +1,605 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Annotations cosign attaches to each signature layer
+const (
+	cosignSignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	cosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	cosignChainAnnotation       = "dev.sigstore.cosign/chain"
+	cosignBundleAnnotation      = "dev.sigstore.cosign/bundle"
+	cosignSignatureType         = "cosign container image signature"
+)
+
+// Fulcio certificate extensions holding the OIDC issuer of the signing identity
+var (
+	fulcioIssuerV1OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	fulcioIssuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// manifestMediaTypes are the manifest formats accepted when fetching images and signatures
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// CosignConfig is the trust policy for cosign signatures, stored in SSM under
+// /{env}/aws-deployer/signing/cosign/. Either PublicKey (key-based signing) or Issuer,
+// SubjectRegex, FulcioRoots and RekorPublicKey (keyless signing) must be set.
+type CosignConfig struct {
+	PublicKey      string // PEM public key (public-key)
+	Issuer         string // OIDC issuer required on keyless certificates (issuer)
+	SubjectRegex   string // Regex the certificate identity must match (subject-regex)
+	FulcioRoots    string // PEM Fulcio root and intermediate certificates (fulcio-roots)
+	RekorPublicKey string // PEM Rekor public key used to verify transparency log bundles (rekor-public-key)
+}
+
+// Keyless returns true if the config verifies keyless (Fulcio/Rekor) signatures
+func (c CosignConfig) Keyless() bool {
+	return c.PublicKey == ""
+}
+
+// validate returns an error if neither key-based nor keyless verification is fully configured
+func (c CosignConfig) validate() error {
+	if c.PublicKey != "" {
+		return nil
+	}
+
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"issuer", c.Issuer},
+		{"subject-regex", c.SubjectRegex},
+		{"fulcio-roots", c.FulcioRoots},
+		{"rekor-public-key", c.RekorPublicKey},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cosign verification requires public-key, or all of issuer, subject-regex, fulcio-roots and rekor-public-key (missing %s)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ECRAuthorizer abstracts ECR authorization token retrieval for registry access
+type ECRAuthorizer interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+}
+
+// SSMPathGetter abstracts SSM GetParametersByPath operations for loading the cosign config
+type SSMPathGetter interface {
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+// loadCosignConfig reads the cosign trust policy from SSM
+func loadCosignConfig(ctx context.Context, client SSMPathGetter, env string) (CosignConfig, error) {
+	if client == nil {
+		return CosignConfig{}, fmt.Errorf("cosign verification requires SSM Parameter Store")
+	}
+
+	path := fmt.Sprintf("/%s/aws-deployer/signing/cosign/", env)
+
+	values := map[string]string{}
+	var nextToken *string
+	for {
+		output, err := client.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			WithDecryption: aws.Bool(true),
+			NextToken:      nextToken,
+		})
+		if err != nil {
+			return CosignConfig{}, fmt.Errorf("failed to get cosign config from %s: %w", path, err)
+		}
+		for _, p := range output.Parameters {
+			values[strings.TrimPrefix(aws.ToString(p.Name), path)] = aws.ToString(p.Value)
+		}
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	config := CosignConfig{
+		PublicKey:      values["public-key"],
+		Issuer:         values["issuer"],
+		SubjectRegex:   values["subject-regex"],
+		FulcioRoots:    values["fulcio-roots"],
+		RekorPublicKey: values["rekor-public-key"],
+	}
+	if err := config.validate(); err != nil {
+		return CosignConfig{}, err
+	}
+	return config, nil
+}
+
+// imageReference is a parsed container image URI
+type imageReference struct {
+	Registry   string // e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com
+	Repository string // e.g. my-app
+	Reference  string // tag or sha256 digest
+}
+
+// parseImageReference parses registry/repository:tag and registry/repository@sha256:digest URIs
+func parseImageReference(imageURI string) (imageReference, error) {
+	registry, rest, ok := strings.Cut(imageURI, "/")
+	if !ok || registry == "" || rest == "" {
+		return imageReference{}, fmt.Errorf("invalid image uri %s: missing registry", imageURI)
+	}
+
+	if repository, digest, ok := strings.Cut(rest, "@"); ok {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return imageReference{}, fmt.Errorf("invalid image uri %s: unsupported digest", imageURI)
+		}
+		return imageReference{Registry: registry, Repository: repository, Reference: digest}, nil
+	}
+
+	if i := strings.LastIndex(rest, ":"); i > 0 {
+		return imageReference{Registry: registry, Repository: rest[:i], Reference: rest[i+1:]}, nil
+	}
+	return imageReference{Registry: registry, Repository: rest, Reference: "latest"}, nil
+}
+
+// ociManifest is the subset of an OCI image manifest used to read signatures
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// errManifestNotFound is returned when a manifest does not exist in the registry
+var errManifestNotFound = errors.New("manifest not found")
+
+// registryClient reads manifests and blobs from an ECR registry over the OCI distribution API
+type registryClient struct {
+	httpClient *http.Client
+	ecrClient  ECRAuthorizer
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// authorization returns the basic auth token for ECR, refreshing it when it expires
+func (c *registryClient) authorization(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	output, err := c.ecrClient.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(output.AuthorizationData) == 0 || output.AuthorizationData[0].AuthorizationToken == nil {
+		return "", fmt.Errorf("ECR returned no authorization token")
+	}
+
+	data := output.AuthorizationData[0]
+	c.token = aws.ToString(data.AuthorizationToken)
+	c.expiresAt = time.Now().Add(time.Hour)
+	if data.ExpiresAt != nil {
+		c.expiresAt = data.ExpiresAt.Add(-time.Minute)
+	}
+	return c.token, nil
+}
+
+// get performs an authenticated GET against the registry and returns the response body
+func (c *registryClient) get(ctx context.Context, url string, accept []string) ([]byte, error) {
+	token, err := c.authorization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Basic "+token)
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", url, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errManifestNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: registry returned %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// manifestDigest returns the digest of the manifest ref points to
+func (c *registryClient) manifestDigest(ctx context.Context, ref imageReference) (string, error) {
+	body, err := c.get(ctx, fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, ref.Reference), manifestMediaTypes)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image manifest: %w", err)
+	}
+
+	digest := sha256Digest(body)
+	if strings.HasPrefix(ref.Reference, "sha256:") && ref.Reference != digest {
+		return "", fmt.Errorf("image manifest digest %s does not match %s", digest, ref.Reference)
+	}
+	return digest, nil
+}
+
+// signatureManifest returns the cosign signature manifest stored under the sha256-{hex}.sig tag
+func (c *registryClient) signatureManifest(ctx context.Context, ref imageReference, digest string) (*ociManifest, error) {
+	tag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	body, err := c.get(ctx, fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, tag), manifestMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse signature manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// blob returns the content of a blob after checking it matches its digest
+func (c *registryClient) blob(ctx context.Context, ref imageReference, digest string) ([]byte, error) {
+	body, err := c.get(ctx, fmt.Sprintf("https://%s/v2/%s/blobs/%s", ref.Registry, ref.Repository, digest), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %s: %w", digest, err)
+	}
+	if got := sha256Digest(body); got != digest {
+		return nil, fmt.Errorf("blob digest %s does not match %s", got, digest)
+	}
+	return body, nil
+}
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// simpleSigningPayload is the payload cosign signs for a container image
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// rekorBundle is the transparency log entry cosign attaches to a signature
+type rekorBundle struct {
+	SignedEntryTimestamp []byte             `json:"SignedEntryTimestamp"`
+	Payload              rekorBundlePayload `json:"Payload"`
+}
+
+// rekorBundlePayload is signed by Rekor in canonical JSON form, so its fields stay in
+// lexical order and must not gain omitempty
+type rekorBundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the subset of a hashedrekord transparency log entry used to tie it to a signature
+type hashedRekord struct {
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// cosignVerifier checks cosign signature layers against a trust policy
+type cosignVerifier struct {
+	config       CosignConfig
+	publicKey    crypto.PublicKey
+	rekorKey     crypto.PublicKey
+	roots        *x509.CertPool
+	intermediate *x509.CertPool
+	subject      *regexp.Regexp
+}
+
+// newCosignVerifier parses the keys, certificates and patterns in config
+func newCosignVerifier(config CosignConfig) (*cosignVerifier, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	v := &cosignVerifier{config: config}
+
+	if config.PublicKey != "" {
+		key, err := parsePublicKey(config.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public-key: %w", err)
+		}
+		v.publicKey = key
+	}
+
+	if config.RekorPublicKey != "" {
+		key, err := parsePublicKey(config.RekorPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rekor-public-key: %w", err)
+		}
+		v.rekorKey = key
+	}
+
+	if config.Keyless() {
+		certs, err := parseCertificates(config.FulcioRoots)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fulcio-roots: %w", err)
+		}
+		v.roots = x509.NewCertPool()
+		v.intermediate = x509.NewCertPool()
+		for _, cert := range certs {
+			if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+				v.roots.AddCert(cert)
+			} else {
+				v.intermediate.AddCert(cert)
+			}
+		}
+
+		subject, err := regexp.Compile(config.SubjectRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject-regex: %w", err)
+		}
+		v.subject = subject
+	}
+
+	return v, nil
+}
+
+// verify checks a single signature layer and its payload. Returns the signer identity and
+// the time the signature was logged in Rekor (zero if the signature has no bundle).
+func (v *cosignVerifier) verify(layer ociDescriptor, payload []byte, digest string) (signedBy string, signedAt time.Time, err error) {
+	var simple simpleSigningPayload
+	if err := json.Unmarshal(payload, &simple); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse signature payload: %w", err)
+	}
+	if simple.Critical.Type != cosignSignatureType {
+		return "", time.Time{}, fmt.Errorf("unexpected signature type %q", simple.Critical.Type)
+	}
+	if simple.Critical.Image.DockerManifestDigest != digest {
+		return "", time.Time{}, fmt.Errorf("signature is for %s, not %s", simple.Critical.Image.DockerManifestDigest, digest)
+	}
+
+	encoded := layer.Annotations[cosignSignatureAnnotation]
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(signature) == 0 {
+		return "", time.Time{}, fmt.Errorf("missing or invalid signature annotation")
+	}
+
+	var integratedTime time.Time
+	// Bundles are only checked when a Rekor key is configured, which keyless verification requires
+	if bundle := layer.Annotations[cosignBundleAnnotation]; bundle != "" && v.rekorKey != nil {
+		integratedTime, err = v.verifyBundle(bundle, encoded, payload)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	if !v.config.Keyless() {
+		if err := verifySignature(v.publicKey, payload, signature); err != nil {
+			return "", time.Time{}, err
+		}
+		return "public-key", integratedTime, nil
+	}
+
+	// Keyless certificates are only valid for minutes, so they are checked at the time Rekor logged them
+	if integratedTime.IsZero() {
+		return "", time.Time{}, fmt.Errorf("keyless signature has no rekor bundle")
+	}
+
+	cert, err := v.verifyCertificate(layer, integratedTime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := verifySignature(cert.PublicKey, payload, signature); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return certificateIdentity(cert, v.subject), integratedTime, nil
+}
+
+// verifyBundle checks the Rekor signed entry timestamp and that the entry is for this signature
+func (v *cosignVerifier) verifyBundle(encoded, signature string, payload []byte) (time.Time, error) {
+	var bundle rekorBundle
+	if err := json.Unmarshal([]byte(encoded), &bundle); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse rekor bundle: %w", err)
+	}
+
+	canonical, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := verifySignature(v.rekorKey, canonical, bundle.SignedEntryTimestamp); err != nil {
+		return time.Time{}, fmt.Errorf("invalid rekor bundle: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rekor entry body: %w", err)
+	}
+	var entry hashedRekord
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse rekor entry: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	if entry.Spec.Signature.Content != signature || entry.Spec.Data.Hash.Value != hex.EncodeToString(sum[:]) {
+		return time.Time{}, fmt.Errorf("rekor entry does not match signature")
+	}
+
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+// verifyCertificate checks the Fulcio certificate chain and identity constraints of a keyless signature
+func (v *cosignVerifier) verifyCertificate(layer ociDescriptor, at time.Time) (*x509.Certificate, error) {
+	certs, err := parseCertificates(layer.Annotations[cosignCertificateAnnotation])
+	if err != nil || len(certs) == 0 {
+		return nil, fmt.Errorf("keyless signature has no valid certificate")
+	}
+	leaf := certs[0]
+
+	intermediates := v.intermediate.Clone()
+	if chain := layer.Annotations[cosignChainAnnotation]; chain != "" {
+		chainCerts, err := parseCertificates(chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate chain: %w", err)
+		}
+		for _, cert := range chainCerts {
+			if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+				intermediates.AddCert(cert)
+			}
+		}
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate is not trusted: %w", err)
+	}
+
+	if issuer := certificateIssuer(leaf); issuer != v.config.Issuer {
+		return nil, fmt.Errorf("certificate issuer %q does not match %q", issuer, v.config.Issuer)
+	}
+	if certificateIdentity(leaf, v.subject) == "" {
+		return nil, fmt.Errorf("certificate identity does not match %q", v.config.SubjectRegex)
+	}
+
+	return leaf, nil
+}
+
+// certificateIssuer returns the OIDC issuer recorded in a Fulcio certificate
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(fulcioIssuerV2OID):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(fulcioIssuerV1OID):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// certificateIdentity returns the first email or URI SAN that matches subject, or "" if none do
+func certificateIdentity(cert *x509.Certificate, subject *regexp.Regexp) string {
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	for _, identity := range identities {
+		if subject.MatchString(identity) {
+			return identity
+		}
+	}
+	return ""
+}
+
+// verifySignature checks signature over message with an ECDSA, Ed25519 or RSA public key
+func verifySignature(key crypto.PublicKey, message, signature []byte) error {
+	digest := sha256.Sum256(message)
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], signature) {
+			return fmt.Errorf("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, message, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+func parsePublicKey(content string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func parseCertificates(content string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(content)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
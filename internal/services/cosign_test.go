@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRegistryToken = "QVdTOnNlY3JldA==" // base64("AWS:secret")
+
+type mockECRAuthorizer struct{}
+
+func (mockECRAuthorizer) GetAuthorizationToken(context.Context, *ecr.GetAuthorizationTokenInput, ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error) {
+	return &ecr.GetAuthorizationTokenOutput{
+		AuthorizationData: []ecrtypes.AuthorizationData{
+			{AuthorizationToken: aws.String(testRegistryToken)},
+		},
+	}, nil
+}
+
+type mockSSMPathGetter struct {
+	parameters map[string]string
+}
+
+func (m mockSSMPathGetter) GetParametersByPath(_ context.Context, params *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var parameters []ssmtypes.Parameter
+	for name, value := range m.parameters {
+		if strings.HasPrefix(name, aws.ToString(params.Path)) {
+			parameters = append(parameters, ssmtypes.Parameter{Name: aws.String(name), Value: aws.String(value)})
+		}
+	}
+	return &ssm.GetParametersByPathOutput{Parameters: parameters}, nil
+}
+
+// testRegistry is a minimal OCI registry stand-in serving manifests and blobs from memory
+type testRegistry struct {
+	server    *httptest.Server
+	manifests map[string][]byte // repository/reference -> manifest
+	blobs     map[string][]byte // digest -> content
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		manifests: map[string][]byte{},
+		blobs:     map[string][]byte{},
+	}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Basic "+testRegistryToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.TrimPrefix(req.URL.Path, "/v2/")
+		var content []byte
+		var ok bool
+		if repository, reference, found := strings.Cut(path, "/manifests/"); found {
+			content, ok = r.manifests[repository+"/"+reference]
+		} else if _, digest, found := strings.Cut(path, "/blobs/"); found {
+			content, ok = r.blobs[digest]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+// pushImage stores an image manifest under tag and returns its digest
+func (r *testRegistry) pushImage(repository, tag string) string {
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:%s"}}`, repository))
+	r.manifests[repository+"/"+tag] = manifest
+	digest := sha256Digest(manifest)
+	r.manifests[repository+"/"+digest] = manifest
+	return digest
+}
+
+// pushSignature stores a signature manifest for digest with the given layers
+func (r *testRegistry) pushSignature(repository, digest string, payload []byte, annotations map[string]string) {
+	payloadDigest := sha256Digest(payload)
+	r.blobs[payloadDigest] = payload
+
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"layers": []ociDescriptor{
+			{
+				MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+				Digest:      payloadDigest,
+				Size:        int64(len(payload)),
+				Annotations: annotations,
+			},
+		},
+	})
+	r.manifests[repository+"/"+strings.Replace(digest, ":", "-", 1)+".sig"] = manifest
+}
+
+func signingPayload(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"my-app"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, digest))
+}
+
+func signPayload(t *testing.T, key *ecdsa.PrivateKey, payload []byte) string {
+	digest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func publicKeyPEM(t *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func certificatePEM(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	return key
+}
+
+// rekorBundleFor creates a Rekor bundle for signature over payload, signed with rekorKey
+func rekorBundleFor(t *testing.T, rekorKey *ecdsa.PrivateKey, signature string, payload []byte, integratedTime time.Time) string {
+	sum := sha256.Sum256(payload)
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data":      map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(sum[:])}},
+			"signature": map[string]interface{}{"content": signature},
+		},
+	})
+	assert.NoError(t, err)
+
+	bundlePayload := rekorBundlePayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime.Unix(),
+		LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+		LogIndex:       42,
+	}
+	canonical, err := json.Marshal(bundlePayload)
+	assert.NoError(t, err)
+	set, err := base64.StdEncoding.DecodeString(signPayload(t, rekorKey, canonical))
+	assert.NoError(t, err)
+
+	bundle, err := json.Marshal(rekorBundle{SignedEntryTimestamp: set, Payload: bundlePayload})
+	assert.NoError(t, err)
+	return string(bundle)
+}
+
+// fulcio is a test certificate authority issuing short-lived code signing certificates
+type fulcio struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+	pem  string
+}
+
+func newFulcio(t *testing.T) *fulcio {
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &fulcio{key: key, cert: cert, pem: certificatePEM(der)}
+}
+
+func (f *fulcio) issue(t *testing.T, key *ecdsa.PrivateKey, email, issuer string) string {
+	issuerValue, err := asn1.Marshal(issuer)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{email},
+		ExtraExtensions: []pkix.Extension{
+			{Id: fulcioIssuerV2OID, Value: issuerValue},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.cert, &key.PublicKey, f.key)
+	assert.NoError(t, err)
+	return certificatePEM(der)
+}
+
+func newTestSignatureVerifier(registry *testRegistry, parameters map[string]string) *signatureVerifier {
+	return &signatureVerifier{
+		env:       "dev",
+		ssmClient: mockSSMPathGetter{parameters: parameters},
+		registry: &registryClient{
+			httpClient: registry.server.Client(),
+			ecrClient:  mockECRAuthorizer{},
+		},
+		logger: zerolog.Nop(),
+	}
+}
+
+func TestVerifyContainerSignature_PublicKey(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	signer := newTestKey(t)
+
+	signed := registry.pushImage("signed", "v1")
+	payload := signingPayload(signed)
+	registry.pushSignature("signed", signed, payload, map[string]string{
+		cosignSignatureAnnotation: signPayload(t, signer, payload),
+	})
+
+	registry.pushImage("unsigned", "v1")
+
+	// A signature copied from another image must not verify
+	copied := registry.pushImage("copied", "v1")
+	registry.pushSignature("copied", copied, payload, map[string]string{
+		cosignSignatureAnnotation: signPayload(t, signer, payload),
+	})
+
+	verifier := newTestSignatureVerifier(registry, map[string]string{
+		"/dev/aws-deployer/signing/cosign/public-key": publicKeyPEM(t, signer),
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/signed:v1")
+		assert.NoError(t, err)
+		assert.True(t, result.Verified, result.ErrorMessage)
+		assert.Equal(t, "public-key", result.SignedBy)
+	})
+
+	t.Run("VerifiedByDigest", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/signed@"+signed)
+		assert.NoError(t, err)
+		assert.True(t, result.Verified, result.ErrorMessage)
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/unsigned:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "not signed")
+	})
+
+	t.Run("SignatureForAnotherImage", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/copied:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		other := newTestSignatureVerifier(registry, map[string]string{
+			"/dev/aws-deployer/signing/cosign/public-key": publicKeyPEM(t, newTestKey(t)),
+		})
+		result, err := other.VerifyContainerSignature(ctx, registry.host()+"/signed:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "invalid signature")
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		other := newTestSignatureVerifier(registry, map[string]string{})
+		_, err := other.VerifyContainerSignature(ctx, registry.host()+"/signed:v1")
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyContainerSignature_Keyless(t *testing.T) {
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	ca := newFulcio(t)
+	rekorKey := newTestKey(t)
+
+	const issuer = "https://token.actions.githubusercontent.com"
+
+	push := func(repository, email, certIssuer string) {
+		digest := registry.pushImage(repository, "v1")
+		payload := signingPayload(digest)
+		ephemeral := newTestKey(t)
+		signature := signPayload(t, ephemeral, payload)
+		registry.pushSignature(repository, digest, payload, map[string]string{
+			cosignSignatureAnnotation:   signature,
+			cosignCertificateAnnotation: ca.issue(t, ephemeral, email, certIssuer),
+			cosignBundleAnnotation:      rekorBundleFor(t, rekorKey, signature, payload, time.Now()),
+		})
+	}
+	push("keyless", "ci@example.com", issuer)
+	push("other-subject", "someone@example.org", issuer)
+	push("other-issuer", "ci@example.com", "https://accounts.google.com")
+
+	verifier := newTestSignatureVerifier(registry, map[string]string{
+		"/dev/aws-deployer/signing/cosign/issuer":           issuer,
+		"/dev/aws-deployer/signing/cosign/subject-regex":    `^ci@example\.com$`,
+		"/dev/aws-deployer/signing/cosign/fulcio-roots":     ca.pem,
+		"/dev/aws-deployer/signing/cosign/rekor-public-key": publicKeyPEM(t, rekorKey),
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/keyless:v1")
+		assert.NoError(t, err)
+		assert.True(t, result.Verified, result.ErrorMessage)
+		assert.Equal(t, "ci@example.com", result.SignedBy)
+		assert.False(t, result.SignedAt.IsZero())
+	})
+
+	t.Run("SubjectMismatch", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/other-subject:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "identity")
+	})
+
+	t.Run("IssuerMismatch", func(t *testing.T) {
+		result, err := verifier.VerifyContainerSignature(ctx, registry.host()+"/other-issuer:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "issuer")
+	})
+
+	t.Run("UntrustedRoot", func(t *testing.T) {
+		other := newTestSignatureVerifier(registry, map[string]string{
+			"/dev/aws-deployer/signing/cosign/issuer":           issuer,
+			"/dev/aws-deployer/signing/cosign/subject-regex":    `^ci@example\.com$`,
+			"/dev/aws-deployer/signing/cosign/fulcio-roots":     newFulcio(t).pem,
+			"/dev/aws-deployer/signing/cosign/rekor-public-key": publicKeyPEM(t, rekorKey),
+		})
+		result, err := other.VerifyContainerSignature(ctx, registry.host()+"/keyless:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "not trusted")
+	})
+
+	t.Run("WrongRekorKey", func(t *testing.T) {
+		other := newTestSignatureVerifier(registry, map[string]string{
+			"/dev/aws-deployer/signing/cosign/issuer":           issuer,
+			"/dev/aws-deployer/signing/cosign/subject-regex":    `^ci@example\.com$`,
+			"/dev/aws-deployer/signing/cosign/fulcio-roots":     ca.pem,
+			"/dev/aws-deployer/signing/cosign/rekor-public-key": publicKeyPEM(t, newTestKey(t)),
+		})
+		result, err := other.VerifyContainerSignature(ctx, registry.host()+"/keyless:v1")
+		assert.NoError(t, err)
+		assert.False(t, result.Verified)
+		assert.Contains(t, result.ErrorMessage, "rekor")
+	})
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want imageReference
+	}{
+		{
+			uri:  "123456789012.dkr.ecr.us-east-1.amazonaws.com/my-app:v1",
+			want: imageReference{Registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com", Repository: "my-app", Reference: "v1"},
+		},
+		{
+			uri:  "123456789012.dkr.ecr.us-east-1.amazonaws.com/team/my-app@sha256:abc",
+			want: imageReference{Registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com", Repository: "team/my-app", Reference: "sha256:abc"},
+		},
+		{
+			uri:  "localhost:5000/my-app",
+			want: imageReference{Registry: "localhost:5000", Repository: "my-app", Reference: "latest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := parseImageReference(tt.uri)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -77,6 +77,21 @@ func (d *DynamoDBService) UpdateBuildStatus(ctx context.Context, input builddao.
 	return d.dao.Find(ctx, id)
 }
 
+// SetBuildChangeSet stores the change set preview on a build (wraps DAO.SetChangeSet)
+func (d *DynamoDBService) SetBuildChangeSet(ctx context.Context, pk builddao.PK, sk string, changeSet builddao.ChangeSet) error {
+	return d.dao.SetChangeSet(ctx, pk, sk, changeSet)
+}
+
+// SetBuildPolicyViolations stores template policy violations on a build (wraps DAO.SetPolicyViolations)
+func (d *DynamoDBService) SetBuildPolicyViolations(ctx context.Context, pk builddao.PK, sk string, violations []builddao.PolicyViolation) error {
+	return d.dao.SetPolicyViolations(ctx, pk, sk, violations)
+}
+
+// SetBuildOutputs stores the stack outputs on a build (wraps DAO.SetOutputs)
+func (d *DynamoDBService) SetBuildOutputs(ctx context.Context, pk builddao.PK, sk string, outputs map[string]string) error {
+	return d.dao.SetOutputs(ctx, pk, sk, outputs)
+}
+
 // QueryBuildsByRepo returns all builds for a given repository and environment
 func (d *DynamoDBService) QueryBuildsByRepo(ctx context.Context, repo, env string) ([]builddao.Record, error) {
 	return d.dao.QueryByRepoEnv(ctx, repo, env)
@@ -13,9 +13,13 @@ import (
 	"golang.org/x/crypto/nacl/box"
 )
 
+// gitHubAPIURL is the base URL of the GitHub REST API
+const gitHubAPIURL = "https://api.github.com"
+
 type GitHubService struct {
 	token      string
 	httpClient *http.Client
+	baseURL    string
 }
 
 type GitHubPublicKey struct {
@@ -32,12 +36,13 @@ func NewGitHubService(token string) *GitHubService {
 	return &GitHubService{
 		token:      token,
 		httpClient: &http.Client{},
+		baseURL:    gitHubAPIURL,
 	}
 }
 
 // GetPublicKey fetches the repository's public key for encrypting secrets
 func (g *GitHubService) GetPublicKey(ctx context.Context, owner, repo string) (*GitHubPublicKey, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/secrets/public-key", owner, repo)
+	url := fmt.Sprintf("%s/repos/%s/%s/actions/secrets/public-key", g.baseURL, owner, repo)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -120,7 +125,7 @@ func (g *GitHubService) CreateOrUpdateSecret(ctx context.Context, owner, repo, s
 	}
 
 	// Create or update the secret
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/secrets/%s", owner, repo, secretName)
+	url := fmt.Sprintf("%s/repos/%s/%s/actions/secrets/%s", g.baseURL, owner, repo, secretName)
 	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -144,3 +149,101 @@ func (g *GitHubService) CreateOrUpdateSecret(ctx context.Context, owner, repo, s
 
 	return nil
 }
+
+// GitHubDeploymentRequest is the body of a create deployment request
+type GitHubDeploymentRequest struct {
+	Ref                   string            `json:"ref"`
+	Environment           string            `json:"environment"`
+	Description           string            `json:"description,omitempty"`
+	AutoMerge             bool              `json:"auto_merge"`
+	RequiredContexts      []string          `json:"required_contexts"`
+	Payload               map[string]string `json:"payload,omitempty"`
+	ProductionEnvironment bool              `json:"production_environment,omitempty"`
+}
+
+// GitHubDeployment is a deployment returned by the GitHub API
+type GitHubDeployment struct {
+	ID          int64  `json:"id"`
+	SHA         string `json:"sha"`
+	Environment string `json:"environment"`
+}
+
+// GitHubDeploymentStatusRequest is the body of a create deployment status request
+type GitHubDeploymentStatusRequest struct {
+	State          string `json:"state"` // error, failure, inactive, in_progress, queued, pending or success
+	Description    string `json:"description,omitempty"`
+	LogURL         string `json:"log_url,omitempty"`
+	EnvironmentURL string `json:"environment_url,omitempty"`
+}
+
+// GitHubCommitStatusRequest is the body of a create commit status request
+type GitHubCommitStatusRequest struct {
+	State       string `json:"state"` // error, failure, pending or success
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context"`
+}
+
+// CreateDeployment creates a deployment of a commit to an environment
+func (g *GitHubService) CreateDeployment(ctx context.Context, owner, repo string, input GitHubDeploymentRequest) (*GitHubDeployment, error) {
+	var deployment GitHubDeployment
+	path := fmt.Sprintf("/repos/%s/%s/deployments", owner, repo)
+	if err := g.post(ctx, path, input, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to create deployment: %w", err)
+	}
+	return &deployment, nil
+}
+
+// CreateDeploymentStatus adds a status to a deployment
+func (g *GitHubService) CreateDeploymentStatus(ctx context.Context, owner, repo string, deploymentID int64, input GitHubDeploymentStatusRequest) error {
+	path := fmt.Sprintf("/repos/%s/%s/deployments/%d/statuses", owner, repo, deploymentID)
+	if err := g.post(ctx, path, input, nil); err != nil {
+		return fmt.Errorf("failed to create deployment status: %w", err)
+	}
+	return nil
+}
+
+// CreateCommitStatus sets a commit status on a commit
+func (g *GitHubService) CreateCommitStatus(ctx context.Context, owner, repo, sha string, input GitHubCommitStatusRequest) error {
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", owner, repo, sha)
+	if err := g.post(ctx, path, input, nil); err != nil {
+		return fmt.Errorf("failed to create commit status: %w", err)
+	}
+	return nil
+}
+
+// post sends a JSON POST to the GitHub API and decodes the response into out, if not nil
+func (g *GitHubService) post(ctx context.Context, path string, in, out interface{}) error {
+	bodyBytes, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", g.baseURL+path, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -16,9 +17,13 @@ type Config struct {
 	DeploymentMode               string
 	S3Bucket                     string
 	AllowedEmail                 string
+	AllowedGroups                []string
 	SessionTokenSecretName       string
 	CustomDomain                 string
 	APIGatewayID                 string
+	GitHubOwner                  string
+	GitHubTokenSecret            string
+	RoleBasedAccess              bool
 }
 
 // ParameterStore defines the interface for accessing configuration parameters
@@ -84,21 +89,24 @@ func (s *SSMParameterStore) GetParameter(ctx context.Context, name string) (stri
 func (s *SSMParameterStore) GetConfig(ctx context.Context) (*Config, error) {
 	path := fmt.Sprintf("/%s/aws-deployer", s.env)
 
-	// Use GetParametersByPath for efficient batch retrieval
-	result, err := s.client.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+	// Use GetParametersByPath for efficient batch retrieval; pages hold at most 10 parameters
+	paginator := ssm.NewGetParametersByPathPaginator(s.client, &ssm.GetParametersByPathInput{
 		Path:           &path,
 		Recursive:      boolPtr(true),
 		WithDecryption: boolPtr(true),
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get parameters by path %s: %w", path, err)
-	}
 
 	// Build a map of parameter names to values
 	params := make(map[string]string)
-	for _, param := range result.Parameters {
-		if param.Name != nil && param.Value != nil {
-			params[*param.Name] = *param.Value
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parameters by path %s: %w", path, err)
+		}
+		for _, param := range result.Parameters {
+			if param.Name != nil && param.Value != nil {
+				params[*param.Name] = *param.Value
+			}
 		}
 	}
 
@@ -116,9 +124,13 @@ func (s *SSMParameterStore) GetConfig(ctx context.Context) (*Config, error) {
 		DeploymentMode:               params[fmt.Sprintf("/%s/aws-deployer/deployment-mode", s.env)],
 		S3Bucket:                     params[fmt.Sprintf("/%s/aws-deployer/s3-bucket", s.env)],
 		AllowedEmail:                 params[fmt.Sprintf("/%s/aws-deployer/allowed-email", s.env)],
+		AllowedGroups:                splitList(params[fmt.Sprintf("/%s/aws-deployer/allowed-groups", s.env)]),
 		SessionTokenSecretName:       params[fmt.Sprintf("/%s/aws-deployer/session-token-secret-name", s.env)],
 		CustomDomain:                 params[fmt.Sprintf("/%s/aws-deployer/custom-domain", s.env)],
 		APIGatewayID:                 params[fmt.Sprintf("/%s/aws-deployer/api-gateway-id", s.env)],
+		GitHubOwner:                  params[fmt.Sprintf("/%s/aws-deployer/github-owner", s.env)],
+		GitHubTokenSecret:            params[fmt.Sprintf("/%s/aws-deployer/github-token-secret", s.env)],
+		RoleBasedAccess:              params[fmt.Sprintf("/%s/aws-deployer/role-based-access", s.env)] == "true",
 	}
 
 	// Set defaults
@@ -158,9 +170,13 @@ func (e *EnvParameterStore) GetConfig(ctx context.Context) (*Config, error) {
 		DeploymentMode:               os.Getenv("DEPLOYMENT_MODE"),
 		S3Bucket:                     os.Getenv("S3_BUCKET_NAME"),
 		AllowedEmail:                 os.Getenv("ALLOWED_EMAIL"),
+		AllowedGroups:                splitList(os.Getenv("ALLOWED_GROUPS")),
 		SessionTokenSecretName:       os.Getenv("SESSION_TOKEN_SECRET_NAME"),
 		CustomDomain:                 os.Getenv("CUSTOM_DOMAIN"),
 		APIGatewayID:                 os.Getenv("API_GATEWAY_ID"),
+		GitHubOwner:                  os.Getenv("GITHUB_OWNER"),
+		GitHubTokenSecret:            os.Getenv("GITHUB_TOKEN_SECRET"),
+		RoleBasedAccess:              os.Getenv("ROLE_BASED_ACCESS") == "true",
 	}
 
 	// Set defaults
@@ -179,3 +195,14 @@ func (e *EnvParameterStore) GetConfig(ctx context.Context) (*Config, error) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+// splitList splits a comma-separated parameter into its trimmed, non-empty values
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -16,13 +16,18 @@ type SecretsManagerService struct {
 }
 
 // OAuthConfig represents OAuth/OIDC provider configuration.
-// Supports multiple providers: Auth0, Google CIAM.
+// Supports multiple providers: Auth0, Google CIAM, and any generic OIDC provider.
 type OAuthConfig struct {
-	Provider     string `json:"provider"`      // "auth0" or "google-ciam"
-	ClientID     string `json:"client_id"`     // OAuth client ID
-	ClientSecret string `json:"client_secret"` // OAuth client secret
-	Domain       string `json:"domain"`        // For Auth0: tenant domain (e.g., "tenant.us.auth0.com")
-	ProjectID    string `json:"project_id"`    // For Google CIAM: GCP project ID
+	Provider      string   `json:"provider"`                  // "auth0", "google-ciam" or "oidc"
+	ClientID      string   `json:"client_id"`                 // OAuth client ID
+	ClientSecret  string   `json:"client_secret"`             // OAuth client secret
+	Domain        string   `json:"domain"`                    // For Auth0: tenant domain (e.g., "tenant.us.auth0.com")
+	ProjectID     string   `json:"project_id"`                // For Google CIAM: GCP project ID
+	IssuerURL     string   `json:"issuer_url,omitempty"`      // For OIDC: issuer URL (e.g., "https://keycloak.example.com/realms/main")
+	EndSessionURL string   `json:"end_session_url,omitempty"` // For OIDC: logout endpoint (default: discovered end_session_endpoint)
+	Scopes        []string `json:"scopes,omitempty"`          // Extra scopes to request (e.g., ["groups"] for Dex and Keycloak)
+	GroupsClaim   string   `json:"groups_claim,omitempty"`    // ID token claim holding groups (default "groups")
+	Claims        []string `json:"claims,omitempty"`          // Custom ID token claims to copy into the profile
 }
 
 func NewSecretsManagerService() (*SecretsManagerService, error) {
@@ -37,7 +42,7 @@ func NewSecretsManagerService() (*SecretsManagerService, error) {
 }
 
 // GetOAuthConfig retrieves OAuth provider configuration from AWS Secrets Manager.
-// Supports multiple providers: "auth0", "google-ciam".
+// Supports multiple providers: "auth0", "google-ciam", "oidc".
 // For backward compatibility, defaults to "auth0" if provider field is missing.
 func (s *SecretsManagerService) GetOAuthConfig(ctx context.Context) (*OAuthConfig, error) {
 	env := os.Getenv("ENV")
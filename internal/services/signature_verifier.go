@@ -2,12 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/signer"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/rs/zerolog"
 )
 
@@ -29,22 +34,38 @@ type SignatureVerifier interface {
 }
 
 type signatureVerifier struct {
+	env          string
 	signerClient *signer.Client
 	s3Client     *s3.Client
+	ssmClient    SSMPathGetter
+	registry     *registryClient
 	logger       zerolog.Logger
 }
 
 // NewSignatureVerifier creates a new signature verifier
 func NewSignatureVerifier(
+	env string,
 	signerClient *signer.Client,
 	s3Client *s3.Client,
+	ecrClient *ecr.Client,
+	ssmClient *ssm.Client,
 	logger zerolog.Logger,
 ) SignatureVerifier {
-	return &signatureVerifier{
+	verifier := &signatureVerifier{
+		env:          env,
 		signerClient: signerClient,
 		s3Client:     s3Client,
-		logger:       logger.With().Str("service", "signature_verifier").Logger(),
+		registry: &registryClient{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			ecrClient:  ecrClient,
+		},
+		logger: logger.With().Str("service", "signature_verifier").Logger(),
 	}
+	// The SSM client is nil when SSM is disabled for local development
+	if ssmClient != nil {
+		verifier.ssmClient = ssmClient
+	}
+	return verifier
 }
 
 // VerifyLambdaSignature verifies a Lambda zip file signature using AWS Signer
@@ -98,11 +119,10 @@ func (v *signatureVerifier) VerifyLambdaSignature(ctx context.Context, s3Bucket,
 	}, nil
 }
 
-// VerifyContainerSignature verifies a container image signature using Cosign
-// Note: This is a simplified implementation. In production, you would:
-// 1. Use cosign Go libraries or exec cosign CLI
-// 2. Verify against public keys or keyless OIDC signatures
-// 3. Check signature metadata in ECR
+// VerifyContainerSignature verifies the cosign signatures stored alongside a container image in ECR.
+// Signatures are read from the sha256-{digest}.sig tag and checked against the trust policy in SSM
+// under /{env}/aws-deployer/signing/cosign/. An image without a valid signature returns
+// Verified=false; an error means verification itself could not be performed.
 func (v *signatureVerifier) VerifyContainerSignature(ctx context.Context, imageURI string) (VerificationResult, error) {
 	logger := v.logger.With().
 		Str("image_uri", imageURI).
@@ -110,22 +130,81 @@ func (v *signatureVerifier) VerifyContainerSignature(ctx context.Context, imageU
 
 	logger.Info().Msg("verifying container signature")
 
-	// For now, this is a placeholder that would integrate with cosign
-	// In a real implementation, you would:
-	// 1. Parse the image URI to get registry, repo, tag/digest
-	// 2. Look for the signature artifact in ECR (sha256-*.sig tag)
-	// 3. Use cosign libraries to verify the signature
-	// 4. Validate against trusted keys or OIDC identities
+	ref, err := parseImageReference(imageURI)
+	if err != nil {
+		return VerificationResult{ErrorMessage: err.Error()}, err
+	}
 
-	// Example of what full implementation would do:
-	// import "github.com/sigstore/cosign/v2/pkg/cosign"
-	// co := &cosign.CheckOpts{...}
-	// verified, _, err := cosign.VerifyImageSignatures(ctx, imageURI, co)
+	config, err := loadCosignConfig(ctx, v.ssmClient, v.env)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load cosign config")
+		return VerificationResult{ErrorMessage: err.Error()}, err
+	}
+
+	verifier, err := newCosignVerifier(config)
+	if err != nil {
+		logger.Error().Err(err).Msg("invalid cosign config")
+		return VerificationResult{ErrorMessage: err.Error()}, err
+	}
+
+	digest, err := v.registry.manifestDigest(ctx, ref)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to resolve image digest")
+		return VerificationResult{ErrorMessage: err.Error()}, err
+	}
+
+	manifest, err := v.registry.signatureManifest(ctx, ref, digest)
+	if errors.Is(err, errManifestNotFound) {
+		logger.Warn().Str("digest", digest).Msg("no cosign signature found for image")
+		return VerificationResult{
+			Verified:     false,
+			ErrorMessage: "no signature found - image not signed",
+		}, nil
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get signature manifest")
+		return VerificationResult{ErrorMessage: err.Error()}, err
+	}
+
+	// An image may carry several signatures; any one that satisfies the trust policy is enough
+	var failures []string
+	for _, layer := range manifest.Layers {
+		payload, err := v.registry.blob(ctx, ref, layer.Digest)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to get signature payload")
+			return VerificationResult{ErrorMessage: err.Error()}, err
+		}
+
+		signedBy, signedAt, err := verifier.verify(layer, payload, digest)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		logger.Info().
+			Str("digest", digest).
+			Str("signed_by", signedBy).
+			Bool("keyless", config.Keyless()).
+			Msg("container signature verified")
+
+		return VerificationResult{
+			Verified: true,
+			SignedBy: signedBy,
+			SignedAt: signedAt,
+		}, nil
+	}
+
+	if len(failures) == 0 {
+		failures = append(failures, "signature manifest has no signatures")
+	}
 
-	logger.Warn().Msg("container signature verification not yet implemented")
+	logger.Warn().
+		Str("digest", digest).
+		Strs("failures", failures).
+		Msg("container signature verification failed")
 
 	return VerificationResult{
 		Verified:     false,
-		ErrorMessage: "container signature verification not yet fully implemented",
+		ErrorMessage: strings.Join(failures, "; "),
 	}, nil
 }
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// SNSService publishes messages to SNS topics using the SNS query API
+type SNSService struct {
+	credentials aws.CredentialsProvider
+	httpClient  *http.Client
+	signer      *v4.Signer
+	endpoint    func(region string) string
+}
+
+// NewSNSService creates a new SNSService using the credentials of the given config
+func NewSNSService(cfg aws.Config) *SNSService {
+	return &SNSService{
+		credentials: cfg.Credentials,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		signer:      v4.NewSigner(),
+		endpoint: func(region string) string {
+			return fmt.Sprintf("https://sns.%s.amazonaws.com/", region)
+		},
+	}
+}
+
+// Publish publishes a message to the topic. The request is sent to the topic's region.
+func (s *SNSService) Publish(ctx context.Context, topicArn, subject, message string) error {
+	// arn:aws:sns:{region}:{account}:{name}
+	parts := strings.Split(topicArn, ":")
+	if len(parts) != 6 || parts[2] != "sns" {
+		return fmt.Errorf("invalid SNS topic ARN: %s", topicArn)
+	}
+	region := parts[3]
+
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", "2010-03-31")
+	form.Set("TopicArn", topicArn)
+	form.Set("Message", message)
+	if subject != "" {
+		// SNS subjects are limited to 100 characters
+		if len(subject) > 100 {
+			subject = subject[:100]
+		}
+		form.Set("Subject", subject)
+	}
+	body := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint(region), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create SNS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(body))
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "sns", region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign SNS request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topicArn, err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to publish to %s: status %d: %s", topicArn, resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+// Package stackevents streams CloudFormation stack events for a build while it deploys
+package stackevents
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/rs/zerolog"
+	"github.com/savaki/aws-deployer/internal/constants"
+	"github.com/savaki/aws-deployer/internal/dao/builddao"
+	"github.com/savaki/aws-deployer/internal/dao/deploymentdao"
+)
+
+// DefaultInterval is how often stacks are polled for new events
+const DefaultInterval = 3 * time.Second
+
+// Event is a resource-level CloudFormation stack event
+type Event struct {
+	ID                   string
+	StackName            string
+	Account              string // Target account for StackSet instances, empty for single-account stacks
+	Region               string // Target region for StackSet instances, empty for single-account stacks
+	LogicalResourceID    string
+	PhysicalResourceID   string
+	ResourceType         string
+	ResourceStatus       string
+	ResourceStatusReason string
+	Timestamp            time.Time
+}
+
+// EventsClient abstracts the CloudFormation DescribeStackEvents operation
+type EventsClient interface {
+	DescribeStackEvents(ctx context.Context, params *cloudformation.DescribeStackEventsInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error)
+}
+
+// ClientFactory returns an EventsClient able to read stacks in a target account and region
+type ClientFactory interface {
+	CreateClient(ctx context.Context, account, region string) (EventsClient, error)
+}
+
+// RoleClientFactory reads StackSet instance events by assuming the read-only
+// constants.StackEventsReaderRoleName role in each target account
+type RoleClientFactory struct {
+	stsClient *sts.Client
+	cfg       aws.Config
+
+	mutex   sync.Mutex
+	clients map[string]EventsClient
+}
+
+// NewRoleClientFactory creates a new RoleClientFactory
+func NewRoleClientFactory(cfg aws.Config) *RoleClientFactory {
+	return &RoleClientFactory{
+		stsClient: sts.NewFromConfig(cfg),
+		cfg:       cfg,
+		clients:   map[string]EventsClient{},
+	}
+}
+
+// CreateClient creates (or reuses) a CloudFormation client for the target account/region
+func (f *RoleClientFactory) CreateClient(_ context.Context, account, region string) (EventsClient, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	key := account + "/" + region
+	if client, ok := f.clients[key]; ok {
+		return client, nil
+	}
+
+	roleARN := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, constants.StackEventsReaderRoleName)
+	creds := stscreds.NewAssumeRoleProvider(f.stsClient, roleARN)
+	targetCfg := f.cfg.Copy()
+	targetCfg.Credentials = aws.NewCredentialsCache(creds)
+	if region != "" {
+		targetCfg.Region = region
+	}
+
+	client := cloudformation.NewFromConfig(targetCfg)
+	f.clients[key] = client
+	return client, nil
+}
+
+// Streamer polls the stacks of a build and emits their events as they happen
+type Streamer struct {
+	cfClient      EventsClient
+	clientFactory ClientFactory
+	buildDAO      *builddao.DAO
+	deploymentDAO *deploymentdao.DAO
+	interval      time.Duration
+}
+
+// New creates a new Streamer. deploymentDAO and clientFactory may be nil in single-account mode.
+func New(cfClient EventsClient, clientFactory ClientFactory, buildDAO *builddao.DAO, deploymentDAO *deploymentdao.DAO, interval time.Duration) *Streamer {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Streamer{
+		cfClient:      cfClient,
+		clientFactory: clientFactory,
+		buildDAO:      buildDAO,
+		deploymentDAO: deploymentDAO,
+		interval:      interval,
+	}
+}
+
+// Subscribe streams events of the build's stacks that occurred after the given time (or after
+// the build started, if later). The channel is closed once the build finishes and its final
+// events have been sent, or when ctx is done.
+func (s *Streamer) Subscribe(ctx context.Context, id builddao.ID, after time.Time) (<-chan Event, error) {
+	build, err := s.buildDAO.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if started := time.Unix(build.CreatedAt, 0); started.After(after) {
+		after = started
+	}
+
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+
+		logger := zerolog.Ctx(ctx)
+		pollers := map[string]*poller{}
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			// Read status before polling so events written just before the build finished are still sent
+			done := build.Status == builddao.BuildStatusSuccess || build.Status == builddao.BuildStatusFailed
+
+			sources, err := s.sources(ctx, build)
+			if err != nil {
+				logger.Warn().Err(err).Str("build_id", string(id)).Msg("Failed to find stacks for build")
+			}
+
+			var events []Event
+			for _, src := range sources {
+				p, ok := pollers[src.key()]
+				if !ok {
+					client, err := s.client(ctx, src)
+					if err != nil {
+						logger.Warn().Err(err).Str("stack", src.stack).Msg("Failed to create stack events client")
+						continue
+					}
+					p = newPoller(client, src, after)
+					pollers[src.key()] = p
+				}
+
+				polled, err := p.poll(ctx)
+				if err != nil {
+					logger.Warn().Err(err).Str("stack", src.stack).Msg("Failed to poll stack events")
+					continue
+				}
+				events = append(events, polled...)
+			}
+
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].Timestamp.Before(events[j].Timestamp)
+			})
+			for _, event := range events {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if done {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			build, err = s.buildDAO.Find(ctx, id)
+			if err != nil {
+				logger.Warn().Err(err).Str("build_id", string(id)).Msg("Failed to refresh build")
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// source identifies a stack to poll
+type source struct {
+	stack   string
+	account string
+	region  string
+}
+
+func (s source) key() string {
+	return s.account + "/" + s.region + "/" + s.stack
+}
+
+// sources returns the stacks deployed by a build: its StackSet instances in multi-account
+// mode, otherwise its single stack
+func (s *Streamer) sources(ctx context.Context, build builddao.Record) ([]source, error) {
+	if s.deploymentDAO != nil {
+		deployments, err := s.deploymentDAO.QueryByBuild(ctx, build.Env, build.Repo, build.SK)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query deployments: %w", err)
+		}
+		if len(deployments) > 0 {
+			var sources []source
+			for _, deployment := range deployments {
+				if deployment.StackID == "" {
+					continue // Instance not created yet
+				}
+				account, region, err := deploymentdao.ParseSK(deployment.SK)
+				if err != nil {
+					continue
+				}
+				sources = append(sources, source{stack: deployment.StackID, account: account, region: region})
+			}
+			return sources, nil
+		}
+	}
+
+	if build.StackName == "" {
+		return nil, nil
+	}
+	return []source{{stack: build.StackName}}, nil
+}
+
+func (s *Streamer) client(ctx context.Context, src source) (EventsClient, error) {
+	if src.account == "" || s.clientFactory == nil {
+		return s.cfClient, nil
+	}
+	return s.clientFactory.CreateClient(ctx, src.account, src.region)
+}
+
+// poller tracks the events already seen for a single stack
+type poller struct {
+	client EventsClient
+	source source
+	after  time.Time
+	seen   map[string]struct{}
+}
+
+func newPoller(client EventsClient, src source, after time.Time) *poller {
+	return &poller{
+		client: client,
+		source: src,
+		after:  after,
+		seen:   map[string]struct{}{},
+	}
+}
+
+// poll returns events not returned by a previous poll, oldest first. DescribeStackEvents lists
+// newest first, so pages are read until reaching events from before the cutoff.
+func (p *poller) poll(ctx context.Context) ([]Event, error) {
+	var events []Event
+	var nextToken *string
+
+pages:
+	for {
+		output, err := p.client.DescribeStackEvents(ctx, &cloudformation.DescribeStackEventsInput{
+			StackName: aws.String(p.source.stack),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stack events for %s: %w", p.source.stack, err)
+		}
+
+		for _, e := range output.StackEvents {
+			timestamp := aws.ToTime(e.Timestamp)
+			if !timestamp.After(p.after) {
+				break pages
+			}
+			eventID := aws.ToString(e.EventId)
+			if _, ok := p.seen[eventID]; ok {
+				break pages // Everything older was returned by a previous poll
+			}
+
+			events = append(events, Event{
+				ID:                   eventID,
+				StackName:            aws.ToString(e.StackName),
+				Account:              p.source.account,
+				Region:               p.source.region,
+				LogicalResourceID:    aws.ToString(e.LogicalResourceId),
+				PhysicalResourceID:   aws.ToString(e.PhysicalResourceId),
+				ResourceType:         aws.ToString(e.ResourceType),
+				ResourceStatus:       string(e.ResourceStatus),
+				ResourceStatusReason: aws.ToString(e.ResourceStatusReason),
+				Timestamp:            timestamp,
+			})
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	for _, event := range events {
+		p.seen[event.ID] = struct{}{}
+	}
+
+	// Reverse into chronological order
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
@@ -0,0 +1,136 @@
+package stackevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// mockEventsClient returns its events newest first, two per page
+type mockEventsClient struct {
+	events []types.StackEvent // oldest first
+	calls  int
+}
+
+func (m *mockEventsClient) add(id, resource string, status types.ResourceStatus, timestamp time.Time) {
+	m.events = append(m.events, types.StackEvent{
+		EventId:           aws.String(id),
+		StackName:         aws.String("my-app-dev"),
+		LogicalResourceId: aws.String(resource),
+		ResourceType:      aws.String("AWS::S3::Bucket"),
+		ResourceStatus:    status,
+		Timestamp:         aws.Time(timestamp),
+	})
+}
+
+func (m *mockEventsClient) DescribeStackEvents(_ context.Context, params *cloudformation.DescribeStackEventsInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error) {
+	m.calls++
+
+	var newest []types.StackEvent
+	for i := len(m.events) - 1; i >= 0; i-- {
+		newest = append(newest, m.events[i])
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start = len(aws.ToString(params.NextToken))
+	}
+	end := start + 2
+	if end >= len(newest) {
+		return &cloudformation.DescribeStackEventsOutput{StackEvents: newest[start:]}, nil
+	}
+
+	// Encode the offset of the next page as the token length
+	token := make([]byte, end)
+	for i := range token {
+		token[i] = 'x'
+	}
+	return &cloudformation.DescribeStackEventsOutput{
+		StackEvents: newest[start:end],
+		NextToken:   aws.String(string(token)),
+	}, nil
+}
+
+func TestPoller_Poll(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+
+	client := &mockEventsClient{}
+	client.add("old", "Assets", types.ResourceStatusCreateComplete, start.Add(-time.Hour))
+	client.add("e1", "my-app-dev", types.ResourceStatusUpdateInProgress, start.Add(1*time.Second))
+	client.add("e2", "Assets", types.ResourceStatusUpdateInProgress, start.Add(2*time.Second))
+	client.add("e3", "Assets", types.ResourceStatusUpdateComplete, start.Add(3*time.Second))
+
+	p := newPoller(client, source{stack: "my-app-dev", account: "123456789012", region: "us-west-2"}, start)
+
+	events, err := p.poll(ctx)
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if got := ids(events); got != "e1,e2,e3" {
+		t.Fatalf("Expected events e1,e2,e3 oldest first, got %s", got)
+	}
+	if events[2].ResourceStatus != "UPDATE_COMPLETE" || events[2].Account != "123456789012" || events[2].Region != "us-west-2" {
+		t.Errorf("Unexpected event: %+v", events[2])
+	}
+
+	t.Run("NoNewEvents", func(t *testing.T) {
+		events, err := p.poll(ctx)
+		if err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("Expected no events, got %s", ids(events))
+		}
+	})
+
+	t.Run("NewEvents", func(t *testing.T) {
+		client.add("e4", "Queue", types.ResourceStatusCreateFailed, start.Add(4*time.Second))
+		client.add("e5", "my-app-dev", types.ResourceStatusUpdateRollbackInProgress, start.Add(4*time.Second))
+		client.calls = 0
+
+		events, err := p.poll(ctx)
+		if err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+		if got := ids(events); got != "e4,e5" {
+			t.Errorf("Expected events e4,e5, got %s", got)
+		}
+		if client.calls != 2 {
+			t.Errorf("Expected polling to stop at the first seen event after 2 pages, got %d calls", client.calls)
+		}
+	})
+}
+
+func TestPoller_After(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	client := &mockEventsClient{}
+	client.add("e1", "Assets", types.ResourceStatusUpdateInProgress, start.Add(1*time.Second))
+	client.add("e2", "Assets", types.ResourceStatusUpdateComplete, start.Add(2*time.Second))
+
+	// Resuming a subscription skips events up to and including the cursor
+	p := newPoller(client, source{stack: "my-app-dev"}, start.Add(1*time.Second))
+	events, err := p.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if got := ids(events); got != "e2" {
+		t.Errorf("Expected event e2, got %s", got)
+	}
+}
+
+func ids(events []Event) string {
+	var s string
+	for i, event := range events {
+		if i > 0 {
+			s += ","
+		}
+		s += event.ID
+	}
+	return s
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// StackOutputs converts CloudFormation stack outputs into a map of output key to value
+// Returns nil if the stack has no outputs
+func StackOutputs(outputs []types.Output) map[string]string {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		if output.OutputKey == nil {
+			continue
+		}
+		m[*output.OutputKey] = aws.ToString(output.OutputValue)
+	}
+	return m
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestStackOutputs(t *testing.T) {
+	got := StackOutputs([]types.Output{
+		{OutputKey: aws.String("ApiUrl"), OutputValue: aws.String("https://api.example.com")},
+		{OutputKey: aws.String("QueueArn"), OutputValue: aws.String("arn:aws:sqs:us-east-1:111111111111:jobs")},
+		{OutputValue: aws.String("ignored")},
+	})
+	want := map[string]string{
+		"ApiUrl":   "https://api.example.com",
+		"QueueArn": "arn:aws:sqs:us-east-1:111111111111:jobs",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StackOutputs() = %v, want %v", got, want)
+	}
+
+	if got := StackOutputs(nil); got != nil {
+		t.Errorf("StackOutputs(nil) = %v, want nil", got)
+	}
+}
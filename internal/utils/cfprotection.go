@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+)
+
+// StackProtector defines the CloudFormation operations that protect a stack
+type StackProtector interface {
+	UpdateTerminationProtection(ctx context.Context, params *cloudformation.UpdateTerminationProtectionInput, optFns ...func(*cloudformation.Options)) (*cloudformation.UpdateTerminationProtectionOutput, error)
+	SetStackPolicy(ctx context.Context, params *cloudformation.SetStackPolicyInput, optFns ...func(*cloudformation.Options)) (*cloudformation.SetStackPolicyOutput, error)
+}
+
+// ProtectStack applies an environment's protection to an existing stack: termination protection
+// and a stack policy denying replacement or deletion of protected resources. allowReplacement
+// applies a policy without the deny, for builds whose replacement was explicitly allowed.
+func ProtectStack(ctx context.Context, client StackProtector, stackName string, protection *targetdao.Protection, allowReplacement bool) error {
+	_, err := client.UpdateTerminationProtection(ctx, &cloudformation.UpdateTerminationProtectionInput{
+		StackName:                   aws.String(stackName),
+		EnableTerminationProtection: aws.Bool(protection != nil && protection.TerminationProtection),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update termination protection of %s: %w", stackName, err)
+	}
+
+	policy, err := protection.StackPolicy(allowReplacement)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SetStackPolicy(ctx, &cloudformation.SetStackPolicyInput{
+		StackName:       aws.String(stackName),
+		StackPolicyBody: aws.String(policy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set stack policy of %s: %w", stackName, err)
+	}
+
+	return nil
+}
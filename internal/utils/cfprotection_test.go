@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/savaki/aws-deployer/internal/dao/targetdao"
+)
+
+type fakeProtector struct {
+	terminationProtection *bool
+	policy                string
+	err                   error
+}
+
+func (f *fakeProtector) UpdateTerminationProtection(ctx context.Context, params *cloudformation.UpdateTerminationProtectionInput, optFns ...func(*cloudformation.Options)) (*cloudformation.UpdateTerminationProtectionOutput, error) {
+	f.terminationProtection = params.EnableTerminationProtection
+	return &cloudformation.UpdateTerminationProtectionOutput{}, nil
+}
+
+func (f *fakeProtector) SetStackPolicy(ctx context.Context, params *cloudformation.SetStackPolicyInput, optFns ...func(*cloudformation.Options)) (*cloudformation.SetStackPolicyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.policy = aws.ToString(params.StackPolicyBody)
+	return &cloudformation.SetStackPolicyOutput{}, nil
+}
+
+func TestProtectStack(t *testing.T) {
+	protection := &targetdao.Protection{TerminationProtection: true, ProtectResources: true}
+
+	client := &fakeProtector{}
+	if err := ProtectStack(context.Background(), client, "prd-my-app", protection, false); err != nil {
+		t.Fatalf("ProtectStack failed: %v", err)
+	}
+	if !aws.ToBool(client.terminationProtection) {
+		t.Error("termination protection was not enabled")
+	}
+	if !strings.Contains(client.policy, `"Deny"`) || !strings.Contains(client.policy, "AWS::S3::Bucket") {
+		t.Errorf("policy = %s, want deny on protected types", client.policy)
+	}
+
+	// An override deploys with a policy that allows every update
+	if err := ProtectStack(context.Background(), client, "prd-my-app", protection, true); err != nil {
+		t.Fatalf("ProtectStack failed: %v", err)
+	}
+	if strings.Contains(client.policy, `"Deny"`) {
+		t.Errorf("policy = %s, want no deny", client.policy)
+	}
+
+	client = &fakeProtector{err: errors.New("access denied")}
+	if err := ProtectStack(context.Background(), client, "prd-my-app", protection, false); err == nil || !strings.Contains(err.Error(), "failed to set stack policy of prd-my-app") {
+		t.Errorf("ProtectStack() error = %v, want stack policy error", err)
+	}
+}